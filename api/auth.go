@@ -0,0 +1,71 @@
+package api
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// apiKeyAuth checks requests against a set of API keys
+type apiKeyAuth struct {
+	keyHashes [][sha256.Size]byte
+}
+
+// newAPIKeyAuth creates an authenticator, or returns nil if no keys are
+// configured and authentication is disabled
+func newAPIKeyAuth(keys []string) *apiKeyAuth {
+	var auth apiKeyAuth
+	for _, key := range keys {
+		if key != "" {
+			auth.keyHashes = append(auth.keyHashes, sha256.Sum256([]byte(key)))
+		}
+	}
+	if len(auth.keyHashes) == 0 {
+		return nil
+	}
+	return &auth
+}
+
+// valid reports whether the key matches a configured key. Keys are compared
+// as fixed-length hashes in constant time, and every configured key is
+// checked, so response timing reveals neither key contents nor length.
+func (a *apiKeyAuth) valid(key string) bool {
+	if key == "" {
+		return false
+	}
+	hash := sha256.Sum256([]byte(key))
+	match := 0
+	for _, keyHash := range a.keyHashes {
+		match |= subtle.ConstantTimeCompare(hash[:], keyHash[:])
+	}
+	return match == 1
+}
+
+// requestAPIKey returns the key from an "Authorization: Bearer" or
+// "X-API-Key" header
+func requestAPIKey(r *http.Request) string {
+	if authorization := r.Header.Get("Authorization"); authorization != "" {
+		scheme, key, ok := strings.Cut(authorization, " ")
+		if ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(key)
+		}
+	}
+	return r.Header.Get("X-API-Key")
+}
+
+// unauthorized checks the request's API key, responding with 401 and
+// returning true if authentication is enabled and the key is missing or invalid
+func (s *Server) unauthorized(w http.ResponseWriter, r *http.Request) bool {
+	if s.auth == nil || r.URL.Path == "/health" {
+		return false
+	}
+
+	if s.auth.valid(requestAPIKey(r)) {
+		return false
+	}
+
+	w.Header().Set("WWW-Authenticate", `Bearer realm="scraper"`)
+	respondError(w, http.StatusUnauthorized, "missing or invalid API key")
+	return true
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zombar/scraper"
+	"github.com/zombar/scraper/db"
+)
+
+func TestAPIKeyAuth(t *testing.T) {
+	server, err := NewServer(Config{
+		DBConfig:      db.Config{Driver: "sqlite", DSN: t.TempDir() + "/test.db"},
+		ScraperConfig: scraper.DefaultConfig(),
+		APIKeys:       []string{"first-key", "second-key"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	defer server.db.Close()
+
+	tests := []struct {
+		name       string
+		path       string
+		headers    map[string]string
+		wantStatus int
+	}{
+		{"bearer key", "/api/data", map[string]string{"Authorization": "Bearer first-key"}, http.StatusOK},
+		{"lowercase bearer scheme", "/api/data", map[string]string{"Authorization": "bearer second-key"}, http.StatusOK},
+		{"X-API-Key header", "/api/data", map[string]string{"X-API-Key": "second-key"}, http.StatusOK},
+		{"invalid bearer key", "/api/data", map[string]string{"Authorization": "Bearer wrong-key"}, http.StatusUnauthorized},
+		{"invalid X-API-Key", "/api/data", map[string]string{"X-API-Key": "first-key-extra"}, http.StatusUnauthorized},
+		{"non-bearer scheme", "/api/data", map[string]string{"Authorization": "Basic first-key"}, http.StatusUnauthorized},
+		{"missing key", "/api/data", nil, http.StatusUnauthorized},
+		{"health without key", "/health", nil, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+			server.server.Handler.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Status code = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("Expected WWW-Authenticate header on 401 response")
+			}
+		})
+	}
+}
+
+func TestAPIKeyAuthDisabled(t *testing.T) {
+	if auth := newAPIKeyAuth(nil); auth != nil {
+		t.Error("Expected nil authenticator with no keys")
+	}
+	if auth := newAPIKeyAuth([]string{""}); auth != nil {
+		t.Error("Expected nil authenticator with only empty keys")
+	}
+
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/data", nil)
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Status code = %d, want %d", w.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultMaxRequestBodyBytes = 1024 * 1024      // 1MB
+	defaultBodyReadTimeout     = 10 * time.Second // per read, not the whole body
+)
+
+// deadlineBody wraps a request body so that every read must make progress
+// within the timeout, and the whole body must arrive by the deadline. A client
+// that stalls is cut off once any single read times out, and one trickling the
+// body byte-by-byte once the deadline passes.
+type deadlineBody struct {
+	body     io.ReadCloser
+	rc       *http.ResponseController
+	timeout  time.Duration
+	deadline time.Time // Zero for none
+}
+
+// Read implements io.Reader
+func (b *deadlineBody) Read(p []byte) (int, error) {
+	deadline := time.Now().Add(b.timeout)
+	if !b.deadline.IsZero() && b.deadline.Before(deadline) {
+		deadline = b.deadline
+	}
+	// Errors mean the connection does not support deadlines (e.g. in tests);
+	// the server-wide ReadTimeout still applies then
+	b.rc.SetReadDeadline(deadline)
+	return b.body.Read(p)
+}
+
+// Close implements io.Closer
+func (b *deadlineBody) Close() error {
+	return b.body.Close()
+}
+
+// limitBody applies the request body size limit and per-read timeout. Setting
+// a read deadline replaces the one the server's ReadTimeout set for the
+// request, so reads are also held to that, counted from now.
+func (s *Server) limitBody(w http.ResponseWriter, r *http.Request) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return
+	}
+	body := &deadlineBody{
+		body:    r.Body,
+		rc:      http.NewResponseController(w),
+		timeout: s.bodyReadTimeout,
+	}
+	if s.server != nil && s.server.ReadTimeout > 0 {
+		body.deadline = time.Now().Add(s.server.ReadTimeout)
+	}
+	r.Body = http.MaxBytesReader(w, body, s.maxBodyBytes)
+}
+
+// decodeJSONBody decodes a JSON request body, responding with an error and
+// returning false if it is malformed, too large, or sent too slowly
+func decodeJSONBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err == nil {
+		return true
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &maxBytesErr):
+		respondError(w, http.StatusRequestEntityTooLarge, "request body too large")
+	case errors.As(err, &netErr) && netErr.Timeout():
+		respondError(w, http.StatusRequestTimeout, "request body read timed out")
+	default:
+		respondError(w, http.StatusBadRequest, "invalid request body")
+	}
+	return false
+}
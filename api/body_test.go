@@ -0,0 +1,119 @@
+package api
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zombar/scraper"
+	"github.com/zombar/scraper/db"
+)
+
+func setupLimitedServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return setupLimitedServerWithReadTimeout(t, 0)
+}
+
+// setupLimitedServerWithReadTimeout is setupLimitedServer with the server's
+// ReadTimeout set, which httptest leaves to the handler's deadlines
+func setupLimitedServerWithReadTimeout(t *testing.T, readTimeout time.Duration) *httptest.Server {
+	t.Helper()
+
+	server, err := NewServer(Config{
+		DBConfig:            db.Config{Driver: "sqlite", DSN: t.TempDir() + "/test.db"},
+		ScraperConfig:       scraper.DefaultConfig(),
+		MaxRequestBodyBytes: 64,
+		BodyReadTimeout:     100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	t.Cleanup(func() { server.db.Close() })
+	server.server.ReadTimeout = readTimeout
+
+	ts := httptest.NewServer(server.server.Handler)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestRequestBodyTooLarge(t *testing.T) {
+	ts := setupLimitedServer(t)
+
+	body := `{"urls": ["` + strings.Repeat("a", 100) + `"]}`
+	resp, err := http.Post(ts.URL+"/api/scrape/batch", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("Status code = %d, want %d", resp.StatusCode, http.StatusRequestEntityTooLarge)
+	}
+}
+
+func TestRequestBodySlowClient(t *testing.T) {
+	ts := setupLimitedServer(t)
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(ts.URL, "http://"))
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	// Announce a body but stall after sending part of it
+	fmt.Fprintf(conn, "POST /api/scrape HTTP/1.1\r\nHost: test\r\nContent-Type: application/json\r\nContent-Length: 40\r\n\r\n{\"url\":")
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatalf("Failed to read response: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusRequestTimeout {
+		t.Errorf("Status code = %d, want %d", resp.StatusCode, http.StatusRequestTimeout)
+	}
+}
+
+func TestRequestBodyTricklingClient(t *testing.T) {
+	ts := setupLimitedServerWithReadTimeout(t, 300*time.Millisecond)
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(ts.URL, "http://"))
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	// Send a byte well within the per-read timeout, but for longer than the
+	// server's ReadTimeout in all
+	body := `{"url": ""}` + strings.Repeat(" ", 29)
+	fmt.Fprintf(conn, "POST /api/scrape HTTP/1.1\r\nHost: test\r\nContent-Type: application/json\r\nContent-Length: %d\r\n\r\n", len(body))
+	start := time.Now()
+	go func() {
+		for i := 0; i < len(body); i++ {
+			if _, err := conn.Write([]byte{body[i]}); err != nil {
+				return
+			}
+			time.Sleep(40 * time.Millisecond)
+		}
+	}()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatalf("Failed to read response: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusRequestTimeout {
+		t.Errorf("Status code = %d, want %d", resp.StatusCode, http.StatusRequestTimeout)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Cut off after %v, want about the 300ms ReadTimeout", elapsed)
+	}
+}
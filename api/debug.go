@@ -0,0 +1,35 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/zombar/scraper/models"
+	"github.com/zombar/scraper/ollama"
+)
+
+// debugTranscript returns a context recording the Ollama requests made with
+// it, and the transcript they are recorded in, when the request asks for
+// debug output with ?debug=true. Without it, ctx is returned with a nil
+// transcript. Asking while debug mode is off responds with 400 and returns
+// ok false.
+func (s *Server) debugTranscript(ctx context.Context, w http.ResponseWriter, r *http.Request) (_ context.Context, transcript *ollama.Transcript, ok bool) {
+	if r.URL.Query().Get("debug") != "true" {
+		return ctx, nil, true
+	}
+	if !s.debugMode {
+		respondError(w, http.StatusBadRequest, "debug output requires the server's debug mode")
+		return ctx, nil, false
+	}
+	transcript = &ollama.Transcript{}
+	return ollama.WithTranscript(ctx, transcript), transcript, true
+}
+
+// debugInfo returns the debug output for a response from a transcript, or
+// nil without one
+func debugInfo(transcript *ollama.Transcript) *models.DebugInfo {
+	if transcript == nil {
+		return nil
+	}
+	return &models.DebugInfo{OllamaExchanges: transcript.Exchanges()}
+}
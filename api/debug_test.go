@@ -0,0 +1,91 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zombar/scraper"
+	"github.com/zombar/scraper/db"
+	"github.com/zombar/scraper/models"
+)
+
+func TestScrapeDebug(t *testing.T) {
+	ollamaServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(models.OllamaResponse{Response: `{"score": 0.8, "reason": "Useful"}`, Done: true})
+	}))
+	defer ollamaServer.Close()
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><head><title>Debugging</title></head><body><p>A page worth reading.</p></body></html>`))
+	}))
+	defer target.Close()
+
+	newServer := func(debugMode bool) *Server {
+		scraperConfig := scraper.DefaultConfig()
+		scraperConfig.OllamaBaseURL = ollamaServer.URL
+		scraperConfig.EnableImageAnalysis = false
+		server, err := NewServer(Config{
+			DBConfig:      db.Config{Driver: "sqlite", DSN: t.TempDir() + "/test.db"},
+			ScraperConfig: scraperConfig,
+			DebugMode:     debugMode,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create server: %v", err)
+		}
+		t.Cleanup(func() { server.db.Close() })
+		return server
+	}
+	scrape := func(server *Server, query string, req ScrapeRequest) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/scrape"+query, bytes.NewReader(body)))
+		return w
+	}
+
+	t.Run("debug mode off", func(t *testing.T) {
+		w := scrape(newServer(false), "?debug=true", ScrapeRequest{URL: target.URL})
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Status = %d, want 400 when debug mode is off", w.Code)
+		}
+	})
+
+	t.Run("debug mode on", func(t *testing.T) {
+		server := newServer(true)
+
+		w := scrape(server, "", ScrapeRequest{URL: target.URL + "/plain"})
+		var data models.ScrapedData
+		json.NewDecoder(w.Body).Decode(&data)
+		if w.Code != http.StatusOK || data.Debug != nil {
+			t.Errorf("Without ?debug=true, status = %d and debug = %+v, want no debug output", w.Code, data.Debug)
+		}
+
+		w = scrape(server, "?debug=true", ScrapeRequest{URL: target.URL})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Scrape status = %d: %s", w.Code, w.Body.String())
+		}
+		data = models.ScrapedData{}
+		json.NewDecoder(w.Body).Decode(&data)
+		if data.Debug == nil || len(data.Debug.OllamaExchanges) == 0 {
+			t.Fatalf("Debug = %+v, want the Ollama exchanges", data.Debug)
+		}
+		for _, exchange := range data.Debug.OllamaExchanges {
+			if exchange.Prompt == "" || exchange.Response != `{"score": 0.8, "reason": "Useful"}` {
+				t.Errorf("Exchange = %+v, want its prompt and raw response", exchange)
+			}
+		}
+		if stored, err := server.db.GetByID(data.ID); err != nil || stored == nil || stored.Debug != nil {
+			t.Errorf("Stored = %+v, %v; want the record without debug output", stored, err)
+		}
+
+		// A cached result made no Ollama requests
+		w = scrape(server, "?debug=true", ScrapeRequest{URL: target.URL, Fields: []string{"title"}})
+		var projected map[string]json.RawMessage
+		json.NewDecoder(w.Body).Decode(&projected)
+		if string(projected["_debug"]) != `{"ollama_exchanges":[]}` {
+			t.Errorf("Cached debug = %s, want no exchanges", projected["_debug"])
+		}
+	})
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/zombar/scraper"
+	"github.com/zombar/scraper/models"
+)
+
+const (
+	// deferredScoringWorkers is the number of records scored at once in the
+	// background; Ollama calls are further limited by the scraper's scoring slots
+	deferredScoringWorkers = 4
+	// deferredScoringQueueSize is the number of records that may wait for a
+	// background score. Records that do not fit keep their provisional score
+	// until POST /api/admin/rescore.
+	deferredScoringQueueSize = 1000
+)
+
+// deferredScorer upgrades the provisional rule-based scores stored under
+// scraper.ScoringDeferred to Ollama scores in the background
+type deferredScorer struct {
+	rescore func(ctx context.Context, id string)
+	queue   chan string
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// newDeferredScorer starts the background workers, or returns nil if the
+// scoring mode does not defer scoring
+func newDeferredScorer(mode scraper.ScoringMode, rescore func(ctx context.Context, id string)) *deferredScorer {
+	if mode != scraper.ScoringDeferred {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &deferredScorer{
+		rescore: rescore,
+		queue:   make(chan string, deferredScoringQueueSize),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	for i := 0; i < deferredScoringWorkers; i++ {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			for id := range d.queue {
+				d.rescore(d.ctx, id)
+			}
+		}()
+	}
+	return d
+}
+
+// enqueue schedules a stored record for a background score, reporting
+// whether it was queued
+func (d *deferredScorer) enqueue(id string) bool {
+	if d == nil {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return false
+	}
+	select {
+	case d.queue <- id:
+		return true
+	default:
+		return false
+	}
+}
+
+// close stops accepting records and waits for the queued ones to be scored.
+// If ctx ends first, scoring in progress is cancelled and the remaining
+// records keep their provisional scores.
+func (d *deferredScorer) close(ctx context.Context) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	d.closed = true
+	close(d.queue)
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		log.Printf("Stopping deferred scoring with %d records still queued", len(d.queue))
+		d.cancel()
+		<-done
+	}
+	d.cancel()
+}
+
+// deferScoring queues a freshly saved result for a background score if its
+// score is provisional
+func (s *Server) deferScoring(data *models.ScrapedData) {
+	if s.deferredScoring == nil || data.Score == nil || data.Score.ScoringPath != scraper.ScoringPathDeferred {
+		return
+	}
+	if !s.deferredScoring.enqueue(data.ID) {
+		log.Printf("Deferred scoring queue full, keeping the provisional score for %s", data.URL)
+	}
+}
+
+// rescoreDeferred replaces a stored record's provisional score with an
+// Ollama score. If Ollama fails, the record is marked as scored by the
+// fallback so that clients stop waiting for an upgrade; POST
+// /api/admin/rescore retries it later.
+func (s *Server) rescoreDeferred(ctx context.Context, id string) {
+	data, err := s.db.GetByID(id)
+	if err != nil {
+		log.Printf("Failed to load %s for deferred scoring: %v", id, err)
+		return
+	}
+	if data == nil || data.Score == nil || data.Score.ScoringPath != scraper.ScoringPathDeferred {
+		return // deleted or re-scraped since
+	}
+
+	score, err := s.scraper.ScoreContent(ctx, data.URL, data.Title, data.Content)
+	if err != nil {
+		log.Printf("Deferred scoring failed for %s, keeping the rule-based score: %v", data.URL, err)
+		data.Score.ScoringPath = scraper.ScoringPathFallback
+	} else {
+		s.scraper.ApplyTrackerPenalty(score, data.Metadata.TrackerCount)
+		score.ScoredAt = time.Now()
+		data.Score = score
+	}
+	if err := s.db.UpdateData(data); err != nil {
+		log.Printf("Failed to save deferred score for %s: %v", data.URL, err)
+	}
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zombar/scraper"
+	"github.com/zombar/scraper/db"
+	"github.com/zombar/scraper/models"
+)
+
+func TestDeferredScoring(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Guide</title></head><body><p>A detailed technical guide.</p></body></html>`))
+	}))
+	defer target.Close()
+
+	release := make(chan struct{})
+	ollamaServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req models.OllamaRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		response := "A detailed technical guide."
+		if strings.Contains(req.Prompt, "content quality assessment") {
+			<-release
+			response = `{"score": 0.9, "reason": "AI scored", "categories": [], "malicious_indicators": []}`
+		}
+		json.NewEncoder(w).Encode(models.OllamaResponse{Response: response, Done: true})
+	}))
+	defer ollamaServer.Close()
+
+	scraperConfig := scraper.DefaultConfig()
+	scraperConfig.EnableImageAnalysis = false
+	scraperConfig.OllamaBaseURL = ollamaServer.URL
+	scraperConfig.ScoringMode = scraper.ScoringDeferred
+	server, err := NewServer(Config{
+		DBConfig:      db.Config{Driver: "sqlite", DSN: t.TempDir() + "/test.db"},
+		ScraperConfig: scraperConfig,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	defer server.db.Close()
+	defer server.deferredScoring.close(context.Background())
+
+	// The response comes back with the provisional score while Ollama is busy
+	body, _ := json.Marshal(ScrapeRequest{URL: target.URL})
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/scrape", bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Scrape status = %d: %s", w.Code, w.Body.String())
+	}
+	var data models.ScrapedData
+	json.NewDecoder(w.Body).Decode(&data)
+	if data.Score == nil || data.Score.AIUsed || data.Score.ScoringPath != scraper.ScoringPathDeferred {
+		t.Fatalf("Score = %+v, want a provisional rule-based score", data.Score)
+	}
+
+	close(release)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		stored, err := server.db.GetByID(data.ID)
+		if err != nil {
+			t.Fatalf("GetByID failed: %v", err)
+		}
+		if stored.Score.AIUsed {
+			if stored.Score.Score != 0.9 || stored.Score.ScoringPath != scraper.ScoringPathAI || stored.Score.ScoredAt.IsZero() {
+				t.Errorf("Stored score = %+v, want the Ollama score", stored.Score)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Stored score = %+v, want it upgraded to the Ollama score", stored.Score)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDeferredScoringKeepsTrackerPenalty(t *testing.T) {
+	ollamaServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(models.OllamaResponse{
+			Response: `{"score": 0.9, "reason": "AI scored", "categories": [], "malicious_indicators": []}`,
+			Done:     true,
+		})
+	}))
+	defer ollamaServer.Close()
+
+	scraperConfig := scraper.DefaultConfig()
+	scraperConfig.OllamaBaseURL = ollamaServer.URL
+	scraperConfig.ScoringMode = scraper.ScoringDeferred
+	scraperConfig.MaxTrackers = 2
+	scraperConfig.LinkScoreThreshold = 0.8
+	server, err := NewServer(Config{
+		DBConfig:      db.Config{Driver: "sqlite", DSN: t.TempDir() + "/test.db"},
+		ScraperConfig: scraperConfig,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	defer server.db.Close()
+	defer server.deferredScoring.close(context.Background())
+
+	data := &models.ScrapedData{
+		ID:        "tracked",
+		URL:       "https://example.com/tracked",
+		Title:     "Tracked page",
+		Content:   "Content of a page loading many trackers",
+		FetchedAt: time.Now(),
+		Metadata:  models.PageMetadata{TrackerCount: 5},
+		Score:     &models.LinkScore{Score: 0.3, ScoringPath: scraper.ScoringPathDeferred},
+	}
+	if err := server.db.SaveScrapedData(data); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
+	}
+
+	server.rescoreDeferred(context.Background(), data.ID)
+	stored, err := server.db.GetByID(data.ID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	score := stored.Score
+	if !score.AIUsed || score.Score < 0.69 || score.Score > 0.71 || score.IsRecommended {
+		t.Errorf("Stored score = %+v, want the Ollama score lowered to 0.7 and not recommended", score)
+	}
+	if !slices.Contains(score.Categories, "tracker_heavy") {
+		t.Errorf("Categories = %v, want tracker_heavy", score.Categories)
+	}
+}
+
+func TestDeferredScorerClose(t *testing.T) {
+	if newDeferredScorer(scraper.ScoringAlways, nil) != nil {
+		t.Error("Expected no deferred scorer outside the deferred scoring mode")
+	}
+
+	var scored int32
+	d := newDeferredScorer(scraper.ScoringDeferred, func(ctx context.Context, id string) {
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&scored, 1)
+	})
+	for i := 0; i < 20; i++ {
+		if !d.enqueue("id") {
+			t.Fatalf("enqueue %d failed", i)
+		}
+	}
+	d.close(context.Background())
+	if scored != 20 {
+		t.Errorf("Scored %d records before close returned, want all 20 drained", scored)
+	}
+	if d.enqueue("late") {
+		t.Error("Expected enqueue after close to fail")
+	}
+
+	// A shutdown deadline cancels scoring in progress instead of waiting
+	var cancelled int32
+	d = newDeferredScorer(scraper.ScoringDeferred, func(ctx context.Context, id string) {
+		<-ctx.Done()
+		atomic.AddInt32(&cancelled, 1)
+	})
+	d.enqueue("stuck")
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	d.close(ctx)
+	if cancelled != 1 {
+		t.Errorf("Cancelled %d scores, want 1", cancelled)
+	}
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// DiscoverRequest represents a link discovery request
+type DiscoverRequest struct {
+	URL string `json:"url"`
+}
+
+// handleDiscover extracts a page's links and scores each one, returning the
+// scores highest first. The scraper's discover budget bounds how long it runs.
+func (s *Server) handleDiscover(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req DiscoverRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	if req.URL == "" {
+		respondError(w, http.StatusBadRequest, "url is required")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Minute)
+	defer cancel()
+
+	result, err := s.scraper.DiscoverLinks(ctx, req.URL)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("link discovery failed: %v", err))
+		return
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zombar/scraper"
+	"github.com/zombar/scraper/models"
+)
+
+// recordFailure stores a record of a failed scrape when Config.StoreFailures
+// is on, so dead links can be monitored. Scrapes cancelled by the client and
+// invalid URLs are not recorded.
+func (s *Server) recordFailure(targetURL string, scrapeErr error) {
+	if !s.storeFailures || errors.Is(scrapeErr, context.Canceled) {
+		return
+	}
+	if u, err := url.Parse(targetURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return
+	}
+
+	now := time.Now()
+	data := &models.ScrapedData{
+		ID:         uuid.New().String(),
+		URL:        s.scraper.NormalizeURL(targetURL),
+		StatusCode: failureStatus(scrapeErr),
+		Images:     []models.ImageInfo{},
+		Links:      []string{},
+		FetchedAt:  now,
+		CreatedAt:  now,
+		Failed:     true,
+		Error:      scrapeErr.Error(),
+	}
+	if err := s.db.SaveScrapedData(data); err != nil {
+		log.Printf("Failed to save failure record for %s: %v", targetURL, err)
+	}
+}
+
+// failureStatus returns the HTTP status a failed scrape's page responded
+// with, or 0 if it failed before getting a response
+func failureStatus(err error) int {
+	var statusErr *scraper.StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode
+	}
+	if errors.Is(err, scraper.ErrRateLimited) {
+		return http.StatusTooManyRequests
+	}
+	return 0
+}
+
+// handleListFailures lists the records of failed scrapes, newest first
+func (s *Server) handleListFailures(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	limit := 20
+	offset := 0
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		fmt.Sscanf(limitStr, "%d", &limit)
+	}
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		fmt.Sscanf(offsetStr, "%d", &offset)
+	}
+	if limit < 1 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	data, err := s.db.ListFailures(limit, offset)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+	count, err := s.db.CountFailures()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+	if data == nil {
+		data = []*models.ScrapedData{}
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data":   data,
+		"total":  count,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
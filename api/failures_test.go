@@ -0,0 +1,114 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/zombar/scraper"
+	"github.com/zombar/scraper/db"
+	"github.com/zombar/scraper/models"
+)
+
+func TestStoreFailures(t *testing.T) {
+	var hits int32
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		http.NotFound(w, r)
+	}))
+	defer target.Close()
+
+	for _, storeFailures := range []bool{false, true} {
+		t.Run(fmt.Sprintf("store=%v", storeFailures), func(t *testing.T) {
+			atomic.StoreInt32(&hits, 0)
+			server, err := NewServer(Config{
+				DBConfig:      db.Config{Driver: "sqlite", DSN: t.TempDir() + "/test.db"},
+				ScraperConfig: scraper.DefaultConfig(),
+				StoreFailures: storeFailures,
+			})
+			if err != nil {
+				t.Fatalf("Failed to create server: %v", err)
+			}
+			defer server.db.Close()
+
+			deadURL := target.URL + "/dead"
+			for i := 0; i < 2; i++ {
+				body, _ := json.Marshal(ScrapeRequest{URL: deadURL})
+				w := httptest.NewRecorder()
+				server.server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/scrape", bytes.NewReader(body)))
+				if w.Code != http.StatusInternalServerError {
+					t.Fatalf("Scrape status = %d, want %d", w.Code, http.StatusInternalServerError)
+				}
+			}
+			// A stored failure is not served as a cached result
+			if hits != 2 {
+				t.Errorf("Target fetched %d times, want 2", hits)
+			}
+
+			w := httptest.NewRecorder()
+			server.server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/failures", nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("List failures status = %d, want %d", w.Code, http.StatusOK)
+			}
+			var failures struct {
+				Data  []models.ScrapedData `json:"data"`
+				Total int                  `json:"total"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&failures); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+
+			if !storeFailures {
+				if failures.Total != 0 || len(failures.Data) != 0 {
+					t.Errorf("Failures = %+v, want none when StoreFailures is off", failures)
+				}
+				return
+			}
+			if failures.Total != 1 || len(failures.Data) != 1 {
+				t.Fatalf("Failures = %+v, want one record for the URL", failures)
+			}
+			record := failures.Data[0]
+			if record.URL != deadURL || !record.Failed || record.StatusCode != http.StatusNotFound || record.Error == "" || record.FetchedAt.IsZero() {
+				t.Errorf("Failure record = %+v", record)
+			}
+
+			// Failures are kept out of the normal listing
+			w = httptest.NewRecorder()
+			server.server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/data", nil))
+			var list struct {
+				Data  []models.ScrapedData `json:"data"`
+				Total int                  `json:"total"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if list.Total != 0 || len(list.Data) != 0 {
+				t.Errorf("List = %+v, want failures excluded", list)
+			}
+		})
+	}
+}
+
+func TestFailureStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{&scraper.StatusError{StatusCode: 410, Status: "410 Gone"}, 410},
+		{fmt.Errorf("wrapped: %w", &scraper.StatusError{StatusCode: 503}), 503},
+		{&scraper.RateLimitError{URL: "https://example.com"}, http.StatusTooManyRequests},
+		{context.DeadlineExceeded, 0},
+		{errors.New("failed to fetch URL: connection refused"), 0},
+	}
+	for _, tt := range tests {
+		if got := failureStatus(tt.err); got != tt.want {
+			t.Errorf("failureStatus(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package api
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/zombar/scraper"
+	"github.com/zombar/scraper/models"
+)
+
+const (
+	defaultFeedLimit = 20
+	maxFeedLimit     = 100
+	// maxFeedScan bounds how many records a feed request reads while looking
+	// for matches, so a rare category cannot scan the whole corpus
+	maxFeedScan = 1000
+	// feedSummaryChars caps entry summaries built from page content
+	feedSummaryChars = 500
+)
+
+// atomFeed is an Atom (RFC 4287) feed document
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Links      []atomLink     `xml:"link"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published,omitempty"`
+	Author     *atomAuthor    `xml:"author,omitempty"`
+	Categories []atomCategory `xml:"category"`
+	Summary    string         `xml:"summary,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// handleFeed renders the most recently scraped records as an Atom feed,
+// optionally limited to those scored with the given category
+func (s *Server) handleFeed(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	limit := defaultFeedLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		fmt.Sscanf(limitStr, "%d", &limit)
+	}
+	if limit < 1 {
+		limit = defaultFeedLimit
+	}
+	if limit > maxFeedLimit {
+		limit = maxFeedLimit
+	}
+	category := strings.TrimSpace(r.URL.Query().Get("category"))
+
+	// Walk pages newest first until enough records match
+	var items []*models.ScrapedData
+	cursor := ""
+	for scanned := 0; len(items) < limit && scanned < maxFeedScan; {
+		page, next, err := s.db.ListPage(maxFeedLimit, cursor)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "database error")
+			return
+		}
+		for _, data := range page {
+			if len(items) < limit && hasCategory(data, category) {
+				items = append(items, data)
+			}
+		}
+		scanned += len(page)
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	title := "Scraped pages"
+	if category != "" {
+		title += ": " + category
+	}
+	self := requestURL(r)
+	feed := atomFeed{
+		ID:      self,
+		Title:   title,
+		Updated: time.Now().UTC().Format(time.RFC3339),
+		Author:  atomAuthor{Name: "scraper"},
+		Links:   []atomLink{{Href: self, Rel: "self"}},
+	}
+	if len(items) > 0 {
+		feed.Updated = entryUpdated(items[0]).Format(time.RFC3339)
+	}
+	for _, data := range items {
+		feed.Entries = append(feed.Entries, newAtomEntry(data))
+	}
+
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(xml.Header))
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(feed); err != nil {
+		log.Printf("Failed to write feed: %v", err)
+	}
+}
+
+// hasCategory reports whether the record was scored with the category
+// (case-insensitively); an empty category matches every record
+func hasCategory(data *models.ScrapedData, category string) bool {
+	if category == "" {
+		return true
+	}
+	if data.Score == nil {
+		return false
+	}
+	for _, c := range data.Score.Categories {
+		if strings.EqualFold(c, category) {
+			return true
+		}
+	}
+	return false
+}
+
+// newAtomEntry converts a scraped record into a feed entry
+func newAtomEntry(data *models.ScrapedData) atomEntry {
+	link := data.URL
+	if data.CanonicalURL != "" {
+		link = data.CanonicalURL
+	} else if data.FinalURL != "" {
+		link = data.FinalURL
+	}
+
+	entry := atomEntry{
+		ID:      "urn:uuid:" + data.ID,
+		Title:   data.Title,
+		Links:   []atomLink{{Href: link, Rel: "alternate"}},
+		Updated: entryUpdated(data).Format(time.RFC3339),
+		Summary: data.Metadata.Description,
+	}
+	if entry.Title == "" {
+		entry.Title = link
+	}
+	if published, ok := scraper.ParsePublishedDate(data.Metadata.PublishedDate); ok {
+		entry.Published = published.Format(time.RFC3339)
+	}
+	if data.Metadata.Author != "" {
+		entry.Author = &atomAuthor{Name: data.Metadata.Author}
+	}
+	if data.Score != nil {
+		for _, c := range data.Score.Categories {
+			entry.Categories = append(entry.Categories, atomCategory{Term: c})
+		}
+	}
+	if entry.Summary == "" {
+		entry.Summary = summarize(data.Content, feedSummaryChars)
+	}
+	return entry
+}
+
+// entryUpdated returns when the record was last scraped
+func entryUpdated(data *models.ScrapedData) time.Time {
+	if !data.FetchedAt.IsZero() {
+		return data.FetchedAt.UTC()
+	}
+	return data.CreatedAt.UTC()
+}
+
+// summarize shortens text to at most maxChars runes, cutting at a word boundary
+func summarize(text string, maxChars int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) <= maxChars {
+		return text
+	}
+	cut := string(runes[:maxChars])
+	if i := strings.LastIndexByte(cut, ' '); i > 0 {
+		cut = cut[:i]
+	}
+	return cut + " ..."
+}
+
+// requestURL reconstructs the absolute URL of the request, used as the feed's
+// id and self link
+func requestURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + r.URL.RequestURI()
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zombar/scraper/models"
+)
+
+func TestHandleFeed(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	records := []*models.ScrapedData{
+		{
+			ID:        "11111111-1111-1111-1111-111111111111",
+			URL:       "https://example.com/go",
+			Title:     "Go Generics",
+			Content:   "A long article about generics in Go.",
+			FetchedAt: base,
+			Metadata:  models.PageMetadata{Description: "All about generics", Author: "Jane Doe", PublishedDate: "2024-02-28"},
+			Score:     &models.LinkScore{Categories: []string{"technical", "educational"}},
+		},
+		{
+			ID:        "22222222-2222-2222-2222-222222222222",
+			URL:       "https://example.com/news",
+			Title:     "Breaking News",
+			Content:   "Something happened today.",
+			FetchedAt: base.Add(time.Hour),
+			Score:     &models.LinkScore{Categories: []string{"news"}},
+		},
+		{
+			ID:        "33333333-3333-3333-3333-333333333333",
+			URL:       "https://example.com/rust",
+			Title:     "Rust Lifetimes",
+			Content:   "Lifetimes explained.",
+			FetchedAt: base.Add(2 * time.Hour),
+			Score:     &models.LinkScore{Categories: []string{"Technical"}},
+		},
+	}
+	for _, data := range records {
+		if err := server.db.SaveScrapedData(data); err != nil {
+			t.Fatalf("Failed to save data: %v", err)
+		}
+	}
+
+	var feed atomFeed
+	w := httptest.NewRecorder()
+	server.handleFeed(w, httptest.NewRequest(http.MethodGet, "/api/feed?category=technical", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Status code = %d, want %d", w.Code, http.StatusOK)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/atom+xml") {
+		t.Errorf("Content-Type = %q, want application/atom+xml", ct)
+	}
+	if err := xml.Unmarshal(w.Body.Bytes(), &feed); err != nil {
+		t.Fatalf("Failed to parse feed: %v\n%s", err, w.Body.String())
+	}
+
+	if len(feed.Entries) != 2 {
+		t.Fatalf("Got %d entries, want the 2 technical records", len(feed.Entries))
+	}
+	if feed.Entries[0].Title != "Rust Lifetimes" || feed.Entries[1].Title != "Go Generics" {
+		t.Errorf("Entries = %q, %q; want newest first", feed.Entries[0].Title, feed.Entries[1].Title)
+	}
+	if feed.Updated != "2024-03-01T14:00:00Z" {
+		t.Errorf("Feed updated = %q, want the newest entry's time", feed.Updated)
+	}
+
+	entry := feed.Entries[1]
+	if entry.ID != "urn:uuid:11111111-1111-1111-1111-111111111111" {
+		t.Errorf("Entry id = %q", entry.ID)
+	}
+	if len(entry.Links) != 1 || entry.Links[0].Href != "https://example.com/go" {
+		t.Errorf("Entry links = %+v", entry.Links)
+	}
+	if entry.Published != "2024-02-28T00:00:00Z" || entry.Updated != "2024-03-01T12:00:00Z" {
+		t.Errorf("Entry published = %q, updated = %q", entry.Published, entry.Updated)
+	}
+	if entry.Summary != "All about generics" || entry.Author == nil || entry.Author.Name != "Jane Doe" {
+		t.Errorf("Entry summary = %q, author = %+v", entry.Summary, entry.Author)
+	}
+	if feed.Entries[0].Summary != "Lifetimes explained." {
+		t.Errorf("Summary = %q, want the content when there is no description", feed.Entries[0].Summary)
+	}
+
+	// Without a category every record is included, up to the limit
+	feed = atomFeed{}
+	w = httptest.NewRecorder()
+	server.handleFeed(w, httptest.NewRequest(http.MethodGet, "/api/feed?limit=2", nil))
+	if err := xml.Unmarshal(w.Body.Bytes(), &feed); err != nil {
+		t.Fatalf("Failed to parse feed: %v", err)
+	}
+	if len(feed.Entries) != 2 || feed.Entries[1].Title != "Breaking News" {
+		t.Errorf("Got %d entries, want the 2 newest records", len(feed.Entries))
+	}
+
+	w = httptest.NewRecorder()
+	server.handleFeed(w, httptest.NewRequest(http.MethodPost, "/api/feed", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Status code = %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestHandleFeedScanLimit(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	// The only matching record is older than the scan limit reaches
+	base := time.Now()
+	for i := 0; i <= maxFeedScan; i++ {
+		data := &models.ScrapedData{
+			ID:        fmt.Sprintf("feed-%d", i),
+			URL:       fmt.Sprintf("https://example.com/%d", i),
+			FetchedAt: base.Add(time.Duration(i) * time.Second),
+		}
+		if i == 0 {
+			data.Score = &models.LinkScore{Categories: []string{"rare"}}
+		}
+		if err := server.db.SaveScrapedData(data); err != nil {
+			t.Fatalf("Failed to save data: %v", err)
+		}
+	}
+
+	var feed atomFeed
+	w := httptest.NewRecorder()
+	server.handleFeed(w, httptest.NewRequest(http.MethodGet, "/api/feed?category=rare", nil))
+	if err := xml.Unmarshal(w.Body.Bytes(), &feed); err != nil {
+		t.Fatalf("Failed to parse feed: %v", err)
+	}
+	if len(feed.Entries) != 0 {
+		t.Errorf("Got %d entries, want none past the scan limit", len(feed.Entries))
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	if got := summarize("  short   text ", 50); got != "short text" {
+		t.Errorf("summarize = %q", got)
+	}
+	if got := summarize("one two three four", 12); got != "one two ..." {
+		t.Errorf("summarize = %q, want a cut at a word boundary", got)
+	}
+}
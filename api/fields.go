@@ -0,0 +1,62 @@
+package api
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/zombar/scraper/models"
+)
+
+// scrapedDataFields maps each top-level ScrapedData JSON field name to its
+// struct field index. It is the allowlist of names accepted by "fields".
+var scrapedDataFields = jsonFieldIndex(reflect.TypeOf(models.ScrapedData{}))
+
+// jsonFieldIndex maps the JSON names of a struct's exported fields to their index
+func jsonFieldIndex(t reflect.Type) map[string]int {
+	index := make(map[string]int, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		index[name] = i
+	}
+	return index
+}
+
+// parseFields validates requested response fields, returning nil (the full
+// response) when none are given
+func parseFields(fields []string) ([]string, error) {
+	var valid []string
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if _, ok := scrapedDataFields[field]; !ok {
+			return nil, fmt.Errorf("invalid field: %s", field)
+		}
+		valid = append(valid, field)
+	}
+	return valid, nil
+}
+
+// projectFields returns the data limited to the given fields for marshalling,
+// or the data itself when fields is empty. Requested fields are always
+// included, even when empty.
+func projectFields(data *models.ScrapedData, fields []string) interface{} {
+	if len(fields) == 0 || data == nil {
+		return data
+	}
+	v := reflect.ValueOf(data).Elem()
+	projected := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		projected[field] = v.Field(scrapedDataFields[field]).Interface()
+	}
+	return projected
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/zombar/scraper/models"
+)
+
+func TestScrapeFields(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	data := &models.ScrapedData{
+		ID:        "fields-1",
+		URL:       "https://example.com/article",
+		Title:     "Article",
+		Content:   "Body text",
+		Links:     []string{"https://example.com/other"},
+		FetchedAt: time.Now(),
+		Score:     &models.LinkScore{Score: 0.8},
+	}
+	if err := server.db.SaveScrapedData(data); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
+	}
+
+	body, _ := json.Marshal(ScrapeRequest{URL: data.URL, Fields: []string{"title", "score", "markdown"}})
+	w := httptest.NewRecorder()
+	server.handleScrape(w, httptest.NewRequest(http.MethodPost, "/api/scrape", bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Status code = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var resp map[string]json.RawMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(resp) != 3 {
+		t.Errorf("Response has fields %v, want only title, score, and markdown", resp)
+	}
+	if string(resp["title"]) != `"Article"` {
+		t.Errorf("title = %s", resp["title"])
+	}
+	if _, ok := resp["markdown"]; !ok {
+		t.Error("Expected requested empty field to be included")
+	}
+	var score models.LinkScore
+	if err := json.Unmarshal(resp["score"], &score); err != nil || score.Score != 0.8 {
+		t.Errorf("score = %s (err: %v)", resp["score"], err)
+	}
+
+	body, _ = json.Marshal(ScrapeRequest{URL: data.URL, Fields: []string{"title", "bogus"}})
+	w = httptest.NewRecorder()
+	server.handleScrape(w, httptest.NewRequest(http.MethodPost, "/api/scrape", bytes.NewReader(body)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Status code = %d, want %d for an invalid field", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestListFields(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	data := &models.ScrapedData{ID: "fields-1", URL: "https://example.com/a", Title: "A", Content: "Body", FetchedAt: time.Now()}
+	if err := server.db.SaveScrapedData(data); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	server.handleList(w, httptest.NewRequest(http.MethodGet, "/api/data?fields=id,title", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Status code = %d, want %d", w.Code, http.StatusOK)
+	}
+	var resp struct {
+		Data  []map[string]interface{} `json:"data"`
+		Total int                      `json:"total"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(resp.Data) != 1 || len(resp.Data[0]) != 2 || resp.Data[0]["title"] != "A" || resp.Data[0]["id"] != "fields-1" {
+		t.Errorf("Data = %v, want only id and title", resp.Data)
+	}
+	if resp.Total != 1 {
+		t.Errorf("Total = %d, want the pagination envelope unchanged", resp.Total)
+	}
+
+	w = httptest.NewRecorder()
+	server.handleList(w, httptest.NewRequest(http.MethodGet, "/api/data?fields=title,Content", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Status code = %d, want %d for an invalid field", w.Code, http.StatusBadRequest)
+	}
+}
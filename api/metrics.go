@@ -0,0 +1,19 @@
+package api
+
+import (
+	"log"
+	"net/http"
+)
+
+// handleMetrics serves scraper metrics in the Prometheus text exposition format
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if _, err := s.scraper.Metrics().WriteTo(w); err != nil {
+		log.Printf("Failed to write metrics: %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zombar/scraper"
+	"github.com/zombar/scraper/db"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	server, err := NewServer(Config{
+		DBConfig:       db.Config{Driver: "sqlite", DSN: t.TempDir() + "/test.db"},
+		ScraperConfig:  scraper.DefaultConfig(),
+		MetricsEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	defer server.db.Close()
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Status code = %d, want %d", w.Code, http.StatusOK)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Content-Type = %q, want text/plain", ct)
+	}
+	if !strings.Contains(w.Body.String(), "scraper_scrapes_total") {
+		t.Errorf("Expected scrape counter in metrics, got:\n%s", w.Body.String())
+	}
+}
+
+func TestMetricsEndpointDisabled(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Status code = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/zombar/scraper"
+)
+
+// ndjsonContentType is the media type of JSON Lines responses, one JSON
+// value per line
+const ndjsonContentType = "application/x-ndjson"
+
+// wantsNDJSON reports whether a request's Accept header asks for JSON Lines
+func wantsNDJSON(r *http.Request) bool {
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		if mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accept)); err == nil && mediaType == ndjsonContentType {
+			return true
+		}
+	}
+	return false
+}
+
+// respondScrape sends a scrape result as a JSON object, or as a single JSON
+// line when the request asks for JSON Lines
+func respondScrape(w http.ResponseWriter, r *http.Request, data interface{}) {
+	if !wantsNDJSON(r) {
+		respondJSON(w, http.StatusOK, data)
+		return
+	}
+	w.Header().Set("Content-Type", ndjsonContentType)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(data)
+}
+
+// streamBatchNDJSON scrapes a batch like handleBatchScrape but writes each
+// BatchResult as a JSON line as soon as its URL finishes, in completion
+// order, without a summary. Disconnecting the client cancels outstanding
+// scrapes.
+func (s *Server) streamBatchNDJSON(w http.ResponseWriter, r *http.Request, req BatchScrapeRequest) {
+	flusher, _ := w.(http.Flusher)
+	w.Header().Set("Content-Type", ndjsonContentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	ctx := scraper.WithImageBudget(r.Context(), scraper.NewImageBudget(s.maxBatchImages))
+
+	// Buffered so scrapes finishing after a disconnect never block
+	results := make(chan BatchResult, len(req.URLs))
+	for _, url := range req.URLs {
+		go func(targetURL string) {
+			results <- s.processSingleURL(ctx, targetURL, req.Force)
+		}(url)
+	}
+
+	encoder := json.NewEncoder(w)
+	for range req.URLs {
+		select {
+		case result := <-results:
+			if req.SummaryOnly {
+				result.Data = nil
+			} else if !s.batchImageData {
+				stripImageData(result.Data)
+			}
+			encoder.Encode(result)
+			if flusher != nil {
+				flusher.Flush()
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zombar/scraper/models"
+)
+
+func TestWantsNDJSON(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{"", false},
+		{"application/json", false},
+		{"application/x-ndjson", true},
+		{"application/json, application/x-ndjson;q=0.9", true},
+		{"*/*", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/api/scrape", nil)
+		r.Header.Set("Accept", tt.accept)
+		if got := wantsNDJSON(r); got != tt.want {
+			t.Errorf("wantsNDJSON(%q) = %v, want %v", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestScrapeNDJSON(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	webServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Lines</title></head><body><p>Content</p></body></html>`))
+	}))
+	defer webServer.Close()
+
+	post := func(path, body, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, req)
+		return w
+	}
+	// lines returns the non-empty lines of a response body
+	lines := func(w *httptest.ResponseRecorder) []string {
+		var lines []string
+		scanner := bufio.NewScanner(w.Body)
+		scanner.Buffer(nil, 1<<20)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				lines = append(lines, line)
+			}
+		}
+		return lines
+	}
+
+	t.Run("single", func(t *testing.T) {
+		w := post("/api/scrape", `{"url": "`+webServer.URL+`"}`, "application/x-ndjson")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Status = %d: %s", w.Code, w.Body.String())
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+			t.Errorf("Content-Type = %q, want application/x-ndjson", ct)
+		}
+		got := lines(w)
+		if len(got) != 1 {
+			t.Fatalf("Got %d lines, want 1: %q", len(got), got)
+		}
+		var data models.ScrapedData
+		if err := json.Unmarshal([]byte(got[0]), &data); err != nil || data.Title != "Lines" {
+			t.Errorf("Line = %q (%v), want the scraped data", got[0], err)
+		}
+
+		w = post("/api/scrape", `{"url": "`+webServer.URL+`"}`, "application/json")
+		if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q without asking for JSON Lines, want application/json", ct)
+		}
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		body := `{"urls": ["` + webServer.URL + `/batch", "ftp://example.com"]}`
+		w := post("/api/scrape/batch", body, "application/x-ndjson")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Status = %d: %s", w.Code, w.Body.String())
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+			t.Errorf("Content-Type = %q, want application/x-ndjson", ct)
+		}
+		got := lines(w)
+		if len(got) != 2 {
+			t.Fatalf("Got %d lines, want one per URL: %q", len(got), got)
+		}
+		results := map[string]BatchResult{}
+		for _, line := range got {
+			var result BatchResult
+			if err := json.Unmarshal([]byte(line), &result); err != nil {
+				t.Fatalf("Failed to decode line %q: %v", line, err)
+			}
+			results[result.URL] = result
+		}
+		if ok := results[webServer.URL+"/batch"]; !ok.Success || ok.Data == nil || ok.Data.Title != "Lines" {
+			t.Errorf("Result = %+v, want a success with its data", ok)
+		}
+		if failed := results["ftp://example.com"]; failed.Success || failed.Error == "" {
+			t.Errorf("Result = %+v, want a failure with its error", failed)
+		}
+	})
+
+	t.Run("errors stay JSON", func(t *testing.T) {
+		w := post("/api/scrape", `{}`, "application/x-ndjson")
+		if w.Code != http.StatusBadRequest || w.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("Status = %d, Content-Type = %q; want a 400 JSON error", w.Code, w.Header().Get("Content-Type"))
+		}
+	})
+}
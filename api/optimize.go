@@ -0,0 +1,97 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/zombar/scraper/db"
+)
+
+// handleOptimize vacuums and optimizes the database, reporting the space
+// reclaimed. Writes made while it runs may fail, as db.Optimize explains.
+func (s *Server) handleOptimize(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if s.auth == nil {
+		respondError(w, http.StatusForbidden, "optimize requires API key authentication")
+		return
+	}
+
+	result, err := s.db.Optimize(r.Context())
+	if errors.Is(err, db.ErrOptimizeInProgress) {
+		respondError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("Database optimize failed: %v", err)
+		respondError(w, http.StatusInternalServerError, "failed to optimize database")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
+
+// optimizeJob periodically vacuums and optimizes the database
+type optimizeJob struct {
+	interval time.Duration
+	optimize func(ctx context.Context) (*db.OptimizeResult, error)
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// newOptimizeJob creates an optimize job, or returns nil if interval is not positive
+func newOptimizeJob(interval time.Duration, optimize func(ctx context.Context) (*db.OptimizeResult, error)) *optimizeJob {
+	if interval <= 0 {
+		return nil
+	}
+	return &optimizeJob{
+		interval: interval,
+		optimize: optimize,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// start runs the job in the background every interval until stopped. Unlike
+// the retention job it does not run at startup, when there is nothing new to
+// reclaim.
+func (j *optimizeJob) start() {
+	go func() {
+		defer close(j.done)
+
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				j.run()
+			case <-j.stop:
+				return
+			}
+		}
+	}()
+}
+
+// run optimizes the database once, logging the outcome
+func (j *optimizeJob) run() {
+	result, err := j.optimize(context.Background())
+	if err != nil {
+		log.Printf("Scheduled database optimize failed: %v", err)
+		return
+	}
+	log.Printf("Database optimized, reclaimed %d bytes (%d -> %d)", result.Reclaimed, result.SizeBefore, result.SizeAfter)
+}
+
+// close stops the job and waits for an in-progress run to finish
+func (j *optimizeJob) close() {
+	if j == nil {
+		return
+	}
+	close(j.stop)
+	<-j.done
+}
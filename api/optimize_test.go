@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zombar/scraper"
+	"github.com/zombar/scraper/db"
+)
+
+func TestHandleOptimize(t *testing.T) {
+	optimize := func(server *Server, method string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/admin/optimize", nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	unauthenticated, cleanup := setupTestServer(t)
+	defer cleanup()
+	if w := optimize(unauthenticated, http.MethodPost); w.Code != http.StatusForbidden {
+		t.Errorf("without API keys: status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+
+	server, err := NewServer(Config{
+		DBConfig:      db.Config{Driver: "sqlite", DSN: t.TempDir() + "/test.db"},
+		ScraperConfig: scraper.DefaultConfig(),
+		APIKeys:       []string{"secret"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	defer server.db.Close()
+
+	if w := optimize(server, http.MethodGet); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET: status = %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+
+	w := optimize(server, http.MethodPost)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var result db.OptimizeResult
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if result.SizeBefore <= 0 || result.SizeAfter <= 0 {
+		t.Errorf("result = %+v, want database sizes", result)
+	}
+}
+
+func TestOptimizeJob(t *testing.T) {
+	if job := newOptimizeJob(0, nil); job != nil {
+		t.Error("newOptimizeJob(0) should disable the job")
+	}
+
+	var runs int32
+	job := newOptimizeJob(10*time.Millisecond, func(ctx context.Context) (*db.OptimizeResult, error) {
+		if atomic.AddInt32(&runs, 1) == 1 {
+			return nil, errors.New("database is locked")
+		}
+		return &db.OptimizeResult{}, nil
+	})
+	job.start()
+	time.Sleep(100 * time.Millisecond)
+	job.close()
+
+	if n := atomic.LoadInt32(&runs); n < 2 {
+		t.Errorf("job ran %d times, want it to keep running after a failure", n)
+	}
+}
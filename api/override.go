@@ -0,0 +1,49 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/zombar/scraper/ollama"
+)
+
+// maxTemperature is the highest sampling temperature a request may ask for
+const maxTemperature = 2.0
+
+// newModelAllowlist returns the Ollama models requests may choose, which
+// always include the configured one
+func newModelAllowlist(defaultModel string, allowed []string) map[string]bool {
+	models := map[string]bool{defaultModel: true}
+	for _, model := range allowed {
+		models[model] = true
+	}
+	return models
+}
+
+// ollamaOverride returns a context whose Ollama requests use the model and
+// temperature a scrape or score request asked for, leaving the server's
+// client untouched. Without either, ctx is returned unchanged. A model
+// outside the allowlist or a temperature outside 0-2 responds with 400 and
+// returns ok false.
+func (s *Server) ollamaOverride(ctx context.Context, w http.ResponseWriter, targetURL, model string, temperature *float64) (_ context.Context, ok bool) {
+	if model == "" && temperature == nil {
+		return ctx, true
+	}
+	if model != "" && !s.allowedModels[model] {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("model %q is not allowed", model))
+		return ctx, false
+	}
+	if temperature != nil && (*temperature < 0 || *temperature > maxTemperature) {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("temperature must be between 0 and %g", maxTemperature))
+		return ctx, false
+	}
+
+	if temperature != nil {
+		log.Printf("Ollama override for %s: model %q, temperature %g", targetURL, model, *temperature)
+	} else {
+		log.Printf("Ollama override for %s: model %q", targetURL, model)
+	}
+	return ollama.WithOverride(ctx, ollama.Override{Model: model, Temperature: temperature}), true
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/zombar/scraper"
+	"github.com/zombar/scraper/db"
+	"github.com/zombar/scraper/models"
+)
+
+func TestOllamaOverride(t *testing.T) {
+	var mu sync.Mutex
+	var requests []models.OllamaRequest
+	ollamaServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req models.OllamaRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		requests = append(requests, req)
+		mu.Unlock()
+		json.NewEncoder(w).Encode(models.OllamaResponse{Response: `{"score": 0.8, "reason": "Useful"}`, Done: true})
+	}))
+	defer ollamaServer.Close()
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><head><title>Models</title></head><body><p>A page worth reading.</p></body></html>`))
+	}))
+	defer target.Close()
+
+	scraperConfig := scraper.DefaultConfig()
+	scraperConfig.OllamaBaseURL = ollamaServer.URL
+	scraperConfig.OllamaModel = "default-model"
+	scraperConfig.EnableImageAnalysis = false
+	server, err := NewServer(Config{
+		DBConfig:      db.Config{Driver: "sqlite", DSN: t.TempDir() + "/test.db"},
+		ScraperConfig: scraperConfig,
+		AllowedModels: []string{"other-model"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	defer server.db.Close()
+
+	post := func(path string, req interface{}) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body)))
+		return w
+	}
+	// sent returns the Ollama requests made since the last call
+	sent := func() []models.OllamaRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		made := requests
+		requests = nil
+		return made
+	}
+	temperature := 0.6
+	tooHot := 2.5
+
+	t.Run("rejected", func(t *testing.T) {
+		for name, w := range map[string]*httptest.ResponseRecorder{
+			"scrape model":       post("/api/scrape", ScrapeRequest{URL: target.URL, Model: "unknown-model"}),
+			"scrape temperature": post("/api/scrape", ScrapeRequest{URL: target.URL, Temperature: &tooHot}),
+			"score model":        post("/api/score", models.ScoreRequest{URL: target.URL, Model: "unknown-model"}),
+		} {
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("%s: status = %d, want 400", name, w.Code)
+			}
+		}
+		if made := sent(); len(made) != 0 {
+			t.Errorf("Made %d Ollama requests for rejected overrides, want none", len(made))
+		}
+	})
+
+	t.Run("scrape", func(t *testing.T) {
+		w := post("/api/scrape", ScrapeRequest{URL: target.URL})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Scrape status = %d: %s", w.Code, w.Body.String())
+		}
+		var stored models.ScrapedData
+		json.NewDecoder(w.Body).Decode(&stored)
+		for _, req := range sent() {
+			if req.Model != "default-model" {
+				t.Errorf("Model = %q without an override, want default-model", req.Model)
+			}
+		}
+
+		w = post("/api/scrape", ScrapeRequest{URL: target.URL, Model: "other-model", Temperature: &temperature})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Scrape status = %d: %s", w.Code, w.Body.String())
+		}
+		var data models.ScrapedData
+		json.NewDecoder(w.Body).Decode(&data)
+		if data.Cached {
+			t.Error("Expected an overridden scrape to bypass the cache")
+		}
+		made := sent()
+		if len(made) == 0 {
+			t.Fatal("Expected the overridden scrape to call Ollama")
+		}
+		for _, req := range made {
+			if req.Model != "other-model" || req.Options == nil || *req.Options.Temperature != 0.6 {
+				t.Errorf("Request = %+v, want other-model at temperature 0.6", req)
+			}
+		}
+
+		if record, err := server.db.GetByURL(stored.URL); err != nil || record == nil || record.ID != stored.ID {
+			t.Errorf("Stored record = %+v, %v; want the default-model scrape %s left in place", record, err, stored.ID)
+		}
+		if record, _ := server.db.GetByID(data.ID); record != nil {
+			t.Error("Expected the overridden result not to be stored")
+		}
+	})
+
+	t.Run("score", func(t *testing.T) {
+		if w := post("/api/score", models.ScoreRequest{URL: target.URL, Model: "other-model"}); w.Code != http.StatusOK {
+			t.Fatalf("Score status = %d: %s", w.Code, w.Body.String())
+		}
+		made := sent()
+		if len(made) != 1 || made[0].Model != "other-model" {
+			t.Errorf("Requests = %+v, want one scoring request to other-model", made)
+		}
+		if score, err := server.db.GetLinkScoreByURL(target.URL); err != nil || score != nil {
+			t.Errorf("Saved score = %+v, %v; want the overridden score not saved", score, err)
+		}
+
+		if w := post("/api/score", models.ScoreRequest{URL: target.URL}); w.Code != http.StatusOK {
+			t.Fatalf("Score status = %d: %s", w.Code, w.Body.String())
+		}
+		if made := sent(); len(made) != 1 || made[0].Model != "default-model" {
+			t.Errorf("Requests = %+v, want the server's model after an override", made)
+		}
+		if score, err := server.db.GetLinkScoreByURL(target.URL); err != nil || score == nil {
+			t.Errorf("Saved score = %+v, %v; want the default-model score saved", score, err)
+		}
+	})
+}
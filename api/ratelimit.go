@@ -0,0 +1,130 @@
+package api
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimitConfig configures per-client request rate limiting
+type RateLimitConfig struct {
+	RequestsPerSecond float64 // Sustained requests per second per client (0 disables rate limiting)
+	Burst             int     // Requests a client may make at once (0 uses 1)
+	// TrustForwardedFor identifies clients by the last X-Forwarded-For entry
+	// instead of the connection address. Only enable behind a proxy that sets it.
+	TrustForwardedFor bool
+}
+
+// bucketIdleTTL is how long an untouched client bucket is kept before eviction
+const bucketIdleTTL = 10 * time.Minute
+
+// tokenBucket tracks the available requests for one client
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter is a per-client token bucket rate limiter
+type rateLimiter struct {
+	config    RateLimitConfig
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastPrune time.Time
+	now       func() time.Time // overridable for tests
+}
+
+// newRateLimiter creates a rate limiter, or returns nil if rate limiting is disabled
+func newRateLimiter(config RateLimitConfig) *rateLimiter {
+	if config.RequestsPerSecond <= 0 {
+		return nil
+	}
+	if config.Burst < 1 {
+		config.Burst = 1
+	}
+	return &rateLimiter{
+		config:  config,
+		buckets: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+// allow takes a token for the client, returning false and the time until the
+// next token is available if the client is over its limit
+func (l *rateLimiter) allow(client string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now)
+
+	bucket, ok := l.buckets[client]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(l.config.Burst), last: now}
+		l.buckets[client] = bucket
+	}
+
+	// Refill for the time elapsed since the last request
+	elapsed := now.Sub(bucket.last).Seconds()
+	bucket.tokens = math.Min(float64(l.config.Burst), bucket.tokens+elapsed*l.config.RequestsPerSecond)
+	bucket.last = now
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, 0
+	}
+
+	wait := time.Duration((1 - bucket.tokens) / l.config.RequestsPerSecond * float64(time.Second))
+	return false, wait
+}
+
+// prune drops buckets idle long enough to have refilled, at most once per TTL
+func (l *rateLimiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < bucketIdleTTL {
+		return
+	}
+	l.lastPrune = now
+	for client, bucket := range l.buckets {
+		if now.Sub(bucket.last) > bucketIdleTTL {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+// clientIP identifies the client making a request
+func (l *rateLimiter) clientIP(r *http.Request) string {
+	if l.config.TrustForwardedFor {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			// The last entry was added by our proxy; earlier ones are client-controlled
+			entries := strings.Split(forwarded, ",")
+			if ip := strings.TrimSpace(entries[len(entries)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// rateLimited checks the request against the rate limit, responding with 429
+// and returning true if the client is over its limit
+func (s *Server) rateLimited(w http.ResponseWriter, r *http.Request) bool {
+	if s.rateLimiter == nil || r.URL.Path == "/health" {
+		return false
+	}
+
+	ok, wait := s.rateLimiter.allow(s.rateLimiter.clientIP(r))
+	if ok {
+		return false
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	respondError(w, http.StatusTooManyRequests, "rate limit exceeded")
+	return true
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/zombar/scraper"
+	"github.com/zombar/scraper/db"
+)
+
+func TestRateLimitBurstThenThrottle(t *testing.T) {
+	server, err := NewServer(Config{
+		DBConfig:      db.Config{Driver: "sqlite", DSN: t.TempDir() + "/test.db"},
+		ScraperConfig: scraper.DefaultConfig(),
+		RateLimit:     RateLimitConfig{RequestsPerSecond: 0.001, Burst: 3},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	defer server.db.Close()
+
+	get := func(path, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < 3; i++ {
+		if w := get("/api/data", "10.0.0.1:1234"); w.Code != http.StatusOK {
+			t.Fatalf("Request %d: status code = %d, want %d", i+1, w.Code, http.StatusOK)
+		}
+	}
+
+	w := get("/api/data", "10.0.0.1:5678")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Status code = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("Expected Retry-After header on throttled response")
+	}
+
+	// Other clients have their own bucket
+	if w := get("/api/data", "10.0.0.2:1234"); w.Code != http.StatusOK {
+		t.Errorf("Other client status code = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	// Health checks are never throttled
+	if w := get("/health", "10.0.0.1:1234"); w.Code != http.StatusOK {
+		t.Errorf("Health status code = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestRateLimiterRefill(t *testing.T) {
+	limiter := newRateLimiter(RateLimitConfig{RequestsPerSecond: 2, Burst: 1})
+	now := time.Unix(1700000000, 0)
+	limiter.now = func() time.Time { return now }
+
+	if ok, _ := limiter.allow("client"); !ok {
+		t.Fatal("Expected first request to be allowed")
+	}
+	ok, wait := limiter.allow("client")
+	if ok {
+		t.Fatal("Expected second request to be throttled")
+	}
+	if wait != 500*time.Millisecond {
+		t.Errorf("Wait = %v, want 500ms", wait)
+	}
+
+	now = now.Add(wait)
+	if ok, _ := limiter.allow("client"); !ok {
+		t.Error("Expected request to be allowed after refill")
+	}
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	if limiter := newRateLimiter(RateLimitConfig{}); limiter != nil {
+		t.Error("Expected nil limiter when rate limiting is disabled")
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name      string
+		trust     bool
+		forwarded string
+		want      string
+	}{
+		{"remote addr", false, "", "192.0.2.1"},
+		{"forwarded ignored when untrusted", false, "203.0.113.5", "192.0.2.1"},
+		{"forwarded trusted", true, "203.0.113.5", "203.0.113.5"},
+		{"last forwarded entry", true, "198.51.100.7, 203.0.113.5", "203.0.113.5"},
+		{"no forwarded header", true, "", "192.0.2.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := newRateLimiter(RateLimitConfig{RequestsPerSecond: 1, TrustForwardedFor: tt.trust})
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = "192.0.2.1:4321"
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if got := limiter.clientIP(req); got != tt.want {
+				t.Errorf("clientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
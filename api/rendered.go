@@ -0,0 +1,45 @@
+package api
+
+import (
+	"html"
+	"net/http"
+
+	"github.com/zombar/scraper"
+)
+
+// renderedContentPolicy is the Content-Security-Policy sent with rendered
+// content: no scripts, frames, or styles, only images from the web
+const renderedContentPolicy = "default-src 'none'; img-src http: https:"
+
+// handleRendered serves a record's content as an HTML fragment for display:
+// its Markdown rendered to HTML when it was scraped with -enable-markdown,
+// otherwise its plain text in a <pre>
+func (s *Server) handleRendered(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	data, err := s.db.GetByID(id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+	if data == nil {
+		respondError(w, http.StatusNotFound, "data not found")
+		return
+	}
+
+	var rendered string
+	if data.Markdown != "" {
+		rendered = scraper.MarkdownToHTML(data.Markdown)
+	} else {
+		rendered = "<pre>" + html.EscapeString(data.Content) + "</pre>"
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", renderedContentPolicy)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(rendered))
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/zombar/scraper/models"
+)
+
+func TestHandleRendered(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	records := []*models.ScrapedData{
+		{ID: "markdown", Content: "Plain", Markdown: "# Title\n\nSee [docs](https://example.com/docs) <script>x</script>"},
+		{ID: "text", Content: "Line one\n<b>not bold</b>"},
+	}
+	for _, data := range records {
+		data.URL = "https://example.com/" + data.ID
+		data.FetchedAt = time.Now()
+		if err := server.db.SaveScrapedData(data); err != nil {
+			t.Fatalf("Failed to save data: %v", err)
+		}
+	}
+
+	tests := []struct {
+		method string
+		id     string
+		status int
+		body   string
+	}{
+		{http.MethodGet, "markdown", http.StatusOK, "<h1>Title</h1>\n<p>See <a href=\"https://example.com/docs\">docs</a> &lt;script&gt;x&lt;/script&gt;</p>"},
+		{http.MethodGet, "text", http.StatusOK, "<pre>Line one\n&lt;b&gt;not bold&lt;/b&gt;</pre>"},
+		{http.MethodGet, "missing", http.StatusNotFound, ""},
+		{http.MethodPost, "text", http.StatusMethodNotAllowed, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, httptest.NewRequest(tt.method, "/api/data/"+tt.id+"/rendered", nil))
+		if w.Code != tt.status {
+			t.Errorf("%s %s status = %d, want %d", tt.method, tt.id, w.Code, tt.status)
+			continue
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+		if got := w.Header().Get("Content-Type"); got != "text/html; charset=utf-8" {
+			t.Errorf("Content-Type = %q", got)
+		}
+		if w.Header().Get("Content-Security-Policy") == "" {
+			t.Error("Expected a Content-Security-Policy header")
+		}
+		if w.Body.String() != tt.body {
+			t.Errorf("GET %s body =\n%s\nwant\n%s", tt.id, w.Body.String(), tt.body)
+		}
+	}
+}
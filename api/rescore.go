@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/zombar/scraper/db"
+	"github.com/zombar/scraper/models"
+)
+
+// RescoreResult reports the outcome of RescoreStale
+type RescoreResult struct {
+	Stale   int `json:"stale"`   // Records whose score needed refreshing
+	Updated int `json:"updated"` // Records re-scored by Ollama and saved
+}
+
+// RescoreStale re-scores stored records whose score came from the rule-based
+// fallback (or is missing), or was computed more than olderThan ago (zero
+// skips the age check). Stored content is scored again with Ollama rather
+// than re-fetched, and each record's JSON is updated in place. A record is
+// left unchanged if Ollama fails again, so running this while Ollama is down
+// never replaces a score with another fallback. At most maxBatchURLs records
+// are scored at once.
+func (s *Server) RescoreStale(ctx context.Context, olderThan time.Duration) (RescoreResult, error) {
+	var cutoff time.Time
+	if olderThan > 0 {
+		cutoff = time.Now().Add(-olderThan)
+	}
+
+	var result RescoreResult
+	save := func(data *models.ScrapedData) {
+		if data == nil {
+			return // scoring failed and was logged
+		}
+		if err := s.db.UpdateData(data); err != nil {
+			log.Printf("Failed to save rescored %s: %v", data.URL, err)
+			return
+		}
+		result.Updated++
+	}
+
+	// Scoring runs concurrently, but results are saved on this goroutine
+	// between Each's batch reads since SQLite allows only one writer. Each
+	// scorer sends exactly one value, nil on failure.
+	scored := make(chan *models.ScrapedData, maxBatchURLs)
+	inFlight := 0
+	err := s.db.Each(ctx, db.Filter{}, func(data *models.ScrapedData) error {
+		if !scoreIsStale(data, cutoff) {
+			return nil
+		}
+		result.Stale++
+
+		for inFlight == maxBatchURLs {
+			select {
+			case done := <-scored:
+				inFlight--
+				save(done)
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		inFlight++
+		go func() {
+			score, err := s.scraper.ScoreContent(ctx, data.URL, data.Title, data.Content)
+			if err != nil {
+				log.Printf("Failed to rescore %s: %v", data.URL, err)
+				scored <- nil
+				return
+			}
+			s.scraper.ApplyTrackerPenalty(score, data.Metadata.TrackerCount)
+			score.ScoredAt = time.Now()
+			data.Score = score
+			scored <- data
+		}()
+		return nil
+	})
+	for ; inFlight > 0; inFlight-- {
+		save(<-scored)
+	}
+
+	log.Printf("Rescored %d of %d stale records", result.Updated, result.Stale)
+	return result, err
+}
+
+// scoreIsStale reports whether a record's score is missing, rule-based, or
+// was computed before cutoff (a zero cutoff skips the age check). Scores are
+// computed when the page is fetched unless a re-score set ScoredAt.
+func scoreIsStale(data *models.ScrapedData, cutoff time.Time) bool {
+	if data.Score == nil || !data.Score.AIUsed {
+		return true
+	}
+	scoredAt := data.Score.ScoredAt
+	if scoredAt.IsZero() {
+		scoredAt = data.FetchedAt
+	}
+	return !cutoff.IsZero() && scoredAt.Before(cutoff)
+}
+
+// handleRescore re-scores stale records with RescoreStale. Because it can
+// make an Ollama request per stored page, it is only available when API keys
+// are configured.
+func (s *Server) handleRescore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if s.auth == nil {
+		respondError(w, http.StatusForbidden, "rescore requires API key authentication")
+		return
+	}
+
+	var olderThan time.Duration
+	if value := r.URL.Query().Get("older_than"); value != "" {
+		var err error
+		olderThan, err = time.ParseDuration(value)
+		if err != nil || olderThan <= 0 {
+			respondError(w, http.StatusBadRequest, "older_than must be a positive duration such as 720h")
+			return
+		}
+	}
+
+	result, err := s.RescoreStale(r.Context(), olderThan)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to rescore data")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
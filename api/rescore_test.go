@@ -0,0 +1,132 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zombar/scraper"
+	"github.com/zombar/scraper/db"
+	"github.com/zombar/scraper/models"
+)
+
+func TestHandleRescore(t *testing.T) {
+	var healthy int32
+	ollamaServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&healthy) == 0 {
+			http.Error(w, "model not loaded", http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(models.OllamaResponse{
+			Response: `{"score": 0.9, "reason": "Substantive article", "categories": ["technical"], "malicious_indicators": []}`,
+			Done:     true,
+		})
+	}))
+	defer ollamaServer.Close()
+
+	scraperConfig := scraper.DefaultConfig()
+	scraperConfig.OllamaBaseURL = ollamaServer.URL
+	scraperConfig.MaxTrackers = 2
+	server, err := NewServer(Config{
+		DBConfig:      db.Config{Driver: "sqlite", DSN: t.TempDir() + "/test.db"},
+		ScraperConfig: scraperConfig,
+		APIKeys:       []string{"secret"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	defer server.db.Close()
+
+	now := time.Now()
+	records := []*models.ScrapedData{
+		{ID: "rule-based", FetchedAt: now, Score: &models.LinkScore{Score: 0.5, AIUsed: false}},
+		{ID: "old-ai", FetchedAt: now.Add(-48 * time.Hour), Score: &models.LinkScore{Score: 0.4, AIUsed: true}},
+		{ID: "fresh-ai", FetchedAt: now, Score: &models.LinkScore{Score: 0.7, AIUsed: true}},
+		{ID: "unscored", FetchedAt: now},
+		{ID: "tracked", FetchedAt: now, Metadata: models.PageMetadata{TrackerCount: 5}},
+	}
+	for _, data := range records {
+		data.URL = "https://example.com/" + data.ID
+		data.Title = "Page " + data.ID
+		data.Content = "Stored content for " + data.ID
+		if err := server.db.SaveScrapedData(data); err != nil {
+			t.Fatalf("Failed to save data: %v", err)
+		}
+	}
+
+	rescore := func(query string) (int, RescoreResult) {
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/rescore"+query, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, req)
+		var result RescoreResult
+		json.NewDecoder(w.Body).Decode(&result)
+		return w.Code, result
+	}
+
+	for _, query := range []string{"?older_than=soon", "?older_than=-1h"} {
+		if code, _ := rescore(query); code != http.StatusBadRequest {
+			t.Errorf("POST /api/admin/rescore%s: status code = %d, want %d", query, code, http.StatusBadRequest)
+		}
+	}
+
+	// While Ollama is down nothing is replaced with another fallback score
+	if code, result := rescore(""); code != http.StatusOK || result != (RescoreResult{Stale: 3, Updated: 0}) {
+		t.Errorf("Rescore with Ollama down = %d %+v, want 200 with 3 stale and none updated", code, result)
+	}
+	if data, _ := server.db.GetByID("rule-based"); data.Score.AIUsed {
+		t.Errorf("Score = %+v, want the rule-based score kept", data.Score)
+	}
+
+	atomic.StoreInt32(&healthy, 1)
+	if code, result := rescore("?older_than=24h"); code != http.StatusOK || result != (RescoreResult{Stale: 4, Updated: 4}) {
+		t.Errorf("Rescore = %d %+v, want 200 with 4 stale and 4 updated", code, result)
+	}
+	for _, id := range []string{"rule-based", "old-ai", "unscored"} {
+		data, err := server.db.GetByID(id)
+		if err != nil {
+			t.Fatalf("GetByID(%q) error = %v", id, err)
+		}
+		if data.Score == nil || !data.Score.AIUsed || data.Score.Score != 0.9 || data.Score.ScoredAt.IsZero() {
+			t.Errorf("%s score = %+v, want the new AI score", id, data.Score)
+		}
+		if data.Content != "Stored content for "+id {
+			t.Errorf("%s content = %q, want it unchanged", id, data.Content)
+		}
+	}
+	// The tracker penalty is applied to the new score as it is on a scrape
+	if data, _ := server.db.GetByID("tracked"); data.Score == nil || data.Score.Score < 0.69 || data.Score.Score > 0.71 || !slices.Contains(data.Score.Categories, "tracker_heavy") {
+		t.Errorf("Tracked score = %+v, want the AI score lowered to 0.7 as tracker_heavy", data.Score)
+	}
+	if data, _ := server.db.GetByID("fresh-ai"); data.Score.Score != 0.7 {
+		t.Errorf("Fresh score = %+v, want it unchanged", data.Score)
+	}
+
+	// Re-scored records count as freshly scored
+	if _, result := rescore("?older_than=24h"); result.Stale != 0 {
+		t.Errorf("Second rescore found %d stale records, want 0", result.Stale)
+	}
+}
+
+func TestHandleRescoreRequiresAuth(t *testing.T) {
+	server, err := NewServer(Config{
+		DBConfig:      db.Config{Driver: "sqlite", DSN: t.TempDir() + "/test.db"},
+		ScraperConfig: scraper.DefaultConfig(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	defer server.db.Close()
+
+	for method, want := range map[string]int{http.MethodPost: http.StatusForbidden, http.MethodGet: http.StatusMethodNotAllowed} {
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, httptest.NewRequest(method, "/api/admin/rescore", nil))
+		if w.Code != want {
+			t.Errorf("%s status code = %d, want %d", method, w.Code, want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"log"
+	"time"
+)
+
+// maxRetentionInterval caps how long the retention job waits between purges
+const maxRetentionInterval = time.Hour
+
+// retentionJob periodically deletes scraped data older than the retention period
+type retentionJob struct {
+	period   time.Duration
+	interval time.Duration
+	purge    func(before time.Time) (int64, error)
+	now      func() time.Time // overridable for tests
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// newRetentionJob creates a retention job, or returns nil if retention is disabled
+func newRetentionJob(period time.Duration, purge func(before time.Time) (int64, error)) *retentionJob {
+	if period <= 0 {
+		return nil
+	}
+	return &retentionJob{
+		period:   period,
+		interval: min(period, maxRetentionInterval),
+		purge:    purge,
+		now:      time.Now,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// start runs the job in the background, purging once immediately and then
+// every interval until stopped
+func (j *retentionJob) start() {
+	go func() {
+		defer close(j.done)
+
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+		for {
+			j.run()
+			select {
+			case <-ticker.C:
+			case <-j.stop:
+				return
+			}
+		}
+	}()
+}
+
+// run deletes the data that has outlived the retention period
+func (j *retentionJob) run() (int64, error) {
+	deleted, err := j.purge(j.now().Add(-j.period))
+	if err != nil {
+		log.Printf("Retention purge failed: %v", err)
+		return 0, err
+	}
+	if deleted > 0 {
+		log.Printf("Retention purge deleted %d records older than %v", deleted, j.period)
+	}
+	return deleted, nil
+}
+
+// close stops the job and waits for an in-progress purge to finish
+func (j *retentionJob) close() {
+	if j == nil {
+		return
+	}
+	close(j.stop)
+	<-j.done
+}
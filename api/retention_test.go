@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/zombar/scraper"
+	"github.com/zombar/scraper/db"
+	"github.com/zombar/scraper/models"
+)
+
+func TestRetentionJobPurgesOldData(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	ages := []time.Duration{72 * time.Hour, 25 * time.Hour, 23 * time.Hour, time.Minute}
+	for i, age := range ages {
+		data := &models.ScrapedData{ID: fmt.Sprintf("retention-%d", i), URL: fmt.Sprintf("https://example.com/%d", i), FetchedAt: now.Add(-age)}
+		if i == 0 {
+			data.Images = []models.ImageInfo{{ID: "retention-img", URL: "https://example.com/a.jpg"}}
+		}
+		if err := server.db.SaveScrapedData(data); err != nil {
+			t.Fatalf("Failed to save data: %v", err)
+		}
+	}
+
+	job := newRetentionJob(24*time.Hour, server.db.DeleteOlderThan)
+	job.now = func() time.Time { return now }
+
+	deleted, err := job.run()
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("run() deleted %d records, want 2", deleted)
+	}
+
+	for i := range ages {
+		data, err := server.db.GetByID(fmt.Sprintf("retention-%d", i))
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		if kept := data != nil; kept != (i >= 2) {
+			t.Errorf("record %d kept = %v, want %v", i, kept, i >= 2)
+		}
+	}
+	if img, err := server.db.GetImageByID("retention-img"); err != nil || img != nil {
+		t.Errorf("image of purged record = %+v, %v; want deleted", img, err)
+	}
+
+	// Nothing left to purge
+	if deleted, err := job.run(); err != nil || deleted != 0 {
+		t.Errorf("second run() = %d, %v; want 0, nil", deleted, err)
+	}
+}
+
+func TestRetentionDisabled(t *testing.T) {
+	if job := newRetentionJob(0, nil); job != nil {
+		t.Errorf("newRetentionJob(0) = %+v, want nil", job)
+	}
+	// Closing a disabled job is a no-op
+	var job *retentionJob
+	job.close()
+}
+
+func TestRetentionJobStopsOnShutdown(t *testing.T) {
+	server, err := NewServer(Config{
+		DBConfig:        db.Config{Driver: "sqlite", DSN: t.TempDir() + "/test.db"},
+		ScraperConfig:   scraper.DefaultConfig(),
+		RetentionPeriod: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	if server.retention == nil {
+		t.Fatal("retention job not created")
+	}
+
+	if err := server.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	select {
+	case <-server.retention.done:
+	default:
+		t.Error("retention job still running after Shutdown")
+	}
+}
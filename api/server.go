@@ -3,9 +3,12 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -15,14 +18,58 @@ import (
 	"github.com/zombar/scraper/models"
 )
 
+// Endpoint names used as keys in Config.EnabledEndpoints
+const (
+	EndpointHealth       = "health"
+	EndpointScrape       = "scrape"
+	EndpointBatchScrape  = "batch"
+	EndpointExtractLinks = "extract_links"
+	EndpointScore        = "score"
+	EndpointGet          = "get"
+	EndpointDelete       = "delete"
+	EndpointList         = "list"
+	EndpointImage        = "image"
+	EndpointImageSearch  = "image_search"
+	EndpointMetrics      = "metrics"
+	EndpointFeed         = "feed"
+	EndpointSitemap      = "sitemap"
+	EndpointRescore      = "rescore"
+	EndpointDiscover     = "discover"
+	EndpointRendered     = "rendered"
+	EndpointTriage       = "triage"
+	EndpointOptimize     = "optimize"
+)
+
+// EndpointNames lists every endpoint name, for validating toggles
+var EndpointNames = []string{
+	EndpointHealth, EndpointScrape, EndpointBatchScrape, EndpointExtractLinks,
+	EndpointScore, EndpointGet, EndpointDelete, EndpointList, EndpointImage,
+	EndpointImageSearch, EndpointMetrics, EndpointFeed, EndpointSitemap,
+	EndpointRescore, EndpointDiscover, EndpointRendered, EndpointTriage,
+	EndpointOptimize,
+}
+
 // Server represents the API server
 type Server struct {
-	db          *db.DB
-	scraper     *scraper.Scraper
-	addr        string
-	server      *http.Server
-	mux         *http.ServeMux
-	corsEnabled bool
+	db               *db.DB
+	scraper          *scraper.Scraper
+	addr             string
+	server           *http.Server
+	mux              *http.ServeMux
+	corsEnabled      bool
+	enabledEndpoints map[string]bool
+	batchImageData   bool
+	maxBatchImages   int
+	storeFailures    bool
+	maxBodyBytes     int64
+	bodyReadTimeout  time.Duration
+	rateLimiter      *rateLimiter // nil when rate limiting is disabled
+	auth             *apiKeyAuth  // nil when authentication is disabled
+	retention        *retentionJob
+	optimize         *optimizeJob
+	deferredScoring  *deferredScorer // nil unless the scoring mode is scraper.ScoringDeferred
+	debugMode        bool
+	allowedModels    map[string]bool
 }
 
 // Config contains server configuration
@@ -31,6 +78,50 @@ type Config struct {
 	DBConfig      db.Config
 	ScraperConfig scraper.Config
 	CORSEnabled   bool
+	// EnabledEndpoints toggles individual endpoints by name (see Endpoint* constants).
+	// Endpoints missing from the map are enabled; a nil map enables everything.
+	EnabledEndpoints map[string]bool
+	// BatchIncludeImageData includes base64 image data in batch responses.
+	// Off by default to keep responses small; image data remains available via /api/images/{id}.
+	BatchIncludeImageData bool
+	// MaxBatchImages caps the images analyzed with Ollama across all pages of
+	// one batch request (0 for no cap). Images past the cap are stored
+	// without analysis and counted in the batch summary.
+	MaxBatchImages int
+	// MaxRequestBodyBytes limits the size of request bodies (0 uses the 1MB default).
+	MaxRequestBodyBytes int64
+	// BodyReadTimeout cuts off clients whose request body stalls for longer than
+	// this between reads (0 uses the 10s default), protecting against slow uploads.
+	BodyReadTimeout time.Duration
+	// RateLimit limits requests per client IP; the zero value disables it.
+	RateLimit RateLimitConfig
+	// MetricsEnabled collects scraper metrics and serves them at /metrics in
+	// the Prometheus text format.
+	MetricsEnabled bool
+	// APIKeys, when non-empty, requires every request except /health to send
+	// one of these keys as "Authorization: Bearer <key>" or "X-API-Key".
+	APIKeys []string
+	// RetentionPeriod, when positive, runs a background job that deletes
+	// scraped data (and its images) older than this.
+	RetentionPeriod time.Duration
+	// OptimizeInterval, when positive, vacuums and optimizes the database
+	// this often in the background, as POST /api/admin/optimize does.
+	OptimizeInterval time.Duration
+	// StoreFailures saves a record of each failed scrape (its URL, error, and
+	// HTTP status) for monitoring dead links; see GET /api/failures.
+	StoreFailures bool
+	// StoreRecommendedLinksOnly stores only the links the Ollama link filter
+	// kept, while scrape responses list every extracted link along with the
+	// kept ones. When the filter is unavailable all links are stored.
+	StoreRecommendedLinksOnly bool
+	// DebugMode lets scrape requests ask for the prompts sent to Ollama and
+	// its raw responses with ?debug=true, returned under "_debug". Off by
+	// default so that production responses stay clean.
+	DebugMode bool
+	// AllowedModels lists the Ollama models scrape and score requests may
+	// choose with "model", besides ScraperConfig.OllamaModel, which is always
+	// allowed.
+	AllowedModels []string
 }
 
 // DefaultConfig returns default server configuration
@@ -45,26 +136,66 @@ func DefaultConfig() Config {
 
 // NewServer creates a new API server
 func NewServer(config Config) (*Server, error) {
+	if err := config.ScraperConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid scraper config: %w", err)
+	}
+
 	// Initialize database
 	database, err := db.New(config.DBConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize database: %w", err)
 	}
 
-	// Initialize scraper
+	// Initialize scraper, reusing stored image analysis for duplicate images
+	if config.ScraperConfig.ImageLookup == nil {
+		config.ScraperConfig.ImageLookup = database.GetImageByHash
+	}
+	if config.MetricsEnabled {
+		config.ScraperConfig.MetricsEnabled = true
+	}
+	if config.StoreRecommendedLinksOnly {
+		config.ScraperConfig.KeepUnfilteredLinks = true
+	}
 	scraperInstance := scraper.New(config.ScraperConfig)
 
 	s := &Server{
-		db:          database,
-		scraper:     scraperInstance,
-		addr:        config.Addr,
-		mux:         http.NewServeMux(),
-		corsEnabled: config.CORSEnabled,
+		db:               database,
+		scraper:          scraperInstance,
+		addr:             config.Addr,
+		mux:              http.NewServeMux(),
+		corsEnabled:      config.CORSEnabled,
+		enabledEndpoints: config.EnabledEndpoints,
+		batchImageData:   config.BatchIncludeImageData,
+		maxBatchImages:   config.MaxBatchImages,
+		storeFailures:    config.StoreFailures,
+		maxBodyBytes:     config.MaxRequestBodyBytes,
+		bodyReadTimeout:  config.BodyReadTimeout,
+		rateLimiter:      newRateLimiter(config.RateLimit),
+		auth:             newAPIKeyAuth(config.APIKeys),
+		retention:        newRetentionJob(config.RetentionPeriod, database.DeleteOlderThan),
+		optimize:         newOptimizeJob(config.OptimizeInterval, database.Optimize),
+		debugMode:        config.DebugMode,
+		allowedModels:    newModelAllowlist(config.ScraperConfig.OllamaModel, config.AllowedModels),
+	}
+	if s.maxBodyBytes <= 0 {
+		s.maxBodyBytes = defaultMaxRequestBodyBytes
 	}
+	if s.bodyReadTimeout <= 0 {
+		s.bodyReadTimeout = defaultBodyReadTimeout
+	}
+
+	s.deferredScoring = newDeferredScorer(config.ScraperConfig.ScoringMode, s.rescoreDeferred)
 
 	// Register routes
 	s.registerRoutes()
 
+	if s.retention != nil {
+		s.retention.start()
+	}
+	if s.optimize != nil {
+		s.optimize.start()
+	}
+
 	// Create HTTP server
 	s.server = &http.Server{
 		Addr:         config.Addr,
@@ -77,17 +208,48 @@ func NewServer(config Config) (*Server, error) {
 	return s, nil
 }
 
-// registerRoutes sets up all API routes
+// registerRoutes sets up all enabled API routes
 func (s *Server) registerRoutes() {
-	s.mux.HandleFunc("/health", s.handleHealth)
-	s.mux.HandleFunc("/api/scrape", s.handleScrape)
-	s.mux.HandleFunc("/api/scrape/batch", s.handleBatchScrape)
-	s.mux.HandleFunc("/api/extract-links", s.handleExtractLinks)
-	s.mux.HandleFunc("/api/score", s.handleScore)
-	s.mux.HandleFunc("/api/data/", s.handleData) // Handles /api/data/{id}
-	s.mux.HandleFunc("/api/data", s.handleList)
-	s.mux.HandleFunc("/api/images/search", s.handleImageSearch)
-	s.mux.HandleFunc("/api/images/", s.handleImage) // Handles /api/images/{id}
+	s.handle(EndpointHealth, "/health", s.handleHealth)
+	s.handle(EndpointScrape, "/api/scrape", s.handleScrape)
+	s.handle(EndpointScrape, "/api/scrape/stream", s.handleScrapeStream)
+	s.handle(EndpointBatchScrape, "/api/scrape/batch", s.handleBatchScrape)
+	s.handle(EndpointBatchScrape, "/api/scrape/batch/stream", s.handleBatchScrapeStream)
+	s.handle(EndpointExtractLinks, "/api/extract-links", s.handleExtractLinks)
+	s.handle(EndpointScore, "/api/score", s.handleScore)
+	s.handle(EndpointDiscover, "/api/discover", s.handleDiscover)
+	s.handle(EndpointTriage, "/api/triage", s.handleTriage)
+	s.handle(EndpointSitemap, "/api/sitemap", s.handleSitemap)
+	s.handle(EndpointRescore, "/api/admin/rescore", s.handleRescore)
+	s.handle(EndpointOptimize, "/api/admin/optimize", s.handleOptimize)
+	if s.endpointEnabled(EndpointGet) || s.endpointEnabled(EndpointDelete) || s.endpointEnabled(EndpointRendered) {
+		s.mux.HandleFunc("/api/data/", s.handleData) // Handles /api/data/{id} and /api/data/{id}/rendered
+	}
+	if s.endpointEnabled(EndpointList) || s.endpointEnabled(EndpointDelete) {
+		s.mux.HandleFunc("/api/data", s.handleDataCollection)
+	}
+	s.handle(EndpointList, "/api/failures", s.handleListFailures)
+	s.handle(EndpointFeed, "/api/feed", s.handleFeed)
+	s.handle(EndpointImageSearch, "/api/images/search", s.handleImageSearch)
+	s.handle(EndpointImage, "/api/images/", s.handleImage) // Handles /api/images/{id} and /api/images/{id}/thumbnail
+	if s.scraper.Metrics() != nil {
+		s.handle(EndpointMetrics, "/metrics", s.handleMetrics)
+	}
+}
+
+// handle registers a route only if its endpoint is enabled
+func (s *Server) handle(endpoint, pattern string, handler http.HandlerFunc) {
+	if !s.endpointEnabled(endpoint) {
+		log.Printf("Endpoint %s disabled, not registering %s", endpoint, pattern)
+		return
+	}
+	s.mux.HandleFunc(pattern, handler)
+}
+
+// endpointEnabled reports whether the named endpoint is enabled
+func (s *Server) endpointEnabled(endpoint string) bool {
+	enabled, ok := s.enabledEndpoints[endpoint]
+	return !ok || enabled
 }
 
 // Start starts the API server
@@ -96,12 +258,20 @@ func (s *Server) Start() error {
 	return s.server.ListenAndServe()
 }
 
+// ReloadSiteRules re-reads the scraper's site rules file
+func (s *Server) ReloadSiteRules() error {
+	return s.scraper.ReloadSiteRules()
+}
+
 // Shutdown gracefully shuts down the server
 func (s *Server) Shutdown(ctx context.Context) error {
 	log.Println("Shutting down API server...")
+	s.retention.close()
+	s.optimize.close()
 	if err := s.server.Shutdown(ctx); err != nil {
 		return err
 	}
+	s.deferredScoring.close(ctx)
 	return s.db.Close()
 }
 
@@ -112,7 +282,7 @@ func (s *Server) middleware(next http.Handler) http.Handler {
 		if s.corsEnabled {
 			w.Header().Set("Access-Control-Allow-Origin", "*")
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key")
 
 			if r.Method == "OPTIONS" {
 				w.WriteHeader(http.StatusOK)
@@ -120,6 +290,16 @@ func (s *Server) middleware(next http.Handler) http.Handler {
 			}
 		}
 
+		if s.rateLimited(w, r) {
+			return
+		}
+
+		if s.unauthorized(w, r) {
+			return
+		}
+
+		s.limitBody(w, r)
+
 		// Logging
 		start := time.Now()
 		log.Printf("%s %s", r.Method, r.URL.Path)
@@ -152,8 +332,11 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 
 // ScrapeRequest represents a scrape request
 type ScrapeRequest struct {
-	URL   string `json:"url"`
-	Force bool   `json:"force"` // Force re-scrape even if exists
+	URL         string   `json:"url"`
+	Force       bool     `json:"force"`                 // Force re-scrape even if exists
+	Fields      []string `json:"fields,omitempty"`      // Return only these ScrapedData fields (default all)
+	Model       string   `json:"model,omitempty"`       // Ollama model for this scrape, from the allowed models (default the configured one)
+	Temperature *float64 `json:"temperature,omitempty"` // Ollama sampling temperature for this scrape, 0-2 (default the configured options)
 }
 
 // handleScrape handles single URL scraping
@@ -164,8 +347,7 @@ func (s *Server) handleScrape(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req ScrapeRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid request body")
+	if !decodeJSONBody(w, r, &req) {
 		return
 	}
 
@@ -173,39 +355,143 @@ func (s *Server) handleScrape(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusBadRequest, "url is required")
 		return
 	}
+	fields, err := parseFields(req.Fields)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Scrape the URL
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Minute)
+	defer cancel()
+
+	ctx, transcript, ok := s.debugTranscript(ctx, w, r)
+	if !ok {
+		return
+	}
+	if transcript != nil && len(fields) > 0 {
+		fields = append(fields, "_debug")
+	}
+	if r.URL.Query().Get("include_raw") == "true" {
+		ctx = scraper.WithRawContent(ctx)
+		if len(fields) > 0 {
+			fields = append(fields, "raw_content")
+		}
+	}
+	if ctx, ok = s.ollamaOverride(ctx, w, req.URL, req.Model, req.Temperature); !ok {
+		return
+	}
+	// Stored results come from the configured model, so an overridden scrape
+	// neither uses nor replaces them
+	overridden := req.Model != "" || req.Temperature != nil
 
 	// Check if URL already exists (unless force is true)
-	if !req.Force {
-		existing, err := s.db.GetByURL(req.URL)
+	if !req.Force && !overridden {
+		existing, err := s.db.GetByURL(s.scraper.NormalizeURL(req.URL))
 		if err != nil {
 			respondError(w, http.StatusInternalServerError, "database error")
 			return
 		}
-		if existing != nil {
+		if existing != nil && !existing.Failed {
 			// Mark as cached
 			existing.Cached = true
-			respondJSON(w, http.StatusOK, existing)
+			existing.Debug = debugInfo(transcript)
+			respondScrape(w, r, projectFields(existing, fields))
 			return
 		}
 	}
 
-	// Scrape the URL
-	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Minute)
-	defer cancel()
-
 	result, err := s.scraper.Scrape(ctx, req.URL)
 	if err != nil {
+		if !overridden {
+			s.recordFailure(req.URL, err)
+		}
 		respondError(w, http.StatusInternalServerError, fmt.Sprintf("scraping failed: %v", err))
 		return
 	}
+	result.Debug = debugInfo(transcript)
+	if overridden {
+		respondScrape(w, r, projectFields(result, fields))
+		return
+	}
 
 	// Save to database
 	if err := s.db.SaveScrapedData(result); err != nil {
 		log.Printf("Failed to save data: %v", err)
 		// Still return the result even if save fails
+	} else {
+		s.deferScoring(result)
+	}
+
+	respondScrape(w, r, projectFields(result, fields))
+}
+
+// handleScrapeStream scrapes a single URL and streams progress as Server-Sent Events.
+// Emits fetched, content_extracted, image_analyzed, and scored events as the
+// scrape runs, then a final done event with the full result (or an error event).
+// Disconnecting the client cancels the scrape.
+func (s *Server) handleScrapeStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	targetURL := r.URL.Query().Get("url")
+	if targetURL == "" {
+		respondError(w, http.StatusBadRequest, "url is required")
+		return
+	}
+	force := r.URL.Query().Get("force") == "true"
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(event string, data interface{}) {
+		writeSSE(w, event, data)
+		flusher.Flush()
+	}
+
+	// Check if URL already exists (unless force is true)
+	if !force {
+		existing, err := s.db.GetByURL(s.scraper.NormalizeURL(targetURL))
+		if err != nil {
+			send("error", map[string]string{"error": "database error"})
+			return
+		}
+		if existing != nil && !existing.Failed {
+			existing.Cached = true
+			send("done", existing)
+			return
+		}
+	}
+
+	// The request context is cancelled when the client disconnects
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Minute)
+	defer cancel()
+
+	result, err := s.scraper.ScrapeWithProgress(ctx, targetURL, send)
+	if err != nil {
+		s.recordFailure(targetURL, err)
+		send("error", map[string]string{"error": fmt.Sprintf("scraping failed: %v", err)})
+		return
+	}
+
+	// Save to database
+	if err := s.db.SaveScrapedData(result); err != nil {
+		log.Printf("Failed to save data: %v", err)
+	} else {
+		s.deferScoring(result)
 	}
 
-	respondJSON(w, http.StatusOK, result)
+	send("done", result)
 }
 
 // ExtractLinksRequest represents an extract links request
@@ -228,8 +514,7 @@ func (s *Server) handleExtractLinks(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req ExtractLinksRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid request body")
+	if !decodeJSONBody(w, r, &req) {
 		return
 	}
 
@@ -259,14 +544,17 @@ func (s *Server) handleExtractLinks(w http.ResponseWriter, r *http.Request) {
 
 // handleScore handles content scoring requests
 func (s *Server) handleScore(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		s.handleGetScore(w, r)
+		return
+	}
 	if r.Method != http.MethodPost {
 		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
 
 	var req models.ScoreRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid request body")
+	if !decodeJSONBody(w, r, &req) {
 		return
 	}
 
@@ -279,12 +567,27 @@ func (s *Server) handleScore(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Minute)
 	defer cancel()
 
+	ctx, ok := s.ollamaOverride(ctx, w, req.URL, req.Model, req.Temperature)
+	if !ok {
+		return
+	}
+
 	score, err := s.scraper.ScoreLinkContent(ctx, req.URL)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, fmt.Sprintf("scoring failed: %v", err))
 		return
 	}
 
+	// Save the score so URLs evaluated without being scraped are on record,
+	// unless it came from an overridden model or temperature
+	score.ScoredAt = time.Now()
+	if req.Model == "" && req.Temperature == nil {
+		if err := s.db.SaveLinkScore(score); err != nil {
+			log.Printf("Failed to save link score: %v", err)
+			// Still return the score even if save fails
+		}
+	}
+
 	response := models.ScoreResponse{
 		URL:   req.URL,
 		Score: *score,
@@ -293,10 +596,36 @@ func (s *Server) handleScore(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, response)
 }
 
+// handleGetScore returns the saved score for the url query parameter
+func (s *Server) handleGetScore(w http.ResponseWriter, r *http.Request) {
+	targetURL := r.URL.Query().Get("url")
+	if targetURL == "" {
+		respondError(w, http.StatusBadRequest, "url is required")
+		return
+	}
+
+	score, err := s.db.GetLinkScoreByURL(targetURL)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+	if score == nil {
+		respondError(w, http.StatusNotFound, "score not found")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.ScoreResponse{URL: targetURL, Score: *score})
+}
+
+// maxBatchURLs is the most URLs a batch scrape accepts, all of which are
+// processed concurrently; it also bounds other bulk work such as re-scoring
+const maxBatchURLs = 50
+
 // BatchScrapeRequest represents a batch scrape request
 type BatchScrapeRequest struct {
-	URLs  []string `json:"urls"`
-	Force bool     `json:"force"`
+	URLs        []string `json:"urls"`
+	Force       bool     `json:"force"`
+	SummaryOnly bool     `json:"summary_only"` // Omit data bodies, returning only IDs and status
 }
 
 // BatchScrapeResponse represents a batch scrape response
@@ -308,6 +637,7 @@ type BatchScrapeResponse struct {
 // BatchResult represents a single result in a batch
 type BatchResult struct {
 	URL     string              `json:"url"`
+	ID      string              `json:"id,omitempty"`
 	Success bool                `json:"success"`
 	Data    *models.ScrapedData `json:"data,omitempty"`
 	Error   string              `json:"error,omitempty"`
@@ -321,6 +651,9 @@ type BatchSummary struct {
 	Failed  int `json:"failed"`
 	Cached  int `json:"cached"`
 	Scraped int `json:"scraped"`
+	// ImagesUnanalyzed counts images stored without analysis because the
+	// batch used up Config.MaxBatchImages
+	ImagesUnanalyzed int `json:"images_unanalyzed,omitempty"`
 }
 
 // handleBatchScrape handles batch URL scraping
@@ -330,23 +663,18 @@ func (s *Server) handleBatchScrape(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req BatchScrapeRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid request body")
-		return
-	}
-
-	if len(req.URLs) == 0 {
-		respondError(w, http.StatusBadRequest, "urls array is required")
+	req, ok := decodeBatchRequest(w, r)
+	if !ok {
 		return
 	}
-
-	if len(req.URLs) > 50 {
-		respondError(w, http.StatusBadRequest, "maximum 50 URLs per batch")
+	if wantsNDJSON(r) {
+		s.streamBatchNDJSON(w, r, req)
 		return
 	}
 
-	// Process URLs concurrently
+	// Process URLs concurrently, sharing one image analysis budget
+	budget := scraper.NewImageBudget(s.maxBatchImages)
+	ctx := scraper.WithImageBudget(r.Context(), budget)
 	results := make([]BatchResult, len(req.URLs))
 	var wg sync.WaitGroup
 	var mu sync.Mutex
@@ -356,7 +684,7 @@ func (s *Server) handleBatchScrape(w http.ResponseWriter, r *http.Request) {
 		go func(index int, targetURL string) {
 			defer wg.Done()
 
-			result := s.processSingleURL(r.Context(), targetURL, req.Force)
+			result := s.processSingleURL(ctx, targetURL, req.Force)
 
 			mu.Lock()
 			results[index] = result
@@ -366,19 +694,21 @@ func (s *Server) handleBatchScrape(w http.ResponseWriter, r *http.Request) {
 
 	wg.Wait()
 
+	if req.SummaryOnly {
+		// Stored records remain retrievable by ID
+		for i := range results {
+			results[i].Data = nil
+		}
+	} else if !s.batchImageData {
+		for _, result := range results {
+			stripImageData(result.Data)
+		}
+	}
+
 	// Calculate summary
-	summary := BatchSummary{Total: len(results)}
+	summary := BatchSummary{Total: len(results), ImagesUnanalyzed: budget.Skipped()}
 	for _, r := range results {
-		if r.Success {
-			summary.Success++
-			if r.Cached {
-				summary.Cached++
-			} else {
-				summary.Scraped++
-			}
-		} else {
-			summary.Failed++
-		}
+		summary.add(r)
 	}
 
 	response := BatchScrapeResponse{
@@ -389,16 +719,114 @@ func (s *Server) handleBatchScrape(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, response)
 }
 
+// handleBatchScrapeStream scrapes URLs like handleBatchScrape but streams the
+// outcome as Server-Sent Events: a result event per URL in completion order
+// (without the data body, which can be fetched by ID), then a summary event.
+// Disconnecting the client cancels outstanding scrapes.
+func (s *Server) handleBatchScrapeStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	req, ok := decodeBatchRequest(w, r)
+	if !ok {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// The request context is cancelled when the client disconnects
+	budget := scraper.NewImageBudget(s.maxBatchImages)
+	ctx := scraper.WithImageBudget(r.Context(), budget)
+
+	// Buffered so scrapes finishing after a disconnect never block
+	results := make(chan BatchResult, len(req.URLs))
+	for _, url := range req.URLs {
+		go func(targetURL string) {
+			results <- s.processSingleURL(ctx, targetURL, req.Force)
+		}(url)
+	}
+
+	summary := BatchSummary{Total: len(req.URLs)}
+	for range req.URLs {
+		select {
+		case result := <-results:
+			summary.add(result)
+			result.Data = nil
+			writeSSE(w, "result", result)
+			flusher.Flush()
+		case <-ctx.Done():
+			return
+		}
+	}
+
+	summary.ImagesUnanalyzed = budget.Skipped()
+	writeSSE(w, "summary", summary)
+	flusher.Flush()
+}
+
+// decodeBatchRequest decodes and validates a batch scrape request, responding
+// with an error and returning false if it is invalid
+func decodeBatchRequest(w http.ResponseWriter, r *http.Request) (BatchScrapeRequest, bool) {
+	var req BatchScrapeRequest
+	if !decodeJSONBody(w, r, &req) {
+		return req, false
+	}
+	return req, validBatchURLs(w, req.URLs)
+}
+
+// validBatchURLs checks the size of a batch's URL list, responding with an
+// error and returning false if it is empty or too long
+func validBatchURLs(w http.ResponseWriter, urls []string) bool {
+	if len(urls) == 0 {
+		respondError(w, http.StatusBadRequest, "urls array is required")
+		return false
+	}
+
+	if len(urls) > maxBatchURLs {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("maximum %d URLs per batch", maxBatchURLs))
+		return false
+	}
+
+	return true
+}
+
+// add counts a result in the summary
+func (b *BatchSummary) add(result BatchResult) {
+	if result.Success {
+		b.Success++
+		if result.Cached {
+			b.Cached++
+		} else {
+			b.Scraped++
+		}
+	} else {
+		b.Failed++
+	}
+}
+
 // processSingleURL processes a single URL for batch scraping
 func (s *Server) processSingleURL(ctx context.Context, url string, force bool) BatchResult {
 	// Check cache first
 	if !force {
-		existing, err := s.db.GetByURL(url)
-		if err == nil && existing != nil {
+		existing, err := s.db.GetByURL(s.scraper.NormalizeURL(url))
+		if err == nil && existing != nil && !existing.Failed {
 			// Mark as cached in the response
 			existing.Cached = true
 			return BatchResult{
 				URL:     url,
+				ID:      existing.ID,
 				Success: true,
 				Data:    existing,
 				Cached:  true,
@@ -412,6 +840,7 @@ func (s *Server) processSingleURL(ctx context.Context, url string, force bool) B
 
 	result, err := s.scraper.Scrape(scrapeCtx, url)
 	if err != nil {
+		s.recordFailure(url, err)
 		return BatchResult{
 			URL:     url,
 			Success: false,
@@ -422,16 +851,29 @@ func (s *Server) processSingleURL(ctx context.Context, url string, force bool) B
 	// Save to database
 	if err := s.db.SaveScrapedData(result); err != nil {
 		log.Printf("Failed to save data for %s: %v", url, err)
+	} else {
+		s.deferScoring(result)
 	}
 
 	return BatchResult{
 		URL:     url,
+		ID:      result.ID,
 		Success: true,
 		Data:    result,
 		Cached:  false,
 	}
 }
 
+// stripImageData removes base64 image data from a result to reduce response size
+func stripImageData(data *models.ScrapedData) {
+	if data == nil {
+		return
+	}
+	for i := range data.Images {
+		data.Images[i].Base64Data = ""
+	}
+}
+
 // handleData handles GET (by ID) and DELETE operations
 func (s *Server) handleData(w http.ResponseWriter, r *http.Request) {
 	// Extract ID from path
@@ -441,10 +883,15 @@ func (s *Server) handleData(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	switch r.Method {
-	case http.MethodGet:
+	if id, ok := strings.CutSuffix(path, "/rendered"); ok && s.endpointEnabled(EndpointRendered) {
+		s.handleRendered(w, r, id)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && s.endpointEnabled(EndpointGet):
 		s.handleGetByID(w, r, path)
-	case http.MethodDelete:
+	case r.Method == http.MethodDelete && s.endpointEnabled(EndpointDelete):
 		s.handleDeleteByID(w, r, path)
 	default:
 		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
@@ -464,11 +911,56 @@ func (s *Server) handleGetByID(w http.ResponseWriter, r *http.Request, id string
 		return
 	}
 
+	// Page through the record's images when requested
+	query := r.URL.Query()
+	if query.Has("image_limit") || query.Has("image_offset") {
+		limit, offset, ok := parseImagePage(w, query)
+		if !ok {
+			return
+		}
+		images, total, err := s.db.GetImagesPage(id, limit, offset)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "database error")
+			return
+		}
+		data.Images = make([]models.ImageInfo, len(images))
+		for i, image := range images {
+			data.Images[i] = *image
+		}
+		data.ImagesTotal = total
+	}
+
 	// Mark as cached since it's from database
 	data.Cached = true
 	respondJSON(w, http.StatusOK, data)
 }
 
+// maxImagePageSize caps image_limit on GET /api/data/{id}
+const maxImagePageSize = 100
+
+// parseImagePage reads the image_limit (default and max 100) and
+// image_offset (default 0) query parameters, responding with 400 if invalid
+func parseImagePage(w http.ResponseWriter, query url.Values) (limit, offset int, ok bool) {
+	limit, offset = maxImagePageSize, 0
+	if value := query.Get("image_limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			respondError(w, http.StatusBadRequest, "image_limit must be a positive integer")
+			return 0, 0, false
+		}
+		limit = min(n, maxImagePageSize)
+	}
+	if value := query.Get("image_offset"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			respondError(w, http.StatusBadRequest, "image_offset must be a non-negative integer")
+			return 0, 0, false
+		}
+		offset = n
+	}
+	return limit, offset, true
+}
+
 // handleDeleteByID deletes data by ID
 func (s *Server) handleDeleteByID(w http.ResponseWriter, r *http.Request, id string) {
 	err := s.db.DeleteByID(id)
@@ -486,7 +978,59 @@ func (s *Server) handleDeleteByID(w http.ResponseWriter, r *http.Request, id str
 	})
 }
 
-// handleList lists all scraped data with pagination
+// handleDataCollection handles GET (list) and DELETE (bulk delete) on /api/data
+func (s *Server) handleDataCollection(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodGet && s.endpointEnabled(EndpointList):
+		s.handleList(w, r)
+	case r.Method == http.MethodDelete && s.endpointEnabled(EndpointDelete):
+		s.handleBulkDelete(w, r)
+	default:
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// handleBulkDelete deletes all data matching one filter: older_than (an
+// RFC 3339 time) or url_like (a SQL LIKE pattern). It is only available
+// when API keys are configured, so an open server cannot be wiped.
+func (s *Server) handleBulkDelete(w http.ResponseWriter, r *http.Request) {
+	if s.auth == nil {
+		respondError(w, http.StatusForbidden, "bulk delete requires API key authentication")
+		return
+	}
+
+	query := r.URL.Query()
+	olderThan, urlLike := query.Get("older_than"), query.Get("url_like")
+	if (olderThan == "") == (urlLike == "") {
+		respondError(w, http.StatusBadRequest, "exactly one of older_than or url_like is required")
+		return
+	}
+
+	var deleted int64
+	var err error
+	if olderThan != "" {
+		cutoff, parseErr := time.Parse(time.RFC3339, olderThan)
+		if parseErr != nil {
+			respondError(w, http.StatusBadRequest, "older_than must be an RFC 3339 time")
+			return
+		}
+		deleted, err = s.db.DeleteOlderThan(cutoff)
+	} else {
+		deleted, err = s.db.DeleteByURLPattern(urlLike)
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to delete data")
+		return
+	}
+
+	log.Printf("Bulk deleted %d records (older_than=%q, url_like=%q)", deleted, olderThan, urlLike)
+	respondJSON(w, http.StatusOK, map[string]int64{
+		"deleted": deleted,
+	})
+}
+
+// handleList lists all scraped data with pagination, optionally filtered by
+// language and minimum word count
 func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
@@ -512,7 +1056,40 @@ func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
 		limit = 100
 	}
 
-	data, err := s.db.List(limit, offset)
+	fields, err := parseFields(strings.Split(r.URL.Query().Get("fields"), ","))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var filter db.ListFilter
+	if lang := r.URL.Query().Get("lang"); lang != "" {
+		if filter.Language = scraper.NormalizeLanguage(lang); filter.Language == "" {
+			respondError(w, http.StatusBadRequest, "invalid lang")
+			return
+		}
+	}
+	if minWords := r.URL.Query().Get("min_words"); minWords != "" {
+		if filter.MinWords, err = strconv.Atoi(minWords); err != nil || filter.MinWords < 0 {
+			respondError(w, http.StatusBadRequest, "invalid min_words")
+			return
+		}
+	}
+
+	// Offset pagination is kept for compatibility; cursors stay fast at any depth
+	cursor := r.URL.Query().Get("cursor")
+	var data []*models.ScrapedData
+	var nextCursor string
+	if cursor != "" || offset == 0 {
+		offset = 0
+		data, nextCursor, err = s.db.ListPageFiltered(limit, cursor, filter)
+	} else {
+		data, err = s.db.ListFiltered(limit, offset, filter)
+	}
+	if errors.Is(err, db.ErrInvalidCursor) {
+		respondError(w, http.StatusBadRequest, "invalid cursor")
+		return
+	}
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "database error")
 		return
@@ -523,14 +1100,23 @@ func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
 		item.Cached = true
 	}
 
-	count, _ := s.db.Count()
+	count, _ := s.db.CountFiltered(filter)
 
-	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"data":   data,
+	items := make([]interface{}, len(data))
+	for i, item := range data {
+		items[i] = projectFields(item, fields)
+	}
+
+	response := map[string]interface{}{
+		"data":   items,
 		"total":  count,
 		"limit":  limit,
 		"offset": offset,
-	})
+	}
+	if nextCursor != "" {
+		response["next_cursor"] = nextCursor
+	}
+	respondJSON(w, http.StatusOK, response)
 }
 
 // respondJSON sends a JSON response
@@ -540,6 +1126,16 @@ func respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	json.NewEncoder(w).Encode(data)
 }
 
+// writeSSE writes a single Server-Sent Event with a JSON-encoded payload
+func writeSSE(w http.ResponseWriter, event string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Failed to marshal %s event: %v", event, err)
+		return
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+}
+
 // respondError sends an error response
 func respondError(w http.ResponseWriter, status int, message string) {
 	respondJSON(w, status, map[string]string{
@@ -561,6 +1157,11 @@ func (s *Server) handleImage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if id, ok := strings.CutSuffix(path, "/thumbnail"); ok {
+		s.handleImageThumbnail(w, r, id)
+		return
+	}
+
 	image, err := s.db.GetImageByID(path)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "database error")
@@ -575,9 +1176,29 @@ func (s *Server) handleImage(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, image)
 }
 
-// ImageSearchRequest represents a search request for images by tags
+// handleImageThumbnail serves the raw JPEG thumbnail for an image
+func (s *Server) handleImageThumbnail(w http.ResponseWriter, r *http.Request, id string) {
+	thumbnail, err := s.db.GetImageThumbnail(id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+
+	if thumbnail == nil {
+		respondError(w, http.StatusNotFound, "thumbnail not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Header().Set("Content-Length", strconv.Itoa(len(thumbnail)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(thumbnail)
+}
+
+// ImageSearchRequest represents a search request for images by tags and/or transcribed text
 type ImageSearchRequest struct {
 	Tags []string `json:"tags"`
+	Text string   `json:"text,omitempty"` // Matches text transcribed from the image
 }
 
 // ImageSearchResponse represents the response for image search
@@ -586,7 +1207,19 @@ type ImageSearchResponse struct {
 	Count  int                 `json:"count"`
 }
 
-// handleImageSearch handles POST requests to search images by tags
+// filterImagesByText keeps images whose transcribed text contains the query (case-insensitive)
+func filterImagesByText(images []*models.ImageInfo, text string) []*models.ImageInfo {
+	text = strings.ToLower(text)
+	filtered := []*models.ImageInfo{}
+	for _, image := range images {
+		if strings.Contains(strings.ToLower(image.OCRText), text) {
+			filtered = append(filtered, image)
+		}
+	}
+	return filtered
+}
+
+// handleImageSearch handles POST requests to search images by tags and/or transcribed text
 func (s *Server) handleImageSearch(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
@@ -594,17 +1227,26 @@ func (s *Server) handleImageSearch(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req ImageSearchRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid request body")
+	if !decodeJSONBody(w, r, &req) {
 		return
 	}
 
-	if len(req.Tags) == 0 {
-		respondError(w, http.StatusBadRequest, "tags array is required and must not be empty")
+	req.Text = strings.TrimSpace(req.Text)
+	if len(req.Tags) == 0 && req.Text == "" {
+		respondError(w, http.StatusBadRequest, "tags array or text is required")
 		return
 	}
 
-	images, err := s.db.SearchImagesByTags(req.Tags)
+	var images []*models.ImageInfo
+	var err error
+	if len(req.Tags) > 0 {
+		images, err = s.db.SearchImagesByTags(req.Tags)
+		if err == nil && req.Text != "" {
+			images = filterImagesByText(images, req.Text)
+		}
+	} else {
+		images, err = s.db.SearchImagesByText(req.Text)
+	}
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "database error")
 		return
@@ -4,13 +4,17 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/zombar/scraper"
 	"github.com/zombar/scraper/db"
+	"github.com/zombar/scraper/models"
 )
 
 func setupTestServer(t *testing.T) (*Server, func()) {
@@ -215,8 +219,8 @@ func TestHandleExtractLinksEdgeCases(t *testing.T) {
 			wantStatusCode: http.StatusInternalServerError, // Will fail because it's not a real URL
 		},
 		{
-			name: "empty request body",
-			body: map[string]string{},
+			name:           "empty request body",
+			body:           map[string]string{},
 			wantStatusCode: http.StatusBadRequest,
 			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
 				var errResp map[string]string
@@ -355,3 +359,1022 @@ func TestHandleHealth(t *testing.T) {
 		t.Errorf("Status = %q, want %q", resp["status"], "healthy")
 	}
 }
+
+func TestDisabledEndpoints(t *testing.T) {
+	config := Config{
+		Addr: ":0",
+		DBConfig: db.Config{
+			Driver: "sqlite",
+			DSN:    t.TempDir() + "/test.db",
+		},
+		ScraperConfig: scraper.DefaultConfig(),
+		EnabledEndpoints: map[string]bool{
+			EndpointScrape:      false,
+			EndpointBatchScrape: false,
+			EndpointDelete:      false,
+			EndpointHealth:      true,
+		},
+	}
+
+	server, err := NewServer(config)
+	if err != nil {
+		t.Fatalf("Failed to create test server: %v", err)
+	}
+	defer server.db.Close()
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		wantStatusCode int
+	}{
+		{"scrape disabled", http.MethodPost, "/api/scrape", http.StatusNotFound},
+		{"batch disabled", http.MethodPost, "/api/scrape/batch", http.StatusNotFound},
+		{"delete disabled", http.MethodDelete, "/api/data/some-id", http.StatusMethodNotAllowed},
+		{"get still enabled", http.MethodGet, "/api/data/some-id", http.StatusNotFound},
+		{"health explicitly enabled", http.MethodGet, "/health", http.StatusOK},
+		{"list enabled by default", http.MethodGet, "/api/data", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			server.mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatusCode {
+				t.Errorf("Status code = %d, want %d", w.Code, tt.wantStatusCode)
+			}
+		})
+	}
+}
+
+func TestHandleScrapeStream(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	imageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("fake image data"))
+	}))
+	defer imageServer.Close()
+
+	webServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Stream Test</title></head><body>
+			<p>Some content</p>
+			<img src="` + imageServer.URL + `/a.png" alt="A">
+			<img src="` + imageServer.URL + `/b.png" alt="B">
+		</body></html>`))
+	}))
+	defer webServer.Close()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/scrape/stream?url="+webServer.URL, nil)
+	w := httptest.NewRecorder()
+
+	server.handleScrapeStream(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Status code = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", ct)
+	}
+
+	body := w.Body.String()
+	wantOrder := []string{
+		"event: fetched",
+		"event: content_extracted",
+		"event: image_analyzed",
+		"event: scored",
+		"event: done",
+	}
+	last := -1
+	for _, want := range wantOrder {
+		idx := strings.Index(body, want)
+		if idx == -1 {
+			t.Fatalf("Expected %q in stream, got:\n%s", want, body)
+		}
+		if idx < last {
+			t.Errorf("Expected %q to come after previous events", want)
+		}
+		last = idx
+	}
+
+	if n := strings.Count(body, "event: image_analyzed"); n != 2 {
+		t.Errorf("Expected 2 image_analyzed events, got %d", n)
+	}
+
+	// The final event carries the full scraped data and is persisted
+	doneData := body[strings.LastIndex(body, "data: ")+len("data: "):]
+	var data models.ScrapedData
+	if err := json.Unmarshal([]byte(strings.TrimSpace(doneData)), &data); err != nil {
+		t.Fatalf("Failed to decode done event: %v", err)
+	}
+	if data.Title != "Stream Test" {
+		t.Errorf("Title = %q, want %q", data.Title, "Stream Test")
+	}
+
+	saved, err := server.db.GetByURL(webServer.URL)
+	if err != nil || saved == nil {
+		t.Errorf("Expected streamed result to be saved, got %v (err: %v)", saved, err)
+	}
+}
+
+func TestHandleScrapeStreamErrors(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	tests := []struct {
+		name           string
+		method         string
+		target         string
+		wantStatusCode int
+		wantBody       string
+	}{
+		{"missing url", http.MethodGet, "/api/scrape/stream", http.StatusBadRequest, "url is required"},
+		{"POST not allowed", http.MethodPost, "/api/scrape/stream?url=https://example.com", http.StatusMethodNotAllowed, "method not allowed"},
+		{"invalid scheme", http.MethodGet, "/api/scrape/stream?url=ftp://example.com", http.StatusOK, "event: error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			w := httptest.NewRecorder()
+
+			server.handleScrapeStream(w, req)
+
+			if w.Code != tt.wantStatusCode {
+				t.Errorf("Status code = %d, want %d", w.Code, tt.wantStatusCode)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("Expected body to contain %q, got %s", tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandleBatchScrapeStream(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	webServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Batch Stream</title></head><body><p>Content</p></body></html>`))
+	}))
+	defer webServer.Close()
+
+	body := `{"urls": ["` + webServer.URL + `", "ftp://example.com"]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/scrape/batch/stream", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	server.handleBatchScrapeStream(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Status code = %d, want %d", w.Code, http.StatusOK)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", ct)
+	}
+
+	stream := w.Body.String()
+	if n := strings.Count(stream, "event: result"); n != 2 {
+		t.Errorf("Expected 2 result events, got %d:\n%s", n, stream)
+	}
+	summaryIdx := strings.Index(stream, "event: summary")
+	if summaryIdx == -1 || summaryIdx < strings.LastIndex(stream, "event: result") {
+		t.Fatalf("Expected summary event after the results, got:\n%s", stream)
+	}
+
+	results := map[string]BatchResult{}
+	for _, line := range strings.Split(stream[:summaryIdx], "\n") {
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		var result BatchResult
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &result); err != nil {
+			t.Fatalf("Failed to decode result event: %v", err)
+		}
+		if result.Data != nil {
+			t.Error("Expected result events to omit the data body")
+		}
+		results[result.URL] = result
+	}
+	if ok := results[webServer.URL]; !ok.Success || ok.ID == "" {
+		t.Errorf("Expected successful result with ID for %s, got %+v", webServer.URL, ok)
+	}
+	if failed := results["ftp://example.com"]; failed.Success || failed.Error == "" {
+		t.Errorf("Expected failed result with error for ftp URL, got %+v", failed)
+	}
+
+	var summary BatchSummary
+	summaryData := stream[strings.LastIndex(stream, "data: ")+len("data: "):]
+	if err := json.Unmarshal([]byte(strings.TrimSpace(summaryData)), &summary); err != nil {
+		t.Fatalf("Failed to decode summary event: %v", err)
+	}
+	if summary.Total != 2 || summary.Success != 1 || summary.Failed != 1 || summary.Scraped != 1 {
+		t.Errorf("Summary = %+v, want 2 total, 1 success, 1 failed, 1 scraped", summary)
+	}
+}
+
+func TestHandleBatchScrapeStreamErrors(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	tests := []struct {
+		name           string
+		method         string
+		body           string
+		wantStatusCode int
+	}{
+		{"GET not allowed", http.MethodGet, "", http.StatusMethodNotAllowed},
+		{"empty urls", http.MethodPost, `{"urls": []}`, http.StatusBadRequest},
+		{"invalid JSON", http.MethodPost, `{"urls":`, http.StatusBadRequest},
+		{"too many urls", http.MethodPost, `{"urls": [` + strings.Repeat(`"https://example.com",`, 50) + `"https://example.com"]}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/scrape/batch/stream", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			server.handleBatchScrapeStream(w, req)
+
+			if w.Code != tt.wantStatusCode {
+				t.Errorf("Status code = %d, want %d", w.Code, tt.wantStatusCode)
+			}
+		})
+	}
+}
+
+func TestBatchScrapeImageData(t *testing.T) {
+	imageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("fake image data"))
+	}))
+	defer imageServer.Close()
+
+	webServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Batch</title></head><body><img src="` + imageServer.URL + `/a.png"></body></html>`))
+	}))
+	defer webServer.Close()
+
+	tests := []struct {
+		name        string
+		includeData bool
+		wantBase64  bool
+	}{
+		{"image data omitted by default", false, false},
+		{"image data included when enabled", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, cleanup := setupTestServer(t)
+			defer cleanup()
+			server.batchImageData = tt.includeData
+
+			bodyBytes, _ := json.Marshal(BatchScrapeRequest{URLs: []string{webServer.URL}, Force: true})
+			req := httptest.NewRequest(http.MethodPost, "/api/scrape/batch", bytes.NewReader(bodyBytes))
+			w := httptest.NewRecorder()
+
+			server.handleBatchScrape(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Status code = %d, want %d", w.Code, http.StatusOK)
+			}
+
+			var resp BatchScrapeResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if len(resp.Results) != 1 || resp.Results[0].Data == nil || len(resp.Results[0].Data.Images) != 1 {
+				t.Fatalf("Expected one result with one image, got %+v", resp.Results)
+			}
+
+			img := resp.Results[0].Data.Images[0]
+			if gotBase64 := img.Base64Data != ""; gotBase64 != tt.wantBase64 {
+				t.Errorf("Base64 data present = %v, want %v", gotBase64, tt.wantBase64)
+			}
+
+			// Stored image data is unaffected
+			stored, err := server.db.GetImageByID(img.ID)
+			if err != nil || stored == nil {
+				t.Fatalf("Expected stored image, got %v (err: %v)", stored, err)
+			}
+			if stored.Base64Data == "" {
+				t.Error("Expected stored image to keep base64 data")
+			}
+		})
+	}
+}
+
+func TestBatchScrapeImageBudget(t *testing.T) {
+	imageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("fake image data"))
+	}))
+	defer imageServer.Close()
+
+	webServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Batch</title></head><body><img src="` + imageServer.URL + r.URL.Path + `.png"></body></html>`))
+	}))
+	defer webServer.Close()
+
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	server.maxBatchImages = 1
+
+	bodyBytes, _ := json.Marshal(BatchScrapeRequest{URLs: []string{webServer.URL + "/a", webServer.URL + "/b", webServer.URL + "/c"}, Force: true})
+	w := httptest.NewRecorder()
+	server.handleBatchScrape(w, httptest.NewRequest(http.MethodPost, "/api/scrape/batch", bytes.NewReader(bodyBytes)))
+
+	var resp BatchScrapeResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Summary.Success != 3 || resp.Summary.ImagesUnanalyzed != 2 {
+		t.Errorf("Summary = %+v, want 3 pages scraped and 2 images left unanalyzed", resp.Summary)
+	}
+}
+
+func TestBatchScrapeSummaryOnly(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	webServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Summary</title></head><body><p>Content</p></body></html>`))
+	}))
+	defer webServer.Close()
+
+	bodyBytes, _ := json.Marshal(BatchScrapeRequest{
+		URLs:        []string{webServer.URL, "ftp://invalid.example"},
+		SummaryOnly: true,
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/scrape/batch", bytes.NewReader(bodyBytes))
+	w := httptest.NewRecorder()
+
+	server.handleBatchScrape(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Status code = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var resp BatchScrapeResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if resp.Summary.Success != 1 || resp.Summary.Failed != 1 {
+		t.Errorf("Summary = %+v, want 1 success and 1 failure", resp.Summary)
+	}
+
+	ok := resp.Results[0]
+	if ok.Data != nil {
+		t.Error("Expected data to be omitted in summary-only mode")
+	}
+	if ok.ID == "" {
+		t.Fatal("Expected ID for successful result")
+	}
+
+	stored, err := server.db.GetByID(ok.ID)
+	if err != nil || stored == nil {
+		t.Errorf("Expected record to be retrievable by ID, got %v (err: %v)", stored, err)
+	}
+
+	if failed := resp.Results[1]; failed.Success || failed.Error == "" {
+		t.Errorf("Expected failed result with error, got %+v", failed)
+	}
+}
+
+func TestHandleImageThumbnail(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	data := &models.ScrapedData{
+		ID:  "scrape-thumb",
+		URL: "https://example.com/thumb",
+		Images: []models.ImageInfo{
+			{ID: "img-thumb", URL: "https://example.com/a.png", ThumbnailData: []byte{0xff, 0xd8, 0xff}},
+			{ID: "img-no-thumb", URL: "https://example.com/b.svg"},
+		},
+		FetchedAt: time.Now(),
+	}
+	if err := server.db.SaveScrapedData(data); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/images/img-thumb/thumbnail", nil)
+	w := httptest.NewRecorder()
+	server.handleImage(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Status code = %d, want %d", w.Code, http.StatusOK)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "image/jpeg" {
+		t.Errorf("Content-Type = %q, want image/jpeg", ct)
+	}
+	if !bytes.Equal(w.Body.Bytes(), []byte{0xff, 0xd8, 0xff}) {
+		t.Errorf("Body = %v, want thumbnail bytes", w.Body.Bytes())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/images/img-no-thumb/thumbnail", nil)
+	w = httptest.NewRecorder()
+	server.handleImage(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Status code = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestScrapeCanonicalDedup(t *testing.T) {
+	var webServer *httptest.Server
+	webServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Story</title>
+			<link rel="canonical" href="` + webServer.URL + `/story">
+		</head><body><p>Content</p></body></html>`))
+	}))
+	defer webServer.Close()
+
+	config := scraper.DefaultConfig()
+	config.EnableImageAnalysis = false
+	config.UseCanonicalForDedup = true
+	server, err := NewServer(Config{
+		DBConfig:      db.Config{Driver: "sqlite", DSN: t.TempDir() + "/test.db"},
+		ScraperConfig: config,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	defer server.db.Close()
+
+	for _, variant := range []string{"/story?utm_source=feed", "/story?utm_source=mail&utm_medium=email"} {
+		body, _ := json.Marshal(ScrapeRequest{URL: webServer.URL + variant})
+		req := httptest.NewRequest(http.MethodPost, "/api/scrape", bytes.NewReader(body))
+		w := httptest.NewRecorder()
+		server.handleScrape(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Status code = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+		}
+	}
+
+	count, err := server.db.Count()
+	if err != nil {
+		t.Fatalf("Failed to count: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected tracking variants to share one record, got %d", count)
+	}
+
+	stored, err := server.db.GetByURL(webServer.URL + "/story")
+	if err != nil || stored == nil {
+		t.Fatalf("Expected record stored under canonical URL, got %v (err: %v)", stored, err)
+	}
+	if stored.CanonicalURL != webServer.URL+"/story" {
+		t.Errorf("CanonicalURL = %q, want %q", stored.CanonicalURL, webServer.URL+"/story")
+	}
+}
+
+func TestScrapeCrossHostRedirect(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Landed</title></head><body><p>Content</p></body></html>`))
+	}))
+	defer target.Close()
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL+"/landing", http.StatusMovedPermanently)
+	}))
+	defer origin.Close()
+
+	config := scraper.DefaultConfig()
+	config.EnableImageAnalysis = false
+	server, err := NewServer(Config{
+		DBConfig:      db.Config{Driver: "sqlite", DSN: t.TempDir() + "/test.db"},
+		ScraperConfig: config,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	defer server.db.Close()
+
+	requested := origin.URL + "/start"
+	for i, wantCached := range []bool{false, true} {
+		body, _ := json.Marshal(ScrapeRequest{URL: requested})
+		w := httptest.NewRecorder()
+		server.handleScrape(w, httptest.NewRequest(http.MethodPost, "/api/scrape", bytes.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Status code = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+		}
+
+		var data models.ScrapedData
+		if err := json.NewDecoder(w.Body).Decode(&data); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if data.Cached != wantCached {
+			t.Errorf("Request %d: Cached = %v, want %v", i, data.Cached, wantCached)
+		}
+		if data.URL != requested || data.FinalURL != target.URL+"/landing" {
+			t.Errorf("Request %d: URL = %q, FinalURL = %q", i, data.URL, data.FinalURL)
+		}
+		if data.StatusCode != http.StatusOK {
+			t.Errorf("Request %d: StatusCode = %d, want %d", i, data.StatusCode, http.StatusOK)
+		}
+	}
+
+	// The record is keyed on the requested URL, not where it landed
+	stored, err := server.db.GetByURL(requested)
+	if err != nil || stored == nil {
+		t.Fatalf("Expected record stored under requested URL, got %v (err: %v)", stored, err)
+	}
+	if stored, _ := server.db.GetByURL(target.URL + "/landing"); stored != nil {
+		t.Error("Expected no record under the final URL")
+	}
+}
+
+func TestHandleGetByIDImagePage(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	data := &models.ScrapedData{ID: "gallery", URL: "https://example.com/gallery", FetchedAt: time.Now()}
+	for i := 0; i < 5; i++ {
+		data.Images = append(data.Images, models.ImageInfo{
+			ID:  fmt.Sprintf("img-%d", i),
+			URL: fmt.Sprintf("https://example.com/%d.jpg", i),
+		})
+	}
+	if err := server.db.SaveScrapedData(data); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
+	}
+
+	get := func(query string) (int, models.ScrapedData) {
+		w := httptest.NewRecorder()
+		server.handleData(w, httptest.NewRequest(http.MethodGet, "/api/data/gallery"+query, nil))
+		var resp models.ScrapedData
+		json.NewDecoder(w.Body).Decode(&resp)
+		return w.Code, resp
+	}
+
+	_, full := get("")
+	if len(full.Images) != 5 || full.ImagesTotal != 0 {
+		t.Errorf("Without paging got %d images (total %d), want all 5 and no total", len(full.Images), full.ImagesTotal)
+	}
+
+	code, page := get("?image_limit=2&image_offset=3")
+	if code != http.StatusOK {
+		t.Fatalf("Status code = %d, want %d", code, http.StatusOK)
+	}
+	if len(page.Images) != 2 || page.Images[0].ID != "img-3" || page.Images[1].ID != "img-4" || page.ImagesTotal != 5 {
+		t.Errorf("Page = %+v (total %d), want img-3 and img-4 of 5", page.Images, page.ImagesTotal)
+	}
+
+	if _, page := get("?image_offset=4"); len(page.Images) != 1 || page.Images[0].ID != "img-4" {
+		t.Errorf("Offset-only page = %+v, want img-4", page.Images)
+	}
+	if _, page := get("?image_offset=10"); len(page.Images) != 0 || page.ImagesTotal != 5 {
+		t.Errorf("Past-the-end page = %+v (total %d), want none of 5", page.Images, page.ImagesTotal)
+	}
+
+	for _, query := range []string{"?image_limit=0", "?image_limit=abc", "?image_offset=-1"} {
+		if code, _ := get(query); code != http.StatusBadRequest {
+			t.Errorf("GET %s: status code = %d, want %d", query, code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestHandleBulkDelete(t *testing.T) {
+	server, err := NewServer(Config{
+		DBConfig:      db.Config{Driver: "sqlite", DSN: t.TempDir() + "/test.db"},
+		ScraperConfig: scraper.DefaultConfig(),
+		APIKeys:       []string{"secret"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	defer server.db.Close()
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, u := range []string{"https://example.com/old", "https://example.com/new", "https://other.com/new"} {
+		data := &models.ScrapedData{ID: fmt.Sprintf("bulk-%d", i), URL: u, FetchedAt: base.Add(time.Duration(i) * time.Hour)}
+		if err := server.db.SaveScrapedData(data); err != nil {
+			t.Fatalf("Failed to save data: %v", err)
+		}
+	}
+
+	bulkDelete := func(query, key string) (int, int64) {
+		req := httptest.NewRequest(http.MethodDelete, "/api/data"+query, nil)
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, req)
+		var resp struct {
+			Deleted int64 `json:"deleted"`
+		}
+		json.NewDecoder(w.Body).Decode(&resp)
+		return w.Code, resp.Deleted
+	}
+
+	if code, _ := bulkDelete("?url_like=%25", ""); code != http.StatusUnauthorized {
+		t.Errorf("Status code = %d, want %d without an API key", code, http.StatusUnauthorized)
+	}
+	for _, query := range []string{"", "?older_than=yesterday", "?older_than=2024-01-01T00:00:00Z&url_like=%25"} {
+		if code, _ := bulkDelete(query, "secret"); code != http.StatusBadRequest {
+			t.Errorf("DELETE /api/data%s: status code = %d, want %d", query, code, http.StatusBadRequest)
+		}
+	}
+
+	if code, deleted := bulkDelete("?older_than=2024-01-01T00:30:00Z", "secret"); code != http.StatusOK || deleted != 1 {
+		t.Errorf("older_than: status code = %d, deleted = %d; want 200 and 1", code, deleted)
+	}
+	if code, deleted := bulkDelete("?url_like=https://example.com/%25", "secret"); code != http.StatusOK || deleted != 1 {
+		t.Errorf("url_like: status code = %d, deleted = %d; want 200 and 1", code, deleted)
+	}
+	if count, _ := server.db.Count(); count != 1 {
+		t.Errorf("Count = %d, want 1 remaining", count)
+	}
+
+	// Without API keys bulk delete is refused outright
+	open, cleanup := setupTestServer(t)
+	defer cleanup()
+	w := httptest.NewRecorder()
+	open.server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/data?url_like=%25", nil))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Status code = %d, want %d without authentication configured", w.Code, http.StatusForbidden)
+	}
+}
+
+func TestHandleListCursor(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	for i := 0; i < 3; i++ {
+		data := &models.ScrapedData{
+			ID:        fmt.Sprintf("list-%d", i),
+			URL:       fmt.Sprintf("https://example.com/%d", i),
+			FetchedAt: time.Now().Add(time.Duration(i) * time.Second),
+		}
+		if err := server.db.SaveScrapedData(data); err != nil {
+			t.Fatalf("Failed to save data: %v", err)
+		}
+	}
+
+	var ids []string
+	path := "/api/data?limit=2"
+	for path != "" {
+		w := httptest.NewRecorder()
+		server.handleList(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Status code = %d, want %d", w.Code, http.StatusOK)
+		}
+
+		var resp struct {
+			Data       []*models.ScrapedData `json:"data"`
+			NextCursor string                `json:"next_cursor"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		for _, data := range resp.Data {
+			ids = append(ids, data.ID)
+		}
+
+		path = ""
+		if resp.NextCursor != "" {
+			path = "/api/data?limit=2&cursor=" + resp.NextCursor
+		}
+	}
+
+	if strings.Join(ids, ",") != "list-2,list-1,list-0" {
+		t.Errorf("Listed %v, want newest first across pages", ids)
+	}
+
+	w := httptest.NewRecorder()
+	server.handleList(w, httptest.NewRequest(http.MethodGet, "/api/data?cursor=bogus", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Status code = %d, want %d for invalid cursor", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandleListFilters(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	for i, language := range []string{"en", "de", "en"} {
+		data := &models.ScrapedData{
+			ID:        fmt.Sprintf("list-%d", i),
+			URL:       fmt.Sprintf("https://example.com/%d", i),
+			Language:  language,
+			WordCount: 100 * i,
+			FetchedAt: time.Now().Add(time.Duration(i) * time.Second),
+		}
+		if err := server.db.SaveScrapedData(data); err != nil {
+			t.Fatalf("Failed to save data: %v", err)
+		}
+	}
+
+	list := func(query string) (ids []string, total int) {
+		w := httptest.NewRecorder()
+		server.handleList(w, httptest.NewRequest(http.MethodGet, "/api/data?"+query, nil))
+		var resp struct {
+			Data  []*models.ScrapedData `json:"data"`
+			Total int                   `json:"total"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		for _, data := range resp.Data {
+			ids = append(ids, data.ID)
+		}
+		return ids, resp.Total
+	}
+
+	if ids, total := list("lang=en-GB"); strings.Join(ids, ",") != "list-2,list-0" || total != 2 {
+		t.Errorf("lang=en-GB listed %v of %d, want the 2 English records", ids, total)
+	}
+	if ids, total := list("min_words=100"); strings.Join(ids, ",") != "list-2,list-1" || total != 2 {
+		t.Errorf("min_words=100 listed %v of %d, want the 2 records with 100 words or more", ids, total)
+	}
+	if ids, total := list("lang=en&min_words=100"); strings.Join(ids, ",") != "list-2" || total != 1 {
+		t.Errorf("lang=en&min_words=100 listed %v of %d, want list-2", ids, total)
+	}
+
+	for _, query := range []string{"lang=english", "min_words=many", "min_words=-1"} {
+		w := httptest.NewRecorder()
+		server.handleList(w, httptest.NewRequest(http.MethodGet, "/api/data?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status code = %d, want %d", query, w.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestHandleScoreSavesScore(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Guide</title></head><body><p>A tutorial on Go programming and software documentation.</p></body></html>`))
+	}))
+	defer target.Close()
+
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	targetURL := target.URL + "/guide"
+	getScore := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/score?url="+targetURL, nil))
+		return w
+	}
+
+	if w := getScore(); w.Code != http.StatusNotFound {
+		t.Errorf("GET before scoring: status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+
+	body, _ := json.Marshal(models.ScoreRequest{URL: targetURL})
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/score", bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("POST status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var scored models.ScoreResponse
+	if err := json.NewDecoder(w.Body).Decode(&scored); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if scored.Score.ScoredAt.IsZero() {
+		t.Error("Expected scored_at in the response")
+	}
+
+	saved, err := server.db.GetLinkScoreByURL(targetURL)
+	if err != nil || saved == nil {
+		t.Fatalf("GetLinkScoreByURL = %+v, %v; want the saved score", saved, err)
+	}
+	if saved.Score != scored.Score.Score || saved.AIUsed != scored.Score.AIUsed || saved.Reason != scored.Score.Reason {
+		t.Errorf("Saved score = %+v, want %+v", saved, scored.Score)
+	}
+
+	w = getScore()
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET status = %d, want %d", w.Code, http.StatusOK)
+	}
+	var fetched models.ScoreResponse
+	if err := json.NewDecoder(w.Body).Decode(&fetched); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if fetched.URL != targetURL || fetched.Score.Score != scored.Score.Score || !fetched.Score.ScoredAt.Equal(scored.Score.ScoredAt) {
+		t.Errorf("GET = %+v, want %+v", fetched, scored)
+	}
+}
+
+func TestHandleSitemap(t *testing.T) {
+	var site *httptest.Server
+	site = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/sitemap.xml" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `<urlset><url><loc>%[1]s/</loc></url><url><loc>%[1]s/about</loc></url></urlset>`, site.URL)
+	}))
+	defer site.Close()
+
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	body, _ := json.Marshal(SitemapRequest{URL: site.URL})
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/sitemap", bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Status code = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var resp SitemapResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Count != 2 || strings.Join(resp.URLs, " ") != site.URL+"/ "+site.URL+"/about" {
+		t.Errorf("Response = %+v", resp)
+	}
+
+	for _, tc := range []struct {
+		body string
+		want int
+	}{
+		{`{}`, http.StatusBadRequest},
+		{`{"url": "` + site.URL + `/blog/"}`, http.StatusOK},
+		{`{"url": "` + site.URL + `/missing.xml"}`, http.StatusInternalServerError},
+	} {
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/sitemap", strings.NewReader(tc.body)))
+		if w.Code != tc.want {
+			t.Errorf("Body %s: status = %d, want %d", tc.body, w.Code, tc.want)
+		}
+	}
+}
+
+func TestStoreRecommendedLinksOnly(t *testing.T) {
+	var filterUp bool
+	ollamaServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req models.OllamaRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if !strings.Contains(req.Prompt, "link filtering assistant") {
+			http.Error(w, "not mocked", http.StatusServiceUnavailable)
+			return
+		}
+		if !filterUp {
+			http.Error(w, "model not loaded", http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(models.OllamaResponse{Response: `["https://example.com/guide", "https://example.com/invented"]`, Done: true})
+	}))
+	defer ollamaServer.Close()
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><head><title>Links</title></head><body><p>Guide index.</p>
+			<a href="https://example.com/guide">Guide</a>
+			<a href="https://example.com/login">Log in</a>
+			<a href="https://example.com/careers">Careers</a>
+		</body></html>`))
+	}))
+	defer target.Close()
+
+	scraperConfig := scraper.DefaultConfig()
+	scraperConfig.OllamaBaseURL = ollamaServer.URL
+	scraperConfig.EnableImageAnalysis = false
+	server, err := NewServer(Config{
+		DBConfig:                  db.Config{Driver: "sqlite", DSN: t.TempDir() + "/test.db"},
+		ScraperConfig:             scraperConfig,
+		StoreRecommendedLinksOnly: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	defer server.db.Close()
+
+	scrape := func(path string) models.ScrapedData {
+		body, _ := json.Marshal(ScrapeRequest{URL: target.URL + path})
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/scrape", bytes.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Scrape status = %d: %s", w.Code, w.Body.String())
+		}
+		var data models.ScrapedData
+		json.NewDecoder(w.Body).Decode(&data)
+		return data
+	}
+	allLinks := "https://example.com/guide https://example.com/login https://example.com/careers"
+
+	filterUp = true
+	data := scrape("/filtered")
+	if got := strings.Join(data.Links, " "); got != allLinks {
+		t.Errorf("Response links = %v, want every extracted link", data.Links)
+	}
+	if got := strings.Join(data.FilteredLinks, " "); got != "https://example.com/guide" {
+		t.Errorf("Filtered links = %v, want the extracted links the filter kept", data.FilteredLinks)
+	}
+	stored, err := server.db.GetByID(data.ID)
+	if err != nil || stored == nil {
+		t.Fatalf("GetByID() = %v, %v", stored, err)
+	}
+	if strings.Join(stored.Links, " ") != "https://example.com/guide" || stored.FilteredLinks != nil {
+		t.Errorf("Stored links = %v (filtered %v), want only the kept link", stored.Links, stored.FilteredLinks)
+	}
+
+	// Without the filter there is nothing to narrow the links down by
+	filterUp = false
+	data = scrape("/unfiltered")
+	if data.FilteredLinks != nil {
+		t.Errorf("Filtered links = %v, want none without the filter", data.FilteredLinks)
+	}
+	if stored, _ := server.db.GetByID(data.ID); stored == nil || strings.Join(stored.Links, " ") != allLinks {
+		t.Errorf("Stored = %+v, want every link", stored)
+	}
+}
+
+func TestScrapeIncludeRaw(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><head><title>Raw</title></head><body><p>Raw page text</p></body></html>`))
+	}))
+	defer target.Close()
+
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	scrape := func(query string, req ScrapeRequest) map[string]json.RawMessage {
+		body, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		server.handleScrape(w, httptest.NewRequest(http.MethodPost, "/api/scrape"+query, bytes.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Scrape status = %d: %s", w.Code, w.Body.String())
+		}
+		var resp map[string]json.RawMessage
+		json.NewDecoder(w.Body).Decode(&resp)
+		return resp
+	}
+
+	if resp := scrape("", ScrapeRequest{URL: target.URL + "/plain"}); resp["raw_content"] != nil {
+		t.Errorf("raw_content = %s, want it left out by default", resp["raw_content"])
+	}
+
+	resp := scrape("?include_raw=true", ScrapeRequest{URL: target.URL, Fields: []string{"content"}})
+	if string(resp["raw_content"]) != `"Raw\nRaw page text"` || resp["content"] == nil {
+		t.Errorf("Response = %v, want content and raw_content", resp)
+	}
+
+	var id string
+	json.Unmarshal(scrape("", ScrapeRequest{URL: target.URL, Fields: []string{"id"}})["id"], &id)
+	if stored, err := server.db.GetByID(id); err != nil || stored == nil || stored.RawContent != "" {
+		t.Errorf("Stored = %+v, %v; want the record without raw content", stored, err)
+	}
+}
+
+func TestScrapeCacheNormalizesURL(t *testing.T) {
+	var hits int32
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Page</title></head><body><p>Hello.</p></body></html>`))
+	}))
+	defer target.Close()
+
+	scraperConfig := scraper.DefaultConfig()
+	scraperConfig.EnableImageAnalysis = false
+	scraperConfig.URLNormalization = true
+	scraperConfig.StripTrailingSlash = true
+	server, err := NewServer(Config{
+		DBConfig:      db.Config{Driver: "sqlite", DSN: t.TempDir() + "/test.db"},
+		ScraperConfig: scraperConfig,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	defer server.db.Close()
+
+	var first models.ScrapedData
+	for i, u := range []string{target.URL + "/page?utm_source=a", target.URL + "/page/", target.URL + "/page?gclid=b#top"} {
+		body, _ := json.Marshal(ScrapeRequest{URL: u})
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/scrape", bytes.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Scrape(%q) status = %d: %s", u, w.Code, w.Body.String())
+		}
+		var data models.ScrapedData
+		json.NewDecoder(w.Body).Decode(&data)
+		if i == 0 {
+			first = data
+			if data.URL != target.URL+"/page" {
+				t.Errorf("Stored URL = %q, want the normalized URL", data.URL)
+			}
+			continue
+		}
+		if !data.Cached || data.ID != first.ID {
+			t.Errorf("Scrape(%q) = cached %v, ID %q; want the cached %q", u, data.Cached, data.ID, first.ID)
+		}
+	}
+	if hits != 1 {
+		t.Errorf("Target fetched %d times, want 1", hits)
+	}
+}
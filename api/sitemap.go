@@ -0,0 +1,54 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// SitemapRequest represents a sitemap discovery request
+type SitemapRequest struct {
+	URL string `json:"url"` // Site URL, or the sitemap's own URL
+}
+
+// SitemapResponse lists the page URLs found in a site's sitemap
+type SitemapResponse struct {
+	URL   string   `json:"url"`
+	URLs  []string `json:"urls"`
+	Count int      `json:"count"`
+}
+
+// handleSitemap finds a site's sitemap and returns the page URLs it lists,
+// ready to be passed to batch scrape
+func (s *Server) handleSitemap(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req SitemapRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	if req.URL == "" {
+		respondError(w, http.StatusBadRequest, "url is required")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Minute)
+	defer cancel()
+
+	urls, err := s.scraper.FetchSitemap(ctx, req.URL)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("sitemap fetch failed: %v", err))
+		return
+	}
+
+	respondJSON(w, http.StatusOK, SitemapResponse{
+		URL:   req.URL,
+		URLs:  urls,
+		Count: len(urls),
+	})
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// TriageRequest represents a link triage request
+type TriageRequest struct {
+	URLs []string `json:"urls"`
+}
+
+// TriageResponse represents a link triage response
+type TriageResponse struct {
+	Results []TriageResult `json:"results"`
+	Summary TriageSummary  `json:"summary"`
+}
+
+// TriageResult is the compact score of a single URL in a triage request
+type TriageResult struct {
+	URL           string  `json:"url"`
+	Success       bool    `json:"success"`
+	Title         string  `json:"title,omitempty"`
+	Score         float64 `json:"score"`
+	IsRecommended bool    `json:"is_recommended"`
+	Category      string  `json:"category,omitempty"` // The first category the scorer reported
+	Error         string  `json:"error,omitempty"`
+}
+
+// TriageSummary provides triage summary statistics
+type TriageSummary struct {
+	Total       int `json:"total"`
+	Recommended int `json:"recommended"`
+	Failed      int `json:"failed"`
+}
+
+// handleTriage scores a batch of URLs without scraping them, returning only
+// each page's title, score, recommendation, and top category in request
+// order. Scores are saved as POST /api/score saves them.
+func (s *Server) handleTriage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req TriageRequest
+	if !decodeJSONBody(w, r, &req) || !validBatchURLs(w, req.URLs) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Minute)
+	defer cancel()
+
+	// Score URLs concurrently; the scraper bounds concurrent Ollama calls
+	results := make([]TriageResult, len(req.URLs))
+	var wg sync.WaitGroup
+	for i, url := range req.URLs {
+		wg.Add(1)
+		go func(index int, targetURL string) {
+			defer wg.Done()
+			results[index] = s.triageURL(ctx, targetURL)
+		}(i, url)
+	}
+	wg.Wait()
+
+	summary := TriageSummary{Total: len(results)}
+	for _, result := range results {
+		switch {
+		case !result.Success:
+			summary.Failed++
+		case result.IsRecommended:
+			summary.Recommended++
+		}
+	}
+
+	respondJSON(w, http.StatusOK, TriageResponse{Results: results, Summary: summary})
+}
+
+// triageURL scores a single URL for handleTriage
+func (s *Server) triageURL(ctx context.Context, targetURL string) TriageResult {
+	result := TriageResult{URL: targetURL}
+
+	title, score, err := s.scraper.TriageLink(ctx, targetURL)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	score.ScoredAt = time.Now()
+	if err := s.db.SaveLinkScore(score); err != nil {
+		log.Printf("Failed to save link score: %v", err)
+	}
+
+	result.Success = true
+	result.Title = title
+	result.Score = score.Score
+	result.IsRecommended = score.IsRecommended
+	if len(score.Categories) > 0 {
+		result.Category = score.Categories[0]
+	}
+	return result
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandleTriage(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Guide</title></head><body><p>A tutorial on Go programming and software documentation.</p><img src="/a.jpg"></body></html>`))
+	}))
+	defer target.Close()
+
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/triage", bytes.NewBufferString(body)))
+		return w
+	}
+
+	if w := post(`{"urls": []}`); w.Code != http.StatusBadRequest {
+		t.Errorf("empty urls: status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	body, _ := json.Marshal(TriageRequest{URLs: []string{target.URL + "/guide", target.URL + "/missing"}})
+	w := post(string(body))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if bytes.Contains(w.Body.Bytes(), []byte(`"content"`)) || bytes.Contains(w.Body.Bytes(), []byte(`"images"`)) {
+		t.Errorf("response includes content or images: %s", w.Body.String())
+	}
+
+	var resp TriageResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(resp.Results) != 2 {
+		t.Fatalf("got %d results, want 2", len(resp.Results))
+	}
+
+	guide, missing := resp.Results[0], resp.Results[1]
+	if !guide.Success || guide.Title != "Guide" || guide.URL != target.URL+"/guide" || guide.Score <= 0 {
+		t.Errorf("guide result = %+v, want a scored page titled Guide", guide)
+	}
+	if missing.Success || missing.Error == "" {
+		t.Errorf("missing result = %+v, want an error", missing)
+	}
+	if resp.Summary.Total != 2 || resp.Summary.Failed != 1 {
+		t.Errorf("summary = %+v, want 2 total and 1 failed", resp.Summary)
+	}
+
+	saved, err := server.db.GetLinkScoreByURL(target.URL + "/guide")
+	if err != nil || saved == nil || saved.Score != guide.Score {
+		t.Errorf("GetLinkScoreByURL = %+v, %v; want the triaged score saved", saved, err)
+	}
+}
@@ -0,0 +1,225 @@
+package scraper
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/zombar/scraper/models"
+	"golang.org/x/net/html"
+)
+
+// maxBylineAuthorWords caps the length of a heuristic author so that author
+// bios and article blurbs are not mistaken for names
+const maxBylineAuthorWords = 5
+
+// bylineDatePattern matches dates as commonly written in bylines and
+// datelines: "March 3, 2024", "3 Mar 2024", and "2024-03-03"
+var bylineDatePattern = regexp.MustCompile(`(?i)\b(?:` +
+	bylineMonths + `\.?\s+\d{1,2}(?:st|nd|rd|th)?,?\s+\d{4}` +
+	`|\d{1,2}(?:st|nd|rd|th)?\s+` + bylineMonths + `\.?,?\s+\d{4}` +
+	`|\d{4}-\d{2}-\d{2})\b`)
+
+// bylineMonths matches full and abbreviated English month names
+const bylineMonths = `(?:jan(?:uary)?|feb(?:ruary)?|mar(?:ch)?|apr(?:il)?|may|june?|july?|aug(?:ust)?|sep(?:t(?:ember)?)?|oct(?:ober)?|nov(?:ember)?|dec(?:ember)?)`
+
+// bylinePrefixPattern matches the lead-in to an author name
+var bylinePrefixPattern = regexp.MustCompile(`(?i)^(?:(?:written|posted|published)\s+)?by\s+|^author:\s*`)
+
+// bylineSeparators end the author part of a byline such as "By Jane Doe | Staff"
+var bylineSeparators = []string{" | ", " • ", " · ", " — ", " – ", " - ", ",", " on ", " updated "}
+
+// bylineDateLayouts are the layouts tried when normalizing a byline date
+var bylineDateLayouts = []string{
+	"January 2, 2006", "January 2 2006", "Jan 2, 2006", "Jan 2 2006",
+	"2 January 2006", "2 January, 2006", "2 Jan 2006", "2 Jan, 2006",
+	"2006-01-02",
+}
+
+// ordinalSuffixPattern matches the suffix of "3rd" so dates can be parsed
+var ordinalSuffixPattern = regexp.MustCompile(`(?i)(\d)(?:st|nd|rd|th)\b`)
+
+// septPattern matches the "Sept" abbreviation, which time.Parse does not accept
+var septPattern = regexp.MustCompile(`(?i)\bsept\b`)
+
+// fillFromByline fills an empty Author or PublishedDate from the visible
+// byline near the page's <h1>. It is a best-effort fallback and never
+// overrides values from meta tags or structured data.
+func fillFromByline(doc *html.Node, metadata *models.PageMetadata) {
+	if metadata.Author != "" && metadata.PublishedDate != "" {
+		return
+	}
+
+	scope := bylineScope(doc)
+	if scope == nil {
+		return
+	}
+
+	author, date := findByline(scope)
+	if metadata.Author == "" {
+		metadata.Author = author
+	}
+	if metadata.PublishedDate == "" {
+		metadata.PublishedDate = date
+	}
+}
+
+// bylineScope returns the part of the page where a byline is expected: the
+// <article> containing the first <h1>, or otherwise the h1's surrounding
+// block. Pages without an h1 fall back to their first <article>.
+func bylineScope(doc *html.Node) *html.Node {
+	h1 := findElement(doc, "h1")
+	if h1 == nil {
+		return findElement(doc, "article")
+	}
+
+	for p := h1.Parent; p != nil; p = p.Parent {
+		if p.Type == html.ElementNode && p.Data == "article" {
+			return p
+		}
+	}
+
+	// Bylines often sit just outside a <header> wrapping the headline
+	scope := h1.Parent
+	if scope != nil && scope.Type == html.ElementNode && (scope.Data == "header" || scope.Data == "hgroup") && scope.Parent != nil {
+		scope = scope.Parent
+	}
+	return scope
+}
+
+// findElement returns the first element with the given tag in document order
+func findElement(n *html.Node, tag string) *html.Node {
+	if n.Type == html.ElementNode && n.Data == tag {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findElement(c, tag); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// findByline walks the scope in document order and returns the first author
+// and date found in byline-like markup
+func findByline(scope *html.Node) (author, date string) {
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		if author != "" && date != "" {
+			return
+		}
+		if n.Type != html.ElementNode {
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				f(c)
+			}
+			return
+		}
+
+		switch n.Data {
+		case "script", "style", "nav", "footer", "aside", "form":
+			return
+		}
+		classes := strings.ToLower(getAttr(n, "class") + " " + getAttr(n, "id"))
+		if strings.Contains(classes, "comment") {
+			return
+		}
+
+		text := nodeText(n)
+		itemprop := strings.ToLower(getAttr(n, "itemprop"))
+
+		if author == "" {
+			switch {
+			case strings.EqualFold(getAttr(n, "rel"), "author"), itemprop == "author":
+				author = cleanBylineAuthor(text)
+			case strings.Contains(classes, "byline"), strings.Contains(classes, "author"):
+				author = cleanBylineAuthor(text)
+			case bylinePrefixPattern.MatchString(text) && len(text) < 120 && isBlockElement(n):
+				author = cleanBylineAuthor(text)
+			}
+		}
+
+		if date == "" {
+			switch {
+			case n.Data == "time", itemprop == "datepublished":
+				date = getAttr(n, "datetime")
+				if date == "" {
+					date = getAttr(n, "content")
+				}
+				if date == "" {
+					date = findBylineDate(text)
+				}
+			case strings.Contains(classes, "byline"), strings.Contains(classes, "date"),
+				strings.Contains(classes, "published"), strings.Contains(classes, "posted"),
+				strings.Contains(classes, "timestamp"):
+				date = findBylineDate(text)
+			case bylinePrefixPattern.MatchString(text) && len(text) < 120 && isBlockElement(n):
+				date = findBylineDate(text)
+			}
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			f(c)
+		}
+	}
+	f(scope)
+	return author, date
+}
+
+// isBlockElement reports whether a node is a short-text container a
+// "By ..." line would plausibly be written in
+func isBlockElement(n *html.Node) bool {
+	switch n.Data {
+	case "p", "div", "span", "address", "small":
+		return true
+	}
+	return false
+}
+
+// nodeText returns the whitespace-normalized text of a node
+func nodeText(n *html.Node) string {
+	text := strings.Join(strings.Fields(extractText(n)), " ")
+	// extractText separates inline elements with spaces; rejoin punctuation
+	return strings.NewReplacer(" ,", ",", " .", ".").Replace(text)
+}
+
+// cleanBylineAuthor extracts the author name from byline text such as
+// "By Jane Doe, March 3, 2024", returning "" if it does not look like a name
+func cleanBylineAuthor(text string) string {
+	text = bylineDatePattern.ReplaceAllString(text, "")
+	text = strings.TrimSpace(bylinePrefixPattern.ReplaceAllString(strings.TrimSpace(text), ""))
+
+	lower := strings.ToLower(text)
+	for _, sep := range bylineSeparators {
+		if i := strings.Index(lower, sep); i >= 0 {
+			text, lower = text[:i], lower[:i]
+		}
+	}
+	text = strings.Trim(text, " ,.|-–—•·:")
+
+	if text == "" || len(strings.Fields(text)) > maxBylineAuthorWords {
+		return ""
+	}
+	if !strings.ContainsAny(strings.ToLower(text), "abcdefghijklmnopqrstuvwxyz") {
+		return ""
+	}
+	return text
+}
+
+// findBylineDate returns the first date in the text, normalized to
+// YYYY-MM-DD when it can be parsed
+func findBylineDate(text string) string {
+	match := bylineDatePattern.FindString(text)
+	if match == "" {
+		return ""
+	}
+
+	normalized := ordinalSuffixPattern.ReplaceAllString(match, "$1")
+	normalized = strings.Replace(normalized, ".", "", 1)
+	normalized = septPattern.ReplaceAllString(normalized, "Sep")
+	for _, layout := range bylineDateLayouts {
+		if t, err := time.Parse(layout, normalized); err == nil {
+			return t.Format("2006-01-02")
+		}
+	}
+	return match
+}
@@ -0,0 +1,110 @@
+package scraper
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestExtractMetadataByline(t *testing.T) {
+	tests := []struct {
+		name       string
+		html       string
+		wantAuthor string
+		wantDate   string
+	}{
+		{
+			name:       "byline paragraph with date",
+			html:       `<article><h1>Headline</h1><p class="byline">By Jane Doe, March 3, 2024</p><p>Body text.</p></article>`,
+			wantAuthor: "Jane Doe",
+			wantDate:   "2024-03-03",
+		},
+		{
+			name: "rel author and time element",
+			html: `<header><h1>Headline</h1></header>
+				<div class="meta">By <a rel="author" href="/authors/jdoe">Jane Doe</a> on <time datetime="2024-03-03T09:00:00Z">March 3</time></div>
+				<p>Body text.</p>`,
+			wantAuthor: "Jane Doe",
+			wantDate:   "2024-03-03T09:00:00Z",
+		},
+		{
+			name: "author and date spans",
+			html: `<article><h1>Headline</h1>
+				<div class="post-meta"><span class="author-name">Written by John Smith</span> | <span class="post-date">3rd Sept 2024</span></div>
+				<p>Body text.</p></article>`,
+			wantAuthor: "John Smith",
+			wantDate:   "2024-09-03",
+		},
+		{
+			name:       "plain By line after headline",
+			html:       `<h1>Headline</h1><p>By Alex Lee | Staff Writer</p><p>Body text about things.</p>`,
+			wantAuthor: "Alex Lee",
+		},
+		{
+			name: "ignores comment authors",
+			html: `<article><h1>Headline</h1><p>Body text.</p>
+				<section class="comments"><span class="comment-author">Troll</span></section></article>`,
+		},
+		{
+			name: "ignores long author bios",
+			html: `<article><h1>Headline</h1><div class="author-bio">Jane has written about technology and science for over twenty years at several outlets.</div></article>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader(tt.html))
+			if err != nil {
+				t.Fatalf("Failed to parse HTML: %v", err)
+			}
+
+			metadata := extractMetadata(doc)
+			if metadata.Author != tt.wantAuthor {
+				t.Errorf("Author = %q, want %q", metadata.Author, tt.wantAuthor)
+			}
+			if metadata.PublishedDate != tt.wantDate {
+				t.Errorf("PublishedDate = %q, want %q", metadata.PublishedDate, tt.wantDate)
+			}
+		})
+	}
+}
+
+func TestExtractMetadataBylineDoesNotOverride(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><head>
+		<meta name="author" content="Meta Author">
+		<script type="application/ld+json">{"@type": "NewsArticle", "datePublished": "2024-01-01"}</script>
+		</head><body><article><h1>Headline</h1><p class="byline">By Jane Doe, March 3, 2024</p></article></body></html>`))
+	if err != nil {
+		t.Fatalf("Failed to parse HTML: %v", err)
+	}
+
+	metadata := extractMetadata(doc)
+	if metadata.Author != "Meta Author" {
+		t.Errorf("Author = %q, want meta tag author", metadata.Author)
+	}
+	if metadata.PublishedDate != "2024-01-01" {
+		t.Errorf("PublishedDate = %q, want JSON-LD date", metadata.PublishedDate)
+	}
+}
+
+func TestFindBylineDate(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Published March 3, 2024", "2024-03-03"},
+		{"Mar. 3 2024", "2024-03-03"},
+		{"3 March 2024", "2024-03-03"},
+		{"Updated 2024-03-03 at noon", "2024-03-03"},
+		{"Sept 12, 2023", "2023-09-12"},
+		{"Market 12, 2024", ""},
+		{"No date here", ""},
+	}
+
+	for _, tt := range tests {
+		if got := findBylineDate(tt.text); got != tt.want {
+			t.Errorf("findBylineDate(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package scraper
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/zombar/scraper/models"
+)
+
+// Character encodings the scraper can decode pages from
+const (
+	CharsetUTF8        = "utf-8"
+	CharsetUTF16LE     = "utf-16le"
+	CharsetUTF16BE     = "utf-16be"
+	CharsetWindows1252 = "windows-1252"
+)
+
+// charsetLabels maps charset labels to the encoding they name. As in
+// browsers, ISO-8859-1 and ASCII labels mean windows-1252, a superset.
+var charsetLabels = map[string]string{
+	"utf-8":             CharsetUTF8,
+	"utf8":              CharsetUTF8,
+	"unicode-1-1-utf-8": CharsetUTF8,
+	"utf-16":            CharsetUTF16LE,
+	"utf-16le":          CharsetUTF16LE,
+	"utf-16be":          CharsetUTF16BE,
+	"windows-1252":      CharsetWindows1252,
+	"cp1252":            CharsetWindows1252,
+	"x-cp1252":          CharsetWindows1252,
+	"iso-8859-1":        CharsetWindows1252,
+	"iso8859-1":         CharsetWindows1252,
+	"latin1":            CharsetWindows1252,
+	"l1":                CharsetWindows1252,
+	"us-ascii":          CharsetWindows1252,
+	"ascii":             CharsetWindows1252,
+}
+
+// windows1252High holds the characters windows-1252 puts at 0x80-0x9F,
+// where ISO-8859-1 has control codes; the five unassigned bytes keep them
+var windows1252High = [32]rune{
+	'\u20ac', '\u0081', '\u201a', '\u0192', '\u201e', '\u2026', '\u2020', '\u2021',
+	'\u02c6', '\u2030', '\u0160', '\u2039', '\u0152', '\u008d', '\u017d', '\u008f',
+	'\u0090', '\u2018', '\u2019', '\u201c', '\u201d', '\u2022', '\u2013', '\u2014',
+	'\u02dc', '\u2122', '\u0161', '\u203a', '\u0153', '\u009d', '\u017e', '\u0178',
+}
+
+// metaCharsetPattern finds the charset in <meta charset="..."> or
+// <meta http-equiv="Content-Type" content="text/html; charset=...">
+var metaCharsetPattern = regexp.MustCompile(`(?i)<meta[^>]+charset\s*=\s*["']?\s*([A-Za-z0-9_:.-]+)`)
+
+// metaCharsetPrescan is how far into a page a <meta> charset is looked for,
+// as in the HTML encoding sniffing algorithm
+const metaCharsetPrescan = 1024
+
+// pageCharset records how a page's bytes were decoded
+type pageCharset struct {
+	Used     string // Encoding the page was decoded with
+	Declared string // From the Content-Type header or a <meta> tag, empty if none
+	Sniffed  string // From a byte order mark or the bytes themselves
+}
+
+// apply records the charset in page metadata, including the declared and
+// sniffed charsets only when they disagree
+func (c pageCharset) apply(metadata *models.PageMetadata) {
+	metadata.Charset = c.Used
+	if c.Declared != "" && c.Declared != c.Sniffed {
+		metadata.DeclaredCharset = c.Declared
+		metadata.SniffedCharset = c.Sniffed
+	}
+}
+
+// readHTML reads a page body and, with Config.DecodeCharset, decodes it to
+// UTF-8 for parsing. A byte order mark takes precedence, then a declared
+// charset the scraper can decode, then the sniffed one.
+func (s *Scraper) readHTML(resp *http.Response) ([]byte, pageCharset, error) {
+	body, err := s.readBody(resp)
+	if err != nil {
+		return nil, pageCharset{}, err
+	}
+	decoded, charset := s.decodeHTML(resp, body)
+	return decoded, charset, nil
+}
+
+// decodeHTML decodes a page body already read, as readHTML does
+func (s *Scraper) decodeHTML(resp *http.Response, body []byte) ([]byte, pageCharset) {
+	charset := pageCharset{
+		Declared: declaredCharset(resp.Header.Get("Content-Type"), body),
+		Sniffed:  sniffCharset(body),
+	}
+	switch _, supported := decodableCharsets[charset.Declared]; {
+	case !s.config.DecodeCharset:
+		charset.Used = CharsetUTF8
+	case hasBOM(body):
+		charset.Used = charset.Sniffed
+	case supported:
+		charset.Used = charset.Declared
+	default:
+		charset.Used = charset.Sniffed
+	}
+	return decodeCharset(body, charset.Used), charset
+}
+
+// decodableCharsets are the encodings decodeCharset handles
+var decodableCharsets = map[string]struct{}{
+	CharsetUTF8:        {},
+	CharsetUTF16LE:     {},
+	CharsetUTF16BE:     {},
+	CharsetWindows1252: {},
+}
+
+// declaredCharset returns the charset named by a Content-Type header or,
+// failing that, a <meta> tag near the start of the page. Known labels are
+// canonicalized; unknown ones are returned lowercased. As in the WHATWG
+// prescan, a <meta> tag naming UTF-16 means UTF-8: a page whose tag could
+// be read as ASCII is not UTF-16, and one that really is has a BOM.
+func declaredCharset(contentType string, body []byte) string {
+	if _, params, err := mime.ParseMediaType(contentType); err == nil && params["charset"] != "" {
+		return canonicalCharset(params["charset"])
+	}
+	m := metaCharsetPattern.FindSubmatch(body[:min(len(body), metaCharsetPrescan)])
+	if m == nil {
+		return ""
+	}
+	charset := canonicalCharset(string(m[1]))
+	if charset == CharsetUTF16LE || charset == CharsetUTF16BE {
+		return CharsetUTF8
+	}
+	return charset
+}
+
+// canonicalCharset returns the canonical name of a charset label, or the
+// label lowercased if it is unknown
+func canonicalCharset(label string) string {
+	label = strings.ToLower(strings.TrimSpace(label))
+	if charset, ok := charsetLabels[label]; ok {
+		return charset
+	}
+	return label
+}
+
+// sniffCharset guesses a page's encoding from its byte order mark, or else
+// from whether it is valid UTF-8, taking windows-1252 if not
+func sniffCharset(body []byte) string {
+	switch {
+	case bytes.HasPrefix(body, []byte{0xEF, 0xBB, 0xBF}):
+		return CharsetUTF8
+	case bytes.HasPrefix(body, []byte{0xFF, 0xFE}):
+		return CharsetUTF16LE
+	case bytes.HasPrefix(body, []byte{0xFE, 0xFF}):
+		return CharsetUTF16BE
+	case utf8.Valid(body):
+		return CharsetUTF8
+	}
+	return CharsetWindows1252
+}
+
+// hasBOM reports whether a page starts with a UTF-8 or UTF-16 byte order mark
+func hasBOM(body []byte) bool {
+	return bytes.HasPrefix(body, []byte{0xEF, 0xBB, 0xBF}) ||
+		bytes.HasPrefix(body, []byte{0xFF, 0xFE}) || bytes.HasPrefix(body, []byte{0xFE, 0xFF})
+}
+
+// decodeCharset converts a page in the given encoding to UTF-8, dropping
+// any byte order mark. UTF-8 pages are returned as they are.
+func decodeCharset(body []byte, charset string) []byte {
+	switch charset {
+	case CharsetWindows1252:
+		var b strings.Builder
+		b.Grow(len(body))
+		for _, c := range body {
+			if c >= 0x80 && c < 0xA0 {
+				b.WriteRune(windows1252High[c-0x80])
+			} else {
+				b.WriteRune(rune(c))
+			}
+		}
+		return []byte(b.String())
+
+	case CharsetUTF16LE, CharsetUTF16BE:
+		if bytes.HasPrefix(body, []byte{0xFF, 0xFE}) || bytes.HasPrefix(body, []byte{0xFE, 0xFF}) {
+			body = body[2:]
+		}
+		units := make([]uint16, len(body)/2)
+		for i := range units {
+			if charset == CharsetUTF16LE {
+				units[i] = uint16(body[2*i]) | uint16(body[2*i+1])<<8
+			} else {
+				units[i] = uint16(body[2*i])<<8 | uint16(body[2*i+1])
+			}
+		}
+		return []byte(string(utf16.Decode(units)))
+	}
+	return bytes.TrimPrefix(body, []byte{0xEF, 0xBB, 0xBF})
+}
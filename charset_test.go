@@ -0,0 +1,103 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReadHTMLCharset(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		decode      bool
+		want        string
+		wantCharset pageCharset
+	}{
+		{"declared UTF-8", "text/html; charset=UTF-8", []byte("caf\u00e9"), true,
+			"caf\u00e9", pageCharset{Used: "utf-8", Declared: "utf-8", Sniffed: "utf-8"}},
+		{"ISO-8859-1 header decodes as windows-1252", "text/html; charset=ISO-8859-1", []byte("caf\xe9"), true,
+			"caf\u00e9", pageCharset{Used: "windows-1252", Declared: "windows-1252", Sniffed: "windows-1252"}},
+		{"meta charset", "text/html", []byte(`<meta charset="windows-1252"><p>` + "\x93quoted\x94 \x80"), true,
+			`<meta charset="windows-1252"><p>` + "\u201cquoted\u201d \u20ac", pageCharset{Used: "windows-1252", Declared: "windows-1252", Sniffed: "windows-1252"}},
+		{"meta http-equiv", "", []byte(`<meta http-equiv="Content-Type" content="text/html; charset=latin1">` + "\xe9"), true,
+			`<meta http-equiv="Content-Type" content="text/html; charset=latin1">` + "\u00e9", pageCharset{Used: "windows-1252", Declared: "windows-1252", Sniffed: "windows-1252"}},
+		{"declared UTF-8 disagrees with bytes", "text/html; charset=utf-8", []byte("caf\xe9"), true,
+			"caf\xe9", pageCharset{Used: "utf-8", Declared: "utf-8", Sniffed: "windows-1252"}},
+		{"undeclared invalid UTF-8 is sniffed", "text/html", []byte("caf\xe9"), true,
+			"caf\u00e9", pageCharset{Used: "windows-1252", Sniffed: "windows-1252"}},
+		{"byte order mark wins", "text/html; charset=iso-8859-1", []byte{0xFF, 0xFE, 'h', 0, 0xE9, 0}, true,
+			"h\u00e9", pageCharset{Used: "utf-16le", Declared: "windows-1252", Sniffed: "utf-16le"}},
+		{"big-endian UTF-16", "text/html; charset=utf-16be", []byte{0, 'h', 0, 0xE9}, true,
+			"h\u00e9", pageCharset{Used: "utf-16be", Declared: "utf-16be", Sniffed: "windows-1252"}},
+		{"meta UTF-16 without a byte order mark means UTF-8", "text/html", []byte(`<meta charset="utf-16"><p>caf` + "\u00e9"), true,
+			`<meta charset="utf-16"><p>caf` + "\u00e9", pageCharset{Used: "utf-8", Declared: "utf-8", Sniffed: "utf-8"}},
+		{"UTF-8 byte order mark dropped", "", []byte("\xef\xbb\xbfcaf\u00e9"), true,
+			"caf\u00e9", pageCharset{Used: "utf-8", Sniffed: "utf-8"}},
+		{"unsupported charset falls back to sniffing", "text/html; charset=Shift_JIS", []byte("plain"), true,
+			"plain", pageCharset{Used: "utf-8", Declared: "shift_jis", Sniffed: "utf-8"}},
+		{"decoding off", "text/html; charset=iso-8859-1", []byte("caf\xe9"), false,
+			"caf\xe9", pageCharset{Used: "utf-8", Declared: "windows-1252", Sniffed: "windows-1252"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.DecodeCharset = tt.decode
+			resp := &http.Response{
+				Header:        http.Header{"Content-Type": {tt.contentType}},
+				Body:          io.NopCloser(bytes.NewReader(tt.body)),
+				ContentLength: int64(len(tt.body)),
+			}
+			body, charset, err := New(config).readHTML(resp)
+			if err != nil {
+				t.Fatalf("readHTML failed: %v", err)
+			}
+			if string(body) != tt.want {
+				t.Errorf("body = %q, want %q", body, tt.want)
+			}
+			if charset != tt.wantCharset {
+				t.Errorf("charset = %+v, want %+v", charset, tt.wantCharset)
+			}
+		})
+	}
+}
+
+func TestScrapeRecordsCharset(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		contentType  string
+		wantDeclared string
+		wantSniffed  string
+	}{
+		{"agreeing", "text/html; charset=iso-8859-1", "", ""},
+		{"disagreeing", "text/html; charset=utf-8", "utf-8", "windows-1252"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			webServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.Write([]byte("<html><head><title>Caf\xe9</title></head><body><p>Men\xfa</p></body></html>"))
+			}))
+			defer webServer.Close()
+
+			config := DefaultConfig()
+			config.OllamaBaseURL = "http://127.0.0.1:1"
+			config.EnableImageAnalysis = false
+			data, err := New(config).Scrape(context.Background(), webServer.URL)
+			if err != nil {
+				t.Fatalf("Scrape failed: %v", err)
+			}
+			metadata := data.Metadata
+			if metadata.DeclaredCharset != tt.wantDeclared || metadata.SniffedCharset != tt.wantSniffed {
+				t.Errorf("DeclaredCharset, SniffedCharset = %q, %q; want %q, %q",
+					metadata.DeclaredCharset, metadata.SniffedCharset, tt.wantDeclared, tt.wantSniffed)
+			}
+			if tt.wantDeclared == "" && (data.Title != "Caf\u00e9" || metadata.Charset != "windows-1252") {
+				t.Errorf("Title = %q with charset %q, want the page decoded from windows-1252", data.Title, metadata.Charset)
+			}
+		})
+	}
+}
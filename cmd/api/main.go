@@ -7,13 +7,17 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"slices"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/zombar/scraper"
 	"github.com/zombar/scraper/api"
 	"github.com/zombar/scraper/db"
+	"github.com/zombar/scraper/models"
+	"github.com/zombar/scraper/ollama"
 )
 
 // getEnv retrieves an environment variable or returns a default value
@@ -24,6 +28,50 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// parseDisabledEndpoints converts a comma-separated list of endpoint names
+// into an endpoint toggle map with each listed endpoint disabled. Unknown
+// names are an error, so that a typo cannot leave an endpoint enabled.
+func parseDisabledEndpoints(value string) (map[string]bool, error) {
+	endpoints := make(map[string]bool)
+	for _, name := range parseList(value) {
+		if !slices.Contains(api.EndpointNames, name) {
+			return nil, fmt.Errorf("unknown endpoint %q, want one of %s", name, strings.Join(api.EndpointNames, ", "))
+		}
+		endpoints[name] = false
+	}
+	return endpoints, nil
+}
+
+// parseList splits a comma-separated flag value, dropping empty entries
+func parseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseHostOverrides parses comma-separated host=address pairs, such as
+// "example.com=10.0.0.5,api.example.com=127.0.0.1:8080"
+func parseHostOverrides(value string) (map[string]string, error) {
+	var overrides map[string]string
+	for _, item := range parseList(value) {
+		host, addr, ok := strings.Cut(item, "=")
+		host, addr = strings.TrimSpace(host), strings.TrimSpace(addr)
+		if !ok || host == "" || addr == "" {
+			return nil, fmt.Errorf("invalid host override %q, want host=address", item)
+		}
+		if overrides == nil {
+			overrides = make(map[string]string)
+		}
+		overrides[host] = addr
+	}
+	return overrides, nil
+}
+
 func main() {
 	// Default values
 	defaultPort := getEnv("PORT", "8080")
@@ -31,6 +79,14 @@ func main() {
 	defaultOllamaURL := getEnv("OLLAMA_URL", "http://localhost:11434")
 	defaultOllamaModel := getEnv("OLLAMA_MODEL", "gpt-oss:20b")
 	defaultLinkScoreThreshold := getEnv("LINK_SCORE_THRESHOLD", "0.5")
+	defaultDisabledEndpoints := getEnv("DISABLED_ENDPOINTS", "")
+	defaultAPIKeys := getEnv("API_KEYS", "")
+	defaultSiteRules := getEnv("SITE_RULES", "")
+	defaultUserAgent := getEnv("USER_AGENT", scraper.DefaultUserAgent)
+	defaultHostOverrides := getEnv("HOST_OVERRIDES", "")
+	defaultRetention := getEnv("RETENTION_PERIOD", "0")
+	defaultProxyURL := getEnv("PROXY_URL", "")
+	defaultOllamaProxyURL := getEnv("OLLAMA_PROXY_URL", "")
 
 	// Parse link score threshold
 	linkScoreThreshold, err := strconv.ParseFloat(defaultLinkScoreThreshold, 64)
@@ -39,6 +95,13 @@ func main() {
 		linkScoreThreshold = 0.5
 	}
 
+	// Parse retention period
+	retentionPeriod, err := time.ParseDuration(defaultRetention)
+	if err != nil {
+		log.Printf("Invalid RETENTION_PERIOD value, retention disabled: %v", err)
+		retentionPeriod = 0
+	}
+
 	// Command-line flags (override environment variables)
 	port := flag.String("port", defaultPort, "Server port")
 	dbPath := flag.String("db", defaultDBPath, "Database file path")
@@ -47,8 +110,99 @@ func main() {
 	scoreThreshold := flag.Float64("link-score-threshold", linkScoreThreshold, "Minimum score for link recommendation (0.0-1.0)")
 	disableCORS := flag.Bool("disable-cors", false, "Disable CORS")
 	disableImageAnalysis := flag.Bool("disable-image-analysis", false, "Disable AI-powered image analysis")
+	disabledEndpoints := flag.String("disabled-endpoints", defaultDisabledEndpoints, "Comma-separated list of endpoints to disable (e.g. scrape,batch,delete)")
+	batchIncludeImageData := flag.Bool("batch-include-image-data", false, "Include base64 image data in batch scrape responses")
+	maxBatchImages := flag.Int("max-batch-images", 0, "Maximum images analyzed with Ollama across all pages of one batch request; the rest are stored without analysis (0 for no limit)")
+	maxRequestBodyBytes := flag.Int64("max-request-body-bytes", 1024*1024, "Maximum API request body size in bytes")
+	bodyReadTimeout := flag.Duration("body-read-timeout", 10*time.Second, "Maximum time a request body read may stall before the request is rejected")
+	allowedImageTypes := flag.String("allowed-image-types", "", "Comma-separated image MIME types to download (e.g. image/jpeg,image/png); empty allows all")
+	minImageWidth := flag.Int("min-image-width", 0, "Skip analysis of images narrower than this many pixels")
+	minImageHeight := flag.Int("min-image-height", 0, "Skip analysis of images shorter than this many pixels")
+	recommendedImagesOnly := flag.Bool("recommended-images-only", false, "Download, analyze, and store images only for pages scored as recommended (scores pages before processing their images)")
+	enableMarkdown := flag.Bool("enable-markdown", false, "Include the page converted to Markdown in scrape results")
+	outputFormatFlag := flag.String("output-format", string(scraper.OutputPlain), "Format of scraped content: plain text, or markdown to keep headings, links, emphasis, and lists")
+	canonicalDedup := flag.Bool("canonical-dedup", false, "Store results under the page's canonical URL so tracking-parameter variants share one record")
+	normalizeURLs := flag.Bool("normalize-urls", false, "Store and look up results by a normalized URL: lowercase host, no default port, tracking parameters (utm_*, fbclid, gclid), or fragment")
+	normalizeLinks := flag.Bool("normalize-links", false, "Return extracted links normalized, without trailing slashes, tracking parameters, or fragments, so variants of one page appear once")
+	keepInPageLinks := flag.Bool("keep-in-page-links", false, "Keep extracted links that only point within the page, such as #section")
+	stripLinkFragments := flag.Bool("strip-link-fragments", false, "Remove fragments from extracted links so anchors into one page list it once (leave off for sites that route with fragments)")
+	stripTrailingSlash := flag.Bool("strip-trailing-slash", false, "With -normalize-urls, also treat /page/ and /page as the same URL")
+	discoverConcurrency := flag.Int("discover-concurrency", 5, "Maximum links scored at once by /api/discover")
+	maxLinkAge := flag.Duration("max-link-age", 0, "Skip links /api/discover finds whose page was published longer ago than this, without scoring them (e.g. 720h; 0 disables)")
+	maxRetryAfter := flag.Duration("max-retry-after", 10*time.Second, "Longest Retry-After on a 429 from a scraped site to wait out before retrying once (0 never waits)")
+	discoverTimeout := flag.Duration("discover-timeout", 2*time.Minute, "Time budget for one /api/discover call; links not scored by then are left out")
+	scoreContentChars := flag.Int("score-content-chars", ollama.DefaultScoreContentChars, "Bytes of page text included in the Ollama scoring prompt (negative includes all of it, up to -max-content-chars)")
+	maxContentChars := flag.Int("max-content-chars", 12000, "Truncate page text to this many characters before sending it to Ollama (negative disables)")
+	includeHiddenText := flag.Bool("include-hidden-text", false, "Keep text of elements hidden with the hidden attribute, aria-hidden, or an inline display:none or visibility:hidden style")
+	flatText := flag.Bool("flat-text", false, "Join extracted text with spaces instead of keeping paragraphs, list items, and quotes on their own lines")
+	textSkipTags := flag.String("text-skip-tags", strings.Join(scraper.DefaultTextSkipTags(), ","), "Comma-separated elements whose text is left out of extracted content (empty skips none)")
+	disableCharsetDecoding := flag.Bool("disable-charset-decoding", false, "Read every page as UTF-8 instead of decoding UTF-16 and windows-1252 (ISO-8859-1) pages")
+	disableUnicodeNormalization := flag.Bool("disable-unicode-normalization", false, "Keep extracted text as-is instead of applying NFC normalization and replacing no-break and zero-width characters")
+	proxyURL := flag.String("proxy-url", defaultProxyURL, "Proxy for page and image requests: http://, https://, or socks5://host:port (default: HTTP_PROXY and HTTPS_PROXY from the environment)")
+	ollamaTemperature := flag.Float64("ollama-temperature", -1, "Sampling temperature for Ollama content extraction (negative uses the model's default)")
+	ollamaNumPredict := flag.Int("ollama-num-predict", 0, "Maximum tokens Ollama generates when extracting content (0 uses the model's default)")
+	ollamaJSONSchemas := flag.Bool("ollama-json-schemas", false, "Constrain Ollama scoring and link filtering answers to a JSON schema instead of any JSON (needs Ollama 0.5 or later)")
+	ollamaProxyURL := flag.String("ollama-proxy-url", defaultOllamaProxyURL, "Proxy for Ollama requests, which may be the same as -proxy-url (default: HTTP_PROXY and HTTPS_PROXY from the environment)")
+	userAgent := flag.String("user-agent", defaultUserAgent, "User-Agent header sent when fetching pages, images, and login forms")
+	hostOverridesFlag := flag.String("host-overrides", defaultHostOverrides, "Comma-separated host=address pairs to connect to instead of resolving the host (e.g. example.com=10.0.0.5)")
+	preflightHEAD := flag.Bool("preflight-head", false, "Check each page's type and size with a HEAD request and skip non-HTML or oversized pages before downloading them")
+	enableCookies := flag.Bool("enable-cookies", false, "Keep cookies set while scraping a page for its images and redirects, discarding them afterwards")
+	enableCookieJar := flag.Bool("enable-cookie-jar", false, "Keep cookies set by each host and send them on later requests to it")
+	rateLimit := flag.Float64("rate-limit", 0, "Maximum sustained API requests per second per client IP (0 disables rate limiting)")
+	rateLimitBurst := flag.Int("rate-limit-burst", 10, "Number of API requests a client may make at once before rate limiting applies")
+	trustForwardedFor := flag.Bool("trust-forwarded-for", false, "Identify rate-limited clients by X-Forwarded-For (only behind a trusted proxy)")
+	enableMetrics := flag.Bool("enable-metrics", false, "Serve Prometheus metrics at /metrics")
+	apiKeys := flag.String("api-keys", defaultAPIKeys, "Comma-separated API keys required on all endpoints except /health (empty disables authentication)")
+	storeRecommendedLinksOnly := flag.Bool("store-recommended-links-only", false, "Store only the links the Ollama link filter keeps, while still returning every extracted link in scrape responses")
+	debugMode := flag.Bool("debug-mode", false, "Allow scrape requests to include the Ollama prompts and raw responses with ?debug=true")
+	allowedModels := flag.String("allowed-models", "", "Comma-separated Ollama models scrape and score requests may choose with \"model\", besides -ollama-model")
+	storeFailures := flag.Bool("store-failures", false, "Record failed scrapes (URL, error, HTTP status) for dead-link monitoring at /api/failures")
+	retention := flag.Duration("retention", retentionPeriod, "Delete scraped data older than this, checked at least hourly (e.g. 720h; 0 keeps data forever)")
+	optimizeInterval := flag.Duration("optimize-interval", 0, "Vacuum and optimize the SQLite database this often to reclaim space from deleted records (e.g. 168h; 0 disables). Writes may fail while it runs")
+	scoringModeFlag := flag.String("scoring-mode", string(scraper.ScoringAlways), "When to score pages with Ollama: always, best_effort (only when a scoring slot is free, otherwise rule-based), deferred (rule-based at first, upgraded in the background), or rule_only")
+	maxConcurrentScores := flag.Int("max-concurrent-scores", 4, "Maximum Ollama scoring calls in flight at once")
+	maxTrackers := flag.Int("max-trackers", 0, "Lower the score of pages loading more known third-party trackers than this (0 disables)")
+	siteRulesFile := flag.String("site-rules", defaultSiteRules, "JSON file of per-host extraction rules (reloaded on SIGHUP)")
 	flag.Parse()
 
+	hostOverrides, err := parseHostOverrides(*hostOverridesFlag)
+	if err != nil {
+		log.Fatalf("Invalid -host-overrides: %v", err)
+	}
+	enabledEndpoints, err := parseDisabledEndpoints(*disabledEndpoints)
+	if err != nil {
+		log.Fatalf("Invalid -disabled-endpoints: %v", err)
+	}
+
+	scoringMode, ok := scraper.ParseScoringMode(*scoringModeFlag)
+	if !ok {
+		log.Fatalf("Invalid -scoring-mode %q: must be always, best_effort, deferred, or rule_only", *scoringModeFlag)
+	}
+	outputFormat, ok := scraper.ParseOutputFormat(*outputFormatFlag)
+	if !ok {
+		log.Fatalf("Invalid -output-format %q: must be plain or markdown", *outputFormatFlag)
+	}
+
+	// Sampling options for content extraction; scoring and link filtering
+	// keep their deterministic defaults
+	var ollamaOptions *models.OllamaOptions
+	if *ollamaTemperature >= 0 || *ollamaNumPredict > 0 {
+		ollamaOptions = &models.OllamaOptions{NumPredict: *ollamaNumPredict}
+		if *ollamaTemperature >= 0 {
+			ollamaOptions.Temperature = ollamaTemperature
+		}
+	}
+
+	// Load per-host extraction rules, refusing to start with an invalid file
+	var siteRules scraper.SiteRules
+	if *siteRulesFile != "" {
+		siteRules, err = scraper.LoadSiteRules(*siteRulesFile)
+		if err != nil {
+			log.Fatalf("Failed to load site rules: %v", err)
+		}
+		log.Printf("Loaded site rules for %d hosts from %s", len(siteRules), *siteRulesFile)
+	}
+
 	// Create server configuration
 	config := api.Config{
 		Addr: ":" + *port,
@@ -57,15 +211,72 @@ func main() {
 			DSN:    *dbPath,
 		},
 		ScraperConfig: scraper.Config{
-			HTTPTimeout:         30 * time.Second,
-			OllamaBaseURL:       *ollamaURL,
-			OllamaModel:         *ollamaModel,
-			EnableImageAnalysis: !*disableImageAnalysis,
-			MaxImageSizeBytes:   10 * 1024 * 1024, // 10MB
-			ImageTimeout:        15 * time.Second,
-			LinkScoreThreshold:  *scoreThreshold,
+			HTTPTimeout:           30 * time.Second,
+			DialTimeout:           30 * time.Second,
+			OllamaBaseURL:         *ollamaURL,
+			OllamaModel:           *ollamaModel,
+			UserAgent:             *userAgent,
+			ProxyURL:              *proxyURL,
+			OllamaProxyURL:        *ollamaProxyURL,
+			OllamaOptions:         ollamaOptions,
+			OllamaJSONSchemas:     *ollamaJSONSchemas,
+			HostOverrides:         hostOverrides,
+			EnableImageAnalysis:   !*disableImageAnalysis,
+			MaxImageSizeBytes:     10 * 1024 * 1024, // 10MB
+			MaxBodyBytes:          20 * 1024 * 1024, // 20MB
+			ImageTimeout:          15 * time.Second,
+			MaxRetryAfter:         *maxRetryAfter,
+			LinkScoreThreshold:    *scoreThreshold,
+			ScoringMode:           scoringMode,
+			MaxConcurrentScores:   *maxConcurrentScores,
+			MaxTrackers:           *maxTrackers,
+			EnableCookieJar:       *enableCookieJar,
+			EnableCookies:         *enableCookies,
+			PreflightHEAD:         *preflightHEAD,
+			UseCanonicalForDedup:  *canonicalDedup,
+			URLNormalization:      *normalizeURLs,
+			NormalizeLinks:        *normalizeLinks,
+			KeepInPageLinks:       *keepInPageLinks,
+			StripLinkFragments:    *stripLinkFragments,
+			StripTrailingSlash:    *stripTrailingSlash,
+			EnableMarkdown:        *enableMarkdown,
+			OutputFormat:          outputFormat,
+			DecodeCharset:         !*disableCharsetDecoding,
+			NormalizeUnicode:      !*disableUnicodeNormalization,
+			SkipHiddenText:        !*includeHiddenText,
+			StructuredText:        !*flatText,
+			TextSkipTags:          append([]string{}, parseList(*textSkipTags)...), // non-nil, so empty skips none
+			MaxContentChars:       *maxContentChars,
+			ScoreContentChars:     *scoreContentChars,
+			DiscoverConcurrency:   *discoverConcurrency,
+			DiscoverTimeout:       *discoverTimeout,
+			MaxLinkAge:            *maxLinkAge,
+			AllowedImageTypes:     parseList(*allowedImageTypes),
+			MinImageWidth:         *minImageWidth,
+			MinImageHeight:        *minImageHeight,
+			RecommendedImagesOnly: *recommendedImagesOnly,
+			SiteRules:             siteRules,
+			SiteRulesFile:         *siteRulesFile,
+		},
+		CORSEnabled:           !*disableCORS,
+		EnabledEndpoints:      enabledEndpoints,
+		BatchIncludeImageData: *batchIncludeImageData,
+		MaxBatchImages:        *maxBatchImages,
+		MaxRequestBodyBytes:   *maxRequestBodyBytes,
+		BodyReadTimeout:       *bodyReadTimeout,
+		RateLimit: api.RateLimitConfig{
+			RequestsPerSecond: *rateLimit,
+			Burst:             *rateLimitBurst,
+			TrustForwardedFor: *trustForwardedFor,
 		},
-		CORSEnabled: !*disableCORS,
+		APIKeys:                   parseList(*apiKeys),
+		MetricsEnabled:            *enableMetrics,
+		RetentionPeriod:           *retention,
+		OptimizeInterval:          *optimizeInterval,
+		StoreFailures:             *storeFailures,
+		StoreRecommendedLinksOnly: *storeRecommendedLinksOnly,
+		DebugMode:                 *debugMode,
+		AllowedModels:             parseList(*allowedModels),
 	}
 
 	// Create server
@@ -81,6 +292,21 @@ func main() {
 		}
 	}()
 
+	// Reload site rules on SIGHUP, keeping the current rules if the file is invalid
+	if *siteRulesFile != "" {
+		reload := make(chan os.Signal, 1)
+		signal.Notify(reload, syscall.SIGHUP)
+		go func() {
+			for range reload {
+				if err := server.ReloadSiteRules(); err != nil {
+					log.Printf("Failed to reload site rules, keeping current rules: %v", err)
+					continue
+				}
+				log.Printf("Reloaded site rules from %s", *siteRulesFile)
+			}
+		}()
+	}
+
 	// Wait for interrupt signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
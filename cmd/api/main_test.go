@@ -150,3 +150,55 @@ func TestGetEnvPrecedence(t *testing.T) {
 		t.Errorf("Expected default value when env var not set. Got %q, want %q", result, defaultValue)
 	}
 }
+
+func TestParseDisabledEndpoints(t *testing.T) {
+	got, err := parseDisabledEndpoints(" scrape, batch,,delete ")
+	if err != nil {
+		t.Fatalf("parseDisabledEndpoints() error: %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("Expected 3 disabled endpoints, got %d: %v", len(got), got)
+	}
+	for _, name := range []string{"scrape", "batch", "delete"} {
+		enabled, ok := got[name]
+		if !ok || enabled {
+			t.Errorf("Expected %q to be disabled, got %v (present: %v)", name, enabled, ok)
+		}
+	}
+
+	if got, _ := parseDisabledEndpoints(""); len(got) != 0 {
+		t.Error("Expected empty value to disable nothing")
+	}
+	if _, err := parseDisabledEndpoints("scrape,delte"); err == nil {
+		t.Error("Expected an unknown endpoint name to be rejected")
+	}
+}
+
+func TestParseList(t *testing.T) {
+	got := parseList(" image/jpeg, image/png,, ")
+	if len(got) != 2 || got[0] != "image/jpeg" || got[1] != "image/png" {
+		t.Errorf("parseList() = %v, want [image/jpeg image/png]", got)
+	}
+	if parseList("") != nil {
+		t.Error("Expected nil for empty value")
+	}
+}
+
+func TestParseHostOverrides(t *testing.T) {
+	got, err := parseHostOverrides(" example.com=10.0.0.5, api.example.com = 127.0.0.1:8080 ")
+	if err != nil {
+		t.Fatalf("parseHostOverrides() error: %v", err)
+	}
+	if len(got) != 2 || got["example.com"] != "10.0.0.5" || got["api.example.com"] != "127.0.0.1:8080" {
+		t.Errorf("parseHostOverrides() = %v", got)
+	}
+
+	for _, value := range []string{"example.com", "=10.0.0.5", "example.com="} {
+		if _, err := parseHostOverrides(value); err == nil {
+			t.Errorf("parseHostOverrides(%q) succeeded, want error", value)
+		}
+	}
+	if got, _ := parseHostOverrides(""); got != nil {
+		t.Error("Expected nil for empty value")
+	}
+}
@@ -0,0 +1,70 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// scrapeClientKey is the context key for a scrape's own HTTP client
+type scrapeClientKey struct{}
+
+// scrapeJar is the cookie jar for a single scrape with Config.EnableCookies.
+// It applies standard cookie domain rules, so a session cookie set by
+// www.example.com for example.com is also sent to images on
+// img.example.com. Cookies are still passed to the scraper's long-lived jar
+// (login sessions and Config.EnableCookieJar), which continues to supply its
+// own cookies.
+type scrapeJar struct {
+	shared http.CookieJar // The scraper's jar, or nil without one
+	jar    *cookiejar.Jar
+}
+
+// SetCookies implements http.CookieJar
+func (j *scrapeJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	if j.shared != nil {
+		j.shared.SetCookies(u, cookies)
+	}
+	j.jar.SetCookies(u, cookies)
+}
+
+// Cookies implements http.CookieJar, preferring the shared jar's cookies
+// over same-named cookies from this scrape
+func (j *scrapeJar) Cookies(u *url.URL) []*http.Cookie {
+	var cookies []*http.Cookie
+	seen := make(map[string]bool)
+	if j.shared != nil {
+		for _, c := range j.shared.Cookies(u) {
+			seen[c.Name] = true
+			cookies = append(cookies, c)
+		}
+	}
+	for _, c := range j.jar.Cookies(u) {
+		if !seen[c.Name] {
+			cookies = append(cookies, c)
+		}
+	}
+	return cookies
+}
+
+// withScrapeCookies returns a context carrying an HTTP client whose cookie jar
+// lasts only as long as the scrape using the context
+func (s *Scraper) withScrapeCookies(ctx context.Context) context.Context {
+	// cookiejar.New only fails for invalid options
+	jar, _ := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	client := *s.httpClient
+	client.Jar = &scrapeJar{shared: s.httpClient.Jar, jar: jar}
+	return context.WithValue(ctx, scrapeClientKey{}, &client)
+}
+
+// client returns the HTTP client for requests made with ctx: the scrape's own
+// client when cookies are enabled, or the scraper's shared client
+func (s *Scraper) client(ctx context.Context) *http.Client {
+	if client, ok := ctx.Value(scrapeClientKey{}).(*http.Client); ok {
+		return client
+	}
+	return s.httpClient
+}
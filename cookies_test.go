@@ -0,0 +1,92 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+func TestScrapeCookies(t *testing.T) {
+	var mu sync.Mutex
+	var pageCookies, imageCookies []bool
+	webServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := r.Cookie("session")
+		hasCookie := err == nil
+
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path == "/pic.png" {
+			imageCookies = append(imageCookies, hasCookie)
+			if !hasCookie {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte("not really a png"))
+			return
+		}
+
+		pageCookies = append(pageCookies, hasCookie)
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc123", Path: "/"})
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Gallery</title></head><body><img src="/pic.png" alt="Pic"></body></html>`))
+	}))
+	defer webServer.Close()
+
+	config := DefaultConfig()
+	config.OllamaBaseURL = "http://127.0.0.1:1"
+	config.EnableCookies = true
+	s := New(config)
+
+	for i := 0; i < 2; i++ {
+		if _, err := s.Scrape(context.Background(), webServer.URL+"/gallery"); err != nil {
+			t.Fatalf("Scrape failed: %v", err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(imageCookies) != 2 || !imageCookies[0] || !imageCookies[1] {
+		t.Errorf("Image requests sent the session cookie: %v, want it on every download", imageCookies)
+	}
+	// Cookies are discarded when a scrape ends
+	if len(pageCookies) != 2 || pageCookies[0] || pageCookies[1] {
+		t.Errorf("Page requests sent a cookie: %v, want a fresh jar per scrape", pageCookies)
+	}
+
+	// Without EnableCookies the client stays stateless
+	imageCookies = nil
+	mu.Unlock()
+	config.EnableCookies = false
+	if _, err := New(config).Scrape(context.Background(), webServer.URL+"/gallery"); err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+	mu.Lock()
+	if len(imageCookies) != 1 || imageCookies[0] {
+		t.Errorf("Image requests sent the session cookie: %v, want none without EnableCookies", imageCookies)
+	}
+}
+
+func TestScrapeJarSharesParentDomainCookies(t *testing.T) {
+	s := New(DefaultConfig())
+	jar := s.client(s.withScrapeCookies(context.Background())).Jar
+
+	page, _ := url.Parse("https://www.example.com/article")
+	jar.SetCookies(page, []*http.Cookie{{Name: "session", Value: "abc", Domain: "example.com"}})
+
+	image, _ := url.Parse("https://img.example.com/photo.jpg")
+	if cookies := jar.Cookies(image); len(cookies) != 1 || cookies[0].Value != "abc" {
+		t.Errorf("Cookies for image host = %v, want the parent domain's session cookie", cookies)
+	}
+	other, _ := url.Parse("https://example.org/")
+	if cookies := jar.Cookies(other); len(cookies) != 0 {
+		t.Errorf("Cookies for other site = %v, want none", cookies)
+	}
+
+	if s.client(context.Background()) != s.httpClient {
+		t.Error("Expected the shared client outside a scrape")
+	}
+}
@@ -1,10 +1,14 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	_ "modernc.org/sqlite"
@@ -14,7 +18,9 @@ import (
 
 // DB wraps the database connection and provides data access methods
 type DB struct {
-	conn *sql.DB
+	conn       *sql.DB
+	driver     string
+	optimizing sync.Mutex // held while Optimize runs
 }
 
 // Config contains database configuration
@@ -33,7 +39,11 @@ func DefaultConfig() Config {
 
 // New creates a new database connection
 func New(config Config) (*DB, error) {
-	conn, err := sql.Open(config.Driver, config.DSN)
+	dsn := config.DSN
+	if config.Driver == "sqlite" {
+		dsn = withForeignKeys(dsn)
+	}
+	conn, err := sql.Open(config.Driver, dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -43,17 +53,12 @@ func New(config Config) (*DB, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	// Enable foreign key constraints (required for SQLite)
-	if _, err := conn.Exec("PRAGMA foreign_keys = ON"); err != nil {
-		return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
-	}
-
 	// Configure connection pool
 	conn.SetMaxOpenConns(25)
 	conn.SetMaxIdleConns(5)
 	conn.SetConnMaxLifetime(5 * time.Minute)
 
-	db := &DB{conn: conn}
+	db := &DB{conn: conn, driver: config.Driver}
 
 	// Run migrations
 	if err := Migrate(conn); err != nil {
@@ -63,12 +68,84 @@ func New(config Config) (*DB, error) {
 	return db, nil
 }
 
+// withForeignKeys adds the foreign_keys pragma to a SQLite DSN. SQLite
+// leaves foreign key constraints off and the pragma is per connection, so
+// it must be applied as each pooled connection opens for deletes to cascade
+// to images.
+func withForeignKeys(dsn string) string {
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	return dsn + sep + "_pragma=foreign_keys(1)"
+}
+
 // Close closes the database connection
 func (db *DB) Close() error {
 	return db.conn.Close()
 }
 
-// SaveScrapedData saves scraped data to the database
+// ErrOptimizeInProgress is returned by Optimize while another run is in progress
+var ErrOptimizeInProgress = errors.New("optimize already in progress")
+
+// OptimizeResult reports the database file size around an Optimize run
+type OptimizeResult struct {
+	SizeBefore int64 `json:"size_before"` // Bytes
+	SizeAfter  int64 `json:"size_after"`  // Bytes
+	Reclaimed  int64 `json:"reclaimed"`   // Bytes freed, SizeBefore - SizeAfter
+}
+
+// Optimize rebuilds the database file with VACUUM, returning the space left
+// by deleted records to the filesystem, then refreshes query planner
+// statistics with PRAGMA optimize. Only SQLite is supported.
+//
+// VACUUM holds an exclusive lock for as long as it runs, which grows with
+// the size of the database: writes made meanwhile fail with "database is
+// locked", and reads may too. Run it when the server is quiet.
+func (db *DB) Optimize(ctx context.Context) (*OptimizeResult, error) {
+	if db.driver != "sqlite" {
+		return nil, fmt.Errorf("optimize is not supported for the %s driver", db.driver)
+	}
+	if !db.optimizing.TryLock() {
+		return nil, ErrOptimizeInProgress
+	}
+	defer db.optimizing.Unlock()
+
+	before, err := db.size(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.conn.ExecContext(ctx, "VACUUM"); err != nil {
+		return nil, fmt.Errorf("failed to vacuum database: %w", err)
+	}
+	if _, err := db.conn.ExecContext(ctx, "PRAGMA optimize"); err != nil {
+		return nil, fmt.Errorf("failed to optimize database: %w", err)
+	}
+	after, err := db.size(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OptimizeResult{SizeBefore: before, SizeAfter: after, Reclaimed: before - after}, nil
+}
+
+// size returns the size of the SQLite database in bytes
+func (db *DB) size(ctx context.Context) (int64, error) {
+	var pages, pageSize int64
+	if err := db.conn.QueryRowContext(ctx, "PRAGMA page_count").Scan(&pages); err != nil {
+		return 0, fmt.Errorf("failed to get page count: %w", err)
+	}
+	if err := db.conn.QueryRowContext(ctx, "PRAGMA page_size").Scan(&pageSize); err != nil {
+		return 0, fmt.Errorf("failed to get page size: %w", err)
+	}
+	return pages * pageSize, nil
+}
+
+// SaveScrapedData saves scraped data to the database, replacing any record
+// for the same URL. A failed scrape (data.Failed) only replaces an earlier
+// failure, never successfully scraped content. When data.FilteredLinks is
+// set, only those links are stored, as the record's Links. Debug output
+// (data.Debug) and raw content (data.RawContent) are never stored.
 func (db *DB) SaveScrapedData(data *models.ScrapedData) error {
 	// Begin transaction to save both scraped data and images atomically
 	tx, err := db.conn.Begin()
@@ -77,20 +154,42 @@ func (db *DB) SaveScrapedData(data *models.ScrapedData) error {
 	}
 	defer tx.Rollback()
 
+	stored := data
+	if data.FilteredLinks != nil || data.Debug != nil || data.RawContent != "" {
+		copied := *data
+		if data.FilteredLinks != nil {
+			copied.Links, copied.FilteredLinks = data.FilteredLinks, nil
+		}
+		copied.Debug, copied.RawContent = nil, ""
+		stored = &copied
+	}
+
 	// Serialize the data to JSON
-	jsonData, err := json.Marshal(data)
+	jsonData, err := json.Marshal(stored)
 	if err != nil {
 		return fmt.Errorf("failed to marshal data: %w", err)
 	}
 
+	// Delete the images of a record this one replaces, which has another ID
+	// when the URL is re-scraped and would otherwise block the ID change
+	_, err = tx.Exec("DELETE FROM images WHERE scrape_id IN (SELECT id FROM scraped_data WHERE url = ? AND id != ? AND (? = 0 OR failed = 1))", data.URL, data.ID, data.Failed)
+	if err != nil {
+		return fmt.Errorf("failed to delete replaced images: %w", err)
+	}
+
 	// Insert or replace scraped data
 	query := `
-		INSERT INTO scraped_data (id, url, data, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?)
+		INSERT INTO scraped_data (id, url, data, failed, language, word_count, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(url) DO UPDATE SET
 			id = excluded.id,
 			data = excluded.data,
+			failed = excluded.failed,
+			language = excluded.language,
+			word_count = excluded.word_count,
+			created_at = excluded.created_at,
 			updated_at = excluded.updated_at
+		WHERE excluded.failed = 0 OR scraped_data.failed = 1
 	`
 
 	_, err = tx.Exec(
@@ -98,6 +197,9 @@ func (db *DB) SaveScrapedData(data *models.ScrapedData) error {
 		data.ID,
 		data.URL,
 		string(jsonData),
+		data.Failed,
+		data.Language,
+		data.WordCount,
 		data.FetchedAt,
 		time.Now(),
 	)
@@ -125,8 +227,8 @@ func (db *DB) SaveScrapedData(data *models.ScrapedData) error {
 		}
 
 		imageQuery := `
-			INSERT INTO images (id, scrape_id, url, alt_text, summary, tags, base64_data, created_at, updated_at)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			INSERT INTO images (id, scrape_id, url, alt_text, summary, tags, base64_data, hash, thumbnail_data, ocr_text, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		`
 
 		_, err = tx.Exec(
@@ -138,6 +240,9 @@ func (db *DB) SaveScrapedData(data *models.ScrapedData) error {
 			image.Summary,
 			string(tagsJSON),
 			image.Base64Data,
+			image.Hash,
+			image.ThumbnailData,
+			image.OCRText,
 			time.Now(),
 			time.Now(),
 		)
@@ -155,6 +260,32 @@ func (db *DB) SaveScrapedData(data *models.ScrapedData) error {
 	return nil
 }
 
+// UpdateData replaces the stored JSON of an existing record in place,
+// leaving its images untouched. It fails if no record has data.ID, such as
+// when the URL was re-scraped since data was read.
+func (db *DB) UpdateData(data *models.ScrapedData) error {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal data: %w", err)
+	}
+
+	result, err := db.conn.Exec("UPDATE scraped_data SET data = ?, language = ?, word_count = ?, updated_at = ? WHERE id = ?", string(jsonData), data.Language, data.WordCount, time.Now(), data.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update data: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("no data found with id: %s", data.ID)
+	}
+
+	return nil
+}
+
 // GetByID retrieves scraped data by ID
 func (db *DB) GetByID(id string) (*models.ScrapedData, error) {
 	var jsonData string
@@ -216,15 +347,89 @@ func (db *DB) DeleteByID(id string) error {
 	return nil
 }
 
-// List returns all scraped data with optional pagination
+// DeleteOlderThan deletes scraped data created before t and returns the
+// number of records deleted. A record's creation time is when its URL was
+// last fetched, since a re-scrape replaces it. Their images are deleted by
+// cascade.
+func (db *DB) DeleteOlderThan(t time.Time) (int64, error) {
+	return db.deleteWhere("created_at < ?", t)
+}
+
+// DeleteByURLPattern deletes scraped data whose URL matches a SQL LIKE
+// pattern ('%' matches any run of characters, '_' any single character,
+// ASCII letters match case-insensitively) and returns the number of records
+// deleted. Their images are deleted by cascade.
+func (db *DB) DeleteByURLPattern(pattern string) (int64, error) {
+	if pattern == "" {
+		return 0, errors.New("empty URL pattern")
+	}
+	return db.deleteWhere("url LIKE ?", pattern)
+}
+
+// deleteWhere deletes the scraped data matching a condition
+func (db *DB) deleteWhere(condition string, args ...interface{}) (int64, error) {
+	result, err := db.conn.Exec("DELETE FROM scraped_data WHERE "+condition, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete data: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return rows, nil
+}
+
+// List returns all scraped data with optional pagination. Failed scrapes
+// are excluded; see ListFailures.
 func (db *DB) List(limit, offset int) ([]*models.ScrapedData, error) {
+	return db.ListFiltered(limit, offset, ListFilter{})
+}
+
+// ListFilter narrows the records returned by ListFiltered, ListPageFiltered,
+// and CountFiltered; the zero value matches every record
+type ListFilter struct {
+	Language string // Only records whose Language is this code (empty for any)
+	MinWords int    // Only records with at least this WordCount (0 for any)
+}
+
+// where returns the SQL conditions for the filter, joined to the failed = 0
+// condition every listing starts with, and their arguments
+func (f ListFilter) where() (string, []interface{}) {
+	where := "failed = 0"
+	var args []interface{}
+	if f.Language != "" {
+		where += " AND language = ?"
+		args = append(args, f.Language)
+	}
+	if f.MinWords > 0 {
+		where += " AND word_count >= ?"
+		args = append(args, f.MinWords)
+	}
+	return where, args
+}
+
+// ListFiltered is List limited to the records matching filter
+func (db *DB) ListFiltered(limit, offset int, filter ListFilter) ([]*models.ScrapedData, error) {
+	where, args := filter.where()
+	query := "SELECT data FROM scraped_data WHERE " + where + " ORDER BY created_at DESC LIMIT ? OFFSET ?"
+	return db.queryData(query, append(args, limit, offset)...)
+}
+
+// ListFailures returns the records of failed scrapes, newest first
+func (db *DB) ListFailures(limit, offset int) ([]*models.ScrapedData, error) {
 	query := `
 		SELECT data FROM scraped_data
+		WHERE failed = 1
 		ORDER BY created_at DESC
 		LIMIT ? OFFSET ?
 	`
+	return db.queryData(query, limit, offset)
+}
 
-	rows, err := db.conn.Query(query, limit, offset)
+// queryData runs a query selecting the data column and decodes the rows
+func (db *DB) queryData(query string, args ...interface{}) ([]*models.ScrapedData, error) {
+	rows, err := db.conn.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query data: %w", err)
 	}
@@ -252,10 +457,211 @@ func (db *DB) List(limit, offset int) ([]*models.ScrapedData, error) {
 	return results, nil
 }
 
-// Count returns the total count of scraped data entries
+// ErrInvalidCursor is returned by ListPage for a malformed cursor
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ListPage retrieves scraped data newest first using keyset pagination. Pass
+// an empty cursor for the first page and the returned nextCursor for later
+// pages; nextCursor is empty on the last page. Unlike List with a large offset,
+// each page costs the same regardless of depth. Failed scrapes are excluded.
+func (db *DB) ListPage(limit int, cursor string) (results []*models.ScrapedData, nextCursor string, err error) {
+	return db.ListPageFiltered(limit, cursor, ListFilter{})
+}
+
+// ListPageFiltered is ListPage limited to the records matching filter.
+// Cursors are only valid with the filter they were returned for.
+func (db *DB) ListPageFiltered(limit int, cursor string, filter ListFilter) (results []*models.ScrapedData, nextCursor string, err error) {
+	where, args := filter.where()
+	query := "SELECT id, CAST(created_at AS TEXT), data FROM scraped_data WHERE " + where
+	if cursor != "" {
+		createdAt, id, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		query += " AND (created_at, id) < (?, ?)"
+		args = append(args, createdAt, id)
+	}
+	// Fetch one extra row to know whether another page follows
+	query += " ORDER BY created_at DESC, id DESC LIMIT ?"
+	args = append(args, limit+1)
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to query data: %w", err)
+	}
+	defer rows.Close()
+
+	var lastCreated, lastID string
+	for rows.Next() {
+		var id, createdAt, jsonData string
+		if err := rows.Scan(&id, &createdAt, &jsonData); err != nil {
+			return nil, "", fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		if len(results) == limit {
+			nextCursor = encodeCursor(lastCreated, lastID)
+			break
+		}
+
+		var data models.ScrapedData
+		if err := json.Unmarshal([]byte(jsonData), &data); err != nil {
+			return nil, "", fmt.Errorf("failed to unmarshal data: %w", err)
+		}
+		results = append(results, &data)
+		lastCreated, lastID = createdAt, id
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return results, nextCursor, nil
+}
+
+// encodeCursor builds an opaque ListPage cursor from the last row's stored
+// created_at text and id
+func encodeCursor(createdAt, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(createdAt + "\x00" + id))
+}
+
+// decodeCursor parses a cursor built by encodeCursor
+func decodeCursor(cursor string) (createdAt, id string, err error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", "", ErrInvalidCursor
+	}
+	createdAt, id, ok := strings.Cut(string(raw), "\x00")
+	if !ok || createdAt == "" || id == "" {
+		return "", "", ErrInvalidCursor
+	}
+	return createdAt, id, nil
+}
+
+// Filter selects the scraped data visited by Each
+type Filter struct {
+	CreatedAfter  time.Time // Only records created after this time (zero for no bound)
+	CreatedBefore time.Time // Only records created before this time (zero for no bound)
+	BatchSize     int       // Records fetched per query (0 uses the default of 100)
+}
+
+// defaultEachBatchSize is the page size used by Each when Filter.BatchSize is unset
+const defaultEachBatchSize = 100
+
+// Each calls fn for every scraped data record matching the filter, oldest first,
+// without loading the whole corpus into memory. Records are fetched in batches
+// using keyset pagination on (created_at, id), so large corpora avoid OFFSET
+// scans and records saved during iteration do not shift pages. Iteration stops
+// at the first error from fn or when ctx is cancelled, returning that error.
+// Failed scrapes are excluded.
+func (db *DB) Each(ctx context.Context, filter Filter, fn func(*models.ScrapedData) error) error {
+	batchSize := filter.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultEachBatchSize
+	}
+
+	conditions := []string{"failed = 0"}
+	var baseArgs []interface{}
+	if !filter.CreatedAfter.IsZero() {
+		conditions = append(conditions, "created_at > ?")
+		baseArgs = append(baseArgs, filter.CreatedAfter)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		baseArgs = append(baseArgs, filter.CreatedBefore)
+	}
+
+	// The cursor is read back as the stored text (not a parsed time) so it
+	// compares exactly against the column
+	var lastCreated, lastID string
+	for first := true; ; first = false {
+		where := conditions
+		args := append([]interface{}(nil), baseArgs...)
+		if !first {
+			where = append(where, "(created_at > ? OR (created_at = ? AND id > ?))")
+			args = append(args, lastCreated, lastCreated, lastID)
+		}
+
+		query := "SELECT id, CAST(created_at AS TEXT), data FROM scraped_data WHERE " + strings.Join(where, " AND ")
+		query += " ORDER BY created_at, id LIMIT ?"
+		args = append(args, batchSize)
+
+		// Read the whole batch before calling fn so the connection is released
+		// and fn is free to write to the database
+		batch, err := db.eachBatch(ctx, query, args, &lastCreated, &lastID)
+		if err != nil {
+			return err
+		}
+
+		for _, data := range batch {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := fn(data); err != nil {
+				return err
+			}
+		}
+
+		if len(batch) < batchSize {
+			return nil
+		}
+	}
+}
+
+// eachBatch runs one page of an Each query, updating the cursor to its last row
+func (db *DB) eachBatch(ctx context.Context, query string, args []interface{}, lastCreated, lastID *string) ([]*models.ScrapedData, error) {
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query data: %w", err)
+	}
+	defer rows.Close()
+
+	var batch []*models.ScrapedData
+	for rows.Next() {
+		var jsonData string
+		if err := rows.Scan(lastID, lastCreated, &jsonData); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		var data models.ScrapedData
+		if err := json.Unmarshal([]byte(jsonData), &data); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal data: %w", err)
+		}
+		batch = append(batch, &data)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return batch, nil
+}
+
+// Count returns the total count of scraped data entries, excluding failed scrapes
 func (db *DB) Count() (int, error) {
+	return db.count(false)
+}
+
+// CountFiltered returns the number of scraped data entries matching filter,
+// excluding failed scrapes
+func (db *DB) CountFiltered(filter ListFilter) (int, error) {
+	where, args := filter.where()
 	var count int
-	err := db.conn.QueryRow("SELECT COUNT(*) FROM scraped_data").Scan(&count)
+	err := db.conn.QueryRow("SELECT COUNT(*) FROM scraped_data WHERE "+where, args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count data: %w", err)
+	}
+	return count, nil
+}
+
+// CountFailures returns the number of failed scrape records
+func (db *DB) CountFailures() (int, error) {
+	return db.count(true)
+}
+
+// count returns the number of records with the given failed state
+func (db *DB) count(failed bool) (int, error) {
+	var count int
+	err := db.conn.QueryRow("SELECT COUNT(*) FROM scraped_data WHERE failed = ?", failed).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count data: %w", err)
 	}
@@ -281,8 +687,8 @@ func (db *DB) SaveImage(image *models.ImageInfo, scrapeID string) error {
 	}
 
 	query := `
-		INSERT INTO images (id, scrape_id, url, alt_text, summary, tags, base64_data, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO images (id, scrape_id, url, alt_text, summary, tags, base64_data, hash, thumbnail_data, ocr_text, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	_, err = db.conn.Exec(
@@ -294,6 +700,9 @@ func (db *DB) SaveImage(image *models.ImageInfo, scrapeID string) error {
 		image.Summary,
 		string(tagsJSON),
 		image.Base64Data,
+		image.Hash,
+		image.ThumbnailData,
+		image.OCRText,
 		time.Now(),
 		time.Now(),
 	)
@@ -307,17 +716,50 @@ func (db *DB) SaveImage(image *models.ImageInfo, scrapeID string) error {
 
 // GetImageByID retrieves an image by its ID
 func (db *DB) GetImageByID(id string) (*models.ImageInfo, error) {
+	query := "SELECT id, url, alt_text, summary, tags, base64_data, COALESCE(hash, ''), COALESCE(ocr_text, '') FROM images WHERE id = ?"
+	return db.getImage(query, id)
+}
+
+// GetImageThumbnail retrieves the JPEG thumbnail for an image.
+// Returns nil if the image does not exist or has no thumbnail.
+func (db *DB) GetImageThumbnail(id string) ([]byte, error) {
+	var thumbnail []byte
+	err := db.conn.QueryRow("SELECT thumbnail_data FROM images WHERE id = ?", id).Scan(&thumbnail)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query thumbnail: %w", err)
+	}
+	if len(thumbnail) == 0 {
+		return nil, nil
+	}
+	return thumbnail, nil
+}
+
+// GetImageByHash retrieves the most recently stored image with the given content hash
+func (db *DB) GetImageByHash(hash string) (*models.ImageInfo, error) {
+	if hash == "" {
+		return nil, nil
+	}
+	query := "SELECT id, url, alt_text, summary, tags, base64_data, COALESCE(hash, ''), COALESCE(ocr_text, '') FROM images WHERE hash = ? ORDER BY created_at DESC LIMIT 1"
+	return db.getImage(query, hash)
+}
+
+// getImage runs a single-image query and scans the result
+func (db *DB) getImage(query string, args ...interface{}) (*models.ImageInfo, error) {
 	var (
-		imageID     string
-		url         string
-		altText     string
-		summary     string
-		tagsJSON    string
-		base64Data  string
+		imageID    string
+		url        string
+		altText    string
+		summary    string
+		tagsJSON   string
+		base64Data string
+		hash       string
+		ocrText    string
 	)
 
-	query := "SELECT id, url, alt_text, summary, tags, base64_data FROM images WHERE id = ?"
-	err := db.conn.QueryRow(query, id).Scan(&imageID, &url, &altText, &summary, &tagsJSON, &base64Data)
+	err := db.conn.QueryRow(query, args...).Scan(&imageID, &url, &altText, &summary, &tagsJSON, &base64Data, &hash, &ocrText)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -340,6 +782,8 @@ func (db *DB) GetImageByID(id string) (*models.ImageInfo, error) {
 		Summary:    summary,
 		Tags:       tags,
 		Base64Data: base64Data,
+		Hash:       hash,
+		OCRText:    ocrText,
 	}
 
 	return image, nil
@@ -353,7 +797,7 @@ func (db *DB) SearchImagesByTags(searchTags []string) ([]*models.ImageInfo, erro
 	}
 
 	// Query all images
-	query := "SELECT id, url, alt_text, summary, tags, base64_data FROM images ORDER BY created_at DESC"
+	query := "SELECT id, url, alt_text, summary, tags, base64_data, COALESCE(hash, ''), COALESCE(ocr_text, '') FROM images ORDER BY created_at DESC"
 	rows, err := db.conn.Query(query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query images: %w", err)
@@ -369,9 +813,11 @@ func (db *DB) SearchImagesByTags(searchTags []string) ([]*models.ImageInfo, erro
 			summary    string
 			tagsJSON   string
 			base64Data string
+			hash       string
+			ocrText    string
 		)
 
-		if err := rows.Scan(&imageID, &url, &altText, &summary, &tagsJSON, &base64Data); err != nil {
+		if err := rows.Scan(&imageID, &url, &altText, &summary, &tagsJSON, &base64Data, &hash, &ocrText); err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
 
@@ -406,6 +852,8 @@ func (db *DB) SearchImagesByTags(searchTags []string) ([]*models.ImageInfo, erro
 				Summary:    summary,
 				Tags:       tags,
 				Base64Data: base64Data,
+				Hash:       hash,
+				OCRText:    ocrText,
 			}
 			results = append(results, image)
 		}
@@ -420,8 +868,53 @@ func (db *DB) SearchImagesByTags(searchTags []string) ([]*models.ImageInfo, erro
 
 // GetImagesByScrapeID retrieves all images associated with a scrape ID
 func (db *DB) GetImagesByScrapeID(scrapeID string) ([]*models.ImageInfo, error) {
-	query := "SELECT id, url, alt_text, summary, tags, base64_data FROM images WHERE scrape_id = ? ORDER BY created_at"
-	rows, err := db.conn.Query(query, scrapeID)
+	query := "SELECT id, url, alt_text, summary, tags, base64_data, COALESCE(hash, ''), COALESCE(ocr_text, '') FROM images WHERE scrape_id = ? ORDER BY created_at"
+	return db.queryImages(query, scrapeID)
+}
+
+// GetImagesPage retrieves up to limit images of a scrape in the order they
+// were saved, skipping the first offset, along with the scrape's total
+// image count
+func (db *DB) GetImagesPage(scrapeID string, limit, offset int) ([]*models.ImageInfo, int, error) {
+	var total int
+	if err := db.conn.QueryRow("SELECT COUNT(*) FROM images WHERE scrape_id = ?", scrapeID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count images: %w", err)
+	}
+
+	query := `SELECT id, url, alt_text, summary, tags, base64_data, COALESCE(hash, ''), COALESCE(ocr_text, '') FROM images
+		WHERE scrape_id = ? ORDER BY created_at, rowid LIMIT ? OFFSET ?`
+	images, err := db.queryImages(query, scrapeID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	return images, total, nil
+}
+
+// SearchImagesByText searches for images whose transcribed text contains the
+// query (case-insensitive), most recent first
+func (db *DB) SearchImagesByText(text string) ([]*models.ImageInfo, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return []*models.ImageInfo{}, nil
+	}
+
+	// Escape LIKE wildcards so the query matches literally
+	escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(text)
+	query := `SELECT id, url, alt_text, summary, tags, base64_data, COALESCE(hash, ''), COALESCE(ocr_text, '') FROM images
+		WHERE ocr_text LIKE ? ESCAPE '\' ORDER BY created_at DESC`
+	results, err := db.queryImages(query, "%"+escaped+"%")
+	if err != nil {
+		return nil, err
+	}
+	if results == nil {
+		results = []*models.ImageInfo{}
+	}
+	return results, nil
+}
+
+// queryImages runs an image query and scans all resulting rows
+func (db *DB) queryImages(query string, args ...interface{}) ([]*models.ImageInfo, error) {
+	rows, err := db.conn.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query images: %w", err)
 	}
@@ -436,9 +929,11 @@ func (db *DB) GetImagesByScrapeID(scrapeID string) ([]*models.ImageInfo, error)
 			summary    string
 			tagsJSON   string
 			base64Data string
+			hash       string
+			ocrText    string
 		)
 
-		if err := rows.Scan(&imageID, &url, &altText, &summary, &tagsJSON, &base64Data); err != nil {
+		if err := rows.Scan(&imageID, &url, &altText, &summary, &tagsJSON, &base64Data, &hash, &ocrText); err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
 
@@ -456,6 +951,8 @@ func (db *DB) GetImagesByScrapeID(scrapeID string) ([]*models.ImageInfo, error)
 			Summary:    summary,
 			Tags:       tags,
 			Base64Data: base64Data,
+			Hash:       hash,
+			OCRText:    ocrText,
 		}
 		results = append(results, image)
 	}
@@ -466,3 +963,91 @@ func (db *DB) GetImagesByScrapeID(scrapeID string) ([]*models.ImageInfo, error)
 
 	return results, nil
 }
+
+// SaveLinkScore saves the score for a URL, replacing any earlier score for it.
+// A zero ScoredAt is saved as the current time.
+func (db *DB) SaveLinkScore(score *models.LinkScore) error {
+	categoriesJSON, err := json.Marshal(score.Categories)
+	if err != nil {
+		return fmt.Errorf("failed to marshal categories: %w", err)
+	}
+	indicatorsJSON, err := json.Marshal(score.MaliciousIndicators)
+	if err != nil {
+		return fmt.Errorf("failed to marshal malicious indicators: %w", err)
+	}
+	scoredAt := score.ScoredAt
+	if scoredAt.IsZero() {
+		scoredAt = time.Now()
+	}
+
+	query := `
+		INSERT INTO link_scores (url, score, reason, categories, is_recommended, malicious_indicators, ai_used, scoring_path, scored_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(url) DO UPDATE SET
+			score = excluded.score,
+			reason = excluded.reason,
+			categories = excluded.categories,
+			is_recommended = excluded.is_recommended,
+			malicious_indicators = excluded.malicious_indicators,
+			ai_used = excluded.ai_used,
+			scoring_path = excluded.scoring_path,
+			scored_at = excluded.scored_at
+	`
+
+	_, err = db.conn.Exec(
+		query,
+		score.URL,
+		score.Score,
+		score.Reason,
+		string(categoriesJSON),
+		score.IsRecommended,
+		string(indicatorsJSON),
+		score.AIUsed,
+		score.ScoringPath,
+		scoredAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save link score: %w", err)
+	}
+	return nil
+}
+
+// GetLinkScoreByURL retrieves the saved score for a URL, or nil if it has not
+// been scored
+func (db *DB) GetLinkScoreByURL(url string) (*models.LinkScore, error) {
+	var (
+		score          models.LinkScore
+		categoriesJSON string
+		indicatorsJSON string
+	)
+	query := `
+		SELECT url, score, reason, categories, is_recommended, malicious_indicators, ai_used, scoring_path, scored_at
+		FROM link_scores WHERE url = ?
+	`
+
+	err := db.conn.QueryRow(query, url).Scan(
+		&score.URL,
+		&score.Score,
+		&score.Reason,
+		&categoriesJSON,
+		&score.IsRecommended,
+		&indicatorsJSON,
+		&score.AIUsed,
+		&score.ScoringPath,
+		&score.ScoredAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query link score: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(categoriesJSON), &score.Categories); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal categories: %w", err)
+	}
+	if err := json.Unmarshal([]byte(indicatorsJSON), &score.MaliciousIndicators); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal malicious indicators: %w", err)
+	}
+	return &score, nil
+}
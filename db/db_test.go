@@ -1,7 +1,13 @@
 package db
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"os"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -185,6 +191,155 @@ func TestDeleteByID(t *testing.T) {
 	}
 }
 
+func TestDeleteOlderThan(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		data := &models.ScrapedData{
+			ID:        fmt.Sprintf("age-%d", i),
+			URL:       fmt.Sprintf("https://example.com/%d", i),
+			FetchedAt: base.Add(time.Duration(i) * 24 * time.Hour),
+		}
+		if i == 0 {
+			data.Images = []models.ImageInfo{{ID: "age-img", URL: "https://example.com/a.jpg"}}
+		}
+		if err := db.SaveScrapedData(data); err != nil {
+			t.Fatalf("Failed to save data: %v", err)
+		}
+	}
+
+	deleted, err := db.DeleteOlderThan(base.Add(36 * time.Hour))
+	if err != nil {
+		t.Fatalf("DeleteOlderThan failed: %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("Deleted %d records, want 2", deleted)
+	}
+
+	count, _ := db.Count()
+	if count != 2 {
+		t.Errorf("Count = %d, want 2 remaining", count)
+	}
+	if data, _ := db.GetByID("age-2"); data == nil {
+		t.Error("Expected newer record to remain")
+	}
+	if img, _ := db.GetImageByID("age-img"); img != nil {
+		t.Error("Expected image of deleted record to be removed via cascade")
+	}
+
+	// A record re-scraped since is as old as the re-scrape
+	rescraped := &models.ScrapedData{ID: "age-2-again", URL: "https://example.com/2", FetchedAt: base.Add(96 * time.Hour)}
+	if err := db.SaveScrapedData(rescraped); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
+	}
+	deleted, err = db.DeleteOlderThan(base.Add(84 * time.Hour))
+	if err != nil {
+		t.Fatalf("DeleteOlderThan failed: %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("Deleted %d records, want only the one not re-scraped", deleted)
+	}
+	if data, _ := db.GetByID("age-2-again"); data == nil {
+		t.Error("Expected re-scraped record to remain")
+	}
+}
+
+func TestDeleteByURLPattern(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	for _, u := range []string{"https://example.com/a", "https://example.com/b", "https://EXAMPLE.com/c", "https://other.com/a"} {
+		data := &models.ScrapedData{ID: u, URL: u, FetchedAt: time.Now()}
+		if err := db.SaveScrapedData(data); err != nil {
+			t.Fatalf("Failed to save data: %v", err)
+		}
+	}
+
+	deleted, err := db.DeleteByURLPattern("https://example.com/%")
+	if err != nil {
+		t.Fatalf("DeleteByURLPattern failed: %v", err)
+	}
+	if deleted != 3 {
+		t.Errorf("Deleted %d records, want 3 (LIKE is case-insensitive)", deleted)
+	}
+	if exists, _ := db.URLExists("https://other.com/a"); !exists {
+		t.Error("Expected non-matching record to remain")
+	}
+
+	deleted, err = db.DeleteByURLPattern("https://nothing.example/%")
+	if err != nil || deleted != 0 {
+		t.Errorf("DeleteByURLPattern() = %d, %v; want 0, nil", deleted, err)
+	}
+	if _, err := db.DeleteByURLPattern(""); err == nil {
+		t.Error("Expected error for empty pattern")
+	}
+}
+
+func TestConcurrentDeletesCascade(t *testing.T) {
+	// A file database, so that the deletes run on separate pooled connections
+	db, err := New(Config{Driver: "sqlite", DSN: t.TempDir() + "/test.db"})
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer db.Close()
+
+	const n = 40
+	for i := 0; i < n; i++ {
+		data := &models.ScrapedData{
+			ID:        fmt.Sprintf("page-%d", i),
+			URL:       fmt.Sprintf("https://example.com/%d", i),
+			FetchedAt: time.Now(),
+			Images:    []models.ImageInfo{{ID: fmt.Sprintf("img-%d", i), URL: fmt.Sprintf("https://example.com/%d.jpg", i)}},
+		}
+		if err := db.SaveScrapedData(data); err != nil {
+			t.Fatalf("Failed to save data: %v", err)
+		}
+	}
+
+	// Some deletes may fail with "database is locked"; those that succeed
+	// must take their images with them
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			db.DeleteByURLPattern(fmt.Sprintf("https://example.com/%d", i))
+		}(i)
+	}
+	wg.Wait()
+
+	var pages, orphans int
+	if err := db.conn.QueryRow("SELECT COUNT(*) FROM scraped_data").Scan(&pages); err != nil {
+		t.Fatalf("Failed to count pages: %v", err)
+	}
+	if pages == n {
+		t.Fatal("Expected some deletes to succeed")
+	}
+	err = db.conn.QueryRow("SELECT COUNT(*) FROM images WHERE scrape_id NOT IN (SELECT id FROM scraped_data)").Scan(&orphans)
+	if err != nil {
+		t.Fatalf("Failed to count images: %v", err)
+	}
+	if orphans != 0 {
+		t.Errorf("%d images left behind by %d deleted pages, want none", orphans, n-pages)
+	}
+
+	// Every pooled connection enforces foreign keys, not just the first
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		c, err := db.conn.Conn(ctx)
+		if err != nil {
+			t.Fatalf("Failed to get connection: %v", err)
+		}
+		defer c.Close()
+		var enabled int
+		if err := c.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&enabled); err != nil || enabled != 1 {
+			t.Errorf("Connection %d: foreign_keys = %d, %v; want 1", i, enabled, err)
+		}
+	}
+}
+
 func TestDeleteByIDNotFound(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
@@ -195,6 +350,44 @@ func TestDeleteByIDNotFound(t *testing.T) {
 	}
 }
 
+func TestUpdateData(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	data := &models.ScrapedData{
+		ID:        "update-test",
+		URL:       "https://example.com/update",
+		Title:     "Update Test",
+		Images:    []models.ImageInfo{{ID: "update-img", URL: "https://example.com/a.jpg"}},
+		FetchedAt: time.Now(),
+		Score:     &models.LinkScore{Score: 0.5},
+	}
+	if err := db.SaveScrapedData(data); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
+	}
+
+	data.Score = &models.LinkScore{Score: 0.9, AIUsed: true}
+	if err := db.UpdateData(data); err != nil {
+		t.Fatalf("UpdateData() error = %v", err)
+	}
+
+	retrieved, err := db.GetByID("update-test")
+	if err != nil {
+		t.Fatalf("GetByID returned error: %v", err)
+	}
+	if retrieved.Score == nil || retrieved.Score.Score != 0.9 || !retrieved.Score.AIUsed {
+		t.Errorf("Score = %+v, want the updated score", retrieved.Score)
+	}
+	if image, _ := db.GetImageByID("update-img"); image == nil {
+		t.Error("Expected the record's images to be kept")
+	}
+
+	data.ID = "nonexistent-id"
+	if err := db.UpdateData(data); err == nil {
+		t.Error("Expected error when updating nonexistent ID")
+	}
+}
+
 func TestList(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
@@ -330,6 +523,7 @@ func TestUpsert(t *testing.T) {
 		Content:        "Original content",
 		FetchedAt:      time.Now(),
 		ProcessingTime: 1.0,
+		Images:         []models.ImageInfo{{ID: "upsert-img-1", URL: "https://example.com/1.jpg"}},
 	}
 
 	if err := db.SaveScrapedData(data1); err != nil {
@@ -344,6 +538,7 @@ func TestUpsert(t *testing.T) {
 		Content:        "Updated content",
 		FetchedAt:      time.Now(),
 		ProcessingTime: 2.0,
+		Images:         []models.ImageInfo{{ID: "upsert-img-2", URL: "https://example.com/2.jpg"}},
 	}
 
 	if err := db.SaveScrapedData(data2); err != nil {
@@ -373,6 +568,23 @@ func TestUpsert(t *testing.T) {
 	if old != nil {
 		t.Error("Old ID should not exist after upsert")
 	}
+
+	// The replaced record's images go with it
+	if img, _ := db.GetImageByID("upsert-img-1"); img != nil {
+		t.Error("Old image should not exist after upsert")
+	}
+	if img, _ := db.GetImageByID("upsert-img-2"); img == nil {
+		t.Error("New image should exist after upsert")
+	}
+
+	// A failure does not replace the record, so its images stay
+	failed := &models.ScrapedData{ID: "upsert-3", URL: url, FetchedAt: time.Now(), Failed: true}
+	if err := db.SaveScrapedData(failed); err != nil {
+		t.Fatalf("Failed to save failure: %v", err)
+	}
+	if img, _ := db.GetImageByID("upsert-img-2"); img == nil {
+		t.Error("Image should survive a failed re-scrape")
+	}
 }
 
 func TestFileDatabase(t *testing.T) {
@@ -635,3 +847,575 @@ func TestImageCascadeDelete(t *testing.T) {
 		t.Error("Image should have been deleted via cascade")
 	}
 }
+
+func TestGetImageByHash(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	data := &models.ScrapedData{
+		ID:    "scrape-hash",
+		URL:   "https://example.com/hash",
+		Title: "Hashed images",
+		Images: []models.ImageInfo{
+			{
+				ID:      "img-hash-1",
+				URL:     "https://example.com/logo.png",
+				Summary: "A logo",
+				Tags:    []string{"logo"},
+				Hash:    "abc123",
+			},
+			{
+				ID:  "img-no-hash",
+				URL: "https://example.com/other.png",
+			},
+		},
+		FetchedAt: time.Now(),
+	}
+
+	if err := db.SaveScrapedData(data); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
+	}
+
+	img, err := db.GetImageByHash("abc123")
+	if err != nil {
+		t.Fatalf("Failed to get image by hash: %v", err)
+	}
+	if img == nil {
+		t.Fatal("Expected image to be found by hash")
+	}
+	if img.ID != "img-hash-1" || img.Summary != "A logo" || img.Hash != "abc123" {
+		t.Errorf("Unexpected image: %+v", img)
+	}
+
+	missing, err := db.GetImageByHash("does-not-exist")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if missing != nil {
+		t.Error("Expected nil for unknown hash")
+	}
+
+	// Images without a hash are never matched by an empty hash
+	empty, err := db.GetImageByHash("")
+	if err != nil || empty != nil {
+		t.Errorf("Expected nil for empty hash, got %v (err: %v)", empty, err)
+	}
+}
+
+func TestGetImageThumbnail(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	data := &models.ScrapedData{
+		ID:    "scrape-thumb",
+		URL:   "https://example.com/thumb",
+		Title: "Thumbnails",
+		Images: []models.ImageInfo{
+			{
+				ID:            "img-thumb",
+				URL:           "https://example.com/photo.png",
+				ThumbnailData: []byte{0xff, 0xd8, 0xff},
+			},
+			{
+				ID:  "img-no-thumb",
+				URL: "https://example.com/icon.svg",
+			},
+		},
+		FetchedAt: time.Now(),
+	}
+
+	if err := db.SaveScrapedData(data); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
+	}
+
+	thumbnail, err := db.GetImageThumbnail("img-thumb")
+	if err != nil {
+		t.Fatalf("Failed to get thumbnail: %v", err)
+	}
+	if !bytes.Equal(thumbnail, []byte{0xff, 0xd8, 0xff}) {
+		t.Errorf("Thumbnail = %v, want stored bytes", thumbnail)
+	}
+
+	for _, id := range []string{"img-no-thumb", "does-not-exist"} {
+		thumbnail, err := db.GetImageThumbnail(id)
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %v", id, err)
+		}
+		if thumbnail != nil {
+			t.Errorf("Expected nil thumbnail for %s", id)
+		}
+	}
+}
+
+func TestGetImagesPage(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	data := &models.ScrapedData{ID: "paged", URL: "https://example.com/paged", FetchedAt: time.Now()}
+	for i := 0; i < 3; i++ {
+		data.Images = append(data.Images, models.ImageInfo{ID: fmt.Sprintf("paged-%d", i), URL: fmt.Sprintf("https://example.com/%d.jpg", i)})
+	}
+	if err := db.SaveScrapedData(data); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
+	}
+
+	images, total, err := db.GetImagesPage("paged", 2, 1)
+	if err != nil {
+		t.Fatalf("GetImagesPage failed: %v", err)
+	}
+	if total != 3 || len(images) != 2 || images[0].ID != "paged-1" || images[1].ID != "paged-2" {
+		t.Errorf("GetImagesPage() = %d images (total %d), want paged-1 and paged-2 of 3", len(images), total)
+	}
+
+	images, total, err = db.GetImagesPage("missing", 2, 0)
+	if err != nil || total != 0 || len(images) != 0 {
+		t.Errorf("GetImagesPage(missing) = %v, %d, %v; want no images", images, total, err)
+	}
+}
+
+func TestSearchImagesByText(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	data := &models.ScrapedData{
+		ID:  "scrape-ocr",
+		URL: "https://example.com/ocr",
+		Images: []models.ImageInfo{
+			{ID: "img-chart", URL: "https://example.com/chart.png", OCRText: "Q3 Revenue grew 50% year over year"},
+			{ID: "img-sign", URL: "https://example.com/sign.png", OCRText: "No parking"},
+			{ID: "img-photo", URL: "https://example.com/photo.png"},
+		},
+		FetchedAt: time.Now(),
+	}
+	if err := db.SaveScrapedData(data); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
+	}
+
+	results, err := db.SearchImagesByText("revenue")
+	if err != nil {
+		t.Fatalf("Failed to search images: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != "img-chart" {
+		t.Fatalf("Expected chart image, got %+v", results)
+	}
+	if results[0].OCRText != "Q3 Revenue grew 50% year over year" {
+		t.Errorf("OCRText = %q", results[0].OCRText)
+	}
+
+	// LIKE wildcards match literally
+	results, err = db.SearchImagesByText("5_%")
+	if err != nil {
+		t.Fatalf("Failed to search images: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("Expected wildcards to be escaped, got %+v", results)
+	}
+
+	results, err = db.SearchImagesByText("  ")
+	if err != nil || len(results) != 0 {
+		t.Errorf("Expected no results for empty query, got %v (err: %v)", results, err)
+	}
+}
+
+func TestEach(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 7; i++ {
+		data := &models.ScrapedData{
+			ID:        fmt.Sprintf("each-%d", i),
+			URL:       fmt.Sprintf("https://example.com/%d", i),
+			Title:     fmt.Sprintf("Page %d", i),
+			FetchedAt: base.Add(time.Duration(i/2) * time.Hour), // pairs share a timestamp
+		}
+		if err := db.SaveScrapedData(data); err != nil {
+			t.Fatalf("Failed to save data: %v", err)
+		}
+	}
+
+	var ids []string
+	err := db.Each(context.Background(), Filter{BatchSize: 2}, func(data *models.ScrapedData) error {
+		ids = append(ids, data.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Each failed: %v", err)
+	}
+	want := []string{"each-0", "each-1", "each-2", "each-3", "each-4", "each-5", "each-6"}
+	if strings.Join(ids, ",") != strings.Join(want, ",") {
+		t.Errorf("Visited %v, want %v", ids, want)
+	}
+
+	// Time bounds
+	ids = nil
+	filter := Filter{CreatedAfter: base, CreatedBefore: base.Add(3 * time.Hour)}
+	if err := db.Each(context.Background(), filter, func(data *models.ScrapedData) error {
+		ids = append(ids, data.ID)
+		return nil
+	}); err != nil {
+		t.Fatalf("Each failed: %v", err)
+	}
+	if strings.Join(ids, ",") != "each-2,each-3,each-4,each-5" {
+		t.Errorf("Visited %v with time bounds", ids)
+	}
+
+	// Errors from fn stop iteration
+	stop := errors.New("stop")
+	visited := 0
+	err = db.Each(context.Background(), Filter{BatchSize: 2}, func(data *models.ScrapedData) error {
+		visited++
+		if visited == 3 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) || visited != 3 {
+		t.Errorf("Expected iteration to stop after 3 records with fn error, got %d (err: %v)", visited, err)
+	}
+
+	// Cancellation stops iteration
+	ctx, cancel := context.WithCancel(context.Background())
+	visited = 0
+	err = db.Each(ctx, Filter{BatchSize: 2}, func(data *models.ScrapedData) error {
+		visited++
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || visited != 1 {
+		t.Errorf("Expected cancellation after 1 record, got %d (err: %v)", visited, err)
+	}
+}
+
+func TestListPage(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		data := &models.ScrapedData{
+			ID:        fmt.Sprintf("page-%d", i),
+			URL:       fmt.Sprintf("https://example.com/%d", i),
+			FetchedAt: base.Add(time.Duration(i/2) * time.Hour), // pairs share a timestamp
+		}
+		if err := db.SaveScrapedData(data); err != nil {
+			t.Fatalf("Failed to save data: %v", err)
+		}
+	}
+
+	var ids []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 5 {
+			t.Fatal("Too many pages")
+		}
+		results, next, err := db.ListPage(2, cursor)
+		if err != nil {
+			t.Fatalf("ListPage failed: %v", err)
+		}
+		for _, data := range results {
+			ids = append(ids, data.ID)
+		}
+		if next == "" {
+			if len(results) == 0 {
+				t.Error("Expected last page to contain results")
+			}
+			break
+		}
+		cursor = next
+	}
+
+	want := "page-4,page-3,page-2,page-1,page-0"
+	if strings.Join(ids, ",") != want {
+		t.Errorf("Visited %v, want %s", ids, want)
+	}
+
+	if _, _, err := db.ListPage(2, "not-a-cursor!"); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("Expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestListPageInsertDuringPaging(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	save := func(id string, fetched time.Time) {
+		t.Helper()
+		data := &models.ScrapedData{ID: id, URL: "https://example.com/" + id, FetchedAt: fetched}
+		if err := db.SaveScrapedData(data); err != nil {
+			t.Fatalf("Failed to save data: %v", err)
+		}
+	}
+	for i := 0; i < 6; i++ {
+		save(fmt.Sprintf("row-%d", i), base.Add(time.Duration(i)*time.Hour))
+	}
+
+	var ids []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 6 {
+			t.Fatal("Too many pages")
+		}
+		results, next, err := db.ListPage(2, cursor)
+		if err != nil {
+			t.Fatalf("ListPage failed: %v", err)
+		}
+		for _, data := range results {
+			ids = append(ids, data.ID)
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+
+		// New rows arriving between pages land before the cursor and must not
+		// shift later pages, unlike with OFFSET
+		save(fmt.Sprintf("new-%d", pages), base.Add(time.Duration(100+pages)*time.Hour))
+	}
+
+	want := "row-5,row-4,row-3,row-2,row-1,row-0"
+	if strings.Join(ids, ",") != want {
+		t.Errorf("Visited %v, want %s with no rows skipped or repeated", ids, want)
+	}
+}
+
+func TestFailures(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	save := func(id, url string, failed bool, fetched time.Time) {
+		t.Helper()
+		data := &models.ScrapedData{ID: id, URL: url, FetchedAt: fetched, Failed: failed}
+		if failed {
+			data.Error = "HTTP error: 404 404 Not Found"
+			data.StatusCode = 404
+		}
+		if err := db.SaveScrapedData(data); err != nil {
+			t.Fatalf("Failed to save data: %v", err)
+		}
+	}
+	save("ok", "https://example.com/ok", false, base)
+	save("dead", "https://example.com/dead", true, base.Add(time.Hour))
+	save("gone", "https://example.com/gone", true, base.Add(2*time.Hour))
+
+	// A failure never replaces scraped content, but a success replaces a failure
+	save("ok-failed", "https://example.com/ok", true, base.Add(3*time.Hour))
+	save("gone-fixed", "https://example.com/gone", false, base.Add(4*time.Hour))
+
+	list, err := db.List(10, 0)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	page, _, err := db.ListPage(10, "")
+	if err != nil {
+		t.Fatalf("ListPage failed: %v", err)
+	}
+	var eachIDs []string
+	if err := db.Each(context.Background(), Filter{}, func(data *models.ScrapedData) error {
+		eachIDs = append(eachIDs, data.ID)
+		return nil
+	}); err != nil {
+		t.Fatalf("Each failed: %v", err)
+	}
+	for name, got := range map[string][]*models.ScrapedData{"List": list, "ListPage": page} {
+		var ids []string
+		for _, data := range got {
+			ids = append(ids, data.ID)
+		}
+		if strings.Join(ids, ",") != "gone-fixed,ok" {
+			t.Errorf("%s returned %v, want only successful scrapes", name, ids)
+		}
+	}
+	if strings.Join(eachIDs, ",") != "ok,gone-fixed" {
+		t.Errorf("Each visited %v, want only successful scrapes", eachIDs)
+	}
+
+	failures, err := db.ListFailures(10, 0)
+	if err != nil {
+		t.Fatalf("ListFailures failed: %v", err)
+	}
+	if len(failures) != 1 || failures[0].ID != "dead" || !failures[0].Failed || failures[0].StatusCode != 404 {
+		t.Errorf("ListFailures returned %+v, want only the dead link", failures)
+	}
+
+	if count, _ := db.Count(); count != 2 {
+		t.Errorf("Count = %d, want 2", count)
+	}
+	if count, _ := db.CountFailures(); count != 1 {
+		t.Errorf("CountFailures = %d, want 1", count)
+	}
+}
+
+func TestSaveAndGetLinkScore(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	score, err := db.GetLinkScoreByURL("https://example.com/unscored")
+	if err != nil || score != nil {
+		t.Fatalf("GetLinkScoreByURL for unscored URL = %+v, %v; want nil, nil", score, err)
+	}
+
+	scoredAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	saved := &models.LinkScore{
+		URL:                 "https://example.com/article",
+		Score:               0.35,
+		Reason:              "Thin content",
+		Categories:          []string{"low_quality", "spam"},
+		IsRecommended:       false,
+		MaliciousIndicators: []string{"keyword stuffing"},
+		AIUsed:              true,
+		ScoringPath:         "ai",
+		ScoredAt:            scoredAt,
+	}
+	if err := db.SaveLinkScore(saved); err != nil {
+		t.Fatalf("SaveLinkScore failed: %v", err)
+	}
+
+	score, err = db.GetLinkScoreByURL(saved.URL)
+	if err != nil {
+		t.Fatalf("GetLinkScoreByURL failed: %v", err)
+	}
+	if score == nil {
+		t.Fatal("Expected saved score")
+	}
+	if score.Score != 0.35 || score.Reason != "Thin content" || !score.AIUsed || score.ScoringPath != "ai" || score.IsRecommended {
+		t.Errorf("Score = %+v", score)
+	}
+	if strings.Join(score.Categories, ",") != "low_quality,spam" || strings.Join(score.MaliciousIndicators, ",") != "keyword stuffing" {
+		t.Errorf("Categories = %v, MaliciousIndicators = %v", score.Categories, score.MaliciousIndicators)
+	}
+	if !score.ScoredAt.Equal(scoredAt) {
+		t.Errorf("ScoredAt = %v, want %v", score.ScoredAt, scoredAt)
+	}
+
+	// Re-scoring replaces the saved score, defaulting ScoredAt to now
+	before := time.Now()
+	if err := db.SaveLinkScore(&models.LinkScore{URL: saved.URL, Score: 0.9, Categories: []string{"technical"}, IsRecommended: true}); err != nil {
+		t.Fatalf("SaveLinkScore failed: %v", err)
+	}
+	score, err = db.GetLinkScoreByURL(saved.URL)
+	if err != nil {
+		t.Fatalf("GetLinkScoreByURL failed: %v", err)
+	}
+	if score.Score != 0.9 || !score.IsRecommended || score.AIUsed || score.ScoringPath != "" || len(score.MaliciousIndicators) != 0 {
+		t.Errorf("Rescored = %+v", score)
+	}
+	if score.ScoredAt.Before(before.Add(-time.Second)) {
+		t.Errorf("ScoredAt = %v, want about now", score.ScoredAt)
+	}
+}
+
+func TestListFiltered(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, language := range []string{"en", "fr", "en", "", "en"} {
+		data := &models.ScrapedData{
+			ID:        fmt.Sprintf("lang-%d", i),
+			URL:       fmt.Sprintf("https://example.com/%d", i),
+			Language:  language,
+			WordCount: i * 100,
+			FetchedAt: base.Add(time.Duration(i) * time.Hour),
+		}
+		if err := db.SaveScrapedData(data); err != nil {
+			t.Fatalf("Failed to save data: %v", err)
+		}
+	}
+
+	ids := func(results []*models.ScrapedData) string {
+		var ids []string
+		for _, data := range results {
+			ids = append(ids, data.ID)
+		}
+		return strings.Join(ids, ",")
+	}
+
+	english := ListFilter{Language: "en"}
+	results, err := db.ListFiltered(10, 1, english)
+	if err != nil {
+		t.Fatalf("ListFiltered failed: %v", err)
+	}
+	if got := ids(results); got != "lang-2,lang-0" {
+		t.Errorf("ListFiltered(en) = %s, want lang-2,lang-0", got)
+	}
+
+	page, next, err := db.ListPageFiltered(2, "", english)
+	if err != nil || ids(page) != "lang-4,lang-2" || next == "" {
+		t.Fatalf("First page = %s, %q, %v", ids(page), next, err)
+	}
+	page, next, err = db.ListPageFiltered(2, next, english)
+	if err != nil || ids(page) != "lang-0" || next != "" {
+		t.Errorf("Second page = %s, %q, %v", ids(page), next, err)
+	}
+
+	results, _ = db.ListFiltered(10, 0, ListFilter{Language: "en", MinWords: 200})
+	if got := ids(results); got != "lang-4,lang-2" {
+		t.Errorf("ListFiltered(en, 200 words) = %s, want lang-4,lang-2", got)
+	}
+
+	for _, tt := range []struct {
+		filter ListFilter
+		want   int
+	}{
+		{english, 3},
+		{ListFilter{MinWords: 300}, 2},
+		{ListFilter{}, 5},
+	} {
+		if count, _ := db.CountFiltered(tt.filter); count != tt.want {
+			t.Errorf("CountFiltered(%+v) = %d, want %d", tt.filter, count, tt.want)
+		}
+	}
+
+	// Updating a record's data keeps its filtered columns in step
+	updated, _ := db.GetByID("lang-1")
+	updated.Language, updated.WordCount = "en", 1000
+	if err := db.UpdateData(updated); err != nil {
+		t.Fatalf("UpdateData failed: %v", err)
+	}
+	if count, _ := db.CountFiltered(ListFilter{Language: "fr"}); count != 0 {
+		t.Errorf("CountFiltered(fr) = %d after the update, want 0", count)
+	}
+	if count, _ := db.CountFiltered(ListFilter{MinWords: 1000}); count != 1 {
+		t.Errorf("CountFiltered(1000 words) = %d after the update, want 1", count)
+	}
+}
+
+func TestOptimize(t *testing.T) {
+	db, err := New(Config{Driver: "sqlite", DSN: t.TempDir() + "/optimize.db"})
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	content := strings.Repeat("Bulky page content. ", 5000)
+	for i := range 20 {
+		data := &models.ScrapedData{ID: fmt.Sprintf("opt-%d", i), URL: fmt.Sprintf("https://example.com/%d", i), Content: content, FetchedAt: time.Now()}
+		if err := db.SaveScrapedData(data); err != nil {
+			t.Fatalf("SaveScrapedData() error = %v", err)
+		}
+	}
+	if _, err := db.DeleteByURLPattern("https://example.com/1%"); err != nil {
+		t.Fatalf("DeleteByURLPattern() error = %v", err)
+	}
+
+	result, err := db.Optimize(context.Background())
+	if err != nil {
+		t.Fatalf("Optimize() error = %v", err)
+	}
+	if result.Reclaimed <= 0 || result.SizeAfter != result.SizeBefore-result.Reclaimed {
+		t.Errorf("Optimize() = %+v, want space reclaimed from deleted records", result)
+	}
+	if data, err := db.GetByID("opt-0"); err != nil || data == nil || data.Content != content {
+		t.Errorf("GetByID() after Optimize = %v, %v; want the record intact", data, err)
+	}
+
+	db.optimizing.Lock()
+	_, err = db.Optimize(context.Background())
+	db.optimizing.Unlock()
+	if !errors.Is(err, ErrOptimizeInProgress) {
+		t.Errorf("concurrent Optimize() error = %v, want ErrOptimizeInProgress", err)
+	}
+}
@@ -75,6 +75,107 @@ var migrations = []Migration{
 			DROP TABLE IF EXISTS images;
 		`,
 	},
+	{
+		Version: 4,
+		Name:    "add_images_hash_column",
+		Up: `
+			ALTER TABLE images ADD COLUMN hash TEXT;
+			CREATE INDEX IF NOT EXISTS idx_images_hash ON images(hash);
+		`,
+		Down: `
+			DROP INDEX IF EXISTS idx_images_hash;
+			ALTER TABLE images DROP COLUMN hash;
+		`,
+	},
+	{
+		Version: 5,
+		Name:    "add_images_thumbnail_column",
+		Up: `
+			ALTER TABLE images ADD COLUMN thumbnail_data BLOB;
+		`,
+		Down: `
+			ALTER TABLE images DROP COLUMN thumbnail_data;
+		`,
+	},
+	{
+		Version: 6,
+		Name:    "add_images_ocr_text_column",
+		Up: `
+			ALTER TABLE images ADD COLUMN ocr_text TEXT;
+		`,
+		Down: `
+			ALTER TABLE images DROP COLUMN ocr_text;
+		`,
+	},
+	{
+		Version: 7,
+		Name:    "add_scraped_data_failed_column",
+		Up: `
+			ALTER TABLE scraped_data ADD COLUMN failed INTEGER NOT NULL DEFAULT 0;
+			CREATE INDEX IF NOT EXISTS idx_scraped_data_failed ON scraped_data(failed, created_at);
+		`,
+		Down: `
+			DROP INDEX IF EXISTS idx_scraped_data_failed;
+			ALTER TABLE scraped_data DROP COLUMN failed;
+		`,
+	},
+	{
+		Version: 8,
+		Name:    "create_link_scores_table",
+		Up: `
+			CREATE TABLE IF NOT EXISTS link_scores (
+				url TEXT PRIMARY KEY,
+				score REAL NOT NULL,
+				reason TEXT,
+				categories TEXT,
+				is_recommended INTEGER NOT NULL DEFAULT 0,
+				malicious_indicators TEXT,
+				ai_used INTEGER NOT NULL DEFAULT 0,
+				scored_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			);
+			CREATE INDEX IF NOT EXISTS idx_link_scores_scored_at ON link_scores(scored_at);
+		`,
+		Down: `
+			DROP INDEX IF EXISTS idx_link_scores_scored_at;
+			DROP TABLE IF EXISTS link_scores;
+		`,
+	},
+	{
+		Version: 9,
+		Name:    "add_link_scores_scoring_path_column",
+		Up: `
+			ALTER TABLE link_scores ADD COLUMN scoring_path TEXT NOT NULL DEFAULT '';
+		`,
+		Down: `
+			ALTER TABLE link_scores DROP COLUMN scoring_path;
+		`,
+	},
+	{
+		Version: 10,
+		Name:    "add_scraped_data_language_column",
+		Up: `
+			ALTER TABLE scraped_data ADD COLUMN language TEXT NOT NULL DEFAULT '';
+			UPDATE scraped_data SET language = COALESCE(json_extract(data, '$.language'), '');
+			CREATE INDEX IF NOT EXISTS idx_scraped_data_language ON scraped_data(language, failed, created_at);
+		`,
+		Down: `
+			DROP INDEX IF EXISTS idx_scraped_data_language;
+			ALTER TABLE scraped_data DROP COLUMN language;
+		`,
+	},
+	{
+		Version: 11,
+		Name:    "add_scraped_data_word_count_column",
+		Up: `
+			ALTER TABLE scraped_data ADD COLUMN word_count INTEGER NOT NULL DEFAULT 0;
+			UPDATE scraped_data SET word_count = COALESCE(json_extract(data, '$.word_count'), 0);
+			CREATE INDEX IF NOT EXISTS idx_scraped_data_word_count ON scraped_data(word_count);
+		`,
+		Down: `
+			DROP INDEX IF EXISTS idx_scraped_data_word_count;
+			ALTER TABLE scraped_data DROP COLUMN word_count;
+		`,
+	},
 }
 
 // Migrate runs all pending migrations
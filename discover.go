@@ -0,0 +1,147 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zombar/scraper/models"
+)
+
+// defaultDiscoverConcurrency is the number of links DiscoverLinks scores at
+// once when Config.DiscoverConcurrency is unset
+const defaultDiscoverConcurrency = 5
+
+// defaultDiscoverTimeout is DiscoverLinks' time budget when
+// Config.DiscoverTimeout is unset
+const defaultDiscoverTimeout = 2 * time.Minute
+
+// DiscoverResult holds the scored links of a page found by DiscoverLinks
+type DiscoverResult struct {
+	URL       string             `json:"url"`
+	Scores    []models.LinkScore `json:"scores"`    // Highest score first
+	Links     int                `json:"links"`     // Number of links found on the page
+	Failed    int                `json:"failed"`    // Links that could not be fetched or scored
+	Stale     int                `json:"stale"`     // Links skipped for pages older than Config.MaxLinkAge
+	Truncated bool               `json:"truncated"` // Some links were not scored within the time budget
+}
+
+// DiscoverLinks extracts a page's links as ExtractLinks does and scores each
+// with ScoreLinkContent, so a link-dense page can be triaged in one call. At
+// most Config.DiscoverConcurrency links are scored at once, and scoring stops
+// when Config.DiscoverTimeout runs out, returning the links scored by then
+// with Truncated set. Links that fail to score are counted and left out, as
+// are links to pages published longer ago than Config.MaxLinkAge.
+func (s *Scraper) DiscoverLinks(ctx context.Context, pageURL string) (*DiscoverResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.discoverTimeout())
+	defer cancel()
+
+	links, err := s.ExtractLinks(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DiscoverResult{URL: pageURL, Scores: []models.LinkScore{}, Links: len(links)}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	queue := make(chan string)
+
+	for range min(s.discoverConcurrency(), len(links)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for link := range queue {
+				score, err := s.scoreDiscoveredLink(ctx, link)
+
+				mu.Lock()
+				switch {
+				case err == nil:
+					result.Scores = append(result.Scores, *score)
+				case errors.Is(err, errStalePage):
+					result.Stale++
+				case ctx.Err() != nil: // cut off by the budget
+					result.Truncated = true
+				default:
+					log.Printf("Failed to score discovered link %s: %v", link, err)
+					result.Failed++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	// Stop handing out links once the budget runs out
+	unscored := false
+	for _, link := range links {
+		if ctx.Err() != nil {
+			unscored = true
+			break
+		}
+		select {
+		case queue <- link:
+		case <-ctx.Done():
+			unscored = true
+		}
+	}
+	close(queue)
+	wg.Wait()
+	result.Truncated = result.Truncated || unscored
+
+	// A cancelled parent context is an error rather than a spent budget
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return nil, ctx.Err()
+	}
+
+	sort.SliceStable(result.Scores, func(i, j int) bool {
+		return result.Scores[i].Score > result.Scores[j].Score
+	})
+	return result, nil
+}
+
+// errStalePage is returned (wrapped) for a page published longer ago than
+// Config.MaxLinkAge
+var errStalePage = errors.New("page is older than the maximum link age")
+
+// scoreDiscoveredLink scores a link for DiscoverLinks like ScoreLinkContent,
+// except that stale pages are skipped and not counted as failed scores
+func (s *Scraper) scoreDiscoveredLink(ctx context.Context, link string) (*models.LinkScore, error) {
+	_, score, err := s.scoreLinkContent(ctx, link, s.config.MaxLinkAge)
+	if !errors.Is(err, errStalePage) {
+		s.metrics.observeScore(err)
+	}
+	return score, err
+}
+
+// publishedDateLayouts are the published date formats found in page metadata
+var publishedDateLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"}
+
+// ParsePublishedDate parses a page's published date as stored in
+// PageMetadata.PublishedDate, in UTC
+func ParsePublishedDate(value string) (time.Time, bool) {
+	for _, layout := range publishedDateLayouts {
+		if t, err := time.Parse(layout, strings.TrimSpace(value)); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// discoverConcurrency returns the configured number of links scored at once
+func (s *Scraper) discoverConcurrency() int {
+	if s.config.DiscoverConcurrency > 0 {
+		return s.config.DiscoverConcurrency
+	}
+	return defaultDiscoverConcurrency
+}
+
+// discoverTimeout returns the configured time budget for DiscoverLinks
+func (s *Scraper) discoverTimeout() time.Duration {
+	if s.config.DiscoverTimeout > 0 {
+		return s.config.DiscoverTimeout
+	}
+	return defaultDiscoverTimeout
+}
@@ -0,0 +1,158 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// discoverServer serves a page at / linking to count pages under prefix;
+// each linked page is served after delay
+func discoverServer(t *testing.T, prefix string, count int, delay time.Duration) (*httptest.Server, *int32) {
+	t.Helper()
+	var inFlight, maxInFlight int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			var b strings.Builder
+			b.WriteString("<html><head><title>Index</title></head><body>")
+			for i := 0; i < count; i++ {
+				fmt.Fprintf(&b, `<a href="%s%d">Article %d</a> `, prefix, i, i)
+			}
+			b.WriteString("</body></html>")
+			w.Write([]byte(b.String()))
+			return
+		}
+
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		fmt.Fprintf(w, "<html><head><title>%s</title></head><body><p>Tutorial documentation about %s.</p></body></html>", r.URL.Path, r.URL.Path)
+	}))
+	t.Cleanup(ts.Close)
+	return ts, &maxInFlight
+}
+
+func TestDiscoverLinks(t *testing.T) {
+	ts, maxInFlight := discoverServer(t, "/articles/", 8, 20*time.Millisecond)
+
+	config := DefaultConfig()
+	config.OllamaBaseURL = "http://127.0.0.1:1" // unreachable, forcing the rule-based fallback
+	config.DiscoverConcurrency = 2
+	result, err := New(config).DiscoverLinks(context.Background(), ts.URL+"/")
+	if err != nil {
+		t.Fatalf("DiscoverLinks() error = %v", err)
+	}
+
+	if result.Links != 8 || len(result.Scores) != 8 || result.Failed != 0 || result.Truncated {
+		t.Errorf("Result = %+v, want all 8 links scored", result)
+	}
+	if *maxInFlight > 2 {
+		t.Errorf("Scored %d links at once, want at most 2", *maxInFlight)
+	}
+	for i := 1; i < len(result.Scores); i++ {
+		if result.Scores[i].Score > result.Scores[i-1].Score {
+			t.Errorf("Scores not sorted highest first: %v then %v", result.Scores[i-1].Score, result.Scores[i].Score)
+		}
+	}
+}
+
+func TestDiscoverLinksTimeout(t *testing.T) {
+	ts, _ := discoverServer(t, "/slow/", 20, time.Minute)
+
+	config := DefaultConfig()
+	config.OllamaBaseURL = "http://127.0.0.1:1"
+	config.DiscoverConcurrency = 4
+	config.DiscoverTimeout = 300 * time.Millisecond
+
+	start := time.Now()
+	result, err := New(config).DiscoverLinks(context.Background(), ts.URL+"/")
+	if err != nil {
+		t.Fatalf("DiscoverLinks() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("DiscoverLinks took %v, want it bounded by the budget", elapsed)
+	}
+	if !result.Truncated || len(result.Scores) != 0 || result.Links != 20 {
+		t.Errorf("Result = %+v, want a truncated result with no scores", result)
+	}
+}
+
+func TestDiscoverLinksCancelled(t *testing.T) {
+	ts, _ := discoverServer(t, "/slow/", 3, time.Minute)
+
+	config := DefaultConfig()
+	config.OllamaBaseURL = "http://127.0.0.1:1"
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+
+	if _, err := New(config).DiscoverLinks(ctx, ts.URL+"/"); err == nil {
+		t.Error("Expected an error when the caller cancels")
+	}
+}
+
+func TestDiscoverLinksMaxAge(t *testing.T) {
+	fresh := time.Now().AddDate(0, 0, -3).Format("2006-01-02")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/":
+			w.Write([]byte(`<html><body><a href="/fresh">Fresh</a> <a href="/old">Old</a> <a href="/undated">Undated</a></body></html>`))
+		case "/fresh":
+			w.Write([]byte(`<html><head><meta property="article:published_time" content="` + fresh + `"></head><body><p>New tutorial</p></body></html>`))
+		case "/old":
+			w.Write([]byte(`<html><head><meta property="article:published_time" content="2015-04-01T09:00:00Z"></head><body><p>Old tutorial</p></body></html>`))
+		default:
+			w.Write([]byte(`<html><body><p>Undated tutorial</p></body></html>`))
+		}
+	}))
+	defer ts.Close()
+
+	config := DefaultConfig()
+	config.OllamaBaseURL = "http://127.0.0.1:1"
+	config.MaxLinkAge = 30 * 24 * time.Hour
+	result, err := New(config).DiscoverLinks(context.Background(), ts.URL+"/")
+	if err != nil {
+		t.Fatalf("DiscoverLinks() error = %v", err)
+	}
+
+	if result.Stale != 1 || result.Failed != 0 || len(result.Scores) != 2 {
+		t.Fatalf("Result = %+v, want the old link skipped and the other two scored", result)
+	}
+	for _, score := range result.Scores {
+		if strings.HasSuffix(score.URL, "/old") {
+			t.Errorf("Stale link %s was scored", score.URL)
+		}
+	}
+}
+
+func TestParsePublishedDate(t *testing.T) {
+	tests := map[string]string{
+		"2024-03-05T10:30:00+02:00": "2024-03-05T08:30:00Z",
+		"2024-03-05T10:30:00":       "2024-03-05T10:30:00Z",
+		" 2024-03-05 ":              "2024-03-05T00:00:00Z",
+	}
+	for value, want := range tests {
+		got, ok := ParsePublishedDate(value)
+		if !ok || got.Format(time.RFC3339) != want {
+			t.Errorf("ParsePublishedDate(%q) = %v, %v; want %s", value, got, ok, want)
+		}
+	}
+	if _, ok := ParsePublishedDate("last Tuesday"); ok {
+		t.Error("ParsePublishedDate accepted an unparseable date")
+	}
+}
@@ -0,0 +1,159 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zombar/scraper/models"
+)
+
+// Content types reported in ScrapedData.ContentType
+const (
+	ContentTypeHTML = "text/html"
+	ContentTypePDF  = "application/pdf"
+	ContentTypeText = "text/plain"
+	ContentTypeJSON = "application/json"
+)
+
+// documentType returns the content type of a response that is a document
+// other than HTML, or "" for HTML. PDFs are also recognized by their header,
+// whatever the Content-Type says.
+func documentType(contentType string, body []byte) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case bytes.HasPrefix(body, pdfMagic) || mediaType == ContentTypePDF:
+		return ContentTypePDF
+	case mediaType == ContentTypeText:
+		return ContentTypeText
+	case mediaType == ContentTypeJSON || strings.HasSuffix(mediaType, "+json"):
+		return ContentTypeJSON
+	}
+	return ""
+}
+
+// isDocumentContentType reports whether a Content-Type is a document type
+// Scrape handles besides HTML
+func isDocumentContentType(contentType string) bool {
+	return documentType(contentType, nil) != ""
+}
+
+// scrapeDocument builds the scraped data of a PDF, plain text, or JSON
+// document from its body. Its text is the content as extracted, without
+// Ollama cleaning, and no links, images, or HTML metadata are extracted.
+func (s *Scraper) scrapeDocument(ctx context.Context, targetURL, contentType string, resp *http.Response, body []byte, timings *models.Timings, progress ProgressFunc, start time.Time) (*models.ScrapedData, error) {
+	if progress != nil {
+		progress(PhaseFetched, FetchProgress{URL: targetURL, StatusCode: resp.StatusCode})
+	}
+
+	phaseStart := time.Now()
+	metadata := models.PageMetadata{FetchMethod: FetchMethodHTTP}
+	var title, text string
+	switch contentType {
+	case ContentTypePDF:
+		var err error
+		// Decompressed streams are held to the page size limit too
+		if title, text, err = extractPDF(ctx, body, s.maxBodyBytes()); err != nil {
+			return nil, err
+		}
+	case ContentTypeText:
+		decoded, _ := s.decodeHTML(resp, body)
+		text = strings.TrimSpace(string(decoded))
+	case ContentTypeJSON:
+		title, metadata.Description, text = extractJSON(body)
+	}
+	if title == "" {
+		title = urlFilename(resp.Request.URL)
+	}
+	if title == "" {
+		title = targetURL
+	}
+	timings.ExtractTime = time.Since(phaseStart).Seconds()
+
+	if progress != nil {
+		progress(PhaseContentExtracted, ContentProgress{Title: title, ContentLength: len(text)})
+	}
+
+	phaseStart = time.Now()
+	linkScore := s.scoreContent(ctx, "scrape", targetURL, title, text)
+	timings.ScoreTime = time.Since(phaseStart).Seconds()
+
+	if progress != nil {
+		progress(PhaseScored, linkScore)
+	}
+
+	wordCount := countWords(text)
+	data := &models.ScrapedData{
+		ID:                 uuid.New().String(),
+		URL:                s.NormalizeURL(targetURL),
+		FinalURL:           resp.Request.URL.String(),
+		StatusCode:         resp.StatusCode,
+		Title:              title,
+		Content:            text,
+		ContentType:        contentType,
+		WordCount:          wordCount,
+		ReadingTimeSeconds: readingTimeSeconds(wordCount),
+		Language:           pageLanguage(nil, resp.Header.Get("Content-Language"), text),
+		FetchedAt:          time.Now(),
+		CreatedAt:          time.Now(),
+		ProcessingTime:     time.Since(start).Seconds(),
+		Timings:            timings,
+		Metadata:           metadata,
+		Score:              linkScore,
+	}
+	if ctx.Value(rawContentKey{}) != nil {
+		data.RawContent = text
+	}
+	return data, nil
+}
+
+// jsonTitleKeys and jsonDescriptionKeys are the top-level fields of a JSON
+// document taken as its title and description, in order of preference
+var (
+	jsonTitleKeys       = []string{"title", "name", "headline"}
+	jsonDescriptionKeys = []string{"description", "summary"}
+)
+
+// extractJSON returns a JSON document pretty-printed, along with its title
+// and description from top-level string fields. Invalid JSON is returned
+// as it was sent.
+func extractJSON(body []byte) (title, description, text string) {
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, bytes.TrimSpace(body), "", "  "); err != nil {
+		return "", "", strings.TrimSpace(string(body))
+	}
+
+	var fields map[string]interface{}
+	if json.Unmarshal(body, &fields) == nil {
+		title = firstJSONString(fields, jsonTitleKeys)
+		description = firstJSONString(fields, jsonDescriptionKeys)
+	}
+	return title, description, pretty.String()
+}
+
+// firstJSONString returns the first of keys holding a non-empty string
+func firstJSONString(fields map[string]interface{}, keys []string) string {
+	for _, key := range keys {
+		if s, ok := fields[key].(string); ok && strings.TrimSpace(s) != "" {
+			return strings.TrimSpace(s)
+		}
+	}
+	return ""
+}
+
+// urlFilename returns the file name at the end of a URL's path, such as
+// "report.pdf", or "" if the path has none
+func urlFilename(u *url.URL) string {
+	name := path.Base(u.Path)
+	if name == "/" || name == "." {
+		return ""
+	}
+	return name
+}
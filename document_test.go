@@ -0,0 +1,90 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDocumentType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		body        string
+		want        string
+	}{
+		{"application/pdf", "%PDF-1.7", ContentTypePDF},
+		{"application/octet-stream", "%PDF-1.4", ContentTypePDF},
+		{"Application/PDF; qs=0.9", "", ContentTypePDF},
+		{"text/plain; charset=utf-8", "notes", ContentTypeText},
+		{"application/json", "{}", ContentTypeJSON},
+		{"application/ld+json", "{}", ContentTypeJSON},
+		{"text/html", "<html>", ""},
+		{"", "<!DOCTYPE html>", ""},
+	}
+	for _, tt := range tests {
+		if got := documentType(tt.contentType, []byte(tt.body)); got != tt.want {
+			t.Errorf("documentType(%q, %q) = %q, want %q", tt.contentType, tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestExtractJSON(t *testing.T) {
+	title, description, text := extractJSON([]byte(`{"name":"Widget API","summary":"Lists widgets","items":[1,2]}`))
+	if title != "Widget API" || description != "Lists widgets" {
+		t.Errorf("title, description = %q, %q; want the name and summary", title, description)
+	}
+	want := "{\n  \"name\": \"Widget API\",\n  \"summary\": \"Lists widgets\",\n  \"items\": [\n    1,\n    2\n  ]\n}"
+	if text != want {
+		t.Errorf("text =\n%s\nwant:\n%s", text, want)
+	}
+
+	title, _, text = extractJSON([]byte("[1, 2"))
+	if title != "" || text != "[1, 2" {
+		t.Errorf("invalid JSON = %q, %q; want it kept as sent", title, text)
+	}
+}
+
+func TestScrapeTextAndJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/notes/readme.txt":
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.Write([]byte("\nRelease notes\n\n<b>Not markup</b> in plain text.\n"))
+		case "/api/article":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"title":"Go 1.24 released","description":"What is new","tags":["go"]}`))
+		}
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.OllamaBaseURL = "http://127.0.0.1:1" // unavailable, so scoring falls back
+	s := New(config)
+
+	data, err := s.Scrape(context.Background(), server.URL+"/notes/readme.txt")
+	if err != nil {
+		t.Fatalf("Scrape text failed: %v", err)
+	}
+	if data.ContentType != ContentTypeText || data.Title != "readme.txt" {
+		t.Errorf("ContentType, Title = %q, %q; want text/plain and the file name", data.ContentType, data.Title)
+	}
+	if data.Content != "Release notes\n\n<b>Not markup</b> in plain text." {
+		t.Errorf("Content = %q, want the body as sent", data.Content)
+	}
+	if len(data.Links) != 0 || len(data.Images) != 0 || data.Score == nil {
+		t.Errorf("Links = %v, Images = %v, Score = %v; want no links or images, and a score", data.Links, data.Images, data.Score)
+	}
+
+	data, err = s.Scrape(context.Background(), server.URL+"/api/article")
+	if err != nil {
+		t.Fatalf("Scrape JSON failed: %v", err)
+	}
+	if data.ContentType != ContentTypeJSON || data.Title != "Go 1.24 released" || data.Metadata.Description != "What is new" {
+		t.Errorf("ContentType, Title, Description = %q, %q, %q; want them from the JSON", data.ContentType, data.Title, data.Metadata.Description)
+	}
+	if !strings.Contains(data.Content, "\n  \"tags\": [\n    \"go\"\n  ]") {
+		t.Errorf("Content = %q, want the JSON pretty-printed", data.Content)
+	}
+}
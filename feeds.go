@@ -0,0 +1,271 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// FeedItem is an entry of an RSS or Atom feed
+type FeedItem struct {
+	Title     string    `json:"title"`
+	Link      string    `json:"link"`               // Absolute URL of the article
+	Published time.Time `json:"published,omitzero"` // Zero if the feed gives no parseable date
+}
+
+// feedDocument holds the parts of RSS 2.0, RSS 1.0 (RDF), and Atom documents
+// needed to list their items
+type feedDocument struct {
+	XMLName xml.Name
+	Channel struct {
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	Items   []rssItem       `xml:"item"` // RSS 1.0 lists items beside the channel
+	Entries []atomFeedEntry `xml:"entry"`
+}
+
+type rssItem struct {
+	Title string   `xml:"title"`
+	Links []string `xml:"link"` // a slice since <atom:link> elements also match
+	GUID  struct {
+		Value       string `xml:",chardata"`
+		IsPermaLink string `xml:"isPermaLink,attr"`
+	} `xml:"guid"`
+	PubDate string `xml:"pubDate"`
+	Date    string `xml:"http://purl.org/dc/elements/1.1/ date"`
+}
+
+type atomFeedEntry struct {
+	Title string `xml:"title"`
+	Links []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	} `xml:"link"`
+	Published string `xml:"published"`
+	Updated   string `xml:"updated"`
+}
+
+// feedDateLayouts are the date formats tried for feed item dates: RFC 822
+// variants for RSS and RFC 3339 for Atom and Dublin Core
+var feedDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"Mon, 2 Jan 2006 15:04 -0700",
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 MST",
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// ParseFeed fetches an RSS 2.0, RSS 1.0, or Atom feed and returns its items
+// in feed order. Links are resolved against the feed's URL, and items
+// without an http(s) link are skipped.
+func (s *Scraper) ParseFeed(ctx context.Context, feedURL string) ([]FeedItem, error) {
+	parsedURL, err := url.Parse(feedURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+		return nil, fmt.Errorf("URL must be http or https")
+	}
+
+	resp, err := s.fetchPage(ctx, feedURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := s.readBody(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseFeed(body, resp.Request.URL)
+}
+
+// parseFeed parses a feed document, resolving links against baseURL
+func parseFeed(body []byte, baseURL *url.URL) ([]FeedItem, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.CharsetReader = feedCharsetReader
+
+	var doc feedDocument
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse feed: %w", err)
+	}
+
+	items := []FeedItem{}
+	add := func(title, link, date string) {
+		if link == "" {
+			return
+		}
+		link, err := resolveURL(baseURL, link)
+		if err != nil {
+			return
+		}
+		if u, err := url.Parse(link); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return
+		}
+		items = append(items, FeedItem{
+			Title:     strings.Join(strings.Fields(title), " "),
+			Link:      link,
+			Published: parseFeedDate(date),
+		})
+	}
+
+	switch doc.XMLName.Local {
+	case "rss", "RDF":
+		rssItems := doc.Channel.Items
+		if doc.XMLName.Local == "RDF" {
+			rssItems = doc.Items
+		}
+		for _, item := range rssItems {
+			date := item.PubDate
+			if date == "" {
+				date = item.Date
+			}
+			add(item.Title, item.link(), date)
+		}
+	case "feed":
+		for _, entry := range doc.Entries {
+			date := entry.Published
+			if date == "" {
+				date = entry.Updated
+			}
+			add(entry.Title, entry.link(), date)
+		}
+	default:
+		return nil, fmt.Errorf("not an RSS or Atom feed: root element <%s>", doc.XMLName.Local)
+	}
+	return items, nil
+}
+
+// link returns the item's <link>, falling back to a permalink <guid>
+func (item rssItem) link() string {
+	for _, link := range item.Links {
+		if link = strings.TrimSpace(link); link != "" {
+			return link
+		}
+	}
+	if guid := strings.TrimSpace(item.GUID.Value); guid != "" && !strings.EqualFold(item.GUID.IsPermaLink, "false") &&
+		(strings.HasPrefix(guid, "http://") || strings.HasPrefix(guid, "https://")) {
+		return guid
+	}
+	return ""
+}
+
+// link returns the entry's alternate link; a link without a rel is alternate
+func (entry atomFeedEntry) link() string {
+	for _, link := range entry.Links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return strings.TrimSpace(link.Href)
+		}
+	}
+	return ""
+}
+
+// parseFeedDate parses an RSS or Atom date, returning the zero time if it
+// is missing or in an unknown format
+func parseFeedDate(value string) time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}
+	}
+	for _, layout := range feedDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// feedCharsetReader decodes the ISO-8859-1 feeds some older sites still
+// publish; encoding/xml handles UTF-8 itself
+func feedCharsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "iso-8859-1", "latin1", "us-ascii":
+		data, err := io.ReadAll(input)
+		if err != nil {
+			return nil, err
+		}
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return strings.NewReader(string(runes)), nil
+	}
+	return nil, fmt.Errorf("unsupported feed encoding %q", charset)
+}
+
+// feedLinkTypes are the <link rel="alternate"> types that advertise a feed
+var feedLinkTypes = map[string]bool{
+	"application/rss+xml":  true,
+	"application/atom+xml": true,
+	"application/rdf+xml":  true,
+}
+
+// DiscoverFeeds fetches a page and returns the URLs of the feeds it
+// advertises with <link rel="alternate"> tags, in document order
+func (s *Scraper) DiscoverFeeds(ctx context.Context, pageURL string) ([]string, error) {
+	parsedURL, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+		return nil, fmt.Errorf("URL must be http or https")
+	}
+
+	resp, err := s.fetchPage(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, _, err := s.readHTML(resp)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	return extractFeedLinks(doc, resp.Request.URL), nil
+}
+
+// extractFeedLinks returns the absolute URLs of the RSS and Atom feeds a
+// page advertises with <link rel="alternate" type="...">
+func extractFeedLinks(n *html.Node, baseURL *url.URL) []string {
+	feeds := []string{}
+	seen := make(map[string]bool)
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "link" {
+			rels := strings.Fields(strings.ToLower(getAttr(n, "rel")))
+			mediaType, _, _ := strings.Cut(strings.ToLower(getAttr(n, "type")), ";")
+			if slices.Contains(rels, "alternate") && feedLinkTypes[strings.TrimSpace(mediaType)] {
+				if href := strings.TrimSpace(getAttr(n, "href")); href != "" {
+					if feedURL, err := resolveURL(baseURL, href); err == nil && !seen[feedURL] {
+						seen[feedURL] = true
+						feeds = append(feeds, feedURL)
+					}
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			f(c)
+		}
+	}
+	f(n)
+	return feeds
+}
@@ -0,0 +1,178 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+func TestParseFeedRSS(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<channel>
+	<title>Example Blog</title>
+	<link>https://example.com/</link>
+	<item>
+		<title>  First
+			post </title>
+		<atom:link href="https://example.com/feed" rel="self"/>
+		<link>/posts/1</link>
+		<pubDate>Tue, 10 Jun 2025 04:00:00 GMT</pubDate>
+	</item>
+	<item>
+		<title>Second post</title>
+		<guid>https://example.com/posts/2</guid>
+		<dc:date>2025-06-11T08:30:00Z</dc:date>
+	</item>
+	<item>
+		<title>No link</title>
+		<guid isPermaLink="false">https://example.com/posts/3</guid>
+	</item>
+	<item>
+		<title>Mail</title>
+		<link>mailto:editor@example.com</link>
+	</item>
+</channel>
+</rss>`))
+	}))
+	defer ts.Close()
+
+	items, err := New(DefaultConfig()).ParseFeed(context.Background(), ts.URL+"/feed.xml")
+	if err != nil {
+		t.Fatalf("ParseFeed() error = %v", err)
+	}
+
+	want := []FeedItem{
+		{Title: "First post", Link: ts.URL + "/posts/1", Published: time.Date(2025, 6, 10, 4, 0, 0, 0, time.UTC)},
+		{Title: "Second post", Link: "https://example.com/posts/2", Published: time.Date(2025, 6, 11, 8, 30, 0, 0, time.UTC)},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("ParseFeed() = %+v, want %+v", items, want)
+	}
+	for i := range want {
+		if items[i].Title != want[i].Title || items[i].Link != want[i].Link || !items[i].Published.Equal(want[i].Published) {
+			t.Errorf("Item %d = %+v, want %+v", i, items[i], want[i])
+		}
+	}
+}
+
+func TestParseFeedAtom(t *testing.T) {
+	base, _ := url.Parse("https://example.com/feeds/atom.xml")
+	items, err := parseFeed([]byte(`<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+	<title>Example</title>
+	<entry>
+		<title type="html">Release notes</title>
+		<link rel="edit" href="/api/entries/1"/>
+		<link rel="alternate" type="text/html" href="../releases/1"/>
+		<published>2025-05-01T12:00:00+02:00</published>
+		<updated>2025-05-02T12:00:00Z</updated>
+	</entry>
+	<entry>
+		<title>Updated only</title>
+		<link href="https://example.com/releases/2"/>
+		<updated>2025-05-03</updated>
+	</entry>
+	<entry>
+		<title>Undated</title>
+		<link href="https://example.com/releases/3"/>
+		<updated>last week</updated>
+	</entry>
+</feed>`), base)
+	if err != nil {
+		t.Fatalf("parseFeed() error = %v", err)
+	}
+
+	if len(items) != 3 {
+		t.Fatalf("parseFeed() = %+v, want 3 items", items)
+	}
+	if items[0].Link != "https://example.com/releases/1" || !items[0].Published.Equal(time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Item 0 = %+v, want the alternate link and published date", items[0])
+	}
+	if items[1].Link != "https://example.com/releases/2" || !items[1].Published.Equal(time.Date(2025, 5, 3, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Item 1 = %+v, want the updated date", items[1])
+	}
+	if !items[2].Published.IsZero() {
+		t.Errorf("Item 2 Published = %v, want zero for an unparseable date", items[2].Published)
+	}
+}
+
+func TestParseFeedRDFAndLatin1(t *testing.T) {
+	base, _ := url.Parse("https://example.com/index.rdf")
+	body := []byte("<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>\n" +
+		`<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/">` +
+		`<channel><title>Old site</title><link>https://example.com/</link></channel>` +
+		"<item><title>Caf\xe9</title><link>https://example.com/cafe</link></item>" +
+		`</rdf:RDF>`)
+
+	items, err := parseFeed(body, base)
+	if err != nil {
+		t.Fatalf("parseFeed() error = %v", err)
+	}
+	want := []FeedItem{{Title: "Caf\u00e9", Link: "https://example.com/cafe"}}
+	if !reflect.DeepEqual(items, want) {
+		t.Errorf("parseFeed() = %+v, want %+v", items, want)
+	}
+}
+
+func TestParseFeedErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/page.html":
+			w.Write([]byte("<html><body>Not a feed</body></html>"))
+		case "/sitemap.xml":
+			w.Write([]byte("<urlset><url><loc>https://example.com/</loc></url></urlset>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	s := New(DefaultConfig())
+	for _, u := range []string{"ftp://example.com/feed", ts.URL + "/missing.xml", ts.URL + "/page.html", ts.URL + "/sitemap.xml"} {
+		if _, err := s.ParseFeed(context.Background(), u); err == nil {
+			t.Errorf("ParseFeed(%q) expected error", u)
+		}
+	}
+}
+
+func TestDiscoverFeeds(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head>
+			<link rel="alternate" type="application/rss+xml" title="Posts" href="/feed.xml">
+			<link rel="Alternate" type="application/atom+xml; charset=utf-8" href="https://example.com/atom.xml">
+			<link rel="alternate" hreflang="fr" href="/fr/">
+			<link rel="alternate" type="application/json" href="/feed.json">
+			<link rel="stylesheet" type="application/rss+xml" href="/odd.xml">
+			<link rel="alternate" type="application/rss+xml" href="/feed.xml">
+		</head><body></body></html>`))
+	}))
+	defer ts.Close()
+
+	feeds, err := New(DefaultConfig()).DiscoverFeeds(context.Background(), ts.URL+"/blog/")
+	if err != nil {
+		t.Fatalf("DiscoverFeeds() error = %v", err)
+	}
+	want := []string{ts.URL + "/feed.xml", "https://example.com/atom.xml"}
+	if strings.Join(feeds, " ") != strings.Join(want, " ") {
+		t.Errorf("DiscoverFeeds() = %v, want %v", feeds, want)
+	}
+}
+
+func TestExtractFeedLinksNone(t *testing.T) {
+	doc, _ := html.Parse(strings.NewReader(`<html><head><title>No feeds</title></head></html>`))
+	base, _ := url.Parse("https://example.com/")
+	if feeds := extractFeedLinks(doc, base); len(feeds) != 0 {
+		t.Errorf("extractFeedLinks() = %v, want none", feeds)
+	}
+}
@@ -0,0 +1,63 @@
+package scraper
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// imageBudgetKey is the context key for an ImageBudget
+type imageBudgetKey struct{}
+
+// ImageBudget caps how many images are analyzed with Ollama across several
+// scrapes, such as the pages of one batch, bounding vision calls regardless
+// of how many images each page has. Once it is used up, images are still
+// downloaded and returned, without analysis. It is safe for concurrent use.
+type ImageBudget struct {
+	remaining atomic.Int64
+	skipped   atomic.Int64
+}
+
+// NewImageBudget returns a budget of n image analyses, or nil (no limit) if
+// n is not positive
+func NewImageBudget(n int) *ImageBudget {
+	if n <= 0 {
+		return nil
+	}
+	b := &ImageBudget{}
+	b.remaining.Store(int64(n))
+	return b
+}
+
+// WithImageBudget returns a context whose scrapes draw their image analyses
+// from b. A nil budget returns ctx unchanged.
+func WithImageBudget(ctx context.Context, b *ImageBudget) context.Context {
+	if b == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, imageBudgetKey{}, b)
+}
+
+// imageBudgetFromContext returns the context's ImageBudget, or nil without one
+func imageBudgetFromContext(ctx context.Context) *ImageBudget {
+	b, _ := ctx.Value(imageBudgetKey{}).(*ImageBudget)
+	return b
+}
+
+// take uses one analysis from the budget, reporting false (and counting the
+// image as skipped) once none are left
+func (b *ImageBudget) take() bool {
+	if b.remaining.Add(-1) >= 0 {
+		return true
+	}
+	b.skipped.Add(1)
+	return false
+}
+
+// Skipped returns how many images went unanalyzed because the budget was
+// used up. A nil budget skips none.
+func (b *ImageBudget) Skipped() int {
+	if b == nil {
+		return 0
+	}
+	return int(b.skipped.Load())
+}
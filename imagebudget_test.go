@@ -0,0 +1,92 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/zombar/scraper/models"
+)
+
+func TestNewImageBudget(t *testing.T) {
+	if b := NewImageBudget(0); b != nil {
+		t.Errorf("NewImageBudget(0) = %v, want nil for no limit", b)
+	}
+	if ctx := WithImageBudget(context.Background(), nil); imageBudgetFromContext(ctx) != nil {
+		t.Error("Expected a nil budget to leave the context without one")
+	}
+
+	b := NewImageBudget(2)
+	for i, want := range []bool{true, true, false, false} {
+		if got := b.take(); got != want {
+			t.Errorf("take() #%d = %v, want %v", i+1, got, want)
+		}
+	}
+	if b.Skipped() != 2 {
+		t.Errorf("Skipped() = %d, want 2", b.Skipped())
+	}
+}
+
+func TestScrapeImageBudget(t *testing.T) {
+	var analyses int32
+	ollamaServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req models.OllamaVisionRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		response := "Extracted content"
+		if len(req.Images) > 0 {
+			atomic.AddInt32(&analyses, 1)
+			response = `{"summary": "A test image", "tags": ["test"]}`
+		}
+		json.NewEncoder(w).Encode(models.OllamaResponse{Response: response, Done: true})
+	}))
+	defer ollamaServer.Close()
+
+	imageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("fake image data " + r.URL.Path))
+	}))
+	defer imageServer.Close()
+
+	webServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Images</title></head><body>
+	<img src="` + imageServer.URL + r.URL.Path + `/one.png">
+	<img src="` + imageServer.URL + r.URL.Path + `/two.png">
+</body></html>`))
+	}))
+	defer webServer.Close()
+
+	config := DefaultConfig()
+	config.OllamaBaseURL = ollamaServer.URL
+	s := New(config)
+
+	budget := NewImageBudget(3)
+	ctx := WithImageBudget(context.Background(), budget)
+	var analyzed, unanalyzed int
+	for _, path := range []string{"/first", "/second"} {
+		data, err := s.Scrape(ctx, webServer.URL+path)
+		if err != nil {
+			t.Fatalf("Scrape failed: %v", err)
+		}
+		for _, img := range data.Images {
+			if img.Base64Data == "" {
+				t.Errorf("Image %s was not downloaded", img.URL)
+			}
+			if img.Summary != "" {
+				analyzed++
+			} else {
+				unanalyzed++
+			}
+		}
+	}
+
+	if analyzed != 3 || unanalyzed != 1 || analyses != 3 {
+		t.Errorf("Analyzed %d images (%d vision calls) and left %d, want 3 analyzed and 1 left", analyzed, analyses, unanalyzed)
+	}
+	if budget.Skipped() != 1 {
+		t.Errorf("Skipped() = %d, want 1", budget.Skipped())
+	}
+}
@@ -0,0 +1,140 @@
+package scraper
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+// languageStopwords lists very common words used to guess the language of a text.
+// Keys are ISO 639-1 language codes.
+var languageStopwords = map[string][]string{
+	"en": {"the", "and", "is", "are", "of", "to", "in", "that", "it", "with", "for", "this", "was", "you", "on"},
+	"es": {"el", "la", "los", "las", "de", "que", "y", "en", "un", "una", "es", "por", "con", "para", "del"},
+	"fr": {"le", "la", "les", "de", "des", "et", "est", "un", "une", "que", "dans", "pour", "pas", "sur", "du"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "ein", "eine", "zu", "mit", "den", "von", "sich", "auf", "für"},
+	"it": {"il", "di", "che", "la", "e", "un", "una", "per", "non", "sono", "del", "della", "gli", "con", "le"},
+	"pt": {"o", "a", "os", "as", "de", "que", "e", "um", "uma", "não", "para", "com", "do", "da", "em"},
+	"nl": {"de", "het", "een", "en", "van", "is", "niet", "dat", "op", "te", "zijn", "voor", "met", "die", "ook"},
+}
+
+// minLanguageHits is the minimum number of stopword matches needed to report a language
+const minLanguageHits = 3
+
+// detectLanguage guesses the language of text by counting common stopwords.
+// Returns an ISO 639-1 code, or an empty string if the language is unknown.
+func detectLanguage(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	if len(words) == 0 {
+		return ""
+	}
+
+	counts := make(map[string]int, len(words))
+	for _, word := range words {
+		counts[word]++
+	}
+
+	bestLang, bestHits := "", 0
+	for lang, stopwords := range languageStopwords {
+		hits := 0
+		for _, stopword := range stopwords {
+			hits += counts[stopword]
+		}
+		// Break ties deterministically by language code
+		if hits > bestHits || (hits == bestHits && hits > 0 && lang < bestLang) {
+			bestLang, bestHits = lang, hits
+		}
+	}
+
+	if bestHits < minLanguageHits {
+		return ""
+	}
+	return bestLang
+}
+
+// pageLanguage returns the language of a page from its <html lang>
+// attribute, then its Content-Language header, then detectLanguage over the
+// extracted text. It is empty when none of them names a language. doc is
+// nil for documents other than HTML.
+func pageLanguage(doc *html.Node, contentLanguage, text string) string {
+	if doc != nil {
+		for c := doc.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && c.Data == "html" {
+				if lang := NormalizeLanguage(getAttr(c, "lang")); lang != "" {
+					return lang
+				}
+			}
+		}
+	}
+	// The header may list several audiences' languages; the first is taken
+	first, _, _ := strings.Cut(contentLanguage, ",")
+	if lang := NormalizeLanguage(first); lang != "" {
+		return lang
+	}
+	return detectLanguage(text)
+}
+
+// NormalizeLanguage returns the primary language subtag of a language tag,
+// lowercased (e.g. "en" for "en-US"), or "" if the tag does not name a
+// language, such as "und" (undetermined)
+func NormalizeLanguage(tag string) string {
+	primary, _, _ := strings.Cut(strings.TrimSpace(tag), "-")
+	primary, _, _ = strings.Cut(primary, "_")
+	primary = strings.ToLower(primary)
+	if len(primary) < 2 || len(primary) > 3 {
+		return ""
+	}
+	for _, r := range primary {
+		if r < 'a' || r > 'z' {
+			return ""
+		}
+	}
+	switch primary {
+	case "und", "mul", "mis", "zxx":
+		return ""
+	}
+	return primary
+}
+
+// defaultSpamKeywords maps a language to spam phrases and the number of
+// occurrences allowed before the content is flagged as spam
+var defaultSpamKeywords = map[string]map[string]int{
+	"en": {"click here": 2, "buy now": 2, "limited offer": 1},
+	"es": {"haga clic aquí": 2, "compra ahora": 2, "oferta limitada": 1},
+	"fr": {"cliquez ici": 2, "achetez maintenant": 2, "offre limitée": 1},
+	"de": {"hier klicken": 2, "jetzt kaufen": 2, "begrenztes angebot": 1},
+}
+
+// defaultQualityKeywords maps a language to keywords that indicate technical or educational content
+var defaultQualityKeywords = map[string][]string{
+	"en": {"documentation", "tutorial", "guide", "research", "study", "analysis", "technical"},
+	"es": {"documentación", "tutorial", "guía", "investigación", "estudio", "análisis", "técnico"},
+	"fr": {"documentation", "tutoriel", "guide", "recherche", "étude", "analyse", "technique"},
+	"de": {"dokumentation", "anleitung", "leitfaden", "forschung", "studie", "analyse", "technisch"},
+}
+
+// DefaultSpamKeywords returns a copy of the built-in per-language spam phrases,
+// suitable as a starting point for Config.SpamKeywords
+func DefaultSpamKeywords() map[string]map[string]int {
+	keywords := make(map[string]map[string]int, len(defaultSpamKeywords))
+	for lang, phrases := range defaultSpamKeywords {
+		keywords[lang] = make(map[string]int, len(phrases))
+		for phrase, allowed := range phrases {
+			keywords[lang][phrase] = allowed
+		}
+	}
+	return keywords
+}
+
+// DefaultQualityKeywords returns a copy of the built-in per-language quality keywords,
+// suitable as a starting point for Config.QualityKeywords
+func DefaultQualityKeywords() map[string][]string {
+	keywords := make(map[string][]string, len(defaultQualityKeywords))
+	for lang, words := range defaultQualityKeywords {
+		keywords[lang] = append([]string(nil), words...)
+	}
+	return keywords
+}
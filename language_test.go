@@ -0,0 +1,93 @@
+package scraper
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"english", "The quick brown fox jumps over the lazy dog and it is happy with the result.", "en"},
+		{"spanish", "El perro de la casa es muy grande y la gente del barrio lo quiere para los niños.", "es"},
+		{"french", "Le chat est dans la maison et les enfants ne sont pas là pour le voir du jardin.", "fr"},
+		{"german", "Der Hund ist nicht mit der Katze und die Kinder sind auf dem Weg zu den Eltern.", "de"},
+		{"too short", "Hello", ""},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectLanguage(tt.text); got != tt.want {
+				t.Errorf("detectLanguage(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageLanguage(t *testing.T) {
+	english := "The guide is for you and it is on the shelf with the others."
+	tests := []struct {
+		name            string
+		page            string
+		contentLanguage string
+		text            string
+		want            string
+	}{
+		{"html lang", `<html lang="fr-CA">`, "de", english, "fr"},
+		{"undetermined html lang", `<html lang="und">`, "de-DE, en", english, "de"},
+		{"header", `<html>`, "es", english, "es"},
+		{"detected", `<html lang="">`, "", english, "en"},
+		{"unknown", `<html>`, "", "Hello", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader(tt.page))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := pageLanguage(doc, tt.contentLanguage, tt.text); got != tt.want {
+				t.Errorf("pageLanguage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeLanguage(t *testing.T) {
+	tests := map[string]string{
+		"en":         "en",
+		" EN-us ":    "en",
+		"pt_BR":      "pt",
+		"haw":        "haw",
+		"zh-Hant-TW": "zh",
+		"und":        "",
+		"x-klingon":  "",
+		"english":    "",
+		"e1":         "",
+		"":           "",
+	}
+	for tag, want := range tests {
+		if got := NormalizeLanguage(tag); got != want {
+			t.Errorf("NormalizeLanguage(%q) = %q, want %q", tag, got, want)
+		}
+	}
+}
+
+func TestDefaultKeywordCopies(t *testing.T) {
+	spam := DefaultSpamKeywords()
+	spam["en"]["click here"] = 100
+	if defaultSpamKeywords["en"]["click here"] != 2 {
+		t.Error("Expected DefaultSpamKeywords to return a copy")
+	}
+
+	quality := DefaultQualityKeywords()
+	quality["en"][0] = "changed"
+	if defaultQualityKeywords["en"][0] != "documentation" {
+		t.Error("Expected DefaultQualityKeywords to return a copy")
+	}
+}
@@ -0,0 +1,91 @@
+package scraper
+
+import (
+	"log"
+	"text/template"
+)
+
+// LinkFilterPromptData is the data available to Config.LinkFilterPrompt templates
+type LinkFilterPromptData struct {
+	Title   string   // Page title
+	Content string   // Extracted page content
+	Links   string   // JSON array of the links to filter
+	Include []string // Kinds of links to keep (Config.LinkFilterInclude)
+	Exclude []string // Kinds of links to drop (Config.LinkFilterExclude)
+}
+
+// defaultLinkFilterInclude describes links the default prompt keeps
+var defaultLinkFilterInclude = []string{
+	"Article links (news stories, blog posts, features)",
+	"Opinion pieces and editorials",
+	"Reports, guides, and documentation",
+	"Individual story/content pages",
+	"Links to specific multimedia content (videos, podcasts with their own pages)",
+}
+
+// defaultLinkFilterExclude describes links the default prompt drops
+var defaultLinkFilterExclude = []string{
+	"Advertising/sponsored content links",
+	"Site navigation (home, sections, categories, topics)",
+	"Social media share/follow buttons",
+	"Login/signup/account links",
+	"Footer links (privacy, terms, about, contact, jobs, press)",
+	"Newsletter/subscription prompts",
+	"Cookie/consent notices",
+	"Generic section/category/tag pages (unless they're the main content)",
+	"Search functionality links",
+	"Pagination controls (next, previous, page numbers)",
+	"Internal site tools (print, save, bookmark)",
+	"Related external sites/sister publications",
+	"Comment section links",
+}
+
+// DefaultLinkFilterPrompt is the template used when Config.LinkFilterPrompt is empty
+const DefaultLinkFilterPrompt = `You are a link filtering assistant. Given a list of URLs extracted from a webpage, identify and return ONLY the links that point to substantive content (articles, blog posts, reports, etc.).
+
+INCLUDE:
+{{range .Include}}- {{.}}
+{{end}}
+EXCLUDE:
+{{range .Exclude}}- {{.}}
+{{end}}
+IMPORTANT: If this is a homepage or news aggregator page, it will contain MANY article links - these should ALL be included as they are the primary content. Only filter out the navigation chrome around them.
+
+Page Title: {{.Title}}
+
+Page Content: {{.Content}}
+
+Links to filter:
+{{.Links}}
+
+Return ONLY a JSON array of the filtered URLs. Do not include any explanation or commentary.
+Format: ["url1", "url2", "url3"]`
+
+// defaultLinkFilterTemplate is the parsed DefaultLinkFilterPrompt
+var defaultLinkFilterTemplate = template.Must(template.New("link_filter").Parse(DefaultLinkFilterPrompt))
+
+// DefaultLinkFilterInclude returns a copy of the built-in include guidance,
+// suitable as a starting point for Config.LinkFilterInclude
+func DefaultLinkFilterInclude() []string {
+	return append([]string(nil), defaultLinkFilterInclude...)
+}
+
+// DefaultLinkFilterExclude returns a copy of the built-in exclude guidance,
+// suitable as a starting point for Config.LinkFilterExclude
+func DefaultLinkFilterExclude() []string {
+	return append([]string(nil), defaultLinkFilterExclude...)
+}
+
+// parseLinkFilterPrompt parses a custom link filter prompt, falling back to the
+// default template if it is empty or invalid
+func parseLinkFilterPrompt(prompt string) *template.Template {
+	if prompt == "" {
+		return defaultLinkFilterTemplate
+	}
+	tmpl, err := template.New("link_filter").Parse(prompt)
+	if err != nil {
+		log.Printf("Invalid link filter prompt, using default: %v", err)
+		return defaultLinkFilterTemplate
+	}
+	return tmpl
+}
@@ -0,0 +1,90 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/zombar/scraper/models"
+	"golang.org/x/net/html"
+)
+
+// linkFilterPromptFor runs link extraction against a mock Ollama server and
+// returns the prompt it received
+func linkFilterPromptFor(t *testing.T, config Config) (string, []string) {
+	t.Helper()
+
+	var prompt string
+	ollamaServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req models.OllamaRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		prompt = req.Prompt
+		json.NewEncoder(w).Encode(models.OllamaResponse{Response: `["https://example.com/docs/intro"]`, Done: true})
+	}))
+	defer ollamaServer.Close()
+
+	config.OllamaBaseURL = ollamaServer.URL
+	s := New(config)
+
+	doc, err := html.Parse(strings.NewReader(`<html><body>
+		<a href="/docs/intro">Intro</a><a href="/login">Login</a>
+	</body></html>`))
+	if err != nil {
+		t.Fatalf("Failed to parse HTML: %v", err)
+	}
+	base, _ := url.Parse("https://example.com/")
+
+	links := s.extractLinksWithOllama(context.Background(), doc, base, "Docs Home", "Welcome to the docs")
+	return prompt, links
+}
+
+func TestLinkFilterCustomPrompt(t *testing.T) {
+	config := DefaultConfig()
+	config.LinkFilterPrompt = `Keep documentation pages from "{{.Title}}".
+{{range .Include}}+ {{.}}
+{{end}}Links: {{.Links}}`
+	config.LinkFilterInclude = []string{"API reference pages"}
+
+	prompt, links := linkFilterPromptFor(t, config)
+
+	want := `Keep documentation pages from "Docs Home".
++ API reference pages
+Links: ["https://example.com/docs/intro","https://example.com/login"]`
+	if prompt != want {
+		t.Errorf("Prompt =\n%s\nwant:\n%s", prompt, want)
+	}
+	if len(links) != 1 || links[0] != "https://example.com/docs/intro" {
+		t.Errorf("Unexpected links: %v", links)
+	}
+}
+
+func TestLinkFilterDefaultPrompt(t *testing.T) {
+	config := DefaultConfig()
+	config.LinkFilterExclude = []string{"Forum signature links"}
+
+	prompt, _ := linkFilterPromptFor(t, config)
+
+	for _, want := range []string{
+		"You are a link filtering assistant.",
+		"- Reports, guides, and documentation\n",
+		"EXCLUDE:\n- Forum signature links\n\nIMPORTANT",
+		"Page Title: Docs Home",
+		"Page Content: Welcome to the docs",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("Expected default prompt to contain %q, got:\n%s", want, prompt)
+		}
+	}
+}
+
+func TestParseLinkFilterPromptInvalid(t *testing.T) {
+	if parseLinkFilterPrompt("{{.Title") != defaultLinkFilterTemplate {
+		t.Error("Expected invalid template to fall back to the default")
+	}
+}
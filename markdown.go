@@ -0,0 +1,281 @@
+package scraper
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// OutputFormat selects the format of ScrapedData.Content
+type OutputFormat string
+
+const (
+	// OutputPlain is the main content as plain text
+	OutputPlain OutputFormat = "plain"
+	// OutputMarkdown is the main content converted to Markdown, keeping
+	// headings, links, emphasis, and lists, with links made absolute
+	OutputMarkdown OutputFormat = "markdown"
+)
+
+// ParseOutputFormat parses an output format name; an empty name is OutputPlain
+func ParseOutputFormat(name string) (OutputFormat, bool) {
+	switch format := OutputFormat(name); format {
+	case "":
+		return OutputPlain, true
+	case OutputPlain, OutputMarkdown:
+		return format, true
+	}
+	return "", false
+}
+
+// markdownSkipTags are elements whose content never appears in Markdown output
+var markdownSkipTags = map[string]bool{
+	"head":     true,
+	"script":   true,
+	"style":    true,
+	"noscript": true,
+	"template": true,
+	"svg":      true,
+	"iframe":   true,
+}
+
+// markdownBlockTags are elements rendered as separate paragraphs
+var markdownBlockTags = map[string]bool{
+	"p":          true,
+	"div":        true,
+	"section":    true,
+	"article":    true,
+	"main":       true,
+	"header":     true,
+	"footer":     true,
+	"aside":      true,
+	"nav":        true,
+	"figure":     true,
+	"figcaption": true,
+	"table":      true,
+	"tr":         true,
+	"dl":         true,
+	"dt":         true,
+	"dd":         true,
+	"address":    true,
+}
+
+// whitespacePattern matches runs of whitespace collapsed in HTML rendering
+var whitespacePattern = regexp.MustCompile(`\s+`)
+
+// htmlToMarkdown converts an HTML document to Markdown, preserving headings,
+// paragraphs, lists, links, emphasis, and code. Links are resolved against baseURL.
+func htmlToMarkdown(n *html.Node, baseURL *url.URL) string {
+	m := &markdownConverter{baseURL: baseURL}
+	return normalizeMarkdown(m.convert(n))
+}
+
+// markdownConverter holds state for a single htmlToMarkdown conversion
+type markdownConverter struct {
+	baseURL *url.URL
+}
+
+// convert renders a node and its children. Block elements are surrounded by
+// blank lines, which normalizeMarkdown collapses afterwards.
+func (m *markdownConverter) convert(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return whitespacePattern.ReplaceAllString(n.Data, " ")
+	case html.DocumentNode:
+		return m.children(n)
+	case html.ElementNode:
+	default:
+		return ""
+	}
+
+	if markdownSkipTags[n.Data] {
+		return ""
+	}
+
+	switch n.Data {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		text := strings.TrimSpace(whitespacePattern.ReplaceAllString(m.children(n), " "))
+		if text == "" {
+			return ""
+		}
+		level := int(n.Data[1] - '0')
+		return "\n\n" + strings.Repeat("#", level) + " " + text + "\n\n"
+
+	case "ul", "ol":
+		return "\n\n" + m.list(n) + "\n\n"
+
+	case "blockquote":
+		text := strings.TrimSpace(normalizeMarkdown(m.children(n)))
+		if text == "" {
+			return ""
+		}
+		return "\n\n> " + strings.ReplaceAll(text, "\n", "\n> ") + "\n\n"
+
+	case "pre":
+		return "\n\n```" + codeLanguage(n) + "\n" + strings.Trim(rawText(n), "\n") + "\n```\n\n"
+
+	case "code":
+		text := rawText(n)
+		if strings.TrimSpace(text) == "" {
+			return ""
+		}
+		return "`" + text + "`"
+
+	case "a":
+		text := strings.TrimSpace(m.children(n))
+		href := strings.TrimSpace(getAttr(n, "href"))
+		if text == "" || href == "" || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+			return text
+		}
+		if resolved, err := resolveURL(m.baseURL, href); err == nil {
+			href = resolved
+		}
+		return "[" + text + "](" + href + ")"
+
+	case "strong", "b":
+		return wrapInline(m.children(n), "**")
+
+	case "em", "i":
+		return wrapInline(m.children(n), "*")
+
+	case "br":
+		return "\n"
+
+	case "hr":
+		return "\n\n---\n\n"
+
+	case "li":
+		// Stray list items outside a list
+		return "\n\n- " + strings.TrimSpace(m.children(n)) + "\n\n"
+	}
+
+	if markdownBlockTags[n.Data] {
+		text := strings.TrimSpace(m.children(n))
+		if text == "" {
+			return ""
+		}
+		return "\n\n" + text + "\n\n"
+	}
+
+	return m.children(n)
+}
+
+// children renders all children of a node
+func (m *markdownConverter) children(n *html.Node) string {
+	var buf strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		buf.WriteString(m.convert(c))
+	}
+	return buf.String()
+}
+
+// list renders a ul or ol, indenting continuation lines (including nested
+// lists) under each item's marker
+func (m *markdownConverter) list(n *html.Node) string {
+	var items []string
+	index := 1
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.Data != "li" {
+			continue
+		}
+
+		marker := "- "
+		if n.Data == "ol" {
+			marker = fmt.Sprintf("%d. ", index)
+		}
+		index++
+
+		text := strings.TrimSpace(normalizeMarkdown(m.children(c)))
+		indent := "\n" + strings.Repeat(" ", len(marker))
+		items = append(items, marker+strings.ReplaceAll(text, "\n", indent))
+	}
+	return strings.Join(items, "\n")
+}
+
+// wrapInline wraps inline text with a Markdown delimiter, keeping surrounding
+// whitespace outside the delimiters
+func wrapInline(text, delimiter string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	leading := text[:len(text)-len(strings.TrimLeft(text, " \n"))]
+	trailing := text[len(strings.TrimRight(text, " \n")):]
+	return leading + delimiter + trimmed + delimiter + trailing
+}
+
+// rawText returns the unmodified text content of a node, for code blocks
+func rawText(n *html.Node) string {
+	var buf strings.Builder
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			buf.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			f(c)
+		}
+	}
+	f(n)
+	return buf.String()
+}
+
+// codeLanguage returns the language of a pre block from a "language-*" or
+// "lang-*" class on the pre or its code child
+func codeLanguage(pre *html.Node) string {
+	nodes := []*html.Node{pre}
+	for c := pre.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.Data == "code" {
+			nodes = append(nodes, c)
+		}
+	}
+	for _, node := range nodes {
+		for _, class := range strings.Fields(getAttr(node, "class")) {
+			for _, prefix := range []string{"language-", "lang-"} {
+				if lang, ok := strings.CutPrefix(class, prefix); ok && lang != "" {
+					return lang
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// normalizeMarkdown trims trailing whitespace from lines and collapses runs of
+// blank lines, leaving fenced code blocks untouched
+func normalizeMarkdown(s string) string {
+	var out []string
+	inFence := false
+	blank := true // suppress leading blank lines
+	for _, line := range strings.Split(s, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			out = append(out, strings.TrimSpace(line))
+			blank = false
+			continue
+		}
+		if inFence {
+			out = append(out, line)
+			continue
+		}
+
+		line = strings.TrimRight(line, " \t")
+		if strings.TrimSpace(line) == "" {
+			if !blank {
+				out = append(out, "")
+			}
+			blank = true
+			continue
+		}
+		// Collapsed whitespace can leave a space at the start of a paragraph line
+		if !strings.HasPrefix(line, "  ") {
+			line = strings.TrimLeft(line, " ")
+		}
+		out = append(out, line)
+		blank = false
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
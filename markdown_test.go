@@ -0,0 +1,109 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/zombar/scraper/models"
+	"golang.org/x/net/html"
+)
+
+func TestHTMLToMarkdown(t *testing.T) {
+	htmlContent := `<html><head><title>Ignored</title><style>p { color: red }</style></head>
+<body>
+	<h1>Main   Title</h1>
+	<p>Intro with <strong>bold</strong>, <em>italic</em>, and <code>inline()</code> text.
+	   See <a href="/docs/guide">the guide</a> or <a href="javascript:void(0)">nothing</a>.</p>
+	<script>var ignored = true;</script>
+	<h2>Steps</h2>
+	<ol>
+		<li>First</li>
+		<li>Second
+			<ul><li>Nested <a href="https://other.com/x">link</a></li></ul>
+		</li>
+	</ol>
+	<pre><code class="language-go">func main() {
+
+	fmt.Println("hi")
+}</code></pre>
+</body></html>`
+
+	doc, err := html.Parse(strings.NewReader(htmlContent))
+	if err != nil {
+		t.Fatalf("Failed to parse HTML: %v", err)
+	}
+	base, _ := url.Parse("https://example.com/articles/post")
+
+	got := htmlToMarkdown(doc, base)
+	want := "# Main Title\n\n" +
+		"Intro with **bold**, *italic*, and `inline()` text. See [the guide](https://example.com/docs/guide) or nothing.\n\n" +
+		"## Steps\n\n" +
+		"1. First\n" +
+		"2. Second\n\n" +
+		"   - Nested [link](https://other.com/x)\n\n" +
+		"```go\nfunc main() {\n\n\tfmt.Println(\"hi\")\n}\n```"
+
+	if got != want {
+		t.Errorf("htmlToMarkdown() =\n%s\n\nwant:\n%s", got, want)
+	}
+}
+
+func TestScrapeOutputMarkdown(t *testing.T) {
+	ollamaServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(models.OllamaResponse{Response: "Cleaned content", Done: true})
+	}))
+	defer ollamaServer.Close()
+
+	webServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Notes</title></head><body>
+			<h1>Release Notes</h1>
+			<h2>Changes</h2>
+			<p>Read <a href="/docs/upgrade">the upgrade guide</a> first.</p>
+			<ul><li>Faster builds</li><li>Smaller binaries</li></ul>
+		</body></html>`))
+	}))
+	defer webServer.Close()
+
+	config := DefaultConfig()
+	config.OllamaBaseURL = ollamaServer.URL
+	config.EnableImageAnalysis = false
+	config.OutputFormat = OutputMarkdown
+	s := New(config)
+
+	data, err := s.Scrape(context.Background(), webServer.URL+"/notes")
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+	want := "# Release Notes\n\n" +
+		"## Changes\n\n" +
+		"Read [the upgrade guide](" + webServer.URL + "/docs/upgrade) first.\n\n" +
+		"- Faster builds\n" +
+		"- Smaller binaries"
+	if data.Content != want {
+		t.Errorf("Content =\n%s\n\nwant:\n%s", data.Content, want)
+	}
+	if data.Markdown != "" {
+		t.Errorf("Markdown = %q, want it left to EnableMarkdown", data.Markdown)
+	}
+	if data.WordCount != 12 || data.ReadingTimeSeconds != 4 {
+		t.Errorf("WordCount, ReadingTimeSeconds = %d, %d; want the Markdown content's 12 words and 4 seconds", data.WordCount, data.ReadingTimeSeconds)
+	}
+}
+
+func TestParseOutputFormat(t *testing.T) {
+	tests := map[string]OutputFormat{"": OutputPlain, "plain": OutputPlain, "markdown": OutputMarkdown}
+	for name, want := range tests {
+		if got, ok := ParseOutputFormat(name); !ok || got != want {
+			t.Errorf("ParseOutputFormat(%q) = %q, %v; want %q", name, got, ok, want)
+		}
+	}
+	if _, ok := ParseOutputFormat("html"); ok {
+		t.Error("ParseOutputFormat(html) accepted an unknown format")
+	}
+}
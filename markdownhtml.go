@@ -0,0 +1,327 @@
+package scraper
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// markdownListItemPattern matches a list item marker: "-", "*", or "+" for
+// bullets and "1." style numbers for ordered lists
+var markdownListItemPattern = regexp.MustCompile(`^([-*+]|\d{1,9}\.) +`)
+
+// markdownHeadingPattern matches an ATX heading such as "## Title"
+var markdownHeadingPattern = regexp.MustCompile(`^(#{1,6}) +(.*?)(?: +#+)? *$`)
+
+// codeLanguagePattern matches fence info strings safe to use in a class name
+var codeLanguagePattern = regexp.MustCompile(`^[A-Za-z0-9_+-]+$`)
+
+// MarkdownToHTML renders the Markdown produced by the scraper as HTML:
+// headings, paragraphs, lists, blockquotes, fenced code, rules, links,
+// images, emphasis, and inline code. Line breaks inside a paragraph are kept
+// as <br>. The output is safe to display as-is: raw HTML in the input is
+// escaped rather than passed through, and links and images are only emitted
+// for http, https, mailto, and relative URLs.
+func MarkdownToHTML(markdown string) string {
+	lines := strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n")
+	var b strings.Builder
+	renderMarkdownBlocks(&b, lines, false)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// renderMarkdownBlocks renders a sequence of block-level lines. In a tight
+// list item, paragraphs are written without <p> tags.
+func renderMarkdownBlocks(b *strings.Builder, lines []string, tight bool) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			i++
+
+		case strings.HasPrefix(trimmed, "```"):
+			lang := strings.TrimSpace(strings.TrimPrefix(trimmed, "```"))
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				code = append(code, lines[i])
+			}
+			i++ // closing fence
+			b.WriteString("<pre><code")
+			if codeLanguagePattern.MatchString(lang) {
+				fmt.Fprintf(b, ` class="language-%s"`, lang)
+			}
+			b.WriteString(">")
+			b.WriteString(html.EscapeString(strings.Join(code, "\n")))
+			b.WriteString("</code></pre>\n")
+
+		case markdownHeadingPattern.MatchString(trimmed):
+			m := markdownHeadingPattern.FindStringSubmatch(trimmed)
+			fmt.Fprintf(b, "<h%d>%s</h%d>\n", len(m[1]), renderMarkdownInline(m[2]), len(m[1]))
+			i++
+
+		case isMarkdownRule(trimmed):
+			b.WriteString("<hr>\n")
+			i++
+
+		case strings.HasPrefix(trimmed, ">"):
+			var quoted []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				text := strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")
+				quoted = append(quoted, strings.TrimPrefix(text, " "))
+			}
+			b.WriteString("<blockquote>\n")
+			renderMarkdownBlocks(b, quoted, false)
+			b.WriteString("</blockquote>\n")
+
+		case markdownListItemPattern.MatchString(line):
+			i = renderMarkdownList(b, lines, i)
+
+		default:
+			var paragraph []string
+			for ; i < len(lines) && !startsMarkdownBlock(lines[i]); i++ {
+				paragraph = append(paragraph, renderMarkdownInline(strings.TrimSpace(lines[i])))
+			}
+			if tight {
+				b.WriteString(strings.Join(paragraph, "<br>\n"))
+				b.WriteString("\n")
+			} else {
+				b.WriteString("<p>" + strings.Join(paragraph, "<br>\n") + "</p>\n")
+			}
+		}
+	}
+}
+
+// startsMarkdownBlock reports whether a line ends a paragraph: a blank line
+// or the start of another block
+func startsMarkdownBlock(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed == "" || strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, ">") ||
+		markdownHeadingPattern.MatchString(trimmed) || isMarkdownRule(trimmed) ||
+		markdownListItemPattern.MatchString(line)
+}
+
+// isMarkdownRule reports whether a trimmed line is a thematic break
+func isMarkdownRule(trimmed string) bool {
+	if len(trimmed) < 3 {
+		return false
+	}
+	compact := strings.ReplaceAll(trimmed, " ", "")
+	for _, c := range "-*_" {
+		if strings.Trim(compact, string(c)) == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// renderMarkdownList renders the list starting at lines[start] and returns
+// the index of the first line after it. Lines indented under an item belong
+// to it and are rendered as its nested blocks. Items separated by blank
+// lines, or with several paragraphs, make a loose list whose paragraphs keep
+// their <p> tags.
+func renderMarkdownList(b *strings.Builder, lines []string, start int) int {
+	ordered := !strings.ContainsAny(lines[start][:1], "-*+")
+	var items [][]string
+	loose := false
+	i := start
+	for i < len(lines) {
+		marker := markdownListItemPattern.FindString(lines[i])
+		if marker == "" || strings.ContainsAny(marker[:1], "-*+") == ordered {
+			break
+		}
+		item := []string{lines[i][len(marker):]}
+		for i++; i < len(lines); i++ {
+			line := lines[i]
+			if strings.TrimSpace(line) == "" {
+				// A blank line continues the item only if indented content follows
+				next := i + 1
+				for next < len(lines) && strings.TrimSpace(lines[next]) == "" {
+					next++
+				}
+				if next < len(lines) && strings.HasPrefix(lines[next], " ") {
+					// A nested list after a blank line keeps the list tight
+					item = append(item, "")
+					if !markdownListItemPattern.MatchString(dedent(lines[next], len(marker))) {
+						loose = true
+					}
+					continue
+				}
+				if next < len(lines) {
+					if marker := markdownListItemPattern.FindString(lines[next]); marker != "" && strings.ContainsAny(marker[:1], "-*+") != ordered {
+						loose = true
+					}
+				}
+				i = next
+				break
+			}
+			if !strings.HasPrefix(line, " ") {
+				break
+			}
+			item = append(item, dedent(line, len(marker)))
+		}
+		items = append(items, item)
+	}
+
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+		number, _ := strconv.Atoi(strings.TrimRight(markdownListItemPattern.FindStringSubmatch(lines[start])[1], "."))
+		if number != 1 {
+			fmt.Fprintf(b, "<ol start=\"%d\">\n", number)
+		} else {
+			b.WriteString("<ol>\n")
+		}
+	} else {
+		b.WriteString("<ul>\n")
+	}
+	for _, item := range items {
+		b.WriteString("<li>")
+		var inner strings.Builder
+		renderMarkdownBlocks(&inner, item, !loose)
+		b.WriteString(strings.TrimSuffix(inner.String(), "\n"))
+		b.WriteString("</li>\n")
+	}
+	b.WriteString("</" + tag + ">\n")
+	return i
+}
+
+// dedent removes up to width leading spaces from a line
+func dedent(line string, width int) string {
+	trimmed := strings.TrimLeft(line, " ")
+	if indent := len(line) - len(trimmed); indent > width {
+		return line[width:]
+	}
+	return trimmed
+}
+
+// renderMarkdownInline renders inline Markdown, escaping everything else
+func renderMarkdownInline(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); {
+		switch c := text[i]; {
+		case c == '\\' && i+1 < len(text) && strings.IndexByte("\\`*_{}[]()#+-.!>", text[i+1]) >= 0:
+			b.WriteString(html.EscapeString(text[i+1 : i+2]))
+			i += 2
+			continue
+
+		case c == '`':
+			run := len(text[i:]) - len(strings.TrimLeft(text[i:], "`"))
+			delimiter := text[i : i+run]
+			if end := strings.Index(text[i+run:], delimiter); end >= 0 {
+				code := text[i+run : i+run+end]
+				b.WriteString("<code>" + html.EscapeString(code) + "</code>")
+				i += run + end + run
+				continue
+			}
+			b.WriteString(delimiter)
+			i += run
+			continue
+
+		case c == '!' && strings.HasPrefix(text[i+1:], "["):
+			if label, dest, n, ok := parseMarkdownLink(text[i+1:]); ok {
+				if src, ok := safeMarkdownURL(dest); ok {
+					fmt.Fprintf(&b, `<img src="%s" alt="%s">`, html.EscapeString(src), html.EscapeString(label))
+				} else {
+					b.WriteString(html.EscapeString(label))
+				}
+				i += 1 + n
+				continue
+			}
+
+		case c == '[':
+			if label, dest, n, ok := parseMarkdownLink(text[i:]); ok {
+				if href, ok := safeMarkdownURL(dest); ok {
+					fmt.Fprintf(&b, `<a href="%s">%s</a>`, html.EscapeString(href), renderMarkdownInline(label))
+				} else {
+					b.WriteString(renderMarkdownInline(label))
+				}
+				i += n
+				continue
+			}
+
+		case c == '*':
+			delimiter := "*"
+			tag := "em"
+			if strings.HasPrefix(text[i:], "**") {
+				delimiter, tag = "**", "strong"
+			}
+			rest := text[i+len(delimiter):]
+			if rest != "" && rest[0] != ' ' {
+				if end := strings.Index(rest, delimiter); end > 0 && rest[end-1] != ' ' {
+					fmt.Fprintf(&b, "<%s>%s</%s>", tag, renderMarkdownInline(rest[:end]), tag)
+					i += len(delimiter) + end + len(delimiter)
+					continue
+				}
+			}
+			b.WriteString(delimiter)
+			i += len(delimiter)
+			continue
+		}
+
+		b.WriteString(html.EscapeString(text[i : i+1]))
+		i++
+	}
+	return b.String()
+}
+
+// parseMarkdownLink parses "[label](destination)" at the start of text,
+// returning the label, the destination, and the length consumed
+func parseMarkdownLink(text string) (label, dest string, n int, ok bool) {
+	depth := 0
+	closeLabel := -1
+	for i := 0; i < len(text) && closeLabel < 0; i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			if depth--; depth == 0 {
+				closeLabel = i
+			}
+		}
+	}
+	if closeLabel < 0 || !strings.HasPrefix(text[closeLabel+1:], "(") {
+		return "", "", 0, false
+	}
+
+	depth = 0
+	for i := closeLabel + 1; i < len(text); i++ {
+		switch text[i] {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				dest = strings.TrimSpace(text[closeLabel+2 : i])
+				// Drop an optional "title"
+				if space := strings.IndexByte(dest, ' '); space >= 0 {
+					dest = dest[:space]
+				}
+				return text[1:closeLabel], strings.Trim(dest, "<>"), i + 1, true
+			}
+		}
+	}
+	return "", "", 0, false
+}
+
+// safeMarkdownURL reports whether a link or image destination may be
+// emitted: http, https, and mailto URLs and relative references. Schemes
+// such as javascript: and data: are refused.
+func safeMarkdownURL(dest string) (string, bool) {
+	if dest == "" {
+		return "", false
+	}
+	u, err := url.Parse(dest)
+	if err != nil {
+		return "", false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "", "http", "https", "mailto":
+		return dest, true
+	}
+	return "", false
+}
@@ -0,0 +1,82 @@
+package scraper
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestMarkdownToHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{"heading", "## Steps ##", "<h2>Steps</h2>"},
+		{"paragraphs", "First line\nsecond line\n\nNext paragraph", "<p>First line<br>\nsecond line</p>\n<p>Next paragraph</p>"},
+		{"emphasis", "**bold**, *italic*, and `a < b`", "<p><strong>bold</strong>, <em>italic</em>, and <code>a &lt; b</code></p>"},
+		{"unmatched emphasis", "2 * 3 and *open", "<p>2 * 3 and *open</p>"},
+		{"escapes", `\*not italic\*`, "<p>*not italic*</p>"},
+		{"link", "[the *guide*](https://example.com/docs_(v2) \"Docs\")", `<p><a href="https://example.com/docs_(v2)">the <em>guide</em></a></p>`},
+		{"relative link", "[home](/)", `<p><a href="/">home</a></p>`},
+		{"image", "![A & B](https://example.com/a.png)", `<p><img src="https://example.com/a.png" alt="A &amp; B"></p>`},
+		{"rule", "above\n\n---\n\nbelow", "<p>above</p>\n<hr>\n<p>below</p>"},
+		{"blockquote", "> quoted\n> **text**", "<blockquote>\n<p>quoted<br>\n<strong>text</strong></p>\n</blockquote>"},
+		{"fenced code", "```go\nif a < b {\n\n}\n```", "<pre><code class=\"language-go\">if a &lt; b {\n\n}</code></pre>"},
+		{"unsafe code language", "```\"><script>\nx\n```", "<pre><code>x</code></pre>"},
+		{"bullet list", "- One\n- Two", "<ul>\n<li>One</li>\n<li>Two</li>\n</ul>"},
+		{"ordered list", "3. Three\n4. Four", "<ol start=\"3\">\n<li>Three</li>\n<li>Four</li>\n</ol>"},
+		{"nested list", "1. First\n   - Sub\n2. Second", "<ol>\n<li>First\n<ul>\n<li>Sub</li>\n</ul></li>\n<li>Second</li>\n</ol>"},
+		{"loose list", "- One\n\n- Two", "<ul>\n<li><p>One</p></li>\n<li><p>Two</p></li>\n</ul>"},
+		{"list then paragraph", "- One\n\nAfter", "<ul>\n<li>One</li>\n</ul>\n<p>After</p>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MarkdownToHTML(tt.markdown); got != tt.want {
+				t.Errorf("MarkdownToHTML(%q) =\n%s\nwant\n%s", tt.markdown, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestMarkdownToHTMLSanitizes tests that markup and unsafe URLs in the
+// Markdown never reach the output as live HTML
+func TestMarkdownToHTMLSanitizes(t *testing.T) {
+	tests := map[string]string{
+		`<script>alert(1)</script>`:                 "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>",
+		`<img src=x onerror=alert(1)>`:              "<p>&lt;img src=x onerror=alert(1)&gt;</p>",
+		`[click](javascript:alert(1))`:              "<p>click</p>",
+		`[click](JavaScript:alert(1))`:              "<p>click</p>",
+		"[click](java\tscript:alert(1))":            "<p>click</p>",
+		`![x](data:image/svg+xml;base64,PHN2Zz4=)`:  "<p>x</p>",
+		`[x](https://example.com/"onmouseover="a)`:  `<p><a href="https://example.com/&#34;onmouseover=&#34;a">x</a></p>`,
+		"# <b>Title</b>":                            "<h1>&lt;b&gt;Title&lt;/b&gt;</h1>",
+		"```\n</code></pre><script>x</script>\n```": "<pre><code>&lt;/code&gt;&lt;/pre&gt;&lt;script&gt;x&lt;/script&gt;</code></pre>",
+	}
+	for markdown, want := range tests {
+		if got := MarkdownToHTML(markdown); got != want {
+			t.Errorf("MarkdownToHTML(%q) = %q, want %q", markdown, got, want)
+		}
+	}
+}
+
+// TestMarkdownRoundTrip tests that Markdown from htmlToMarkdown renders back
+// to the page's structure
+func TestMarkdownRoundTrip(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<h1>Title</h1><p>Intro with <a href="/docs">docs</a>.</p>
+		<ul><li>One<ul><li>Nested</li></ul></li><li>Two</li></ul><pre><code class="language-sh">make test</code></pre>`))
+	if err != nil {
+		t.Fatalf("Failed to parse HTML: %v", err)
+	}
+	base, _ := url.Parse("https://example.com/")
+
+	want := "<h1>Title</h1>\n" +
+		"<p>Intro with <a href=\"https://example.com/docs\">docs</a>.</p>\n" +
+		"<ul>\n<li>One\n<ul>\n<li>Nested</li>\n</ul></li>\n<li>Two</li>\n</ul>\n" +
+		"<pre><code class=\"language-sh\">make test</code></pre>"
+	if got := MarkdownToHTML(htmlToMarkdown(doc, base)); got != want {
+		t.Errorf("MarkdownToHTML() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,108 @@
+package scraper
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/zombar/scraper/models"
+	"golang.org/x/net/html"
+)
+
+// Media item types
+const (
+	MediaVideo = "video" // <video> or its <source>
+	MediaAudio = "audio" // <audio> or its <source>
+	MediaEmbed = "embed" // <iframe> player from a known video provider
+)
+
+// videoProviders maps the hosts of embeddable video players (matching
+// subdomains too) to the provider name reported for them
+var videoProviders = map[string]string{
+	"youtube.com":          "youtube",
+	"youtube-nocookie.com": "youtube",
+	"youtu.be":             "youtube",
+	"vimeo.com":            "vimeo",
+	"dailymotion.com":      "dailymotion",
+	"dai.ly":               "dailymotion",
+	"twitch.tv":            "twitch",
+	"wistia.com":           "wistia",
+	"wistia.net":           "wistia",
+	"loom.com":             "loom",
+	"streamable.com":       "streamable",
+	"ted.com":              "ted",
+}
+
+// extractMedia extracts embedded videos and audio from the HTML: HTML5
+// <video> and <audio> elements with their <source> children, and <iframe>
+// players from known video providers. Other iframes, such as ads and
+// widgets, are ignored. URLs are resolved against the base URL and
+// deduplicated.
+func extractMedia(n *html.Node, baseURL *url.URL) []models.MediaItem {
+	var media []models.MediaItem
+	seen := make(map[string]bool)
+
+	add := func(mediaType, src, provider string) {
+		mediaURL, err := resolveURL(baseURL, src)
+		if err != nil || seen[mediaURL] {
+			return
+		}
+		if u, err := url.Parse(mediaURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return // skip data: and blob: sources
+		}
+		seen[mediaURL] = true
+		media = append(media, models.MediaItem{Type: mediaType, URL: mediaURL, Provider: provider})
+	}
+
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "video", "audio":
+				if src := strings.TrimSpace(getAttr(n, "src")); src != "" {
+					add(n.Data, src, "")
+				}
+			case "source":
+				if n.Parent != nil && (n.Parent.Data == "video" || n.Parent.Data == "audio") {
+					if src := strings.TrimSpace(getAttr(n, "src")); src != "" {
+						add(n.Parent.Data, src, "")
+					}
+				}
+			case "iframe":
+				src := strings.TrimSpace(getAttr(n, "src"))
+				if src == "" || src == "about:blank" {
+					// Lazy-loaded players keep the real URL in data-src
+					src = strings.TrimSpace(getAttr(n, "data-src"))
+				}
+				if src == "" {
+					break
+				}
+				if iframeURL, err := resolveURL(baseURL, src); err == nil {
+					if provider := videoProvider(iframeURL); provider != "" {
+						add(MediaEmbed, iframeURL, provider)
+					}
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			f(c)
+		}
+	}
+	f(n)
+	return media
+}
+
+// videoProvider returns the provider name for a video player URL, or "" if
+// the host is not a known video provider
+func videoProvider(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	host := strings.ToLower(u.Hostname())
+	for domain, provider := range videoProviders {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return provider
+		}
+	}
+	return ""
+}
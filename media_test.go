@@ -0,0 +1,66 @@
+package scraper
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zombar/scraper/models"
+	"golang.org/x/net/html"
+)
+
+func TestExtractMedia(t *testing.T) {
+	htmlContent := `<html><body>
+		<iframe src="https://www.youtube.com/embed/dQw4w9WgXcQ" allowfullscreen></iframe>
+		<iframe src="//player.vimeo.com/video/76979871"></iframe>
+		<iframe src="about:blank" data-src="https://www.youtube-nocookie.com/embed/abc123"></iframe>
+		<iframe src="https://ads.example.net/banner.html"></iframe>
+		<iframe src="/widgets/comments"></iframe>
+		<video controls poster="/poster.jpg">
+			<source src="/media/clip.webm" type="video/webm">
+			<source src="/media/clip.mp4" type="video/mp4">
+		</video>
+		<video src="intro.mp4"></video>
+		<video src="blob:https://example.com/1234"></video>
+		<audio src="/media/podcast.mp3"></audio>
+		<picture><source srcset="/hero.webp"></picture>
+		<iframe src="https://www.youtube.com/embed/dQw4w9WgXcQ"></iframe>
+	</body></html>`
+
+	doc, err := html.Parse(strings.NewReader(htmlContent))
+	if err != nil {
+		t.Fatalf("Failed to parse HTML: %v", err)
+	}
+	base, _ := url.Parse("https://example.com/posts/demo")
+
+	want := []models.MediaItem{
+		{Type: MediaEmbed, URL: "https://www.youtube.com/embed/dQw4w9WgXcQ", Provider: "youtube"},
+		{Type: MediaEmbed, URL: "https://player.vimeo.com/video/76979871", Provider: "vimeo"},
+		{Type: MediaEmbed, URL: "https://www.youtube-nocookie.com/embed/abc123", Provider: "youtube"},
+		{Type: MediaVideo, URL: "https://example.com/media/clip.webm"},
+		{Type: MediaVideo, URL: "https://example.com/media/clip.mp4"},
+		{Type: MediaVideo, URL: "https://example.com/posts/intro.mp4"},
+		{Type: MediaAudio, URL: "https://example.com/media/podcast.mp3"},
+	}
+	if got := extractMedia(doc, base); !reflect.DeepEqual(got, want) {
+		t.Errorf("extractMedia() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestVideoProvider(t *testing.T) {
+	tests := map[string]string{
+		"https://www.youtube.com/embed/x":        "youtube",
+		"https://youtu.be/x":                     "youtube",
+		"https://player.vimeo.com/video/1":       "vimeo",
+		"https://fast.wistia.net/embed/iframe/1": "wistia",
+		"https://notyoutube.com/embed/x":         "",
+		"https://youtube.com.evil.example/x":     "",
+		"https://example.com/embed":              "",
+	}
+	for rawURL, want := range tests {
+		if got := videoProvider(rawURL); got != want {
+			t.Errorf("videoProvider(%q) = %q, want %q", rawURL, got, want)
+		}
+	}
+}
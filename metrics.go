@@ -0,0 +1,184 @@
+package scraper
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Metric label values
+const (
+	metricSuccess = "success"
+	metricFailure = "failure"
+)
+
+// scrapeDurationBuckets are the upper bounds (in seconds) of the scrape
+// processing time histogram
+var scrapeDurationBuckets = []float64{0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+// Metrics collects operational metrics for a Scraper and writes them in the
+// Prometheus text exposition format. Methods are safe for concurrent use and
+// on a nil *Metrics, which records nothing.
+type Metrics struct {
+	mu              sync.Mutex
+	scrapes         map[string]uint64 // by result
+	scores          map[string]uint64 // by result
+	ollamaFallbacks map[string]uint64 // by operation
+	scoringShed     map[string]uint64 // by operation
+	imageAnalyses   map[string]uint64 // by result
+	scrapeBuckets   []uint64          // per scrapeDurationBuckets entry, not cumulative
+	scrapeCount     uint64
+	scrapeSum       float64
+}
+
+// newMetrics creates a collector with every series initialized to zero
+func newMetrics() *Metrics {
+	return &Metrics{
+		scrapes:         map[string]uint64{metricSuccess: 0, metricFailure: 0},
+		scores:          map[string]uint64{metricSuccess: 0, metricFailure: 0},
+		ollamaFallbacks: map[string]uint64{"scrape": 0, "score": 0},
+		scoringShed:     map[string]uint64{"scrape": 0, "score": 0},
+		imageAnalyses:   map[string]uint64{metricSuccess: 0, metricFailure: 0},
+		scrapeBuckets:   make([]uint64, len(scrapeDurationBuckets)),
+	}
+}
+
+// resultLabel maps an operation's error to its result label
+func resultLabel(err error) string {
+	if err != nil {
+		return metricFailure
+	}
+	return metricSuccess
+}
+
+// observeScrape records a finished scrape and its processing time
+func (m *Metrics) observeScrape(err error, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.scrapes[resultLabel(err)]++
+	seconds := duration.Seconds()
+	m.scrapeCount++
+	m.scrapeSum += seconds
+	for i, bound := range scrapeDurationBuckets {
+		if seconds <= bound {
+			m.scrapeBuckets[i]++
+			break
+		}
+	}
+}
+
+// observeScore records a finished ScoreLinkContent call
+func (m *Metrics) observeScore(err error) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.scores[resultLabel(err)]++
+}
+
+// observeOllamaFallback records rule-based scoring used in place of Ollama
+func (m *Metrics) observeOllamaFallback(operation string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ollamaFallbacks[operation]++
+}
+
+// observeScoringShed records rule-based scoring used because every scoring
+// slot was busy in ScoringBestEffort mode
+func (m *Metrics) observeScoringShed(operation string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.scoringShed[operation]++
+}
+
+// observeImageAnalysis records an Ollama image analysis attempt
+func (m *Metrics) observeImageAnalysis(err error) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.imageAnalyses[resultLabel(err)]++
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	if m == nil {
+		return 0, nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	writeCounter(cw, "scraper_scrapes_total", "Scrapes by result.", "result", m.scrapes)
+	writeCounter(cw, "scraper_scores_total", "Link scoring requests by result.", "result", m.scores)
+	writeCounter(cw, "scraper_ollama_fallbacks_total", "Rule-based scoring used because Ollama was unavailable, by operation.", "operation", m.ollamaFallbacks)
+	writeCounter(cw, "scraper_scoring_shed_total", "Rule-based scoring used because every Ollama scoring slot was busy, by operation.", "operation", m.scoringShed)
+	writeCounter(cw, "scraper_image_analyses_total", "Ollama image analyses by result.", "result", m.imageAnalyses)
+
+	const name = "scraper_scrape_duration_seconds"
+	fmt.Fprintf(cw, "# HELP %s Scrape processing time in seconds.\n# TYPE %s histogram\n", name, name)
+	var cumulative uint64
+	for i, bound := range scrapeDurationBuckets {
+		cumulative += m.scrapeBuckets[i]
+		fmt.Fprintf(cw, "%s_bucket{le=%q} %d\n", name, strconv.FormatFloat(bound, 'g', -1, 64), cumulative)
+	}
+	fmt.Fprintf(cw, "%s_bucket{le=\"+Inf\"} %d\n", name, m.scrapeCount)
+	fmt.Fprintf(cw, "%s_sum %s\n", name, strconv.FormatFloat(m.scrapeSum, 'g', -1, 64))
+	fmt.Fprintf(cw, "%s_count %d\n", name, m.scrapeCount)
+
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+	return cw.n, cw.err
+}
+
+// writeCounter writes a counter with one label, sorted by label value
+func writeCounter(w io.Writer, name, help, label string, values map[string]uint64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s{%s=%q} %d\n", name, label, key, values[key])
+	}
+}
+
+// countingWriter counts bytes written and remembers the first error
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	cw.err = err
+	return n, err
+}
+
+// Metrics returns the scraper's metrics, or nil if Config.MetricsEnabled is off
+func (s *Scraper) Metrics() *Metrics {
+	return s.metrics
+}
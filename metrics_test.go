@@ -0,0 +1,109 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsWriteTo(t *testing.T) {
+	m := newMetrics()
+	m.observeScrape(nil, 700*time.Millisecond)
+	m.observeScrape(errors.New("failed"), 45*time.Second)
+	m.observeScore(nil)
+	m.observeOllamaFallback("scrape")
+	m.observeScoringShed("score")
+	m.observeImageAnalysis(nil)
+	m.observeImageAnalysis(errors.New("failed"))
+
+	var buf strings.Builder
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"# TYPE scraper_scrapes_total counter",
+		`scraper_scrapes_total{result="success"} 1`,
+		`scraper_scrapes_total{result="failure"} 1`,
+		`scraper_scores_total{result="success"} 1`,
+		`scraper_scores_total{result="failure"} 0`,
+		`scraper_ollama_fallbacks_total{operation="scrape"} 1`,
+		`scraper_ollama_fallbacks_total{operation="score"} 0`,
+		`scraper_scoring_shed_total{operation="scrape"} 0`,
+		`scraper_scoring_shed_total{operation="score"} 1`,
+		`scraper_image_analyses_total{result="success"} 1`,
+		`scraper_image_analyses_total{result="failure"} 1`,
+		"# TYPE scraper_scrape_duration_seconds histogram",
+		`scraper_scrape_duration_seconds_bucket{le="0.5"} 0`,
+		`scraper_scrape_duration_seconds_bucket{le="1"} 1`,
+		`scraper_scrape_duration_seconds_bucket{le="30"} 1`,
+		`scraper_scrape_duration_seconds_bucket{le="60"} 2`,
+		`scraper_scrape_duration_seconds_bucket{le="+Inf"} 2`,
+		"scraper_scrape_duration_seconds_sum 45.7",
+		"scraper_scrape_duration_seconds_count 2",
+	} {
+		if !strings.Contains(out, want+"\n") {
+			t.Errorf("Expected %q in metrics output:\n%s", want, out)
+		}
+	}
+}
+
+func TestMetricsNilSafe(t *testing.T) {
+	var m *Metrics
+	m.observeScrape(nil, time.Second)
+	m.observeScore(nil)
+	m.observeOllamaFallback("scrape")
+	m.observeImageAnalysis(nil)
+	if n, err := m.WriteTo(&strings.Builder{}); n != 0 || err != nil {
+		t.Errorf("WriteTo on nil metrics = %d, %v; want 0, nil", n, err)
+	}
+
+	if New(DefaultConfig()).Metrics() != nil {
+		t.Error("Expected no metrics unless enabled")
+	}
+}
+
+func TestScrapeRecordsMetrics(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Metrics</title></head><body><p>Content</p></body></html>`))
+	}))
+	defer ts.Close()
+
+	config := DefaultConfig()
+	config.EnableImageAnalysis = false
+	config.OllamaBaseURL = "http://127.0.0.1:1" // unreachable, forcing the rule-based fallback
+	config.MetricsEnabled = true
+	s := New(config)
+
+	if _, err := s.Scrape(context.Background(), ts.URL); err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+	if _, err := s.Scrape(context.Background(), "ftp://example.com"); err == nil {
+		t.Fatal("Expected scrape of ftp URL to fail")
+	}
+	if _, err := s.ScoreLinkContent(context.Background(), ts.URL); err != nil {
+		t.Fatalf("ScoreLinkContent failed: %v", err)
+	}
+
+	var buf strings.Builder
+	s.Metrics().WriteTo(&buf)
+	out := buf.String()
+	for _, want := range []string{
+		`scraper_scrapes_total{result="success"} 1`,
+		`scraper_scrapes_total{result="failure"} 1`,
+		`scraper_scores_total{result="success"} 1`,
+		`scraper_ollama_fallbacks_total{operation="scrape"} 1`,
+		`scraper_ollama_fallbacks_total{operation="score"} 1`,
+		"scraper_scrape_duration_seconds_count 2",
+	} {
+		if !strings.Contains(out, want+"\n") {
+			t.Errorf("Expected %q in metrics output:\n%s", want, out)
+		}
+	}
+}
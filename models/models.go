@@ -4,18 +4,66 @@ import "time"
 
 // ScrapedData represents the complete output of a web scraping operation
 type ScrapedData struct {
-	ID             string       `json:"id"`
-	URL            string       `json:"url"`
-	Title          string       `json:"title"`
-	Content        string       `json:"content"`
-	Images         []ImageInfo  `json:"images"`
-	Links          []string     `json:"links"`
-	FetchedAt      time.Time    `json:"fetched_at"`
-	CreatedAt      time.Time    `json:"created_at"`
-	ProcessingTime float64      `json:"processing_time_seconds"`
-	Cached         bool         `json:"cached"`
-	Metadata       PageMetadata `json:"metadata"`
-	Score          *LinkScore   `json:"score,omitempty"` // Quality score for the URL
+	ID                 string       `json:"id"`
+	URL                string       `json:"url"`
+	FinalURL           string       `json:"final_url,omitempty"`     // URL after following redirects
+	StatusCode         int          `json:"status_code,omitempty"`   // HTTP status of the final response
+	CanonicalURL       string       `json:"canonical_url,omitempty"` // From <link rel="canonical">
+	Title              string       `json:"title"`
+	Content            string       `json:"content"`
+	RawContent         string       `json:"raw_content,omitempty"`          // Page text before Ollama cleaned it, on request only; never stored
+	ContentType        string       `json:"content_type,omitempty"`         // "text/html", "application/pdf", "text/plain", or "application/json"; empty on older records
+	WordCount          int          `json:"word_count,omitempty"`           // Words in Content
+	ReadingTimeSeconds int          `json:"reading_time_seconds,omitempty"` // Estimated reading time of Content at 200 words per minute
+	Language           string       `json:"language,omitempty"`             // ISO 639 code such as "en", empty when unknown
+	Markdown           string       `json:"markdown,omitempty"`             // Page converted to Markdown (when enabled)
+	Headings           []Heading    `json:"headings,omitempty"`             // h1-h6 outline in document order
+	Images             []ImageInfo  `json:"images"`
+	ImagesTotal        int          `json:"images_total,omitempty"` // Total images when Images holds one page of them
+	Media              []MediaItem  `json:"media,omitempty"`        // Embedded videos and audio
+	Links              []string     `json:"links"`
+	FilteredLinks      []string     `json:"filtered_links,omitempty"` // Links the Ollama link filter kept, when Links holds them all; only these are stored
+	FetchedAt          time.Time    `json:"fetched_at"`
+	CreatedAt          time.Time    `json:"created_at"`
+	ProcessingTime     float64      `json:"processing_time_seconds"`
+	Timings            *Timings     `json:"timings,omitempty"` // Per-phase breakdown of ProcessingTime
+	Cached             bool         `json:"cached"`
+	Metadata           PageMetadata `json:"metadata"`
+	Score              *LinkScore   `json:"score,omitempty"`  // Quality score for the URL
+	Failed             bool         `json:"failed,omitempty"` // The scrape failed; only URL, Error, StatusCode, and timestamps are set
+	Error              string       `json:"error,omitempty"`  // Why a failed scrape failed
+	Debug              *DebugInfo   `json:"_debug,omitempty"` // Ollama prompts and responses, in API debug mode only; never stored
+}
+
+// DebugInfo is debugging output for a single scrape
+type DebugInfo struct {
+	OllamaExchanges []OllamaExchange `json:"ollama_exchanges"` // In the order the requests finished
+}
+
+// OllamaExchange is one request made to Ollama: the prompt sent and the
+// model's raw answer, before any cleanup or JSON parsing
+type OllamaExchange struct {
+	Model    string      `json:"model"`
+	Prompt   string      `json:"prompt"`
+	Format   interface{} `json:"format,omitempty"` // As in OllamaRequest
+	Images   int         `json:"images,omitempty"` // Images sent with a vision request (their data is left out)
+	Response string      `json:"response"`
+	Error    string      `json:"error,omitempty"` // Why the request failed, in which case Response is empty
+	Duration float64     `json:"duration_seconds"`
+}
+
+// Heading is an entry in a page's heading outline
+type Heading struct {
+	Level int    `json:"level"` // 1-6, from h1-h6
+	Text  string `json:"text"`
+}
+
+// Timings breaks down scrape processing time by pipeline phase (in seconds)
+type Timings struct {
+	FetchTime   float64 `json:"fetch_seconds"`   // HTTP request and HTML parsing
+	ExtractTime float64 `json:"extract_seconds"` // Content extraction, link filtering, and metadata
+	ImageTime   float64 `json:"image_seconds"`   // Image download and analysis
+	ScoreTime   float64 `json:"score_seconds"`   // Content quality scoring
 }
 
 // ImageInfo contains information about an extracted image
@@ -26,6 +74,18 @@ type ImageInfo struct {
 	Summary    string   `json:"summary"`
 	Tags       []string `json:"tags"`
 	Base64Data string   `json:"base64_data,omitempty"` // Base64 encoded image data
+	Hash       string   `json:"hash,omitempty"`        // SHA-256 of the decoded pixels (or raw bytes if undecodable)
+	OCRText    string   `json:"ocr_text,omitempty"`    // Text visible in the image, transcribed verbatim
+	// ThumbnailData is a JPEG thumbnail stored alongside the image and served
+	// by GET /api/images/{id}/thumbnail; it is not included in JSON output
+	ThumbnailData []byte `json:"-"`
+}
+
+// MediaItem is an embedded video or audio source
+type MediaItem struct {
+	Type     string `json:"type"`               // "video" or "audio" for HTML5 media, "embed" for a video provider iframe
+	URL      string `json:"url"`                // Absolute URL of the media file or player
+	Provider string `json:"provider,omitempty"` // Video provider of an embed, e.g. "youtube"
 }
 
 // PageMetadata contains additional metadata about the scraped page
@@ -34,14 +94,42 @@ type PageMetadata struct {
 	Keywords      []string `json:"keywords,omitempty"`
 	Author        string   `json:"author,omitempty"`
 	PublishedDate string   `json:"published_date,omitempty"`
+	Type          string   `json:"type,omitempty"`          // og:type (e.g. "article")
+	ImageURL      string   `json:"image_url,omitempty"`     // og:image or twitter:image
+	SiteName      string   `json:"site_name,omitempty"`     // og:site_name
+	OGTitle       string   `json:"og_title,omitempty"`      // og:title, which may differ from the <title>
+	OGImage       string   `json:"og_image,omitempty"`      // og:image only, where ImageURL also falls back to twitter:image
+	TwitterCard   string   `json:"twitter_card,omitempty"`  // twitter:card (e.g. "summary_large_image")
+	TwitterImage  string   `json:"twitter_image,omitempty"` // twitter:image
+	FetchMethod   string   `json:"fetch_method,omitempty"`  // "http", or "rendered" if re-fetched with JS rendering
+	// Charset is the character encoding the page was decoded with. When the
+	// declared and sniffed charsets disagree, both are recorded.
+	Charset         string `json:"charset,omitempty"`
+	DeclaredCharset string `json:"declared_charset,omitempty"` // From the Content-Type header or a <meta> tag
+	SniffedCharset  string `json:"sniffed_charset,omitempty"`  // From a byte order mark or the bytes themselves
+	// ThirdPartyHosts lists the other sites the page loads scripts, images, or iframes from
+	ThirdPartyHosts []string `json:"third_party_hosts,omitempty"`
+	TrackerCount    int      `json:"tracker_count,omitempty"` // ThirdPartyHosts on known analytics and advertising domains
+	// StructuredData holds JSON-LD objects keyed by their schema.org @type
+	StructuredData map[string]interface{} `json:"structured_data,omitempty"`
 }
 
 // OllamaRequest represents a request to the Ollama API
 type OllamaRequest struct {
-	Model  string `json:"model"`
-	Prompt string `json:"prompt"`
-	Stream bool   `json:"stream"`
-	Format string `json:"format,omitempty"`
+	Model   string         `json:"model"`
+	Prompt  string         `json:"prompt"`
+	Stream  bool           `json:"stream"`
+	Format  interface{}    `json:"format,omitempty"` // "json", or a JSON schema the answer must match (Ollama 0.5 and later)
+	Options *OllamaOptions `json:"options,omitempty"`
+}
+
+// OllamaOptions are sampling options for an Ollama request; unset fields use
+// the model's defaults
+type OllamaOptions struct {
+	Temperature *float64 `json:"temperature,omitempty"` // Pointer so that 0, the most deterministic setting, can be sent
+	TopP        *float64 `json:"top_p,omitempty"`
+	NumPredict  int      `json:"num_predict,omitempty"` // Maximum tokens to generate (0 for the model's default)
+	Seed        *int     `json:"seed,omitempty"`
 }
 
 // OllamaResponse represents a response from the Ollama API
@@ -62,18 +150,22 @@ type OllamaVisionRequest struct {
 
 // LinkScore represents a scored link with quality assessment
 type LinkScore struct {
-	URL               string   `json:"url"`
-	Score             float64  `json:"score"`              // 0.0 to 1.0, higher is better quality
-	Reason            string   `json:"reason"`             // Explanation for the score
-	Categories        []string `json:"categories"`         // Detected categories (e.g., "social_media", "spam")
-	IsRecommended       bool     `json:"is_recommended"`     // Whether the link is recommended for ingestion
-	MaliciousIndicators []string `json:"malicious_indicators,omitempty"` // Any detected malicious patterns
-	AIUsed              bool     `json:"ai_used"`            // Whether AI (Ollama) was used for scoring (true) or rule-based fallback (false)
+	URL                 string    `json:"url"`
+	Score               float64   `json:"score"`                          // 0.0 to 1.0, higher is better quality
+	Reason              string    `json:"reason"`                         // Explanation for the score
+	Categories          []string  `json:"categories"`                     // Detected categories (e.g., "social_media", "spam")
+	IsRecommended       bool      `json:"is_recommended"`                 // Whether the link is recommended for ingestion
+	MaliciousIndicators []string  `json:"malicious_indicators,omitempty"` // Any detected malicious patterns
+	AIUsed              bool      `json:"ai_used"`                        // Whether AI (Ollama) was used for scoring (true) or rule-based fallback (false)
+	ScoredAt            time.Time `json:"scored_at,omitzero"`             // When the score was computed; unset in ScrapedData until a re-score, meaning FetchedAt
+	ScoringPath         string    `json:"scoring_path,omitempty"`         // How the score was computed: "ai", "rule_only", "shed" (Ollama busy), "fallback" (Ollama failed), or "deferred" (provisional)
 }
 
 // ScoreRequest represents a request to score a URL
 type ScoreRequest struct {
-	URL string `json:"url"`
+	URL         string   `json:"url"`
+	Model       string   `json:"model,omitempty"`       // Ollama model for this request, from the server's allowed models
+	Temperature *float64 `json:"temperature,omitempty"` // Ollama sampling temperature for this request, 0-2
 }
 
 // ScoreResponse represents a response containing link score
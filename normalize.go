@@ -0,0 +1,166 @@
+package scraper
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// combiningClassRange assigns a canonical combining class to a range of characters
+type combiningClassRange struct {
+	lo, hi rune
+	class  uint8
+}
+
+// Hangul syllable constants from the Unicode standard (section 3.12)
+const (
+	hangulSBase  = 0xAC00
+	hangulLBase  = 0x1100
+	hangulVBase  = 0x1161
+	hangulTBase  = 0x11A7
+	hangulLCount = 19
+	hangulVCount = 21
+	hangulTCount = 28
+	hangulNCount = hangulVCount * hangulTCount
+	hangulSCount = hangulLCount * hangulNCount
+)
+
+// textReplacer maps no-break spaces to regular spaces and removes zero-width
+// characters. Zero-width spaces mark word breaks and become spaces; joiners,
+// word joiners, soft hyphens, and byte order marks sit inside words and are
+// dropped so they do not split them.
+var textReplacer = strings.NewReplacer(
+	"\u00A0", " ", // no-break space
+	"\u2007", " ", // figure space
+	"\u202F", " ", // narrow no-break space
+	"\u200B", " ", // zero-width space
+	"\u200C", "", // zero-width non-joiner
+	"\u200D", "", // zero-width joiner
+	"\u2060", "", // word joiner
+	"\u00AD", "", // soft hyphen
+	"\uFEFF", "", // byte order mark
+)
+
+// normalizeText applies Unicode NFC normalization and replaces no-break and
+// zero-width characters, so that visually identical text compares equal
+func normalizeText(text string) string {
+	return nfc(textReplacer.Replace(text))
+}
+
+// nfc returns the text in Unicode Normalization Form C
+func nfc(text string) string {
+	if isASCII(text) {
+		return text
+	}
+
+	var b strings.Builder
+	b.Grow(len(text))
+	for _, r := range compose(canonicalOrder(decompose(text))) {
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// isASCII reports whether the text is entirely ASCII, which is always in NFC
+func isASCII(text string) bool {
+	for i := 0; i < len(text); i++ {
+		if text[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// decompose returns the full canonical decomposition of the text
+func decompose(text string) []rune {
+	runes := make([]rune, 0, len(text))
+	for _, r := range text {
+		if s := r - hangulSBase; s >= 0 && s < hangulSCount {
+			runes = append(runes, hangulLBase+s/hangulNCount, hangulVBase+(s%hangulNCount)/hangulTCount)
+			if t := s % hangulTCount; t != 0 {
+				runes = append(runes, hangulTBase+t)
+			}
+			continue
+		}
+		if d, ok := canonicalDecompositions[r]; ok {
+			runes = append(runes, []rune(d)...)
+			continue
+		}
+		runes = append(runes, r)
+	}
+	return runes
+}
+
+// canonicalOrder stably sorts each run of combining marks by combining class
+func canonicalOrder(runes []rune) []rune {
+	for i := 0; i < len(runes); {
+		if combiningClass(runes[i]) == 0 {
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && combiningClass(runes[j]) != 0 {
+			j++
+		}
+		run := runes[i:j]
+		sort.SliceStable(run, func(a, b int) bool {
+			return combiningClass(run[a]) < combiningClass(run[b])
+		})
+		i = j
+	}
+	return runes
+}
+
+// compose canonically composes decomposed, canonically ordered text
+func compose(runes []rune) []rune {
+	if len(runes) == 0 {
+		return runes
+	}
+
+	starterPos := 0
+	lastClass := int(combiningClass(runes[0]))
+	if lastClass != 0 {
+		// Text starting with a combining mark has no starter to compose with
+		lastClass = 256
+	}
+	out := 1
+	for _, r := range runes[1:] {
+		class := int(combiningClass(r))
+		if composite, ok := composePair(runes[starterPos], r); ok && (lastClass < class || lastClass == 0) {
+			runes[starterPos] = composite
+			continue
+		}
+		if class == 0 {
+			starterPos = out
+		}
+		lastClass = class
+		runes[out] = r
+		out++
+	}
+	return runes[:out]
+}
+
+// composePair returns the primary composite of two characters, if any
+func composePair(a, b rune) (rune, bool) {
+	// Hangul leading consonant + vowel
+	if l, v := a-hangulLBase, b-hangulVBase; l >= 0 && l < hangulLCount && v >= 0 && v < hangulVCount {
+		return hangulSBase + (l*hangulVCount+v)*hangulTCount, true
+	}
+	// Hangul LV syllable + trailing consonant
+	if s, t := a-hangulSBase, b-hangulTBase; s >= 0 && s < hangulSCount && s%hangulTCount == 0 && t > 0 && t < hangulTCount {
+		return a + t, true
+	}
+	composite, ok := canonicalCompositions[[2]rune{a, b}]
+	return composite, ok
+}
+
+// combiningClass returns the canonical combining class of a character
+func combiningClass(r rune) uint8 {
+	i := sort.Search(len(combiningClassRanges), func(i int) bool {
+		return combiningClassRanges[i].hi >= r
+	})
+	if i < len(combiningClassRanges) && combiningClassRanges[i].lo <= r {
+		return combiningClassRanges[i].class
+	}
+	return 0
+}
@@ -0,0 +1,64 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNormalizeText(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"ASCII unchanged", "plain text", "plain text"},
+		{"no-break spaces", "10\u00a0km and 5\u202fkg", "10 km and 5 kg"},
+		{"combining accent", "Cafe\u0301 cre\u0300me", "Caf\u00e9 cr\u00e8me"},
+		{"precomposed unchanged", "Caf\u00e9", "Caf\u00e9"},
+		{"combining marks reordered", "e\u0302\u0323", "\u1ec7"},
+		{"composite with extra mark", "\u00ea\u0323", "\u1ec7"},
+		{"blocked mark stays separate", "a\u0328\u0301", "\u0105\u0301"},
+		{"singleton decomposition", "\u212b", "\u00c5"},
+		{"Hangul jamo", "\u1100\u1161\u11a8", "\uac01"},
+		{"zero-width space", "word\u200bbreak", "word break"},
+		{"zero-width joiner and soft hyphen", "co\u00adop\u200deration\ufeff", "cooperation"},
+		{"leading combining mark", "\u0301e", "\u0301e"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeText(tt.input); got != tt.want {
+				t.Errorf("normalizeText(%+q) = %+q, want %+q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScrapeNormalizesUnicode(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("<html><body><p>Cafe\u0301&nbsp;menu</p></body></html>"))
+	}))
+	defer ts.Close()
+
+	config := DefaultConfig()
+	config.EnableImageAnalysis = false
+	data, err := New(config).Scrape(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+	if data.Content != "Caf\u00e9 menu" {
+		t.Errorf("Content = %+q, want normalized text", data.Content)
+	}
+
+	config.NormalizeUnicode = false
+	data, err = New(config).Scrape(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+	if data.Content != "Cafe\u0301\u00a0menu" {
+		t.Errorf("Content = %+q, want text unchanged", data.Content)
+	}
+}
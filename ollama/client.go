@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/zombar/scraper/models"
@@ -17,38 +18,149 @@ const (
 	DefaultBaseURL = "http://localhost:11434"
 	DefaultModel   = "llama3.2"
 	DefaultTimeout = 120 * time.Second
+	// DefaultScoreContentChars is how much page text ScoreContent includes
+	// in its prompt unless SetScoreContentChars changes it
+	DefaultScoreContentChars = 1000
 )
 
+// jsonReprompt is appended to the scoring prompt when the first answer was
+// not valid JSON
+const jsonReprompt = `
+
+Your previous answer could not be parsed. Respond with ONLY the JSON object described above: no explanation, no markdown, no other text.`
+
 // Client is a client for interacting with Ollama
 type Client struct {
-	baseURL    string
-	httpClient *http.Client
-	model      string
+	baseURL           string
+	httpClient        *http.Client
+	model             string
+	options           *models.OllamaOptions
+	structuredOptions *models.OllamaOptions
+	useSchemas        bool
+	scoreContentChars int
+}
+
+// ScoreSchema is the JSON schema ScoreContent asks the model to answer with
+// when schemas are enabled
+var ScoreSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"score":                map[string]interface{}{"type": "number", "minimum": 0, "maximum": 1},
+		"reason":               map[string]interface{}{"type": "string"},
+		"categories":           map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		"malicious_indicators": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+	},
+	"required": []string{"score", "reason", "categories", "malicious_indicators"},
+}
+
+// URLListSchema is the JSON schema for an answer that is a list of URLs
+var URLListSchema = map[string]interface{}{
+	"type":  "array",
+	"items": map[string]interface{}{"type": "string"},
+}
+
+// DefaultStructuredOptions returns the sampling options used for tasks that
+// must answer in JSON, such as scoring and link filtering: temperature 0 and
+// a fixed seed, so that the same page gets the same answer across runs
+func DefaultStructuredOptions() *models.OllamaOptions {
+	temperature, seed := 0.0, 42
+	return &models.OllamaOptions{Temperature: &temperature, Seed: &seed}
 }
 
 // NewClient creates a new Ollama client
 func NewClient(baseURL, model string) *Client {
+	return NewClientWithHTTPClient(baseURL, model, nil)
+}
+
+// NewClientWithHTTPClient creates a new Ollama client that sends its requests
+// with httpClient, for custom transports, proxies, or TLS settings. A nil
+// httpClient uses one with DefaultTimeout.
+func NewClientWithHTTPClient(baseURL, model string, httpClient *http.Client) *Client {
 	if baseURL == "" {
 		baseURL = DefaultBaseURL
 	}
 	if model == "" {
 		model = DefaultModel
 	}
-	return &Client{
-		baseURL: baseURL,
-		httpClient: &http.Client{
+	if httpClient == nil {
+		httpClient = &http.Client{
 			Timeout: DefaultTimeout,
-		},
-		model: model,
+		}
+	}
+	return &Client{
+		baseURL:           baseURL,
+		httpClient:        httpClient,
+		model:             model,
+		structuredOptions: DefaultStructuredOptions(),
+		scoreContentChars: DefaultScoreContentChars,
+	}
+}
+
+// SetOptions sets the sampling options for free-form generation, such as
+// content extraction, and for structured JSON tasks. A nil options uses the
+// model's defaults; a nil structured uses DefaultStructuredOptions.
+func (c *Client) SetOptions(options, structured *models.OllamaOptions) {
+	if structured == nil {
+		structured = DefaultStructuredOptions()
+	}
+	c.options = options
+	c.structuredOptions = structured
+}
+
+// SetJSONSchemas makes GenerateStructured send its schema as the request
+// format instead of plain "json". Schemas need Ollama 0.5 or later.
+func (c *Client) SetJSONSchemas(enabled bool) {
+	c.useSchemas = enabled
+}
+
+// SetScoreContentChars sets how many bytes of page text ScoreContent
+// includes in its prompt, keeping it well within the model's context window.
+// Zero uses DefaultScoreContentChars and a negative value includes it all.
+func (c *Client) SetScoreContentChars(n int) {
+	if n == 0 {
+		n = DefaultScoreContentChars
 	}
+	c.scoreContentChars = n
 }
 
 // Generate sends a text generation request to Ollama
 func (c *Client) Generate(ctx context.Context, prompt string) (string, error) {
+	return c.generate(ctx, prompt, nil, c.options)
+}
+
+// GenerateStructured sends a text generation request for a prompt that asks
+// for a JSON answer, using the structured sampling options. Ollama is asked
+// for JSON output, constrained to schema when schemas are enabled. Code
+// fences and prose around the JSON value are removed from the answer.
+func (c *Client) GenerateStructured(ctx context.Context, prompt string, schema interface{}) (string, error) {
+	var format interface{} = "json"
+	if c.useSchemas && schema != nil {
+		format = schema
+	}
+	response, err := c.generate(ctx, prompt, format, c.structuredOptions)
+	if err != nil {
+		return "", err
+	}
+	return extractJSON(response), nil
+}
+
+// generate sends a text generation request with the given output format and
+// sampling options, unless the context overrides them (see WithOverride)
+func (c *Client) generate(ctx context.Context, prompt string, format interface{}, options *models.OllamaOptions) (response string, err error) {
+	model, options := applyOverride(ctx, c.model, options)
+	if t := transcriptFromContext(ctx); t != nil {
+		start := time.Now()
+		defer func() {
+			t.record(models.OllamaExchange{Model: model, Prompt: prompt, Format: format}, response, err, start)
+		}()
+	}
+
 	reqBody := models.OllamaRequest{
-		Model:  c.model,
-		Prompt: prompt,
-		Stream: false,
+		Model:   model,
+		Prompt:  prompt,
+		Stream:  false,
+		Format:  format,
+		Options: options,
 	}
 
 	jsonData, err := json.Marshal(reqBody)
@@ -82,7 +194,14 @@ func (c *Client) Generate(ctx context.Context, prompt string) (string, error) {
 }
 
 // GenerateWithVision sends a vision request to Ollama with an image
-func (c *Client) GenerateWithVision(ctx context.Context, prompt string, imageData []byte) (string, error) {
+func (c *Client) GenerateWithVision(ctx context.Context, prompt string, imageData []byte) (response string, err error) {
+	if t := transcriptFromContext(ctx); t != nil {
+		start := time.Now()
+		defer func() {
+			t.record(models.OllamaExchange{Model: c.model, Prompt: prompt, Images: 1}, response, err, start)
+		}()
+	}
+
 	// Base64 encode the image
 	encodedImage := base64.StdEncoding.EncodeToString(imageData)
 
@@ -137,16 +256,35 @@ Extracted content:`, rawText)
 	return c.Generate(ctx, prompt)
 }
 
+// ImageAnalysis is the result of analyzing an image with Ollama vision
+type ImageAnalysis struct {
+	Summary string
+	Tags    []string
+	Text    string // Legible text in the image, transcribed verbatim (empty if none)
+}
+
 // AnalyzeImage uses Ollama vision to generate a summary and tags for an image
 func (c *Client) AnalyzeImage(ctx context.Context, imageData []byte, altText string) (summary string, tags []string, err error) {
+	analysis, err := c.AnalyzeImageDetailed(ctx, imageData, altText)
+	if err != nil {
+		return "", nil, err
+	}
+	return analysis.Summary, analysis.Tags, nil
+}
+
+// AnalyzeImageDetailed uses Ollama vision to generate a summary and tags for an
+// image and to transcribe any text visible in it
+func (c *Client) AnalyzeImageDetailed(ctx context.Context, imageData []byte, altText string) (*ImageAnalysis, error) {
 	prompt := `Analyze this image and provide:
 1. A 4-5 sentence summary describing what you see
 2. A list of 5-10 relevant tags for categorizing the image
+3. Any legible text in the image (signs, captions, labels, chart text, screenshots), transcribed verbatim. Use an empty string if there is no text.
 
 Format your response as JSON with the following structure:
 {
   "summary": "Your 4-5 sentence description here",
-  "tags": ["tag1", "tag2", "tag3"]
+  "tags": ["tag1", "tag2", "tag3"],
+  "text": "Exact text from the image, or empty"
 }`
 
 	if altText != "" {
@@ -155,7 +293,7 @@ Format your response as JSON with the following structure:
 
 	response, err := c.GenerateWithVision(ctx, prompt, imageData)
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to analyze image: %w", err)
+		return nil, fmt.Errorf("failed to analyze image: %w", err)
 	}
 
 	// Strip markdown code blocks if present
@@ -165,14 +303,19 @@ Format your response as JSON with the following structure:
 	var result struct {
 		Summary string   `json:"summary"`
 		Tags    []string `json:"tags"`
+		Text    string   `json:"text"`
 	}
 
 	if err := json.Unmarshal([]byte(response), &result); err != nil {
-		// If JSON parsing fails, try to extract manually
-		return response, []string{}, nil
+		// If JSON parsing fails, use the raw response as the summary
+		return &ImageAnalysis{Summary: response, Tags: []string{}}, nil
 	}
 
-	return result.Summary, result.Tags, nil
+	return &ImageAnalysis{
+		Summary: result.Summary,
+		Tags:    result.Tags,
+		Text:    strings.TrimSpace(result.Text),
+	}, nil
 }
 
 // stripMarkdownCodeBlocks removes markdown code block wrappers from a string
@@ -195,6 +338,22 @@ func stripMarkdownCodeBlocks(s string) string {
 	return s
 }
 
+// extractJSON returns the JSON value in a model's answer, without markdown
+// code fences or prose before and after it. An answer with no valid JSON
+// value is returned with only the code fences removed.
+func extractJSON(s string) string {
+	s = stripMarkdownCodeBlocks(s)
+	if json.Valid([]byte(s)) {
+		return s
+	}
+	start := strings.IndexAny(s, "{[")
+	end := strings.LastIndexAny(s, "}]")
+	if start >= 0 && end > start && json.Valid([]byte(s[start:end+1])) {
+		return s[start : end+1]
+	}
+	return s
+}
+
 // truncateString truncates a string to the specified length
 func truncateString(s string, maxLen int) string {
 	if len(s) <= maxLen {
@@ -203,8 +362,21 @@ func truncateString(s string, maxLen int) string {
 	return s[:maxLen] + "..."
 }
 
-// ScoreContent analyzes content and assigns a quality score for ingestion
-// Returns a score (0.0-1.0), reason, categories, and malicious indicators
+// truncateScoreContent limits page text to the client's scoring prompt size
+func (c *Client) truncateScoreContent(content string) string {
+	if c.scoreContentChars < 0 {
+		return content
+	}
+	return truncateString(content, c.scoreContentChars)
+}
+
+// ScoreContent analyzes content and assigns a quality score for ingestion.
+// It returns the score, clamped to 0.0-1.0 with higher meaning better
+// quality; the model's reason for it; content categories such as "news" or
+// "spam"; and malicious indicators such as "phishing", both non-nil. The
+// title and the content are truncated (see SetScoreContentChars) before
+// they are put in the prompt. An answer that is not valid JSON is asked for
+// again once with a stricter prompt before ScoreContent gives up.
 func (c *Client) ScoreContent(ctx context.Context, url string, title string, content string) (score float64, reason string, categories []string, maliciousIndicators []string, err error) {
 	prompt := fmt.Sprintf(`You are a content quality assessment assistant. Analyze the following webpage and determine if it should be ingested into a knowledge database.
 
@@ -253,26 +425,34 @@ Categories should include any applicable labels: "social_media", "gambling", "ad
 Malicious indicators should list any suspicious patterns detected: "phishing", "malware", "scam", "misleading", etc.`,
 		url,
 		truncateString(title, 200),
-		truncateString(content, 1000))
-
-	response, err := c.Generate(ctx, prompt)
-	if err != nil {
-		return 0.0, "", nil, nil, fmt.Errorf("failed to score content: %w", err)
-	}
-
-	// Strip markdown code blocks if present
-	response = stripMarkdownCodeBlocks(response)
+		c.truncateScoreContent(content))
 
-	// Parse JSON response
-	var result struct {
+	type scoreResult struct {
 		Score               float64  `json:"score"`
 		Reason              string   `json:"reason"`
 		Categories          []string `json:"categories"`
 		MaliciousIndicators []string `json:"malicious_indicators"`
 	}
+	var result scoreResult
+	for attempt := 0; ; attempt++ {
+		response, err := c.GenerateStructured(ctx, prompt, ScoreSchema)
+		if err != nil {
+			return 0.0, "", nil, nil, fmt.Errorf("failed to score content: %w", err)
+		}
 
-	if err := json.Unmarshal([]byte(response), &result); err != nil {
-		return 0.0, "", nil, nil, fmt.Errorf("failed to parse scoring response: %w", err)
+		// Strip markdown code blocks if present
+		response = stripMarkdownCodeBlocks(response)
+
+		var parsed scoreResult
+		err = json.Unmarshal([]byte(response), &parsed)
+		if err == nil {
+			result = parsed
+			break
+		}
+		if attempt > 0 {
+			return 0.0, "", nil, nil, fmt.Errorf("failed to parse scoring response: %w", err)
+		}
+		prompt += jsonReprompt
 	}
 
 	// Ensure score is within bounds
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -93,6 +95,44 @@ func TestGenerate(t *testing.T) {
 	}
 }
 
+// roundTripperFunc adapts a function to http.RoundTripper
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestNewClientWithHTTPClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Proxy-Auth") != "token" {
+			t.Errorf("Expected the request to go through the custom transport")
+		}
+		json.NewEncoder(w).Encode(models.OllamaResponse{Response: "ok", Done: true})
+	}))
+	defer server.Close()
+
+	httpClient := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		r.Header.Set("X-Proxy-Auth", "token")
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+	client := NewClientWithHTTPClient(server.URL, "test-model", httpClient)
+	if client.httpClient != httpClient {
+		t.Error("Expected the injected client to be used")
+	}
+
+	response, err := client.Generate(context.Background(), "test prompt")
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if response != "ok" {
+		t.Errorf("Unexpected response: %s", response)
+	}
+
+	if NewClientWithHTTPClient("", "", nil).httpClient.Timeout != DefaultTimeout {
+		t.Error("Expected a nil client to use one with DefaultTimeout")
+	}
+}
+
 func TestGenerateError(t *testing.T) {
 	// Create a test server that returns an error
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -197,6 +237,36 @@ func TestAnalyzeImage(t *testing.T) {
 	}
 }
 
+func TestAnalyzeImageDetailed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req models.OllamaVisionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode vision request: %v", err)
+		}
+
+		if !strings.Contains(req.Prompt, "verbatim") {
+			t.Error("Expected prompt to ask for verbatim text transcription")
+		}
+
+		jsonResp := `{"summary": "A bar chart", "tags": ["chart"], "text": "  Q3 Revenue: $4.2M  "}`
+		json.NewEncoder(w).Encode(models.OllamaResponse{Response: jsonResp, Done: true})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-model")
+	analysis, err := client.AnalyzeImageDetailed(context.Background(), []byte("fake image data"), "")
+	if err != nil {
+		t.Fatalf("AnalyzeImageDetailed failed: %v", err)
+	}
+
+	if analysis.Summary != "A bar chart" {
+		t.Errorf("Unexpected summary: %s", analysis.Summary)
+	}
+	if analysis.Text != "Q3 Revenue: $4.2M" {
+		t.Errorf("Unexpected text: %q", analysis.Text)
+	}
+}
+
 func TestAnalyzeImageInvalidJSON(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Return non-JSON response
@@ -346,3 +416,269 @@ func TestTruncateString(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateOptions(t *testing.T) {
+	var bodies []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		bodies = append(bodies, body)
+		json.NewEncoder(w).Encode(models.OllamaResponse{
+			Response: `{"score": 0.8, "reason": "ok", "categories": [], "malicious_indicators": []}`,
+			Done:     true,
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-model")
+	ctx := context.Background()
+
+	// Free-form generation uses the model's defaults unless options are set
+	if _, err := client.Generate(ctx, "test prompt"); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if _, ok := bodies[0]["options"]; ok {
+		t.Errorf("Expected no options by default, got %v", bodies[0]["options"])
+	}
+
+	// Structured tasks default to temperature 0 and a fixed seed
+	if _, _, _, _, err := client.ScoreContent(ctx, "https://example.com", "Title", "Content"); err != nil {
+		t.Fatalf("ScoreContent failed: %v", err)
+	}
+	options, _ := bodies[1]["options"].(map[string]interface{})
+	if options["temperature"] != 0.0 || options["seed"] != 42.0 {
+		t.Errorf("Expected temperature 0 and seed 42 for scoring, got %v", bodies[1]["options"])
+	}
+
+	temperature, topP := 0.7, 0.9
+	client.SetOptions(&models.OllamaOptions{Temperature: &temperature, TopP: &topP, NumPredict: 256}, nil)
+	if _, err := client.Generate(ctx, "test prompt"); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	options, _ = bodies[2]["options"].(map[string]interface{})
+	want := map[string]interface{}{"temperature": 0.7, "top_p": 0.9, "num_predict": 256.0}
+	if !reflect.DeepEqual(options, want) {
+		t.Errorf("Expected options %v, got %v", want, bodies[2]["options"])
+	}
+}
+
+func TestScoreContentStructuredOutput(t *testing.T) {
+	var formats []interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req models.OllamaRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		formats = append(formats, req.Format)
+		json.NewEncoder(w).Encode(models.OllamaResponse{
+			Response: "Here is my assessment:\n{\"score\": 0.8, \"reason\": \"Good guide\", \"categories\": [\"technical\"], \"malicious_indicators\": []}\nLet me know if you need more.",
+			Done:     true,
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-model")
+	score, reason, _, _, err := client.ScoreContent(context.Background(), "https://example.com", "Title", "Content")
+	if err != nil {
+		t.Fatalf("ScoreContent failed on an answer wrapped in prose: %v", err)
+	}
+	if score != 0.8 || reason != "Good guide" {
+		t.Errorf("ScoreContent = %v, %q; want 0.8, Good guide", score, reason)
+	}
+	if formats[0] != "json" {
+		t.Errorf("Expected format json, got %v", formats[0])
+	}
+
+	client.SetJSONSchemas(true)
+	if _, _, _, _, err := client.ScoreContent(context.Background(), "https://example.com", "Title", "Content"); err != nil {
+		t.Fatalf("ScoreContent failed: %v", err)
+	}
+	schema, _ := formats[1].(map[string]interface{})
+	if schema["type"] != "object" || schema["required"] == nil {
+		t.Errorf("Expected the score schema as format, got %v", formats[1])
+	}
+}
+
+func TestExtractJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain object", `{"score": 1}`, `{"score": 1}`},
+		{"code fence", "```json\n{\"score\": 1}\n```", `{"score": 1}`},
+		{"prose around object", "Sure! {\"score\": 1} Hope this helps.", `{"score": 1}`},
+		{"prose around array", "The links are: [\"https://a.example\"].", `["https://a.example"]`},
+		{"no JSON", "I cannot help with that.", "I cannot help with that."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractJSON(tt.in); got != tt.want {
+				t.Errorf("extractJSON(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScoreContentReprompt(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []string
+		wantErr   bool
+	}{
+		{"valid first answer", []string{`{"score": 0.7, "reason": "ok"}`}, false},
+		{"reprompt after malformed answer", []string{"I think it scores about 0.7", `{"score": 0.7, "reason": "ok"}`}, false},
+		{"gives up after second malformed answer", []string{"I think it scores about 0.7", "Still 0.7", `{"score": 0.7}`}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var prompts []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req models.OllamaRequest
+				json.NewDecoder(r.Body).Decode(&req)
+				prompts = append(prompts, req.Prompt)
+				json.NewEncoder(w).Encode(models.OllamaResponse{Response: tt.responses[len(prompts)-1], Done: true})
+			}))
+			defer server.Close()
+
+			client := NewClient(server.URL, "test-model")
+			score, reason, categories, indicators, err := client.ScoreContent(context.Background(), "https://example.com", "Title", "Content")
+			wantCalls := min(len(tt.responses), 2)
+			if len(prompts) != wantCalls {
+				t.Fatalf("Made %d requests, want %d", len(prompts), wantCalls)
+			}
+			if wantCalls == 2 && !strings.HasSuffix(prompts[1], jsonReprompt) {
+				t.Errorf("Expected the second prompt to end with the stricter instruction, got %q", prompts[1])
+			}
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected an error after two malformed answers")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ScoreContent failed: %v", err)
+			}
+			if score != 0.7 || reason != "ok" || categories == nil || indicators == nil {
+				t.Errorf("ScoreContent = %v, %q, %v, %v", score, reason, categories, indicators)
+			}
+		})
+	}
+}
+
+func TestScoreContentTruncation(t *testing.T) {
+	var prompt string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req models.OllamaRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		prompt = req.Prompt
+		json.NewEncoder(w).Encode(models.OllamaResponse{Response: `{"score": 0.5, "reason": "ok"}`, Done: true})
+	}))
+	defer server.Close()
+
+	content := strings.Repeat("~", 5000)
+	client := NewClient(server.URL, "test-model")
+	tests := []struct {
+		chars int
+		want  int
+	}{
+		{0, DefaultScoreContentChars},
+		{50, 50},
+		{-1, 5000},
+	}
+	for _, tt := range tests {
+		client.SetScoreContentChars(tt.chars)
+		if _, _, _, _, err := client.ScoreContent(context.Background(), "https://example.com", "Title", content); err != nil {
+			t.Fatalf("ScoreContent failed: %v", err)
+		}
+		if got := strings.Count(prompt, "~"); got != tt.want {
+			t.Errorf("SetScoreContentChars(%d): prompt has %d characters of content, want %d", tt.chars, got, tt.want)
+		}
+	}
+}
+
+func TestTranscript(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req models.OllamaRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Prompt == "describe" {
+			http.Error(w, "no vision model", http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(models.OllamaResponse{Response: "```json\n[\"a\"]\n```", Done: true})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-model")
+	if _, err := client.Generate(context.Background(), "not recorded"); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	transcript := &Transcript{}
+	ctx := WithTranscript(context.Background(), transcript)
+	if _, err := client.GenerateStructured(ctx, "list", URLListSchema); err != nil {
+		t.Fatalf("GenerateStructured failed: %v", err)
+	}
+	if _, err := client.GenerateWithVision(ctx, "describe", []byte("image")); err == nil {
+		t.Fatal("Expected GenerateWithVision to fail")
+	}
+
+	exchanges := transcript.Exchanges()
+	if len(exchanges) != 2 {
+		t.Fatalf("Recorded %d exchanges, want 2: %+v", len(exchanges), exchanges)
+	}
+	structured := exchanges[0]
+	if structured.Model != "test-model" || structured.Prompt != "list" || structured.Format != "json" {
+		t.Errorf("Structured exchange = %+v", structured)
+	}
+	if structured.Response != "```json\n[\"a\"]\n```" {
+		t.Errorf("Response = %q, want the raw answer before cleanup", structured.Response)
+	}
+	vision := exchanges[1]
+	if vision.Prompt != "describe" || vision.Images != 1 || vision.Response != "" || !strings.Contains(vision.Error, "400") {
+		t.Errorf("Vision exchange = %+v, want the failed request with its error", vision)
+	}
+}
+
+func TestOverride(t *testing.T) {
+	var requests []models.OllamaRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req models.OllamaRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		requests = append(requests, req)
+		json.NewEncoder(w).Encode(models.OllamaResponse{Response: `{"score": 0.8, "reason": "ok"}`, Done: true})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-model")
+	temperature := 0.9
+	transcript := &Transcript{}
+	ctx := WithTranscript(context.Background(), transcript)
+	ctx = WithOverride(ctx, Override{Model: "other-model", Temperature: &temperature})
+
+	if _, _, _, _, err := client.ScoreContent(ctx, "https://example.com", "Title", "Content"); err != nil {
+		t.Fatalf("ScoreContent failed: %v", err)
+	}
+	if _, err := client.Generate(context.Background(), "not overridden"); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	overridden := requests[0]
+	if overridden.Model != "other-model" || overridden.Options == nil || *overridden.Options.Temperature != 0.9 {
+		t.Errorf("Overridden request = %+v, want other-model at temperature 0.9", overridden)
+	}
+	if overridden.Options.Seed == nil || *overridden.Options.Seed != 42 {
+		t.Errorf("Options = %+v, want the structured seed kept", overridden.Options)
+	}
+	if exchanges := transcript.Exchanges(); len(exchanges) != 1 || exchanges[0].Model != "other-model" {
+		t.Errorf("Exchanges = %+v, want the overriding model recorded", exchanges)
+	}
+	if plain := requests[1]; plain.Model != "test-model" || plain.Options != nil {
+		t.Errorf("Request without override = %+v, want the client's model and options", plain)
+	}
+	if *client.structuredOptions.Temperature != 0 {
+		t.Errorf("Structured temperature = %v, want the client's options unchanged", *client.structuredOptions.Temperature)
+	}
+}
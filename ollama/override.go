@@ -0,0 +1,45 @@
+package ollama
+
+import (
+	"context"
+
+	"github.com/zombar/scraper/models"
+)
+
+// overrideKey is the context key for an Override
+type overrideKey struct{}
+
+// Override replaces the model or sampling temperature of the text generation
+// requests made with a context, so that a single call can try another model
+// without changing the client. Vision requests keep the client's model,
+// which must support images.
+type Override struct {
+	Model       string   // Empty keeps the client's model
+	Temperature *float64 // Nil keeps the client's sampling options
+}
+
+// WithOverride returns a context whose text generation requests use o
+func WithOverride(ctx context.Context, o Override) context.Context {
+	return context.WithValue(ctx, overrideKey{}, o)
+}
+
+// applyOverride returns the model and sampling options for a request made with ctx,
+// leaving options unmodified
+func applyOverride(ctx context.Context, model string, options *models.OllamaOptions) (string, *models.OllamaOptions) {
+	o, ok := ctx.Value(overrideKey{}).(Override)
+	if !ok {
+		return model, options
+	}
+	if o.Model != "" {
+		model = o.Model
+	}
+	if o.Temperature != nil {
+		overridden := models.OllamaOptions{}
+		if options != nil {
+			overridden = *options
+		}
+		overridden.Temperature = o.Temperature
+		options = &overridden
+	}
+	return model, options
+}
@@ -0,0 +1,52 @@
+package ollama
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/zombar/scraper/models"
+)
+
+// transcriptKey is the context key for a Transcript
+type transcriptKey struct{}
+
+// Transcript records the prompts sent to Ollama and the model's raw
+// responses, for debugging prompts. Attach one to a context with
+// WithTranscript; every request made with that context is recorded. It is
+// safe for concurrent use.
+type Transcript struct {
+	mu        sync.Mutex
+	exchanges []models.OllamaExchange
+}
+
+// WithTranscript returns a context whose Ollama requests are recorded in t
+func WithTranscript(ctx context.Context, t *Transcript) context.Context {
+	return context.WithValue(ctx, transcriptKey{}, t)
+}
+
+// transcriptFromContext returns the context's Transcript, or nil without one
+func transcriptFromContext(ctx context.Context) *Transcript {
+	t, _ := ctx.Value(transcriptKey{}).(*Transcript)
+	return t
+}
+
+// Exchanges returns the recorded requests in the order they finished
+func (t *Transcript) Exchanges() []models.OllamaExchange {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]models.OllamaExchange{}, t.exchanges...)
+}
+
+// record adds a finished request that started at start
+func (t *Transcript) record(exchange models.OllamaExchange, response string, err error, start time.Time) {
+	exchange.Response = response
+	if err != nil {
+		exchange.Error = err.Error()
+	}
+	exchange.Duration = time.Since(start).Seconds()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.exchanges = append(t.exchanges, exchange)
+}
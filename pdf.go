@@ -0,0 +1,780 @@
+package scraper
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// ErrEncryptedPDF is returned (wrapped) when a PDF is encrypted, since its
+// text cannot be extracted without the password
+var ErrEncryptedPDF = errors.New("encrypted PDF")
+
+// pdfMagic starts every PDF file
+var pdfMagic = []byte("%PDF-")
+
+// pdfObjectPattern finds the start of an indirect object, "12 0 obj"
+var pdfObjectPattern = regexp.MustCompile(`(\d+)\s+\d+\s+obj\b`)
+
+// pdfTrailerPattern finds a classic trailer dictionary
+var pdfTrailerPattern = regexp.MustCompile(`trailer\s*<<`)
+
+// maxPDFPageDepth bounds the depth of the page tree walk
+const maxPDFPageDepth = 32
+
+// Values of the PDF object model as parsed by pdfParser: pdfDict, []any
+// (arrays), pdfName, float64, string (string objects, as raw bytes), bool,
+// nil (null), pdfRef, and pdfKeyword (content stream operators)
+type (
+	pdfDict    map[pdfName]any
+	pdfName    string
+	pdfKeyword string
+	pdfRef     int // object number; generations are ignored
+)
+
+// pdfObject is an indirect object, with its stream data if it has any
+type pdfObject struct {
+	value  any
+	stream []byte // still encoded
+}
+
+// pdfDocument is a parsed PDF file, read by scanning for objects rather
+// than through the cross-reference table so that damaged files still work
+type pdfDocument struct {
+	objects map[int]*pdfObject
+	trailer pdfDict
+
+	maxDecoded int64          // Limit on the bytes decompressed from all streams
+	decoded    int64          // Bytes decompressed so far
+	streams    map[int][]byte // Decoded streams by object number
+	visited    map[int]bool   // Page tree nodes walked, by object number
+	err        error          // Why extraction stopped early, if it did
+}
+
+// extractPDF returns the title from a PDF's document information (empty if
+// it has none) and the text of its pages in order. Text is decoded through
+// the fonts' ToUnicode maps where present, and otherwise read as Latin-1,
+// so text in fonts with neither, such as scanned pages, is missed.
+// Decompressing more than maxDecoded bytes of streams in all fails with
+// ErrPageTooLarge, guarding against compression bombs.
+func extractPDF(ctx context.Context, body []byte, maxDecoded int64) (title, text string, err error) {
+	if !bytes.HasPrefix(body, pdfMagic) {
+		return "", "", errors.New("invalid PDF: missing %PDF header")
+	}
+	doc := parsePDFDocument(body, maxDecoded)
+	if doc.err != nil {
+		return "", "", doc.err
+	}
+	if doc.trailer == nil {
+		return "", "", errors.New("invalid PDF: no trailer")
+	}
+	if _, ok := doc.trailer["Encrypt"]; ok {
+		return "", "", fmt.Errorf("%w: text cannot be extracted", ErrEncryptedPDF)
+	}
+
+	if info, ok := doc.resolve(doc.trailer["Info"]).(pdfDict); ok {
+		if s, ok := doc.resolve(info["Title"]).(string); ok {
+			title = strings.TrimSpace(decodePDFTextString(s))
+		}
+	}
+
+	var b strings.Builder
+	if root, ok := doc.resolve(doc.trailer["Root"]).(pdfDict); ok {
+		doc.pageText(ctx, &b, root["Pages"], nil, 0)
+	}
+	if doc.err != nil {
+		return "", "", doc.err
+	}
+	return title, strings.TrimSpace(b.String()), nil
+}
+
+// parsePDFDocument reads every object in a PDF, including those packed into
+// object streams. Later definitions of an object replace earlier ones, as an
+// incremental update does.
+func parsePDFDocument(body []byte, maxDecoded int64) *pdfDocument {
+	doc := &pdfDocument{
+		objects:    make(map[int]*pdfObject),
+		maxDecoded: maxDecoded,
+		streams:    make(map[int][]byte),
+		visited:    make(map[int]bool),
+	}
+	end := 0
+	for _, m := range pdfObjectPattern.FindAllSubmatchIndex(body, -1) {
+		if m[0] < end {
+			continue // a match inside the previous object's stream
+		}
+		num, _ := strconv.Atoi(string(body[m[2]:m[3]]))
+		p := &pdfParser{data: body, pos: m[1]}
+		obj := &pdfObject{value: p.value()}
+		if dict, ok := obj.value.(pdfDict); ok {
+			obj.stream = p.stream(dict)
+			if t, _ := dict["Type"].(pdfName); t == "XRef" {
+				doc.trailer = dict // the trailer of a cross-reference stream
+			}
+		}
+		doc.objects[num] = obj
+		end = p.pos
+	}
+	for _, m := range pdfTrailerPattern.FindAllIndex(body, -1) {
+		p := &pdfParser{data: body, pos: m[1] - 2}
+		if dict, ok := p.value().(pdfDict); ok {
+			doc.trailer = dict
+		}
+	}
+
+	for _, obj := range doc.objects {
+		if dict, ok := obj.value.(pdfDict); ok && dict["Type"] == pdfName("ObjStm") {
+			doc.unpackObjectStream(dict, obj.stream)
+		}
+	}
+	return doc
+}
+
+// unpackObjectStream adds the objects held in an object stream, which begins
+// with N pairs of object numbers and offsets relative to First
+func (doc *pdfDocument) unpackObjectStream(dict pdfDict, stream []byte) {
+	data, err := doc.decodeStream(dict, stream)
+	if err != nil {
+		return
+	}
+	n, _ := dict["N"].(float64)
+	first, _ := dict["First"].(float64)
+	header := &pdfParser{data: data}
+	for range int(n) {
+		num, ok1 := header.value().(float64)
+		offset, ok2 := header.value().(float64)
+		if !ok1 || !ok2 {
+			return
+		}
+		if _, defined := doc.objects[int(num)]; defined {
+			continue
+		}
+		if pos := int(first + offset); pos >= 0 && pos < len(data) {
+			p := &pdfParser{data: data, pos: pos}
+			doc.objects[int(num)] = &pdfObject{value: p.value()}
+		}
+	}
+}
+
+// resolve follows a reference to the object it names
+func (doc *pdfDocument) resolve(v any) any {
+	for range 8 { // references to references are rare, cycles rarer
+		ref, ok := v.(pdfRef)
+		if !ok {
+			return v
+		}
+		obj := doc.objects[int(ref)]
+		if obj == nil {
+			return nil
+		}
+		v = obj.value
+	}
+	return nil
+}
+
+// streamOf returns the decoded stream of a referenced object, decoding each
+// object's stream only once however many pages use it
+func (doc *pdfDocument) streamOf(v any) []byte {
+	ref, ok := v.(pdfRef)
+	if !ok || doc.objects[int(ref)] == nil {
+		return nil
+	}
+	if data, ok := doc.streams[int(ref)]; ok {
+		return data
+	}
+	obj := doc.objects[int(ref)]
+	dict, _ := obj.value.(pdfDict)
+	data, err := doc.decodeStream(dict, obj.stream)
+	if err != nil {
+		data = nil
+	}
+	doc.streams[int(ref)] = data
+	return data
+}
+
+// decodeStream applies a stream's filters; only FlateDecode is supported.
+// Once the document's streams have decompressed to more than maxDecoded
+// bytes, it fails with ErrPageTooLarge and records the error in doc.err.
+func (doc *pdfDocument) decodeStream(dict pdfDict, stream []byte) ([]byte, error) {
+	var filters []any
+	switch f := doc.resolve(dict["Filter"]).(type) {
+	case pdfName:
+		filters = []any{f}
+	case []any:
+		filters = f
+	}
+	data := stream
+	for _, f := range filters {
+		if f != pdfName("FlateDecode") {
+			return nil, fmt.Errorf("unsupported PDF filter %v", f)
+		}
+		r, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		// Keep what was decoded from streams with a damaged end
+		data, err = io.ReadAll(io.LimitReader(r, doc.maxDecoded-doc.decoded+1))
+		if doc.decoded += int64(len(data)); doc.decoded > doc.maxDecoded {
+			if doc.err == nil {
+				doc.err = fmt.Errorf("%w: PDF streams decompress to more than %d bytes", ErrPageTooLarge, doc.maxDecoded)
+			}
+			return nil, doc.err
+		}
+		if err != nil && len(data) == 0 {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// pageText walks the page tree in order, writing the text of each page.
+// Resources are inherited from parent nodes unless a page has its own. Each
+// node is walked once, so that reference cycles and nodes listed repeatedly
+// cannot make the walk run on, and it stops when ctx is done, recording why
+// in doc.err.
+func (doc *pdfDocument) pageText(ctx context.Context, b *strings.Builder, node any, resources pdfDict, depth int) {
+	if doc.err != nil || depth > maxPDFPageDepth {
+		return
+	}
+	if err := ctx.Err(); err != nil {
+		doc.err = err
+		return
+	}
+	if ref, ok := node.(pdfRef); ok {
+		if doc.visited[int(ref)] {
+			return
+		}
+		doc.visited[int(ref)] = true
+	}
+	dict, ok := doc.resolve(node).(pdfDict)
+	if !ok {
+		return
+	}
+	if r, ok := doc.resolve(dict["Resources"]).(pdfDict); ok {
+		resources = r
+	}
+
+	if kids, ok := doc.resolve(dict["Kids"]).([]any); ok {
+		for _, kid := range kids {
+			doc.pageText(ctx, b, kid, resources, depth+1)
+		}
+		return
+	}
+
+	var content []byte
+	switch c := dict["Contents"].(type) {
+	case pdfRef:
+		if arr, ok := doc.resolve(c).([]any); ok {
+			for _, part := range arr {
+				content = append(append(content, doc.streamOf(part)...), '\n')
+			}
+		} else {
+			content = doc.streamOf(c)
+		}
+	case []any:
+		for _, part := range c {
+			content = append(append(content, doc.streamOf(part)...), '\n')
+		}
+	}
+	if len(content) > 0 {
+		b.WriteString(contentStreamText(content, doc.fontMaps(resources)))
+		b.WriteString("\n\n")
+	}
+}
+
+// fontMaps returns the ToUnicode maps of a page's fonts by resource name
+func (doc *pdfDocument) fontMaps(resources pdfDict) map[pdfName]*toUnicodeMap {
+	maps := make(map[pdfName]*toUnicodeMap)
+	fonts, _ := doc.resolve(resources["Font"]).(pdfDict)
+	for name, ref := range fonts {
+		font, ok := doc.resolve(ref).(pdfDict)
+		if !ok {
+			continue
+		}
+		if cmap := doc.streamOf(font["ToUnicode"]); cmap != nil {
+			maps[name] = parseToUnicode(cmap)
+		}
+	}
+	return maps
+}
+
+// contentStreamText returns the text shown by a page content stream. Text
+// objects and line moves start new lines; wide gaps in TJ arrays become spaces.
+func contentStreamText(content []byte, fonts map[pdfName]*toUnicodeMap) string {
+	var b strings.Builder
+	var font *toUnicodeMap
+	var operands []any
+	newline := func() {
+		if b.Len() > 0 && !strings.HasSuffix(b.String(), "\n") {
+			b.WriteByte('\n')
+		}
+	}
+	show := func(s string) { b.WriteString(font.decode(s)) }
+
+	p := &pdfParser{data: content}
+	for {
+		v := p.value()
+		op, ok := v.(pdfKeyword)
+		if !ok {
+			if v == nil && p.pos >= len(p.data) {
+				break
+			}
+			operands = append(operands, v)
+			continue
+		}
+
+		switch op {
+		case "Tf":
+			if len(operands) >= 2 {
+				name, _ := operands[len(operands)-2].(pdfName)
+				font = fonts[name]
+			}
+		case "Tj":
+			if len(operands) > 0 {
+				s, _ := operands[len(operands)-1].(string)
+				show(s)
+			}
+		case "'", "\"":
+			newline()
+			if len(operands) > 0 {
+				s, _ := operands[len(operands)-1].(string)
+				show(s)
+			}
+		case "TJ":
+			if len(operands) > 0 {
+				parts, _ := operands[len(operands)-1].([]any)
+				for _, part := range parts {
+					switch part := part.(type) {
+					case string:
+						show(part)
+					case float64:
+						if part < -200 { // a gap of a fifth of an em or more
+							b.WriteByte(' ')
+						}
+					}
+				}
+			}
+		case "Td", "TD":
+			if len(operands) >= 2 {
+				if ty, _ := operands[len(operands)-1].(float64); ty != 0 {
+					newline()
+				} else {
+					b.WriteByte(' ')
+				}
+			}
+		case "T*", "ET":
+			newline()
+		case "BI":
+			p.skipInlineImage()
+		}
+		operands = operands[:0]
+	}
+	return collapseSpaces(b.String())
+}
+
+// collapseSpaces trims each line and collapses runs of spaces within it
+func collapseSpaces(s string) string {
+	lines := strings.Split(s, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// toUnicodeMap maps character codes of one font to text, from its ToUnicode CMap
+type toUnicodeMap struct {
+	codeLen int // Bytes per character code
+	runes   map[string]string
+}
+
+// parseToUnicode reads the bfchar and bfrange mappings of a ToUnicode CMap
+func parseToUnicode(cmap []byte) *toUnicodeMap {
+	m := &toUnicodeMap{codeLen: 1, runes: make(map[string]string)}
+	add := func(code, text string) {
+		m.runes[code] = text
+		m.codeLen = len(code)
+	}
+
+	p := &pdfParser{data: cmap}
+	var operands []any
+	for {
+		v := p.value()
+		op, ok := v.(pdfKeyword)
+		if !ok {
+			if v == nil && p.pos >= len(p.data) {
+				break
+			}
+			operands = append(operands, v)
+			continue
+		}
+		switch op {
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				code, ok1 := operands[i].(string)
+				dst, ok2 := operands[i+1].(string)
+				if ok1 && ok2 {
+					add(code, decodeUTF16BE(dst))
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, ok1 := operands[i].(string)
+				hi, ok2 := operands[i+1].(string)
+				if !ok1 || !ok2 || len(lo) != len(hi) || len(lo) == 0 {
+					continue
+				}
+				start, end := codeValue(lo), codeValue(hi)
+				for code := start; code <= end && code-start < 0x10000; code++ {
+					var text string
+					switch dst := operands[i+2].(type) {
+					case string:
+						text = decodeUTF16BE(incrementCode(dst, int(code-start)))
+					case []any:
+						if int(code-start) < len(dst) {
+							text, _ = dst[code-start].(string)
+							text = decodeUTF16BE(text)
+						}
+					}
+					add(codeBytes(code, len(lo)), text)
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+	return m
+}
+
+// decode converts a shown string to text, through the map if there is one
+// and otherwise as Latin-1; codes missing from the map are dropped
+func (m *toUnicodeMap) decode(s string) string {
+	if m == nil || len(m.runes) == 0 {
+		return latin1(s)
+	}
+	var b strings.Builder
+	for i := 0; i+m.codeLen <= len(s); i += m.codeLen {
+		b.WriteString(m.runes[s[i:i+m.codeLen]])
+	}
+	return b.String()
+}
+
+// codeValue returns the numeric value of a big-endian character code
+func codeValue(code string) uint32 {
+	var v uint32
+	for i := 0; i < len(code); i++ {
+		v = v<<8 | uint32(code[i])
+	}
+	return v
+}
+
+// codeBytes returns a character code as n big-endian bytes
+func codeBytes(v uint32, n int) string {
+	b := make([]byte, n)
+	for i := n - 1; i >= 0; i-- {
+		b[i] = byte(v)
+		v >>= 8
+	}
+	return string(b)
+}
+
+// incrementCode adds n to the last UTF-16 unit of a bfrange destination
+func incrementCode(dst string, n int) string {
+	if len(dst) < 2 {
+		return dst
+	}
+	last := codeValue(dst[len(dst)-2:]) + uint32(n)
+	return dst[:len(dst)-2] + codeBytes(last, 2)
+}
+
+// decodeUTF16BE decodes big-endian UTF-16 bytes
+func decodeUTF16BE(s string) string {
+	units := make([]uint16, len(s)/2)
+	for i := range units {
+		units[i] = uint16(s[2*i])<<8 | uint16(s[2*i+1])
+	}
+	return string(utf16.Decode(units))
+}
+
+// decodePDFTextString decodes a PDF text string such as a document title:
+// UTF-16BE after a byte order mark, otherwise PDFDocEncoding, read as Latin-1
+func decodePDFTextString(s string) string {
+	if rest, ok := strings.CutPrefix(s, "\xfe\xff"); ok {
+		return decodeUTF16BE(rest)
+	}
+	return latin1(s)
+}
+
+// latin1 decodes bytes as ISO-8859-1
+func latin1(s string) string {
+	runes := make([]rune, len(s))
+	for i := 0; i < len(s); i++ {
+		runes[i] = rune(s[i])
+	}
+	return string(runes)
+}
+
+// pdfParser reads PDF objects from a file or content stream
+type pdfParser struct {
+	data []byte
+	pos  int
+}
+
+// isPDFDelimiter reports whether c ends a name, number, or keyword
+func isPDFDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/% \t\r\n\f\x00", c) >= 0
+}
+
+// skipSpace skips whitespace and comments
+func (p *pdfParser) skipSpace() {
+	for p.pos < len(p.data) {
+		switch c := p.data[p.pos]; {
+		case c == '%':
+			for p.pos < len(p.data) && p.data[p.pos] != '\n' && p.data[p.pos] != '\r' {
+				p.pos++
+			}
+		case strings.IndexByte(" \t\r\n\f\x00", c) >= 0:
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+// value parses the next object, returning nil at the end of the data. A
+// number followed by a generation and R is a reference.
+func (p *pdfParser) value() any {
+	p.skipSpace()
+	if p.pos >= len(p.data) {
+		return nil
+	}
+	switch c := p.data[p.pos]; {
+	case c == '<' && p.pos+1 < len(p.data) && p.data[p.pos+1] == '<':
+		p.pos += 2
+		dict := make(pdfDict)
+		for {
+			p.skipSpace()
+			if p.pos >= len(p.data) {
+				return dict
+			}
+			if bytes.HasPrefix(p.data[p.pos:], []byte(">>")) {
+				p.pos += 2
+				return dict
+			}
+			key, ok := p.value().(pdfName)
+			if !ok {
+				return dict // malformed; keep what was read
+			}
+			dict[key] = p.value()
+		}
+	case c == '<':
+		return p.hexString()
+	case c == '(':
+		return p.literalString()
+	case c == '[':
+		p.pos++
+		var arr []any
+		for {
+			p.skipSpace()
+			if p.pos >= len(p.data) {
+				return arr
+			}
+			if p.data[p.pos] == ']' {
+				p.pos++
+				return arr
+			}
+			arr = append(arr, p.value())
+		}
+	case c == '/':
+		p.pos++
+		return pdfName(p.token())
+	case c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		n, err := strconv.ParseFloat(p.token(), 64)
+		if err != nil {
+			return float64(0)
+		}
+		if ref, ok := p.reference(n); ok {
+			return ref
+		}
+		return n
+	case c == ']' || c == '>' || c == ')' || c == '{' || c == '}':
+		p.pos++ // stray delimiter
+		return pdfKeyword(string(c))
+	}
+
+	switch word := p.token(); word {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null":
+		return nil
+	default:
+		return pdfKeyword(word)
+	}
+}
+
+// token reads up to the next delimiter
+func (p *pdfParser) token() string {
+	start := p.pos
+	for p.pos < len(p.data) && !isPDFDelimiter(p.data[p.pos]) {
+		p.pos++
+	}
+	if p.pos == start && p.pos < len(p.data) {
+		p.pos++ // never stall on an unexpected byte
+	}
+	return string(p.data[start:p.pos])
+}
+
+// reference checks whether the number just read starts "num gen R",
+// consuming the rest of the reference if so
+func (p *pdfParser) reference(num float64) (pdfRef, bool) {
+	save := p.pos
+	p.skipSpace()
+	gen := p.token()
+	p.skipSpace()
+	if _, err := strconv.Atoi(gen); err == nil && num == float64(int(num)) && p.token() == "R" {
+		return pdfRef(num), true
+	}
+	p.pos = save
+	return 0, false
+}
+
+// hexString reads a <...> string
+func (p *pdfParser) hexString() string {
+	p.pos++
+	var digits []byte
+	for p.pos < len(p.data) && p.data[p.pos] != '>' {
+		if c := p.data[p.pos]; strings.IndexByte("0123456789abcdefABCDEF", c) >= 0 {
+			digits = append(digits, c)
+		}
+		p.pos++
+	}
+	p.pos++
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	for i := range out {
+		v, _ := strconv.ParseUint(string(digits[2*i:2*i+2]), 16, 8)
+		out[i] = byte(v)
+	}
+	return string(out)
+}
+
+// literalString reads a (...) string, which may nest balanced parentheses
+func (p *pdfParser) literalString() string {
+	p.pos++
+	var b []byte
+	depth := 1
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		p.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return string(b)
+			}
+		case '\\':
+			if p.pos >= len(p.data) {
+				return string(b)
+			}
+			c = p.data[p.pos]
+			p.pos++
+			switch c {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r', '\n': // line continuation
+				if c == '\r' && p.pos < len(p.data) && p.data[p.pos] == '\n' {
+					p.pos++
+				}
+				continue
+			default:
+				if c >= '0' && c <= '7' {
+					v := int(c - '0')
+					for i := 0; i < 2 && p.pos < len(p.data) && p.data[p.pos] >= '0' && p.data[p.pos] <= '7'; i++ {
+						v = v*8 + int(p.data[p.pos]-'0')
+						p.pos++
+					}
+					c = byte(v)
+				}
+			}
+		}
+		b = append(b, c)
+	}
+	return string(b)
+}
+
+// stream reads the stream data following a stream dictionary, if any. It
+// looks for endstream rather than trusting Length, which may be indirect.
+func (p *pdfParser) stream(dict pdfDict) []byte {
+	p.skipSpace()
+	rest := p.data[p.pos:]
+	if !bytes.HasPrefix(rest, []byte("stream")) {
+		return nil
+	}
+	start := p.pos + len("stream")
+	if start < len(p.data) && p.data[start] == '\r' {
+		start++
+	}
+	if start < len(p.data) && p.data[start] == '\n' {
+		start++
+	}
+
+	end := bytes.Index(p.data[start:], []byte("endstream"))
+	if end < 0 {
+		return p.data[start:]
+	}
+	data := p.data[start : start+end]
+	if length, ok := dict["Length"].(float64); ok && int(length) <= len(data) {
+		data = data[:int(length)]
+	} else {
+		data = bytes.TrimSuffix(bytes.TrimSuffix(data, []byte("\n")), []byte("\r"))
+	}
+	p.pos = start + end + len("endstream")
+	return data
+}
+
+// skipInlineImage skips the binary data of an inline image, from after BI
+// to past its EI operator
+func (p *pdfParser) skipInlineImage() {
+	id := bytes.Index(p.data[p.pos:], []byte("ID"))
+	if id < 0 {
+		p.pos = len(p.data)
+		return
+	}
+	p.pos += id + 2
+	for p.pos < len(p.data) {
+		ei := bytes.Index(p.data[p.pos:], []byte("EI"))
+		if ei < 0 {
+			p.pos = len(p.data)
+			return
+		}
+		p.pos += ei + 2
+		before, after := p.data[p.pos-3], byte(' ')
+		if p.pos < len(p.data) {
+			after = p.data[p.pos]
+		}
+		if isPDFDelimiter(before) && isPDFDelimiter(after) {
+			return
+		}
+	}
+}
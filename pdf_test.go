@@ -0,0 +1,209 @@
+package scraper
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// buildPDF assembles a PDF from numbered object bodies and a trailer
+// dictionary. A body containing "stream\n" gets its stream data compressed
+// with FlateDecode when flate is set.
+func buildPDF(t *testing.T, objects []string, trailer string, flate bool) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.5\n%\xe2\xe3\xcf\xd3\n")
+	for i, body := range objects {
+		fmt.Fprintf(&b, "%d 0 obj\n", i+1)
+		if dict, data, ok := strings.Cut(body, "stream\n"); ok {
+			data = strings.TrimSuffix(data, "\nendstream")
+			raw := []byte(data)
+			if flate {
+				var z bytes.Buffer
+				w := zlib.NewWriter(&z)
+				w.Write(raw)
+				w.Close()
+				raw = z.Bytes()
+				dict = strings.Replace(dict, "<<", "<< /Filter /FlateDecode", 1)
+			}
+			dict = strings.Replace(dict, "<<", fmt.Sprintf("<< /Length %d", len(raw)), 1)
+			b.WriteString(dict + "stream\n")
+			b.Write(raw)
+			b.WriteString("\nendstream")
+		} else {
+			b.WriteString(body)
+		}
+		b.WriteString("\nendobj\n")
+	}
+	b.WriteString("trailer\n" + trailer + "\n%%EOF\n")
+	return b.Bytes()
+}
+
+// samplePDF is a two-page document: the first page in a simple font, the
+// second in a two-byte font with a ToUnicode map
+func samplePDF(t *testing.T, info string, flate bool) []byte {
+	objects := []string{
+		`<< /Type /Catalog /Pages 2 0 R >>`,
+		`<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 /Resources << /Font << /F1 7 0 R >> >> >>`,
+		`<< /Type /Page /Parent 2 0 R /Contents 5 0 R >>`,
+		`<< /Type /Page /Parent 2 0 R /Contents [6 0 R] /Resources << /Font << /F2 8 0 R >> >> >>`,
+		"<< >>stream\nBT /F1 12 Tf 72 720 Td (Quarterly \\(Q3\\) results) Tj 0 -14 Td [(Revenue) -300 (grew)] TJ ET\nendstream",
+		"<< >>stream\nBT /F2 12 Tf 72 720 Td <00010002> Tj ET\nendstream",
+		`<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>`,
+		`<< /Type /Font /Subtype /Type0 /BaseFont /Custom /Encoding /Identity-H /ToUnicode 9 0 R >>`,
+		"<< >>stream\n/CIDInit /ProcSet findresource begin\n1 begincodespacerange <0000> <FFFF> endcodespacerange\n" +
+			"1 beginbfchar <0001> <004F> endbfchar\n1 beginbfrange <0002> <0002> <006B> endbfrange\nendcmap\nendstream",
+	}
+	trailer := `<< /Root 1 0 R /Size 10 >>`
+	if info != "" {
+		objects = append(objects, info)
+		trailer = `<< /Root 1 0 R /Info 10 0 R /Size 11 >>`
+	}
+	return buildPDF(t, objects, trailer, flate)
+}
+
+func TestExtractPDF(t *testing.T) {
+	for _, flate := range []bool{false, true} {
+		title, text, err := extractPDF(context.Background(), samplePDF(t, `<< /Title (Annual Report) /Author (Finance) >>`, flate), defaultMaxBodyBytes)
+		if err != nil {
+			t.Fatalf("flate=%v: extractPDF() error = %v", flate, err)
+		}
+		if title != "Annual Report" {
+			t.Errorf("flate=%v: title = %q, want Annual Report", flate, title)
+		}
+		want := "Quarterly (Q3) results\nRevenue grew\n\nOk"
+		if text != want {
+			t.Errorf("flate=%v: text = %q, want %q", flate, text, want)
+		}
+	}
+
+	title, _, err := extractPDF(context.Background(), samplePDF(t, `<< /Title <FEFF0052006500700020201C0031201D> >>`, false), defaultMaxBodyBytes)
+	if err != nil || title != "Rep \u201c1\u201d" {
+		t.Errorf("UTF-16 title = %q, %v; want %q", title, err, "Rep \u201c1\u201d")
+	}
+}
+
+func TestExtractPDFErrors(t *testing.T) {
+	encrypted := buildPDF(t, []string{
+		`<< /Type /Catalog /Pages 2 0 R >>`,
+		`<< /Type /Pages /Kids [] /Count 0 >>`,
+		`<< /Filter /Standard /V 2 /R 3 /O <00> /U <00> /P -4 >>`,
+	}, `<< /Root 1 0 R /Encrypt 3 0 R /Size 4 >>`, false)
+	if _, _, err := extractPDF(context.Background(), encrypted, defaultMaxBodyBytes); !errors.Is(err, ErrEncryptedPDF) {
+		t.Errorf("encrypted PDF: error = %v, want ErrEncryptedPDF", err)
+	}
+
+	if _, _, err := extractPDF(context.Background(), []byte("<html><body>Not a PDF</body></html>"), defaultMaxBodyBytes); err == nil {
+		t.Error("HTML body: expected an error")
+	}
+}
+
+func TestExtractPDFHostile(t *testing.T) {
+	ctx := context.Background()
+
+	// A page tree listing itself, and a tree listing each node twice
+	for name, pdf := range map[string][]byte{
+		"self-reference": buildPDF(t, []string{
+			`<< /Type /Catalog /Pages 2 0 R >>`,
+			`<< /Type /Pages /Kids [2 0 R 2 0 R] /Count 2 >>`,
+		}, `<< /Root 1 0 R /Size 3 >>`, false),
+		"repeated kids": buildPDF(t, func() []string {
+			objects := []string{`<< /Type /Catalog /Pages 2 0 R >>`}
+			for i := 2; i < 30; i++ {
+				objects = append(objects, fmt.Sprintf(`<< /Type /Pages /Kids [%d 0 R %d 0 R] >>`, i+1, i+1))
+			}
+			return append(objects, "<< /Type /Page /Contents 31 0 R >>", "<< >>stream\nBT (Once) Tj ET\nendstream")
+		}(), `<< /Root 1 0 R /Size 32 >>`, false),
+	} {
+		done := make(chan string, 1)
+		go func() {
+			_, text, _ := extractPDF(ctx, pdf, defaultMaxBodyBytes)
+			done <- text
+		}()
+		select {
+		case text := <-done:
+			if name == "repeated kids" && text != "Once" {
+				t.Errorf("%s: text = %q, want the page once", name, text)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: extractPDF still running after 5s", name)
+		}
+	}
+
+	// A stream that decompresses far beyond the limit
+	bomb := buildPDF(t, []string{
+		`<< /Type /Catalog /Pages 2 0 R >>`,
+		`<< /Type /Pages /Kids [3 0 R] /Count 1 >>`,
+		`<< /Type /Page /Contents 4 0 R >>`,
+		"<< >>stream\nBT (" + strings.Repeat("0", 1<<20) + ") Tj ET\nendstream",
+	}, `<< /Root 1 0 R /Size 5 >>`, true)
+	if len(bomb) > 1<<14 {
+		t.Fatalf("Bomb is %d bytes, want a small file", len(bomb))
+	}
+	if _, _, err := extractPDF(ctx, bomb, 64*1024); !errors.Is(err, ErrPageTooLarge) {
+		t.Errorf("compression bomb: error = %v, want ErrPageTooLarge", err)
+	}
+	if _, text, err := extractPDF(ctx, bomb, 2<<20); err != nil || len(text) != 1<<20 {
+		t.Errorf("within the limit: %d bytes of text, %v; want all of it", len(text), err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, _, err := extractPDF(cancelled, samplePDF(t, "", true), defaultMaxBodyBytes); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled context: error = %v, want context.Canceled", err)
+	}
+}
+
+func TestScrapePDF(t *testing.T) {
+	pdf := samplePDF(t, "", true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/files/q3-report.pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Write(pdf)
+		case "/download":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write(pdf)
+		case "/broken.pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Write([]byte("<html><body>Error page</body></html>"))
+		}
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.OllamaBaseURL = "http://127.0.0.1:1" // unavailable, so scoring falls back
+	s := New(config)
+
+	data, err := s.Scrape(context.Background(), server.URL+"/files/q3-report.pdf")
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+	if data.ContentType != ContentTypePDF {
+		t.Errorf("ContentType = %q, want %q", data.ContentType, ContentTypePDF)
+	}
+	if data.Title != "q3-report.pdf" {
+		t.Errorf("Title = %q, want the file name", data.Title)
+	}
+	if !strings.HasPrefix(data.Content, "Quarterly (Q3) results") || data.WordCount != 6 {
+		t.Errorf("Content = %q (%d words), want the PDF text", data.Content, data.WordCount)
+	}
+	if len(data.Links) != 0 || len(data.Images) != 0 || data.Score == nil {
+		t.Errorf("Links = %v, Images = %v, Score = %v; want no links or images, and a score", data.Links, data.Images, data.Score)
+	}
+
+	if data, err := s.Scrape(context.Background(), server.URL+"/download"); err != nil || data.ContentType != ContentTypePDF {
+		t.Errorf("PDF served as octet-stream: %v, %v; want it detected as a PDF", data, err)
+	}
+
+	if _, err := s.Scrape(context.Background(), server.URL+"/broken.pdf"); err == nil || !strings.Contains(err.Error(), "invalid PDF") {
+		t.Errorf("HTML served as PDF: error = %v, want an invalid PDF error", err)
+	}
+}
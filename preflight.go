@@ -0,0 +1,99 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupportedContentType is returned (wrapped) when Config.PreflightHEAD
+// finds that a URL serves something other than HTML or a document Scrape
+// handles (PDF, plain text, or JSON), such as a video
+var ErrUnsupportedContentType = errors.New("unsupported content type")
+
+// ErrPageTooLarge is returned (wrapped) when a page exceeds Config.MaxBodyBytes,
+// whether detected by the preflight check or while reading the body
+var ErrPageTooLarge = errors.New("page too large")
+
+// preflight checks a page's type and size before it is downloaded. It tries a
+// HEAD request, falling back to a one-byte ranged GET for servers that reject
+// HEAD. When neither yields usable headers the check passes, leaving the real
+// fetch to report any error.
+func (s *Scraper) preflight(ctx context.Context, targetURL string) error {
+	header, size, ok := s.probe(ctx, http.MethodHead, targetURL)
+	if !ok {
+		header, size, ok = s.probe(ctx, http.MethodGet, targetURL)
+		if !ok {
+			return nil
+		}
+	}
+
+	if contentType := header.Get("Content-Type"); contentType != "" && !isHTMLContentType(contentType) && !isDocumentContentType(contentType) {
+		return fmt.Errorf("%w: %s", ErrUnsupportedContentType, contentType)
+	}
+
+	maxBytes := s.config.MaxBodyBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxBodyBytes
+	}
+	if size > maxBytes {
+		return fmt.Errorf("%w: %d bytes (max: %d)", ErrPageTooLarge, size, maxBytes)
+	}
+	return nil
+}
+
+// probe requests only the headers of a page: a HEAD, or a GET for its first
+// byte. It returns the response headers and the full page size (-1 if
+// unknown), and false if the server did not answer successfully.
+func (s *Scraper) probe(ctx context.Context, method, targetURL string) (http.Header, int64, bool) {
+	req, err := http.NewRequestWithContext(ctx, method, targetURL, nil)
+	if err != nil {
+		return nil, 0, false
+	}
+	req.Header.Set("User-Agent", s.config.UserAgent)
+	if method == http.MethodGet {
+		req.Header.Set("Range", "bytes=0-0")
+	}
+
+	resp, err := s.client(ctx).Do(req)
+	if err != nil {
+		return nil, 0, false
+	}
+	// Close without draining: a server ignoring the Range sends the whole page
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Header, resp.ContentLength, true
+	case http.StatusPartialContent:
+		return resp.Header, contentRangeSize(resp.Header.Get("Content-Range")), true
+	}
+	return nil, 0, false
+}
+
+// contentRangeSize returns the complete length from a Content-Range header
+// such as "bytes 0-0/12345", or -1 if it is unknown
+func contentRangeSize(contentRange string) int64 {
+	_, total, ok := strings.Cut(contentRange, "/")
+	if !ok {
+		return -1
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(total), 10, 64)
+	if err != nil {
+		return -1
+	}
+	return size
+}
+
+// isHTMLContentType reports whether a Content-Type is HTML or XHTML
+func isHTMLContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "text/html" || mediaType == "application/xhtml+xml"
+}
@@ -0,0 +1,144 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestScrapePreflightUnsupportedContentType(t *testing.T) {
+	var gets int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "video/mp4")
+		if r.Method == http.MethodGet {
+			atomic.AddInt32(&gets, 1)
+		}
+		w.Write([]byte("\x00\x00\x00\x18ftypmp42"))
+	}))
+	defer ts.Close()
+
+	config := DefaultConfig()
+	config.PreflightHEAD = true
+	_, err := New(config).Scrape(context.Background(), ts.URL+"/talk.mp4")
+	if !errors.Is(err, ErrUnsupportedContentType) {
+		t.Fatalf("Scrape error = %v, want ErrUnsupportedContentType", err)
+	}
+	if !strings.Contains(err.Error(), "video/mp4") {
+		t.Errorf("Error %q should name the content type", err)
+	}
+	if gets != 0 {
+		t.Errorf("Made %d GET requests, want none after a failed HEAD check", gets)
+	}
+}
+
+func TestScrapePreflightOversizedPage(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Content-Length", "5000")
+		if r.Method == http.MethodGet {
+			t.Error("Expected the page not to be downloaded")
+		}
+	}))
+	defer ts.Close()
+
+	config := DefaultConfig()
+	config.PreflightHEAD = true
+	config.MaxBodyBytes = 1000
+	_, err := New(config).Scrape(context.Background(), ts.URL)
+	if !errors.Is(err, ErrPageTooLarge) {
+		t.Fatalf("Scrape error = %v, want ErrPageTooLarge", err)
+	}
+}
+
+func TestScrapePreflightHEADUnsupported(t *testing.T) {
+	var ranged int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Header.Get("Range") == "bytes=0-0" {
+			atomic.AddInt32(&ranged, 1)
+			w.Header().Set("Content-Type", "video/mp4")
+			w.Header().Set("Content-Range", "bytes 0-0/900000000")
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write([]byte{0})
+			return
+		}
+		t.Error("Expected only a ranged GET")
+	}))
+	defer ts.Close()
+
+	config := DefaultConfig()
+	config.PreflightHEAD = true
+	_, err := New(config).Scrape(context.Background(), ts.URL+"/movie")
+	if !errors.Is(err, ErrUnsupportedContentType) {
+		t.Fatalf("Scrape error = %v, want ErrUnsupportedContentType", err)
+	}
+	if ranged != 1 {
+		t.Errorf("Made %d ranged GET requests, want 1", ranged)
+	}
+}
+
+func TestPreflightProceedsWithoutUsableHeaders(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead || r.Header.Get("Range") != "" {
+			w.WriteHeader(http.StatusNotImplemented)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Page</title></head><body><p>Text</p></body></html>`))
+	}))
+	defer ts.Close()
+
+	config := DefaultConfig()
+	config.PreflightHEAD = true
+	config.EnableImageAnalysis = false
+	data, err := New(config).Scrape(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+	if data.Title != "Page" {
+		t.Errorf("Title = %q, want Page", data.Title)
+	}
+}
+
+func TestPreflightAllowedContentTypes(t *testing.T) {
+	tests := map[string]bool{
+		"text/html; charset=utf-8": true,
+		"application/xhtml+xml":    true,
+		"text/plain":               true,
+		"application/pdf":          true,
+		"application/json":         true,
+		"video/mp4":                false,
+		"application/zip":          false,
+		"image/png":                false,
+	}
+	for contentType, allowed := range tests {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", contentType)
+		}))
+		err := New(DefaultConfig()).preflight(context.Background(), ts.URL)
+		ts.Close()
+		if got := !errors.Is(err, ErrUnsupportedContentType); got != allowed {
+			t.Errorf("preflight(%s) error = %v, want allowed = %v", contentType, err, allowed)
+		}
+	}
+}
+
+func TestContentRangeSize(t *testing.T) {
+	tests := map[string]int64{
+		"bytes 0-0/12345": 12345,
+		"bytes 0-0/*":     -1,
+		"":                -1,
+	}
+	for header, want := range tests {
+		if got := contentRangeSize(header); got != want {
+			t.Errorf("contentRangeSize(%q) = %d, want %d", header, got, want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package scraper
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/zombar/scraper/ollama"
+)
+
+// proxySchemes are the proxy URL schemes net/http can send requests through
+var proxySchemes = map[string]bool{
+	"http":    true,
+	"https":   true,
+	"socks5":  true,
+	"socks5h": true,
+}
+
+// parseProxyURL parses a proxy URL, rejecting schemes net/http cannot use.
+// Errors leave out the URL, which may hold proxy credentials.
+func parseProxyURL(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.New("invalid proxy URL: failed to parse")
+	}
+	if !proxySchemes[strings.ToLower(u.Scheme)] {
+		return nil, fmt.Errorf("invalid proxy URL: unsupported scheme %q (use http, https, socks5, or socks5h)", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, errors.New("invalid proxy URL: missing host")
+	}
+	return u, nil
+}
+
+// proxyFunc returns a transport's Proxy function for a proxy URL. An empty
+// URL uses the HTTP_PROXY, HTTPS_PROXY, and NO_PROXY environment variables.
+// An invalid one fails every request rather than silently bypassing the
+// proxy; Config.Validate reports it up front.
+func proxyFunc(rawURL string) func(*http.Request) (*url.URL, error) {
+	if rawURL == "" {
+		return http.ProxyFromEnvironment
+	}
+	proxyURL, err := parseProxyURL(rawURL)
+	if err != nil {
+		log.Printf("Requests through the proxy will fail: %v", err)
+		return func(*http.Request) (*url.URL, error) {
+			return nil, err
+		}
+	}
+	return http.ProxyURL(proxyURL)
+}
+
+// newOllamaHTTPClient returns the client for Ollama requests: the caller's
+// Config.OllamaHTTPClient, or one sending requests through
+// Config.OllamaProxyURL, or nil for the Ollama client's default
+func newOllamaHTTPClient(config Config) *http.Client {
+	if config.OllamaHTTPClient != nil || config.OllamaProxyURL == "" {
+		return config.OllamaHTTPClient
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxyFunc(config.OllamaProxyURL)
+	return &http.Client{
+		Timeout:   ollama.DefaultTimeout,
+		Transport: transport,
+	}
+}
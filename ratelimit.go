@@ -0,0 +1,114 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrRateLimited is matched by errors.Is for pages that responded with
+// 429 Too Many Requests. Use errors.As with *RateLimitError to get the
+// server's requested back-off.
+var ErrRateLimited = errors.New("rate limited")
+
+// RateLimitError is returned when a page responds with 429 and the
+// Retry-After is missing, exceeds Config.MaxRetryAfter, or the retry is
+// rate limited again
+type RateLimitError struct {
+	URL        string
+	RetryAfter time.Duration // 0 if the server did not say
+}
+
+// Error implements error
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("rate limited by %s: retry after %v", e.URL, e.RetryAfter)
+	}
+	return fmt.Sprintf("rate limited by %s", e.URL)
+}
+
+// Unwrap lets errors.Is match ErrRateLimited
+func (e *RateLimitError) Unwrap() error {
+	return ErrRateLimited
+}
+
+// StatusError is returned when a page responds with an HTTP status other
+// than 200 OK (or 429, which yields a RateLimitError)
+type StatusError struct {
+	StatusCode int
+	Status     string // e.g. "404 Not Found"
+}
+
+// Error implements error
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("HTTP error: %d %s", e.StatusCode, e.Status)
+}
+
+// fetchPage GETs a page and returns the response if it succeeded. A 429 whose
+// Retry-After is within Config.MaxRetryAfter is waited out and retried once.
+// The caller must close the response body.
+func (s *Scraper) fetchPage(ctx context.Context, targetURL string) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, "GET", targetURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("User-Agent", s.config.UserAgent)
+
+		resp, err := s.client(ctx).Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch URL: %w", err)
+		}
+
+		if resp.StatusCode == http.StatusOK {
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusTooManyRequests {
+			return nil, &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+		}
+
+		retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		if !ok || attempt > 0 || retryAfter > s.config.MaxRetryAfter {
+			return nil, &RateLimitError{URL: targetURL, RetryAfter: retryAfter}
+		}
+
+		timer := time.NewTimer(retryAfter)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// parseRetryAfter parses a Retry-After header given either as delay seconds or
+// an HTTP date. Dates in the past yield a zero delay.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if delay := date.Sub(now); delay > 0 {
+		return delay, true
+	}
+	return 0, true
+}
@@ -0,0 +1,129 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"seconds", "120", 2 * time.Minute, true},
+		{"zero seconds", "0", 0, true},
+		{"negative seconds", "-5", 0, false},
+		{"HTTP date", "Mon, 01 Jan 2024 12:00:30 GMT", 30 * time.Second, true},
+		{"HTTP date in the past", "Mon, 01 Jan 2024 11:00:00 GMT", 0, true},
+		{"empty", "", 0, false},
+		{"garbage", "soon", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value, now)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("parseRetryAfter(%q) = %v, %v; want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestFetchPageRetriesAfter429(t *testing.T) {
+	var requests int32
+	webServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>OK</title></head><body></body></html>`))
+	}))
+	defer webServer.Close()
+
+	s := New(DefaultConfig())
+	resp, err := s.fetchPage(context.Background(), webServer.URL)
+	if err != nil {
+		t.Fatalf("Expected retry to succeed, got %v", err)
+	}
+	resp.Body.Close()
+
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Errorf("Expected 2 requests, got %d", got)
+	}
+}
+
+func TestFetchPageRateLimited(t *testing.T) {
+	tests := []struct {
+		name         string
+		retryAfter   string
+		wantRequests int32
+		wantAfter    time.Duration
+	}{
+		{"exceeds max wait", "3600", 1, time.Hour},
+		{"date exceeds max wait", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), 1, 59 * time.Minute},
+		{"missing header", "", 1, 0},
+		{"limited again after retry", "0", 2, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int32
+			webServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(http.StatusTooManyRequests)
+			}))
+			defer webServer.Close()
+
+			s := New(DefaultConfig())
+			_, err := s.fetchPage(context.Background(), webServer.URL)
+
+			if !errors.Is(err, ErrRateLimited) {
+				t.Fatalf("Expected ErrRateLimited, got %v", err)
+			}
+			var rateErr *RateLimitError
+			if !errors.As(err, &rateErr) {
+				t.Fatalf("Expected *RateLimitError, got %T", err)
+			}
+			if rateErr.RetryAfter < tt.wantAfter || rateErr.RetryAfter > tt.wantAfter+time.Minute {
+				t.Errorf("RetryAfter = %v, want about %v", rateErr.RetryAfter, tt.wantAfter)
+			}
+			if got := atomic.LoadInt32(&requests); got != tt.wantRequests {
+				t.Errorf("Expected %d requests, got %d", tt.wantRequests, got)
+			}
+		})
+	}
+}
+
+func TestFetchPageRetryRespectsContext(t *testing.T) {
+	webServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "5")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer webServer.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := New(DefaultConfig()).fetchPage(ctx, webServer.URL)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context deadline error, got %v", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Error("Expected wait to be cut short by context")
+	}
+}
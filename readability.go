@@ -0,0 +1,221 @@
+package scraper
+
+import (
+	"math"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Class and id patterns that readabilityExtract weighs containers by, after
+// Arc90's readability
+var (
+	unlikelyCandidatePattern = regexp.MustCompile(`(?i)combx|comment|community|disqus|extra|foot|header|menu|remark|rss|shoutbox|sidebar|sponsor|ad-break|agegate|pagination|pager|popup|tweet|twitter|cookie|banner|related|share|social`)
+	maybeCandidatePattern    = regexp.MustCompile(`(?i)and|article|body|column|main|shadow|content`)
+	positiveClassPattern     = regexp.MustCompile(`(?i)article|body|content|entry|hentry|main|page|post|text|blog|story`)
+	negativeClassPattern     = regexp.MustCompile(`(?i)combx|comment|com-|contact|foot|footer|footnote|masthead|media|meta|outbrain|promo|related|scroll|shoutbox|sidebar|sponsor|shopping|tags|tool|widget|nav|menu|share|social`)
+)
+
+const (
+	// minReadabilityParagraph is the length of the shortest paragraph that
+	// counts towards its container's score
+	minReadabilityParagraph = 25
+	// readabilityClassWeight is added for a positive class or id name and
+	// subtracted for a negative one
+	readabilityClassWeight = 25
+)
+
+// readabilityExtract returns the text of a page's main content block, found
+// without an LLM in the manner of Arc90's readability. Paragraphs score
+// their parent and grandparent by their length and commas, containers are
+// weighted by their tag and class and id names such as "article" or
+// "sidebar", and each score is scaled down by the container's link density.
+// Sibling blocks scoring close to the best one, such as an article split
+// across several <div>s, are kept with it. It returns "" when the page has no
+// paragraphs to score.
+func (s *Scraper) readabilityExtract(doc *html.Node) string {
+	sep := " "
+	if s.config.StructuredText {
+		sep = "\n"
+	}
+	var texts []string
+	for _, block := range readabilityBlocks(doc, s.config.SkipHiddenText) {
+		if text := s.extractText(block); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return strings.Join(texts, sep)
+}
+
+// fallbackContent is the content used when Ollama cannot extract it: the
+// readability main content, or all of the page's text when no block stands out
+func (s *Scraper) fallbackContent(root *html.Node, textContent string) string {
+	if content := s.readabilityExtract(root); content != "" {
+		return content
+	}
+	return textContent
+}
+
+// readabilityBlocks returns the highest-scoring content block and the
+// siblings that belong with it, in document order, or nil if nothing scored
+func readabilityBlocks(doc *html.Node, skipHidden bool) []*html.Node {
+	scores := make(map[*html.Node]float64)
+	var candidates []*html.Node
+	addScore := func(n *html.Node, points float64) {
+		if n == nil || n.Type != html.ElementNode {
+			return
+		}
+		if _, ok := scores[n]; !ok {
+			scores[n] = readabilityWeight(n)
+			candidates = append(candidates, n)
+		}
+		scores[n] += points
+	}
+
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "script", "style", "noscript", "template", "nav", "aside", "footer", "form", "iframe":
+				return
+			}
+			if (skipHidden && isHiddenElement(n)) || isUnlikelyCandidate(n) {
+				return
+			}
+			if isParagraph(n) {
+				if text := flatText(n); len(text) >= minReadabilityParagraph {
+					points := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len(text))/100, 3)
+					addScore(n.Parent, points)
+					if n.Parent != nil {
+						addScore(n.Parent.Parent, points/2)
+					}
+				}
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	var top *html.Node
+	for _, n := range candidates {
+		scores[n] *= 1 - linkDensity(n)
+		if top == nil || scores[n] > scores[top] {
+			top = n
+		}
+	}
+	if top == nil {
+		return nil
+	}
+	if top.Parent == nil {
+		return []*html.Node{top}
+	}
+
+	// Keep siblings that score well too, and standalone paragraphs of prose
+	threshold := math.Max(10, scores[top]*0.2)
+	var blocks []*html.Node
+	for sibling := top.Parent.FirstChild; sibling != nil; sibling = sibling.NextSibling {
+		if sibling.Type != html.ElementNode {
+			continue
+		}
+		score, scored := scores[sibling]
+		switch {
+		case sibling == top, scored && score >= threshold:
+			blocks = append(blocks, sibling)
+		case sibling.Data == "p" && len(flatText(sibling)) >= 80 && linkDensity(sibling) < 0.25:
+			blocks = append(blocks, sibling)
+		}
+	}
+	return blocks
+}
+
+// readabilityWeight is a container's starting score, from its tag and its
+// class and id names
+func readabilityWeight(n *html.Node) float64 {
+	var weight float64
+	switch n.Data {
+	case "div", "article", "main", "section":
+		weight = 5
+	case "pre", "td", "blockquote":
+		weight = 3
+	case "address", "ol", "ul", "dl", "dd", "dt", "li", "form":
+		weight = -3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		weight = -5
+	}
+	for _, name := range []string{getAttr(n, "class"), getAttr(n, "id")} {
+		if name == "" {
+			continue
+		}
+		if negativeClassPattern.MatchString(name) {
+			weight -= readabilityClassWeight
+		}
+		if positiveClassPattern.MatchString(name) {
+			weight += readabilityClassWeight
+		}
+	}
+	return weight
+}
+
+// isUnlikelyCandidate reports whether an element's class or id marks it as
+// page furniture, such as a comment section or sidebar, whose paragraphs
+// are not scored
+func isUnlikelyCandidate(n *html.Node) bool {
+	switch n.Data {
+	case "html", "body", "article", "main":
+		return false
+	}
+	names := getAttr(n, "class") + " " + getAttr(n, "id")
+	return unlikelyCandidatePattern.MatchString(names) && !maybeCandidatePattern.MatchString(names)
+}
+
+// isParagraph reports whether an element holds a paragraph of text: a <p> or
+// <pre>, or a <div> used as one, with no block elements inside
+func isParagraph(n *html.Node) bool {
+	switch n.Data {
+	case "p", "pre":
+		return true
+	case "div":
+		return !hasBlockDescendant(n)
+	}
+	return false
+}
+
+// hasBlockDescendant reports whether n contains a block element other than <br>
+func hasBlockDescendant(n *html.Node) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && ((blockElements[c.Data] && c.Data != "br") || hasBlockDescendant(c)) {
+			return true
+		}
+	}
+	return false
+}
+
+// flatText returns the visible text of n on a single line
+func flatText(n *html.Node) string {
+	return strings.TrimSpace(collectText(n, true, false, nil))
+}
+
+// linkDensity returns the fraction of n's text that is inside links
+func linkDensity(n *html.Node) float64 {
+	total := len(flatText(n))
+	if total == 0 {
+		return 0
+	}
+	linked := 0
+	var walk func(*html.Node)
+	walk = func(c *html.Node) {
+		if c.Type == html.ElementNode && c.Data == "a" {
+			linked += len(flatText(c))
+			return
+		}
+		for child := c.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return math.Min(float64(linked)/float64(total), 1)
+}
@@ -0,0 +1,104 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+// articlePage is an article wrapped in the navigation, sidebar, comments,
+// and footer that readabilityExtract should leave out
+const articlePage = `<html><head><title>Tide Pools</title></head><body>
+<div id="header"><a href="/">Home</a> <a href="/news">News</a> <a href="/about">About</a></div>
+<div class="menu"><ul><li><a href="/a">Section A</a></li><li><a href="/b">Section B</a></li></ul></div>
+<div class="layout">
+	<div class="sidebar">
+		<p>Subscribe to our newsletter for weekly updates on everything.</p>
+		<ul><li><a href="/popular/1">Most popular story of the week</a></li><li><a href="/popular/2">Another popular story</a></li></ul>
+	</div>
+	<div class="post-body">
+		<h1>Life in the Tide Pools</h1>
+		<p>Tide pools form where the sea retreats from rocky shores, leaving behind small basins of water, each a world of its own.</p>
+		<p>Anemones, crabs, and snails survive hours of sun, wind, and changing salinity, then are drowned again when the tide returns.</p>
+		<div>Visitors should step only on bare rock, since the algae, barnacles, and mussels underfoot are alive and easily crushed.</div>
+	</div>
+	<div class="comments">
+		<p>Great article, thanks for sharing, I learned a lot from it today!</p>
+	</div>
+</div>
+<div class="site-footer"><p>Copyright 2024 Example Media, all rights reserved, no reuse without permission.</p></div>
+</body></html>`
+
+func TestReadabilityExtract(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(articlePage))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := New(DefaultConfig()).readabilityExtract(doc)
+	want := "Life in the Tide Pools\n" +
+		"Tide pools form where the sea retreats from rocky shores, leaving behind small basins of water, each a world of its own.\n" +
+		"Anemones, crabs, and snails survive hours of sun, wind, and changing salinity, then are drowned again when the tide returns.\n" +
+		"Visitors should step only on bare rock, since the algae, barnacles, and mussels underfoot are alive and easily crushed."
+	if got != want {
+		t.Errorf("readabilityExtract() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestReadabilityExtractSiblings(t *testing.T) {
+	paragraph := "<p>This paragraph of the story has enough words, commas, and clauses to count, as any real one would.</p>"
+	doc, err := html.Parse(strings.NewReader(`<html><body><main>
+		<div class="part">` + strings.Repeat(paragraph, 3) + `</div>
+		<div class="ad"><a href="/buy">Buy now</a></div>
+		<div class="part">` + strings.Repeat(paragraph, 2) + `</div>
+	</main></body></html>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := New(DefaultConfig()).readabilityExtract(doc)
+	if n := strings.Count(got, "This paragraph"); n != 5 {
+		t.Errorf("Extracted %d paragraphs, want both parts of the story:\n%s", n, got)
+	}
+	if strings.Contains(got, "Buy now") {
+		t.Errorf("Extracted the advertisement between the parts:\n%s", got)
+	}
+}
+
+func TestReadabilityExtractNoParagraphs(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><body><ul><li><a href="/">Home</a></li></ul></body></html>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := New(DefaultConfig()).readabilityExtract(doc); got != "" {
+		t.Errorf("readabilityExtract() = %q, want nothing", got)
+	}
+}
+
+func TestScrapeReadabilityFallback(t *testing.T) {
+	webServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(articlePage))
+	}))
+	defer webServer.Close()
+
+	config := DefaultConfig()
+	config.OllamaBaseURL = "http://127.0.0.1:1" // unreachable
+	config.EnableImageAnalysis = false
+	data, err := New(config).Scrape(context.Background(), webServer.URL)
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+	if !strings.HasPrefix(data.Content, "Life in the Tide Pools\n") {
+		t.Errorf("Content = %q, want the article", data.Content)
+	}
+	for _, noise := range []string{"Section A", "newsletter", "Great article", "Copyright"} {
+		if strings.Contains(data.Content, noise) {
+			t.Errorf("Content contains %q from around the article", noise)
+		}
+	}
+}
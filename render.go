@@ -0,0 +1,81 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// Fetch methods recorded in PageMetadata.FetchMethod
+const (
+	FetchMethodHTTP     = "http"     // Plain HTTP fetch
+	FetchMethodRendered = "rendered" // Re-fetched through Config.Renderer after thin content
+)
+
+const (
+	defaultThinContentWords     = 50
+	defaultMaxConcurrentRenders = 2
+	defaultRenderTimeout        = 30 * time.Second
+)
+
+// Renderer loads a page in a JavaScript-capable browser and returns the
+// rendered HTML. The scraper does not bundle one; wrap a headless browser
+// such as chromedp to enable Config.RenderJSOnThin.
+type Renderer interface {
+	Render(ctx context.Context, url string) ([]byte, error)
+}
+
+// renderIfThin re-fetches a page through the configured renderer when its
+// plain HTTP fetch yielded thin content, as is typical of single-page apps.
+// It returns the rendered document, or nil if rendering is disabled, was not
+// needed, failed, or produced no more text than the original.
+func (s *Scraper) renderIfThin(ctx context.Context, pageURL string, doc *html.Node) *html.Node {
+	if !s.config.RenderJSOnThin || s.config.Renderer == nil {
+		return nil
+	}
+
+	threshold := s.config.ThinContentWords
+	if threshold <= 0 {
+		threshold = defaultThinContentWords
+	}
+	words := len(strings.Fields(extractText(doc)))
+	if words >= threshold {
+		return nil
+	}
+
+	// Rendering is expensive, so bound how many run at once
+	select {
+	case s.renderSlots <- struct{}{}:
+		defer func() { <-s.renderSlots }()
+	case <-ctx.Done():
+		return nil
+	}
+
+	timeout := s.config.RenderTimeout
+	if timeout <= 0 {
+		timeout = defaultRenderTimeout
+	}
+	renderCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	log.Printf("Thin content (%d words) at %s, retrying with renderer", words, pageURL)
+	body, err := s.config.Renderer.Render(renderCtx, pageURL)
+	if err != nil {
+		log.Printf("Failed to render %s: %v", pageURL, err)
+		return nil
+	}
+
+	rendered, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		log.Printf("Failed to parse rendered HTML for %s: %v", pageURL, err)
+		return nil
+	}
+	if len(strings.Fields(extractText(rendered))) <= words {
+		return nil
+	}
+	return rendered
+}
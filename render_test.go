@@ -0,0 +1,136 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+// fakeRenderer returns fixed HTML and counts how often it is called
+type fakeRenderer struct {
+	html  string
+	err   error
+	calls atomic.Int32
+}
+
+func (r *fakeRenderer) Render(ctx context.Context, url string) ([]byte, error) {
+	r.calls.Add(1)
+	return []byte(r.html), r.err
+}
+
+func newRenderTestServer(t *testing.T, body string) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func newRenderTestScraper(renderer Renderer) *Scraper {
+	config := DefaultConfig()
+	config.EnableImageAnalysis = false
+	config.RenderJSOnThin = true
+	config.Renderer = renderer
+	return New(config)
+}
+
+const spaShell = `<html><head><title>App</title></head><body><div id="root"></div><script>render()</script></body></html>`
+
+func TestScrapeRendersThinContent(t *testing.T) {
+	ts := newRenderTestServer(t, spaShell)
+	renderer := &fakeRenderer{
+		html: `<html><head><title>App</title></head><body><h1>Rendered</h1><p>` +
+			strings.Repeat("Content produced by client-side JavaScript. ", 20) + `</p></body></html>`,
+	}
+
+	data, err := newRenderTestScraper(renderer).Scrape(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+
+	if renderer.calls.Load() != 1 {
+		t.Errorf("Renderer called %d times, want 1", renderer.calls.Load())
+	}
+	if data.Metadata.FetchMethod != FetchMethodRendered {
+		t.Errorf("FetchMethod = %q, want %q", data.Metadata.FetchMethod, FetchMethodRendered)
+	}
+	if !strings.Contains(data.Content, "client-side JavaScript") {
+		t.Errorf("Expected rendered content, got %q", data.Content)
+	}
+}
+
+func TestScrapeSkipsRenderForSubstantialContent(t *testing.T) {
+	ts := newRenderTestServer(t, `<html><body><p>`+strings.Repeat("Server-rendered article text. ", 30)+`</p></body></html>`)
+	renderer := &fakeRenderer{html: spaShell}
+
+	data, err := newRenderTestScraper(renderer).Scrape(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+
+	if renderer.calls.Load() != 0 {
+		t.Errorf("Renderer called %d times, want 0", renderer.calls.Load())
+	}
+	if data.Metadata.FetchMethod != FetchMethodHTTP {
+		t.Errorf("FetchMethod = %q, want %q", data.Metadata.FetchMethod, FetchMethodHTTP)
+	}
+}
+
+func TestScrapeRenderFailureFallsBack(t *testing.T) {
+	ts := newRenderTestServer(t, spaShell)
+	renderer := &fakeRenderer{err: errors.New("browser crashed")}
+
+	data, err := newRenderTestScraper(renderer).Scrape(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+
+	if renderer.calls.Load() != 1 {
+		t.Errorf("Renderer called %d times, want 1", renderer.calls.Load())
+	}
+	if data.Metadata.FetchMethod != FetchMethodHTTP {
+		t.Errorf("FetchMethod = %q, want %q", data.Metadata.FetchMethod, FetchMethodHTTP)
+	}
+}
+
+func TestScrapeRenderDisabled(t *testing.T) {
+	ts := newRenderTestServer(t, spaShell)
+	renderer := &fakeRenderer{html: spaShell}
+
+	s := newRenderTestScraper(renderer)
+	s.config.RenderJSOnThin = false
+	if _, err := s.Scrape(context.Background(), ts.URL); err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+
+	if renderer.calls.Load() != 0 {
+		t.Errorf("Renderer called %d times, want 0", renderer.calls.Load())
+	}
+}
+
+func TestRenderIfThinWaitsForSlot(t *testing.T) {
+	s := newRenderTestScraper(&fakeRenderer{html: spaShell})
+
+	// Occupy every render slot, then cancel while waiting
+	for i := 0; i < cap(s.renderSlots); i++ {
+		s.renderSlots <- struct{}{}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	doc, err := html.Parse(strings.NewReader(spaShell))
+	if err != nil {
+		t.Fatalf("Failed to parse HTML: %v", err)
+	}
+	if rendered := s.renderIfThin(ctx, "https://example.com", doc); rendered != nil {
+		t.Error("Expected no render when all slots are busy and the context is done")
+	}
+}
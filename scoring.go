@@ -0,0 +1,94 @@
+package scraper
+
+import (
+	"context"
+	"log"
+
+	"github.com/zombar/scraper/models"
+)
+
+// ScoringMode selects how Scrape and ScoreLinkContent score pages
+type ScoringMode string
+
+const (
+	// ScoringAlways scores with Ollama, waiting for a free scoring slot, and
+	// falls back to the rule-based heuristics only if Ollama fails. It is
+	// the default.
+	ScoringAlways ScoringMode = "always"
+	// ScoringRuleOnly never calls Ollama for scoring
+	ScoringRuleOnly ScoringMode = "rule_only"
+	// ScoringBestEffort scores with Ollama only if a scoring slot is free,
+	// and otherwise uses the rule-based heuristics instead of waiting, so
+	// scraping keeps its pace under load
+	ScoringBestEffort ScoringMode = "best_effort"
+	// ScoringDeferred has Scrape return a provisional rule-based score
+	// straight away, leaving the Ollama score to be computed later: the API
+	// server upgrades stored records in the background, while library users
+	// call ScoreContent themselves. ScoreLinkContent scores as ScoringAlways.
+	ScoringDeferred ScoringMode = "deferred"
+)
+
+// Scoring paths reported in LinkScore.ScoringPath
+const (
+	ScoringPathAI       = "ai"        // scored by Ollama
+	ScoringPathRuleOnly = "rule_only" // rule-based because of ScoringRuleOnly
+	ScoringPathShed     = "shed"      // rule-based because every scoring slot was busy
+	ScoringPathFallback = "fallback"  // rule-based because Ollama failed
+	ScoringPathDeferred = "deferred"  // provisional rule-based score under ScoringDeferred
+)
+
+// defaultMaxConcurrentScores is the number of Ollama scoring calls in flight
+// when Config.MaxConcurrentScores is unset
+const defaultMaxConcurrentScores = 4
+
+// ParseScoringMode parses a scoring mode name; an empty name is ScoringAlways
+func ParseScoringMode(name string) (ScoringMode, bool) {
+	switch mode := ScoringMode(name); mode {
+	case "":
+		return ScoringAlways, true
+	case ScoringAlways, ScoringRuleOnly, ScoringBestEffort, ScoringDeferred:
+		return mode, true
+	}
+	return "", false
+}
+
+// scoreContent scores a page for Scrape and ScoreLinkContent according to
+// Config.ScoringMode. It always returns a score: the rule-based one when
+// Ollama is skipped or fails. operation labels the fallback metrics.
+func (s *Scraper) scoreContent(ctx context.Context, operation, targetURL, title, content string) *models.LinkScore {
+	ruleBased := func(path string) *models.LinkScore {
+		score := s.ScoreContentRuleBased(targetURL, title, content)
+		score.ScoringPath = path
+		return &score
+	}
+
+	var linkScore *models.LinkScore
+	var err error
+	switch s.config.ScoringMode {
+	case ScoringRuleOnly:
+		return ruleBased(ScoringPathRuleOnly)
+	case ScoringBestEffort:
+		select {
+		case s.scoringSlots <- struct{}{}:
+		default:
+			s.metrics.observeScoringShed(operation)
+			return ruleBased(ScoringPathShed)
+		}
+		linkScore, err = s.scoreWithOllama(ctx, targetURL, title, content)
+		<-s.scoringSlots
+	case ScoringDeferred:
+		if operation == "scrape" {
+			return ruleBased(ScoringPathDeferred)
+		}
+		linkScore, err = s.ScoreContent(ctx, targetURL, title, content)
+	default:
+		linkScore, err = s.ScoreContent(ctx, targetURL, title, content)
+	}
+
+	if err != nil {
+		log.Printf("Ollama scoring failed for %s, using rule-based fallback: %v", targetURL, err)
+		s.metrics.observeOllamaFallback(operation)
+		return ruleBased(ScoringPathFallback)
+	}
+	return linkScore
+}
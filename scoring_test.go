@@ -0,0 +1,212 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zombar/scraper/models"
+)
+
+// scoringServers starts a page server and an Ollama server whose scoring
+// responses wait for release to be closed, counting the scoring calls
+func scoringServers(t *testing.T, release <-chan struct{}) (page, ollama *httptest.Server, calls *int32) {
+	t.Helper()
+	calls = new(int32)
+	page = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Guide</title></head><body><p>A detailed technical guide.</p></body></html>`))
+	}))
+	t.Cleanup(page.Close)
+	ollama = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		<-release
+		json.NewEncoder(w).Encode(models.OllamaResponse{
+			Response: `{"score": 0.9, "reason": "AI scored", "categories": [], "malicious_indicators": []}`,
+			Done:     true,
+		})
+	}))
+	t.Cleanup(ollama.Close)
+	return page, ollama, calls
+}
+
+func TestScoringModeRuleOnly(t *testing.T) {
+	release := make(chan struct{})
+	close(release)
+	page, ollama, calls := scoringServers(t, release)
+
+	config := DefaultConfig()
+	config.OllamaBaseURL = ollama.URL
+	config.ScoringMode = ScoringRuleOnly
+	score, err := New(config).ScoreLinkContent(context.Background(), page.URL)
+	if err != nil {
+		t.Fatalf("ScoreLinkContent failed: %v", err)
+	}
+	if score.AIUsed || score.ScoringPath != ScoringPathRuleOnly {
+		t.Errorf("Score = %+v, want a rule-based score with path %q", score, ScoringPathRuleOnly)
+	}
+	if *calls != 0 {
+		t.Errorf("Ollama called %d times, want 0", *calls)
+	}
+}
+
+func TestScoringModeBestEffort(t *testing.T) {
+	release := make(chan struct{})
+	page, ollama, calls := scoringServers(t, release)
+
+	config := DefaultConfig()
+	config.OllamaBaseURL = ollama.URL
+	config.ScoringMode = ScoringBestEffort
+	config.MaxConcurrentScores = 1
+	config.MetricsEnabled = true
+	s := New(config)
+
+	// The first call takes the only slot and waits on Ollama
+	first := make(chan *models.LinkScore)
+	go func() {
+		score, err := s.ScoreLinkContent(context.Background(), page.URL)
+		if err != nil {
+			t.Errorf("ScoreLinkContent failed: %v", err)
+		}
+		first <- score
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(calls) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the first scoring call")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// The second is shed to the rule-based score instead of waiting
+	score, err := s.ScoreLinkContent(context.Background(), page.URL)
+	if err != nil {
+		t.Fatalf("ScoreLinkContent failed: %v", err)
+	}
+	if score.AIUsed || score.ScoringPath != ScoringPathShed {
+		t.Errorf("Busy score = %+v, want a rule-based score with path %q", score, ScoringPathShed)
+	}
+
+	close(release)
+	if score := <-first; score == nil || !score.AIUsed || score.ScoringPath != ScoringPathAI || score.Reason != "AI scored" {
+		t.Errorf("First score = %+v, want the AI score", score)
+	}
+	if got := atomic.LoadInt32(calls); got != 1 {
+		t.Errorf("Ollama called %d times, want 1", got)
+	}
+
+	// With the slot free again, Ollama is used
+	score, err = s.ScoreLinkContent(context.Background(), page.URL)
+	if err != nil {
+		t.Fatalf("ScoreLinkContent failed: %v", err)
+	}
+	if score.ScoringPath != ScoringPathAI {
+		t.Errorf("Score path = %q, want %q once the slot is free", score.ScoringPath, ScoringPathAI)
+	}
+	if s.Metrics().scoringShed["score"] != 1 {
+		t.Errorf("Shed count = %d, want 1", s.Metrics().scoringShed["score"])
+	}
+}
+
+func TestScoringModeAlwaysWaitsForSlot(t *testing.T) {
+	release := make(chan struct{})
+	page, ollama, calls := scoringServers(t, release)
+
+	config := DefaultConfig()
+	config.OllamaBaseURL = ollama.URL
+	config.MaxConcurrentScores = 1
+	s := New(config)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.ScoreLinkContent(context.Background(), page.URL)
+	}()
+	for atomic.LoadInt32(calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// A second call waits for the slot rather than shedding, so it runs out
+	// of time here without reaching Ollama
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := s.ScoreContent(ctx, page.URL, "Guide", "Text"); err == nil {
+		t.Error("Expected ScoreContent to time out waiting for the slot")
+	}
+	close(release)
+	<-done
+	if got := atomic.LoadInt32(calls); got != 1 {
+		t.Errorf("Ollama called %d times, want 1", got)
+	}
+}
+
+func TestScoringPathFallback(t *testing.T) {
+	page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Guide</title></head><body><p>Text.</p></body></html>`))
+	}))
+	defer page.Close()
+	ollama := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "model not loaded", http.StatusServiceUnavailable)
+	}))
+	defer ollama.Close()
+
+	config := DefaultConfig()
+	config.OllamaBaseURL = ollama.URL
+	score, err := New(config).ScoreLinkContent(context.Background(), page.URL)
+	if err != nil {
+		t.Fatalf("ScoreLinkContent failed: %v", err)
+	}
+	if score.AIUsed || score.ScoringPath != ScoringPathFallback {
+		t.Errorf("Score = %+v, want a rule-based score with path %q", score, ScoringPathFallback)
+	}
+}
+
+func TestParseScoringMode(t *testing.T) {
+	for name, want := range map[string]ScoringMode{"": ScoringAlways, "always": ScoringAlways, "rule_only": ScoringRuleOnly, "best_effort": ScoringBestEffort, "deferred": ScoringDeferred} {
+		if got, ok := ParseScoringMode(name); !ok || got != want {
+			t.Errorf("ParseScoringMode(%q) = %q, %v; want %q", name, got, ok, want)
+		}
+	}
+	if _, ok := ParseScoringMode("sometimes"); ok {
+		t.Error("Expected an unknown mode to be rejected")
+	}
+	config := DefaultConfig()
+	config.ScoringMode = "sometimes"
+	if err := config.Validate(); err == nil {
+		t.Error("Expected Validate to reject an unknown scoring mode")
+	}
+}
+
+func TestScoringModeDeferred(t *testing.T) {
+	release := make(chan struct{})
+	close(release)
+	page, ollama, _ := scoringServers(t, release)
+
+	config := DefaultConfig()
+	config.OllamaBaseURL = ollama.URL
+	config.EnableImageAnalysis = false
+	config.ScoringMode = ScoringDeferred
+	s := New(config)
+
+	data, err := s.Scrape(context.Background(), page.URL)
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+	if data.Score.AIUsed || data.Score.ScoringPath != ScoringPathDeferred {
+		t.Errorf("Scrape score = %+v, want a provisional rule-based score", data.Score)
+	}
+
+	// There is nothing to upgrade later, so ScoreLinkContent scores with Ollama
+	score, err := s.ScoreLinkContent(context.Background(), page.URL)
+	if err != nil {
+		t.Fatalf("ScoreLinkContent failed: %v", err)
+	}
+	if !score.AIUsed || score.ScoringPath != ScoringPathAI {
+		t.Errorf("ScoreLinkContent score = %+v, want an Ollama score", score)
+	}
+}
@@ -1,45 +1,212 @@
 package scraper
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"image"
+	_ "image/gif"  // Register GIF decoder for image hashing and size checks
+	_ "image/jpeg" // Register JPEG decoder for image hashing and size checks
+	_ "image/png"  // Register PNG decoder for image hashing and size checks
 	"io"
 	"log"
+	"math"
+	"mime"
+	"net"
 	"net/http"
 	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"text/template"
 	"time"
+	"unicode"
 
 	"github.com/google/uuid"
 	"github.com/zombar/scraper/models"
 	"github.com/zombar/scraper/ollama"
 	"golang.org/x/net/html"
+	"golang.org/x/net/publicsuffix"
 )
 
 // Config contains scraper configuration
 type Config struct {
-	HTTPTimeout         time.Duration
-	OllamaBaseURL       string
-	OllamaModel         string
-	EnableImageAnalysis bool          // Enable AI-powered image analysis
-	MaxImageSizeBytes   int64         // Maximum image size to download (bytes)
-	ImageTimeout        time.Duration // Timeout for downloading individual images
-	LinkScoreThreshold  float64       // Minimum score for link to be recommended (0.0-1.0)
+	HTTPTimeout           time.Duration // Overall timeout per request, including reading the body (0 for none)
+	DialTimeout           time.Duration // Timeout for establishing TCP connections (0 for none)
+	ResponseHeaderTimeout time.Duration // Timeout waiting for response headers after sending a request (0 for none)
+	MaxRedirects          int           // Maximum redirects to follow (0 uses the default of 10, negative disables redirects)
+	OllamaBaseURL         string
+	OllamaModel           string
+	OllamaHTTPClient      *http.Client              // Client for Ollama requests (nil uses one with ollama.DefaultTimeout)
+	HTTPClient            *http.Client              // Client for page, image, and login requests, e.g. with custom TLS (nil builds one from the timeout, redirect, proxy, and dialing settings)
+	ProxyURL              string                    // Proxy for page, image, and login requests: http://, https://, or socks5://host:port (empty uses HTTP_PROXY and HTTPS_PROXY from the environment)
+	OllamaProxyURL        string                    // Proxy for Ollama requests, e.g. the same as ProxyURL (empty uses the environment; ignored with OllamaHTTPClient)
+	OllamaOptions         *models.OllamaOptions     // Sampling options for content extraction (nil uses the model's defaults)
+	OllamaJSONOptions     *models.OllamaOptions     // Sampling options for scoring and link filtering (nil uses ollama.DefaultStructuredOptions: temperature 0, seed 42)
+	OllamaJSONSchemas     bool                      // Constrain scoring and link filtering answers to a JSON schema rather than any JSON (needs Ollama 0.5 or later)
+	UserAgent             string                    // User-Agent header sent on every request (empty uses DefaultUserAgent)
+	Resolver              *net.Resolver             // DNS resolver for outgoing connections (nil uses the system resolver)
+	HostOverrides         map[string]string         // Hostnames mapped to the address to connect to instead, like /etc/hosts: "10.0.0.5" or "127.0.0.1:8080"
+	EnableImageAnalysis   bool                      // Enable AI-powered image analysis
+	MaxImageSizeBytes     int64                     // Maximum image size to download (bytes, 0 uses the 10MB default)
+	MaxBodyBytes          int64                     // Maximum HTML page size to read (bytes, 0 uses the 20MB default)
+	PreflightHEAD         bool                      // Check a page's Content-Type and Content-Length with a HEAD request before downloading it
+	ImageTimeout          time.Duration             // Timeout for downloading individual images
+	AllowedImageTypes     []string                  // Image MIME types to download, e.g. "image/jpeg" (empty allows all)
+	MinImageWidth         int                       // Images narrower than this are not analyzed (0 for no minimum)
+	MinImageHeight        int                       // Images shorter than this are not analyzed (0 for no minimum)
+	RecommendedImagesOnly bool                      // Download and analyze images only on pages scored as recommended, scoring before image processing (not supported with ScoringDeferred)
+	LinkScoreThreshold    float64                   // Minimum score for link to be recommended (0.0-1.0)
+	ScoringMode           ScoringMode               // When Scrape and ScoreLinkContent use Ollama for scoring (empty uses ScoringAlways)
+	MaxConcurrentScores   int                       // Maximum Ollama scoring calls in flight across all scrapes (0 uses the default of 4)
+	MaxTrackers           int                       // Pages loading more known third-party trackers than this score 0.2 lower (0 disables)
+	BlockedDomains        map[string]string         // URL substrings mapped to the category they block (nil uses DefaultBlockedDomains)
+	QualityDomains        []string                  // URL substrings that boost the fallback score (nil uses DefaultQualityDomains); built-in blocklist entries a matching one contains are exempt
+	LinkFilterPrompt      string                    // text/template for the link filtering prompt (see LinkFilterPromptData; empty uses DefaultLinkFilterPrompt)
+	LinkFilterInclude     []string                  // Kinds of links the filter should keep (nil uses DefaultLinkFilterInclude)
+	LinkFilterExclude     []string                  // Kinds of links the filter should drop (nil uses DefaultLinkFilterExclude)
+	TrustedDomains        map[string]float64        // Hosts or TLDs (e.g. "example.com", ".corp") mapped to a fallback score boost; a match takes precedence over QualityDomains
+	SpamKeywords          map[string]map[string]int // Per-language spam phrases and allowed occurrences (nil uses DefaultSpamKeywords)
+	QualityKeywords       map[string][]string       // Per-language technical/educational keywords (nil uses DefaultQualityKeywords)
+	Logins                []LoginConfig             // Form logins performed before scraping matching hosts (optional)
+	EnableCookieJar       bool                      // Keep cookies per host across requests for the scraper's lifetime (default stateless)
+	EnableCookies         bool                      // Keep cookies set during a scrape for its later requests, such as image downloads, then discard them
+	EnableMarkdown        bool                      // Populate ScrapedData.Markdown with the page converted to Markdown
+	OutputFormat          OutputFormat              // Format of ScrapedData.Content (empty uses OutputPlain); scoring, word counts, and Ollama prompts always use plain text
+	MaxContentChars       int                       // Page text is truncated to this many characters before use in Ollama prompts (0 uses the default of 12000, negative disables)
+	ScoreContentChars     int                       // Bytes of page text in the Ollama scoring prompt, within MaxContentChars (0 uses the default of 1000, negative sends all of it)
+	DecodeCharset         bool                      // Decode windows-1252, ISO-8859-1, and UTF-16 pages to UTF-8 before parsing (otherwise pages are parsed as UTF-8)
+	NormalizeUnicode      bool                      // Apply NFC normalization and replace no-break and zero-width characters in extracted text
+	SkipHiddenText        bool                      // Leave out text of elements hidden with the hidden attribute, aria-hidden="true", or an inline display:none or visibility:hidden style
+	TextSkipTags          []string                  // Elements whose text is left out of extracted content besides script and style (nil uses DefaultTextSkipTags, empty skips none)
+	StructuredText        bool                      // Keep block structure in extracted text: a line per paragraph or heading, "- " or "1. " before list items, and "> " before quoted lines
+	ContentSelectors      map[string]string         // Hosts (matching subdomains too) mapped to a CSS selector for the main content, e.g. "article.post-body"
+	SiteRules             SiteRules                 // Per-host extraction rules; a rule's content selector takes precedence over ContentSelectors
+	SiteRulesFile         string                    // JSON file the SiteRules were loaded from, re-read by ReloadSiteRules (optional)
+	MaxSitemapDepth       int                       // Levels of nested sitemap indexes ParseSitemap follows (0 uses the default of 3, negative follows none)
+	MaxSitemapURLs        int                       // Page URLs ParseSitemap and FetchSitemap return at most (0 uses the default of 50000)
+	DiscoverConcurrency   int                       // Links DiscoverLinks scores at once (0 uses the default of 5)
+	DiscoverTimeout       time.Duration             // Total time budget for one DiscoverLinks call, including fetching the page (0 uses the 2m default)
+	MaxLinkAge            time.Duration             // DiscoverLinks skips links to pages published longer ago than this, before scoring them (0 disables); undated pages are kept
+	KeepUnfilteredLinks   bool                      // Return every extracted link in Links and the ones the Ollama link filter kept in FilteredLinks
+	UseCanonicalForDedup  bool                      // Store results under the page's canonical URL when it is on the same site
+	URLNormalization      bool                      // Store results under NormalizeURL's form of the URL: lowercase host, no default port, tracking parameters, or fragment
+	StripTrailingSlash    bool                      // With URLNormalization, also treat /page/ and /page as the same URL
+	NormalizeLinks        bool                      // Return extracted links in normalized form, without trailing slashes, so variants of one page appear once
+	KeepInPageLinks       bool                      // Keep links that are only a fragment, such as href="#section" (dropped by default)
+	StripLinkFragments    bool                      // Remove fragments from extracted links; leave off for sites that route with fragments
+	MaxRetryAfter         time.Duration             // Longest Retry-After on a 429 to wait out before retrying once (0 never waits)
+	Renderer              Renderer                  // Headless browser used by RenderJSOnThin (optional)
+	RenderJSOnThin        bool                      // Retry pages with thin content once through Renderer
+	ThinContentWords      int                       // Pages with fewer words of text are thin (0 uses the default of 50)
+	MaxConcurrentRenders  int                       // Maximum renders in flight across all scrapes (0 uses the default of 2)
+	RenderTimeout         time.Duration             // Timeout for a single render (0 uses the 30s default)
+	ImageLookup           ImageLookupFunc           // Finds a previously analyzed image by content hash to skip re-analysis (optional)
+	MetricsEnabled        bool                      // Collect scrape, scoring, and image analysis metrics (see Scraper.Metrics)
 }
 
+// ImageLookupFunc returns a previously stored image with the given content hash,
+// or nil if there is none
+type ImageLookupFunc func(hash string) (*models.ImageInfo, error)
+
 // DefaultConfig returns default scraper configuration
 func DefaultConfig() Config {
 	return Config{
 		HTTPTimeout:         30 * time.Second,
+		DialTimeout:         30 * time.Second,
+		MaxRedirects:        defaultMaxRedirects,
+		UserAgent:           DefaultUserAgent,
 		OllamaBaseURL:       ollama.DefaultBaseURL,
 		OllamaModel:         ollama.DefaultModel,
-		EnableImageAnalysis: true,              // Enable image analysis by default
-		MaxImageSizeBytes:   10 * 1024 * 1024,  // 10MB max image size
-		ImageTimeout:        15 * time.Second,  // 15s timeout per image
-		LinkScoreThreshold:  0.5,               // Default threshold for link scoring
+		EnableImageAnalysis: true,                     // Enable image analysis by default
+		MaxImageSizeBytes:   defaultMaxImageSizeBytes, // 10MB max image size
+		MaxBodyBytes:        defaultMaxBodyBytes,
+		ImageTimeout:        15 * time.Second, // 15s timeout per image
+		LinkScoreThreshold:  0.5,              // Default threshold for link scoring
+		MaxRetryAfter:       10 * time.Second,
+		NormalizeUnicode:    true,
+		SkipHiddenText:      true,
+		StructuredText:      true,
+		DecodeCharset:       true,
+	}
+}
+
+// Validate checks the configuration values New cannot recover from: the
+// proxy URLs, the scoring mode, the output format, and the site rules
+func (c Config) Validate() error {
+	if _, ok := ParseScoringMode(string(c.ScoringMode)); !ok {
+		return fmt.Errorf("invalid scoring mode %q", c.ScoringMode)
+	}
+	if c.RecommendedImagesOnly && c.ScoringMode == ScoringDeferred {
+		// Images would be skipped on the provisional score, and the
+		// background upgrade does not go back for them
+		return fmt.Errorf("RecommendedImagesOnly is not supported with the %q scoring mode", ScoringDeferred)
+	}
+	if _, ok := ParseOutputFormat(string(c.OutputFormat)); !ok {
+		return fmt.Errorf("invalid output format %q", c.OutputFormat)
+	}
+	if c.ProxyURL != "" {
+		if _, err := parseProxyURL(c.ProxyURL); err != nil {
+			return err
+		}
 	}
+	if c.OllamaProxyURL != "" {
+		if _, err := parseProxyURL(c.OllamaProxyURL); err != nil {
+			return fmt.Errorf("ollama: %w", err)
+		}
+	}
+	return c.SiteRules.Validate()
+}
+
+// Progress phases reported to a ProgressFunc
+const (
+	PhaseFetched          = "fetched"           // detail is FetchProgress
+	PhaseContentExtracted = "content_extracted" // detail is ContentProgress
+	PhaseImageStarted     = "image_started"     // detail is ImageProgress
+	PhaseImageAnalyzed    = "image_analyzed"    // detail is ImageProgress
+	PhaseLinksExtracted   = "links_extracted"   // detail is LinksProgress
+	PhaseScored           = "scored"            // detail is *models.LinkScore
+)
+
+// ProgressFunc receives progress events while a scrape is running.
+// It is called synchronously from the scraping goroutine, so a slow callback
+// stalls the pipeline: avoid heavy work inline and hand events off to a
+// buffered channel or goroutine if the consumer may block.
+type ProgressFunc func(phase string, detail interface{})
+
+// FetchProgress describes a fetched page
+type FetchProgress struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code"`
+}
+
+// ContentProgress describes the extracted page content
+type ContentProgress struct {
+	Title         string `json:"title"`
+	ContentLength int    `json:"content_length"`
+	ImageCount    int    `json:"image_count"`
+}
+
+// LinksProgress describes the filtered page links
+type LinksProgress struct {
+	Count int `json:"count"`
+}
+
+// ImageProgress describes a single image before or after processing.
+// Index is the image's position on the page; when images are processed
+// concurrently, events may arrive in a different order than Index.
+type ImageProgress struct {
+	Index int              `json:"index"`
+	Total int              `json:"total"`
+	Image models.ImageInfo `json:"image"`
 }
 
 // Scraper handles web scraping operations
@@ -47,22 +214,195 @@ type Scraper struct {
 	config       Config
 	httpClient   *http.Client
 	ollamaClient *ollama.Client
+	sessions     *sessionJar
+	renderSlots  chan struct{} // Semaphore bounding concurrent renders
+	scoringSlots chan struct{} // Semaphore bounding concurrent Ollama scoring calls
+	metrics      *Metrics      // nil unless metrics are enabled
+
+	siteRules atomic.Pointer[map[string]*siteRule] // Compiled SiteRules and ContentSelectors keyed by lowercase host
+
+	linkFilterPrompt  *template.Template
+	linkFilterInclude []string
+	linkFilterExclude []string
+	textSkipTags      map[string]bool
 }
 
 // New creates a new Scraper instance
 func New(config Config) *Scraper {
-	return &Scraper{
-		config: config,
-		httpClient: &http.Client{
-			Timeout: config.HTTPTimeout,
-		},
-		ollamaClient: ollama.NewClient(config.OllamaBaseURL, config.OllamaModel),
+	if strings.TrimSpace(config.UserAgent) == "" {
+		config.UserAgent = DefaultUserAgent
+	}
+	s := &Scraper{
+		config:            config,
+		httpClient:        newHTTPClient(config),
+		ollamaClient:      ollama.NewClientWithHTTPClient(config.OllamaBaseURL, config.OllamaModel, newOllamaHTTPClient(config)),
+		linkFilterPrompt:  parseLinkFilterPrompt(config.LinkFilterPrompt),
+		linkFilterInclude: config.LinkFilterInclude,
+		linkFilterExclude: config.LinkFilterExclude,
+	}
+	s.ollamaClient.SetOptions(config.OllamaOptions, config.OllamaJSONOptions)
+	s.ollamaClient.SetJSONSchemas(config.OllamaJSONSchemas)
+	s.ollamaClient.SetScoreContentChars(config.ScoreContentChars)
+	if config.MetricsEnabled {
+		s.metrics = newMetrics()
+	}
+
+	if err := s.setSiteRules(config.SiteRules); err != nil {
+		// Callers are expected to validate rules; run without them rather than fail
+		log.Printf("Ignoring site rules: %v", err)
+		s.setSiteRules(nil)
+	}
+
+	maxRenders := config.MaxConcurrentRenders
+	if maxRenders <= 0 {
+		maxRenders = defaultMaxConcurrentRenders
+	}
+	s.renderSlots = make(chan struct{}, maxRenders)
+
+	maxScores := config.MaxConcurrentScores
+	if maxScores <= 0 {
+		maxScores = defaultMaxConcurrentScores
+	}
+	s.scoringSlots = make(chan struct{}, maxScores)
+
+	if s.linkFilterInclude == nil {
+		s.linkFilterInclude = defaultLinkFilterInclude
+	}
+	skipTags := config.TextSkipTags
+	if skipTags == nil {
+		skipTags = defaultTextSkipTags
+	}
+	s.textSkipTags = make(map[string]bool, len(skipTags))
+	for _, tag := range skipTags {
+		s.textSkipTags[strings.ToLower(strings.TrimSpace(tag))] = true
+	}
+	if s.linkFilterExclude == nil {
+		s.linkFilterExclude = defaultLinkFilterExclude
+	}
+
+	if len(config.Logins) > 0 || config.EnableCookieJar {
+		sessions, err := newSessionJar(config.Logins, config.EnableCookieJar)
+		if err != nil {
+			log.Printf("Failed to configure cookie jar: %v", err)
+		} else {
+			s.sessions = sessions
+			s.httpClient.Jar = sessions
+		}
+	}
+
+	return s
+}
+
+// newHTTPClient returns the client for page, image, and login requests. A
+// Config.HTTPClient is copied, sharing its transport and connection pool, so
+// the scraper can set its own cookie jar without changing the caller's client;
+// the timeout, redirect, and dialing settings do not apply to it.
+func newHTTPClient(config Config) *http.Client {
+	if config.HTTPClient != nil {
+		client := *config.HTTPClient
+		return &client
+	}
+	return &http.Client{
+		Timeout:       config.HTTPTimeout,
+		Transport:     newTransport(config),
+		CheckRedirect: checkRedirect(config.MaxRedirects),
+	}
+}
+
+// newTransport builds an HTTP transport with the configured proxy, connection
+// timeouts, and name resolution
+func newTransport(config Config) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxyFunc(config.ProxyURL)
+	transport.DialContext = dialContext(config)
+	transport.ResponseHeaderTimeout = config.ResponseHeaderTimeout
+	return transport
+}
+
+// dialContext returns the transport's dial function. Hosts in
+// Config.HostOverrides connect to their override address instead of being
+// resolved; all others resolve through Config.Resolver (the system resolver
+// when nil). Only the connection address changes: the URL, Host header, and
+// TLS server name keep the original hostname.
+func dialContext(config Config) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{
+		Timeout:   config.DialTimeout,
+		KeepAlive: 30 * time.Second,
+		Resolver:  config.Resolver,
+	}
+	if len(config.HostOverrides) == 0 {
+		return dialer.DialContext
+	}
+
+	overrides := make(map[string]string, len(config.HostOverrides))
+	for host, target := range config.HostOverrides {
+		overrides[strings.ToLower(host)] = target
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		if target, ok := overrides[strings.ToLower(host)]; ok {
+			// An override without a port keeps the requested one
+			if _, _, err := net.SplitHostPort(target); err != nil {
+				target = net.JoinHostPort(target, port)
+			}
+			addr = target
+		}
+		return dialer.DialContext(ctx, network, addr)
+	}
+}
+
+// DefaultUserAgent is the User-Agent sent when Config.UserAgent is empty
+const DefaultUserAgent = "Mozilla/5.0 (compatible; Scraper/1.0)"
+
+// defaultMaxRedirects matches the net/http client's default redirect limit
+const defaultMaxRedirects = 10
+
+// checkRedirect returns a redirect policy that follows at most maxRedirects redirects
+func checkRedirect(maxRedirects int) func(*http.Request, []*http.Request) error {
+	if maxRedirects == 0 {
+		maxRedirects = defaultMaxRedirects
+	}
+	return func(req *http.Request, via []*http.Request) error {
+		if maxRedirects < 0 {
+			// Return the redirect response itself rather than following it
+			return http.ErrUseLastResponse
+		}
+		if len(via) > maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		return nil
 	}
 }
 
 // Scrape fetches and processes a URL
 func (s *Scraper) Scrape(ctx context.Context, targetURL string) (*models.ScrapedData, error) {
+	return s.ScrapeWithProgress(ctx, targetURL, nil)
+}
+
+// rawContentKey is the context key set by WithRawContent
+type rawContentKey struct{}
+
+// WithRawContent returns a context whose scrapes also return the page text
+// as extracted before Ollama cleaned it, in ScrapedData.RawContent, so
+// that the two can be compared
+func WithRawContent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, rawContentKey{}, true)
+}
+
+// ScrapeWithProgress fetches and processes a URL, reporting progress to the
+// given callback as the scrape runs. A nil callback behaves like Scrape.
+func (s *Scraper) ScrapeWithProgress(ctx context.Context, targetURL string, progress ProgressFunc) (*models.ScrapedData, error) {
 	start := time.Now()
+	data, err := s.scrape(ctx, targetURL, progress, start)
+	s.metrics.observeScrape(err, time.Since(start))
+	return data, err
+}
+
+// scrape implements ScrapeWithProgress
+func (s *Scraper) scrape(ctx context.Context, targetURL string, progress ProgressFunc, start time.Time) (*models.ScrapedData, error) {
 
 	// Validate URL
 	parsedURL, err := url.Parse(targetURL)
@@ -73,100 +413,214 @@ func (s *Scraper) Scrape(ctx context.Context, targetURL string) (*models.Scraped
 		return nil, fmt.Errorf("URL must be http or https")
 	}
 
-	// Fetch the page
-	req, err := http.NewRequestWithContext(ctx, "GET", targetURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	// Log in first if the host requires an authenticated session
+	if err := s.ensureLogin(ctx, parsedURL); err != nil {
+		return nil, err
+	}
+
+	// Give the page, its redirects, and its images a shared cookie jar
+	if s.config.EnableCookies {
+		ctx = s.withScrapeCookies(ctx)
+	}
+
+	// Track per-phase timings alongside the aggregate processing time
+	timings := &models.Timings{}
+	phaseStart := time.Now()
+
+	// Skip non-HTML and oversized resources before downloading them
+	if s.config.PreflightHEAD {
+		if err := s.preflight(ctx, targetURL); err != nil {
+			return nil, err
+		}
 	}
-	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; Scraper/1.0)")
 
-	resp, err := s.httpClient.Do(req)
+	// Fetch the page
+	resp, err := s.fetchPage(ctx, targetURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch URL: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP error: %d %s", resp.StatusCode, resp.Status)
+	// Read the page, handing PDF, plain text, and JSON documents to their
+	// own extraction
+	body, err := s.readBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	if contentType := documentType(resp.Header.Get("Content-Type"), body); contentType != "" {
+		timings.FetchTime = time.Since(phaseStart).Seconds()
+		return s.scrapeDocument(ctx, targetURL, contentType, resp, body, timings, progress, start)
 	}
 
 	// Parse HTML
-	doc, err := html.Parse(resp.Body)
+	body, charset := s.decodeHTML(resp, body)
+	doc, err := html.Parse(bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse HTML: %w", err)
 	}
 
+	// Resolve relative links and images against where we landed after redirects
+	parsedURL = resp.Request.URL
+
+	// Retry suspected single-page apps with the headless renderer
+	fetchMethod := FetchMethodHTTP
+	if rendered := s.renderIfThin(ctx, parsedURL.String(), doc); rendered != nil {
+		doc = rendered
+		fetchMethod = FetchMethodRendered
+	}
+
+	timings.FetchTime = time.Since(phaseStart).Seconds()
+	phaseStart = time.Now()
+
+	if progress != nil {
+		progress(PhaseFetched, FetchProgress{URL: targetURL, StatusCode: resp.StatusCode})
+	}
+
 	// Extract title
-	title := extractTitle(doc)
+	title := s.extractTitle(parsedURL, doc)
 	if title == "" {
 		title = targetURL
 	}
 
-	// Extract text content
-	textContent := extractText(doc)
+	// Extract text content, limited to the main content when a selector is configured
+	contentRoot, selected := s.selectContent(parsedURL, doc)
+	textContent := s.extractText(contentRoot)
+	headings := extractHeadings(doc)
 
-	// Use Ollama to extract meaningful content
-	content, err := s.ollamaClient.ExtractContent(ctx, textContent)
-	if err != nil {
-		// If Ollama extraction fails, fall back to raw text
-		content = textContent
+	content := textContent
+	if !selected {
+		// Use Ollama to extract meaningful content; a matched selector already
+		// isolates it, so the call is skipped
+		content, err = s.ollamaClient.ExtractContent(ctx, truncateContent(textContent, s.maxContentChars()))
+		if err != nil {
+			// If Ollama extraction fails, fall back to the main content block
+			content = s.fallbackContent(contentRoot, textContent)
+		}
+	}
+
+	// Convert the page to Markdown if requested, as a separate field or as the content
+	var markdown string
+	if s.config.EnableMarkdown || s.config.OutputFormat == OutputMarkdown {
+		markdown = htmlToMarkdown(contentRoot, parsedURL)
 	}
 
 	// Extract images
 	images := extractImages(doc, parsedURL)
 
-	// Process images (download and analyze if enabled)
-	images = s.processImages(ctx, images)
+	// Extract embedded videos and audio
+	media := extractMedia(doc, parsedURL)
 
-	// Extract links with Ollama sanitization
-	links := s.extractLinksWithOllama(ctx, doc, parsedURL, title, content)
+	timings.ExtractTime = time.Since(phaseStart).Seconds()
+	phaseStart = time.Now()
+
+	if progress != nil {
+		progress(PhaseContentExtracted, ContentProgress{Title: title, ContentLength: len(content), ImageCount: len(images)})
+	}
+
+	// Process images (download and analyze if enabled), unless that waits
+	// for the score below
+	if !s.config.RecommendedImagesOnly {
+		images = s.processImages(ctx, images, progress)
+
+		timings.ImageTime = time.Since(phaseStart).Seconds()
+		phaseStart = time.Now()
+	}
+
+	// Extract links with Ollama sanitization, or keep the full set alongside
+	// the filter's picks so that only those are stored
+	var links, filteredLinks []string
+	if s.config.KeepUnfilteredLinks {
+		links, filteredLinks = s.filterLinksWithOllama(ctx, doc, parsedURL, title, content)
+		filteredLinks = keepListed(filteredLinks, links)
+	} else {
+		links = s.extractLinksWithOllama(ctx, doc, parsedURL, title, content)
+	}
+
+	if progress != nil {
+		progress(PhaseLinksExtracted, LinksProgress{Count: len(links)})
+	}
 
 	// Extract metadata
 	metadata := extractMetadata(doc)
+	metadata.FetchMethod = fetchMethod
+	charset.apply(&metadata)
+	if _, rule := s.siteRuleFor(parsedURL); rule != nil {
+		if author := selectText(rule.author, doc); author != "" {
+			metadata.Author = author
+		}
+	}
+	metadata.ThirdPartyHosts, metadata.TrackerCount = extractThirdPartyHosts(doc, parsedURL)
+	canonicalURL := extractCanonicalURL(doc, parsedURL)
+
+	// Link filtering and metadata count towards extraction time
+	timings.ExtractTime += time.Since(phaseStart).Seconds()
+	phaseStart = time.Now()
 
 	// Score the content (with fallback to rule-based scoring)
-	score, reason, categories, maliciousIndicators, err := s.ollamaClient.ScoreContent(ctx, targetURL, title, content)
-	var linkScore *models.LinkScore
-	if err != nil {
-		// Fallback to rule-based scoring when Ollama is unavailable
-		log.Printf("Ollama scoring failed for %s, using rule-based fallback: %v", targetURL, err)
-		score, reason, categories, maliciousIndicators = scoreContentFallback(targetURL, title, content)
-		linkScore = &models.LinkScore{
-			URL:                 targetURL,
-			Score:               score,
-			Reason:              reason,
-			Categories:          categories,
-			IsRecommended:       score >= s.config.LinkScoreThreshold,
-			MaliciousIndicators: maliciousIndicators,
-			AIUsed:              false, // Rule-based fallback
-		}
-	} else {
-		linkScore = &models.LinkScore{
-			URL:                 targetURL,
-			Score:               score,
-			Reason:              reason,
-			Categories:          categories,
-			IsRecommended:       score >= s.config.LinkScoreThreshold,
-			MaliciousIndicators: maliciousIndicators,
-			AIUsed:              true, // AI-powered scoring
+	linkScore := s.scoreContent(ctx, "scrape", targetURL, title, content)
+	s.ApplyTrackerPenalty(linkScore, metadata.TrackerCount)
+
+	timings.ScoreTime = time.Since(phaseStart).Seconds()
+
+	if progress != nil {
+		progress(PhaseScored, linkScore)
+	}
+
+	// Only pages worth keeping get their images downloaded and analyzed;
+	// the others keep image URLs and alt text
+	if s.config.RecommendedImagesOnly {
+		phaseStart = time.Now()
+		if linkScore.IsRecommended {
+			images = s.processImages(ctx, images, progress)
+		} else if len(images) > 0 {
+			log.Printf("Skipping %d images of %s: page is not recommended", len(images), targetURL)
 		}
+		timings.ImageTime = time.Since(phaseStart).Seconds()
 	}
 
+	// Key storage on the canonical URL so tracking-parameter variants share one record
+	dataURL := targetURL
+	if s.config.UseCanonicalForDedup && canonicalURL != "" && sameSite(canonicalURL, parsedURL) {
+		dataURL = canonicalURL
+	}
+	dataURL = s.NormalizeURL(dataURL)
+
 	// Create scraped data
 	data := &models.ScrapedData{
 		ID:             uuid.New().String(),
-		URL:            targetURL,
+		URL:            dataURL,
+		FinalURL:       parsedURL.String(),
+		StatusCode:     resp.StatusCode,
+		CanonicalURL:   canonicalURL,
 		Title:          title,
 		Content:        content,
+		ContentType:    ContentTypeHTML,
+		Language:       pageLanguage(doc, resp.Header.Get("Content-Language"), content),
+		Headings:       headings,
 		Images:         images,
+		Media:          media,
 		Links:          links,
+		FilteredLinks:  filteredLinks,
 		FetchedAt:      time.Now(),
 		CreatedAt:      time.Now(),
 		ProcessingTime: time.Since(start).Seconds(),
+		Timings:        timings,
 		Cached:         false,
 		Metadata:       metadata,
 		Score:          linkScore,
 	}
+	if s.config.EnableMarkdown {
+		data.Markdown = markdown
+	}
+	if s.config.OutputFormat == OutputMarkdown {
+		data.Content = markdown
+	}
+	// Count the stored content, so min_words filters on what clients read
+	data.WordCount = countWords(data.Content)
+	data.ReadingTimeSeconds = readingTimeSeconds(data.WordCount)
+	if ctx.Value(rawContentKey{}) != nil {
+		data.RawContent = textContent
+	}
 
 	return data, nil
 }
@@ -182,29 +636,31 @@ func (s *Scraper) ExtractLinks(ctx context.Context, targetURL string) ([]string,
 		return nil, fmt.Errorf("URL must be http or https")
 	}
 
-	// Fetch the page
-	req, err := http.NewRequestWithContext(ctx, "GET", targetURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	// Log in first if the host requires an authenticated session
+	if err := s.ensureLogin(ctx, parsedURL); err != nil {
+		return nil, err
 	}
-	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; Scraper/1.0)")
 
-	resp, err := s.httpClient.Do(req)
+	// Fetch the page
+	resp, err := s.fetchPage(ctx, targetURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch URL: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP error: %d %s", resp.StatusCode, resp.Status)
-	}
-
 	// Parse HTML
-	doc, err := html.Parse(resp.Body)
+	body, _, err := s.readHTML(resp)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := html.Parse(bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse HTML: %w", err)
 	}
 
+	// Resolve relative links against where we landed after redirects
+	parsedURL = resp.Request.URL
+
 	// Extract title
 	title := extractTitle(doc)
 	if title == "" {
@@ -212,13 +668,13 @@ func (s *Scraper) ExtractLinks(ctx context.Context, targetURL string) ([]string,
 	}
 
 	// Extract text content
-	textContent := extractText(doc)
+	textContent := s.extractText(doc)
 
 	// Use Ollama to extract meaningful content
-	content, err := s.ollamaClient.ExtractContent(ctx, textContent)
+	content, err := s.ollamaClient.ExtractContent(ctx, truncateContent(textContent, s.maxContentChars()))
 	if err != nil {
-		// If Ollama extraction fails, fall back to raw text
-		content = textContent
+		// If Ollama extraction fails, fall back to the main content block
+		content = s.fallbackContent(doc, textContent)
 	}
 
 	// Extract links with Ollama sanitization and fallback
@@ -246,55 +702,297 @@ func extractTitle(n *html.Node) string {
 	return strings.TrimSpace(title)
 }
 
+// defaultTextSkipTags are the page landmarks left out of extracted content
+// when Config.TextSkipTags is unset: menus, banners, and sidebars
+var defaultTextSkipTags = []string{"nav", "header", "footer", "aside"}
+
+// DefaultTextSkipTags returns a copy of the built-in text skip tags,
+// suitable as a starting point for Config.TextSkipTags
+func DefaultTextSkipTags() []string {
+	return append([]string(nil), defaultTextSkipTags...)
+}
+
 // extractText extracts all text content from the HTML
 func extractText(n *html.Node) string {
+	return collectText(n, false, true, nil)
+}
+
+// blockElements are the elements whose boundaries start a new line in
+// extracted text, so paragraphs, list items, and headings stay apart
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "br": true,
+	"dd": true, "div": true, "dl": true, "dt": true, "figcaption": true, "figure": true,
+	"footer": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"header": true, "hr": true, "li": true, "main": true, "nav": true, "ol": true, "p": true,
+	"pre": true, "section": true, "table": true, "tr": true, "ul": true,
+}
+
+// collectText extracts the text content of the HTML, leaving out elements
+// that are hidden from readers if skipHidden is set and elements in skipTags.
+// Runs of whitespace become a single space, except inside <pre>, and table
+// cells are separated by a space. If structured is set, text in different
+// block elements is separated by a newline, list items start with "- " (or
+// "1. " in an ordered list) and nested lists are indented under them, and
+// quoted lines start with "> "; otherwise blocks are separated by a space.
+// An element with role="navigation" counts as a nav, and a header or footer
+// only when it belongs to the page rather than to an article or section. The
+// root itself is never skipped, so a selected landmark keeps its text.
+func collectText(root *html.Node, skipHidden, structured bool, skipTags map[string]bool) string {
 	var buf strings.Builder
-	var f func(*html.Node)
-	f = func(n *html.Node) {
+	sep := ""
+	inPre := 0
+	quoteDepth := 0
+	indent := ""        // continuation indent under the enclosing list items
+	pendingMarker := "" // marker and indent for the first line of a list item
+
+	// linePrefix returns the quote markers and list indent or marker that
+	// start a new line
+	linePrefix := func() string {
+		prefix := strings.Repeat("> ", quoteDepth)
+		if pendingMarker != "" {
+			prefix += pendingMarker
+			pendingMarker = ""
+		} else {
+			prefix += indent
+		}
+		return prefix
+	}
+
+	var f func(n *html.Node, inSection bool)
+	f = func(n *html.Node, inSection bool) {
 		if n.Type == html.TextNode {
-			text := strings.TrimSpace(n.Data)
+			text := strings.TrimFunc(n.Data, isHTMLSpace)
+			if inPre == 0 {
+				text = strings.Join(strings.FieldsFunc(text, isHTMLSpace), " ")
+			}
+			// Text split by inline markup, as in "<em>two</em>.", stays joined
+			// unless the source has whitespace between the parts
+			if sep == "" && buf.Len() > 0 && n.Data != "" && isHTMLSpace(rune(n.Data[0])) {
+				sep = " "
+			}
 			if text != "" {
+				if structured && (buf.Len() == 0 || sep == "\n") {
+					buf.WriteString(sep)
+					buf.WriteString(linePrefix())
+					if inPre > 0 {
+						text = strings.ReplaceAll(text, "\n", "\n"+strings.Repeat("> ", quoteDepth)+indent)
+					}
+				} else {
+					buf.WriteString(sep)
+				}
 				buf.WriteString(text)
-				buf.WriteString(" ")
+				sep = ""
+				if isHTMLSpace(rune(n.Data[len(n.Data)-1])) {
+					sep = " "
+				}
+			}
+		}
+		if n.Type == html.ElementNode {
+			// Skip script and style tags
+			if n.Data == "script" || n.Data == "style" {
+				return
+			}
+			if n != root && skipLandmark(n, skipTags, inSection) {
+				return
+			}
+			switch n.Data {
+			case "article", "aside", "main", "nav", "section":
+				inSection = true
 			}
 		}
-		// Skip script and style tags
-		if n.Type == html.ElementNode && (n.Data == "script" || n.Data == "style") {
+		if skipHidden && isHiddenElement(n) {
 			return
 		}
+		block := n.Type == html.ElementNode && (blockElements[n.Data] || n.Data == "td" || n.Data == "th")
+		breakSep := " "
+		if structured && blockElements[n.Data] {
+			breakSep = "\n"
+		}
+		if block && buf.Len() > 0 && sep != "\n" {
+			sep = breakSep
+		}
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "pre":
+				inPre++
+				defer func() { inPre-- }()
+			case "blockquote":
+				quoteDepth++
+				defer func() { quoteDepth-- }()
+			case "li":
+				if structured {
+					marker := listItemMarker(n)
+					savedIndent := indent
+					pendingMarker = indent + marker
+					indent += strings.Repeat(" ", len(marker))
+					defer func() {
+						indent = savedIndent
+						pendingMarker = "" // an item without text gets no marker
+					}()
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			f(c, inSection)
+		}
+		if block && buf.Len() > 0 && sep != "\n" {
+			sep = breakSep
+		}
+	}
+	f(root, false)
+	return buf.String()
+}
+
+// listItemMarker returns the marker written before a list item's text: its
+// number in an ordered list, counting from the list's start attribute, or a
+// bullet otherwise
+func listItemMarker(li *html.Node) string {
+	if li.Parent == nil || li.Parent.Type != html.ElementNode || li.Parent.Data != "ol" {
+		return "- "
+	}
+	number := 1
+	if start, err := strconv.Atoi(strings.TrimSpace(getAttr(li.Parent, "start"))); err == nil {
+		number = start
+	}
+	for c := li.PrevSibling; c != nil; c = c.PrevSibling {
+		if c.Type == html.ElementNode && c.Data == "li" {
+			number++
+		}
+	}
+	return strconv.Itoa(number) + ". "
+}
+
+// isHTMLSpace reports whether r is ASCII whitespace, the only whitespace
+// HTML collapses; no-break spaces are left to normalizeText
+func isHTMLSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\f' || r == '\r'
+}
+
+// skipLandmark reports whether an element's text is left out because its
+// tag is in skipTags. Headers and footers inside sectioning content hold an
+// article's title or byline rather than page chrome, so they are kept.
+func skipLandmark(n *html.Node, skipTags map[string]bool, inSection bool) bool {
+	if len(skipTags) == 0 {
+		return false
+	}
+	if skipTags["nav"] && strings.EqualFold(strings.TrimSpace(getAttr(n, "role")), "navigation") {
+		return true
+	}
+	if !skipTags[n.Data] {
+		return false
+	}
+	return !(inSection && (n.Data == "header" || n.Data == "footer"))
+}
+
+// isHiddenElement reports whether an element is hidden by its own markup: the
+// hidden attribute, aria-hidden="true", or an inline display:none or
+// visibility:hidden style. hidden="until-found" content is still findable in
+// the page, so it counts as visible. Stylesheets are not consulted.
+func isHiddenElement(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	if hasAttr(n, "hidden") && !strings.EqualFold(strings.TrimSpace(getAttr(n, "hidden")), "until-found") {
+		return true
+	}
+	if strings.EqualFold(strings.TrimSpace(getAttr(n, "aria-hidden")), "true") {
+		return true
+	}
+
+	for _, declaration := range strings.Split(getAttr(n, "style"), ";") {
+		property, value, ok := strings.Cut(declaration, ":")
+		if !ok {
+			continue
+		}
+		property = strings.ToLower(strings.TrimSpace(property))
+		value = strings.ToLower(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "!important")))
+		switch {
+		case property == "display" && value == "none":
+			return true
+		case property == "visibility" && (value == "hidden" || value == "collapse"):
+			return true
+		}
+	}
+	return false
+}
+
+// extractText extracts text content, skipping hidden elements and page
+// landmarks and normalizing Unicode if configured
+func (s *Scraper) extractText(n *html.Node) string {
+	text := collectText(n, s.config.SkipHiddenText, s.config.StructuredText, s.textSkipTags)
+	if s.config.NormalizeUnicode {
+		text = normalizeText(text)
+	}
+	return text
+}
+
+// extractHeadings returns the h1-h6 outline of the page, skipping empty headings
+func extractHeadings(n *html.Node) []models.Heading {
+	var headings []models.Heading
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "script", "style":
+				return
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				text := strings.Join(strings.Fields(extractText(n)), " ")
+				if text != "" {
+					headings = append(headings, models.Heading{Level: int(n.Data[1] - '0'), Text: text})
+				}
+				return
+			}
+		}
 		for c := n.FirstChild; c != nil; c = c.NextSibling {
 			f(c)
 		}
 	}
 	f(n)
-	return strings.TrimSpace(buf.String())
+	return headings
 }
 
 // extractImages extracts image information from the HTML
 func extractImages(n *html.Node, baseURL *url.URL) []models.ImageInfo {
 	var images []models.ImageInfo
+	seen := make(map[string]int) // resolved URL -> index in images
+
+	add := func(src, alt string) {
+		src = strings.TrimSpace(src)
+		if src == "" {
+			return
+		}
+		// Resolve relative URLs
+		imgURL, err := resolveURL(baseURL, src)
+		if err != nil {
+			return
+		}
+		if i, ok := seen[imgURL]; ok {
+			// Keep alt text from a later <img> duplicating a <source> or background
+			if images[i].AltText == "" {
+				images[i].AltText = alt
+			}
+			return
+		}
+		seen[imgURL] = len(images)
+		images = append(images, models.ImageInfo{
+			URL:     imgURL,
+			AltText: alt,
+			Summary: "",
+			Tags:    []string{},
+		})
+	}
+
 	var f func(*html.Node)
 	f = func(n *html.Node) {
-		if n.Type == html.ElementNode && n.Data == "img" {
-			var src, alt string
-			for _, attr := range n.Attr {
-				switch attr.Key {
-				case "src":
-					src = attr.Val
-				case "alt":
-					alt = attr.Val
-				}
+		if n.Type == html.ElementNode {
+			switch {
+			case n.Data == "img":
+				add(getAttr(n, "src"), getAttr(n, "alt"))
+			case n.Data == "source" && n.Parent != nil && n.Parent.Data == "picture":
+				add(bestSrcsetCandidate(getAttr(n, "srcset")), "")
 			}
-			if src != "" {
-				// Resolve relative URLs
-				if imgURL, err := resolveURL(baseURL, src); err == nil {
-					images = append(images, models.ImageInfo{
-						URL:     imgURL,
-						AltText: alt,
-						Summary: "",
-						Tags:    []string{},
-					})
-				}
+			for _, bg := range backgroundImageURLs(getAttr(n, "style")) {
+				add(bg, "")
 			}
 		}
 		for c := n.FirstChild; c != nil; c = c.NextSibling {
@@ -305,10 +1003,79 @@ func extractImages(n *html.Node, baseURL *url.URL) []models.ImageInfo {
 	return images
 }
 
+// bestSrcsetCandidate returns the highest-resolution URL in a srcset attribute,
+// judged by its width or density descriptor
+func bestSrcsetCandidate(srcset string) string {
+	var best string
+	var bestSize float64
+	for _, candidate := range strings.Split(srcset, ",") {
+		fields := strings.Fields(candidate)
+		if len(fields) == 0 {
+			continue
+		}
+
+		size := 1.0 // no descriptor means 1x
+		if len(fields) > 1 {
+			descriptor := fields[1]
+			if value, err := strconv.ParseFloat(descriptor[:len(descriptor)-1], 64); err == nil &&
+				(strings.HasSuffix(descriptor, "w") || strings.HasSuffix(descriptor, "x")) {
+				size = value
+			}
+		}
+
+		if best == "" || size > bestSize {
+			best, bestSize = fields[0], size
+		}
+	}
+	return best
+}
+
+// cssURLPattern matches url(...) values in CSS, with or without quotes
+var cssURLPattern = regexp.MustCompile(`url\(\s*['"]?([^'")]+?)['"]?\s*\)`)
+
+// backgroundImageURLs returns the image URLs referenced by background and
+// background-image declarations in an inline style attribute. Data URIs are
+// skipped since they are usually placeholders.
+func backgroundImageURLs(style string) []string {
+	if style == "" {
+		return nil
+	}
+
+	var urls []string
+	for _, declaration := range strings.Split(style, ";") {
+		property, value, ok := strings.Cut(declaration, ":")
+		if !ok {
+			continue
+		}
+		property = strings.ToLower(strings.TrimSpace(property))
+		if property != "background" && property != "background-image" {
+			continue
+		}
+		for _, match := range cssURLPattern.FindAllStringSubmatch(value, -1) {
+			if !strings.HasPrefix(strings.ToLower(match[1]), "data:") {
+				urls = append(urls, match[1])
+			}
+		}
+	}
+	return urls
+}
+
 // extractLinksWithOllama extracts links from HTML and uses Ollama to sanitize them
 func (s *Scraper) extractLinksWithOllama(ctx context.Context, n *html.Node, baseURL *url.URL, pageTitle string, pageContent string) []string {
+	allLinks, kept := s.filterLinksWithOllama(ctx, n, baseURL, pageTitle, pageContent)
+	if kept == nil {
+		// The filter was unavailable, so fall back to returning all links
+		return allLinks
+	}
+	return kept
+}
+
+// filterLinksWithOllama extracts links from HTML and asks Ollama which are
+// worth keeping. It returns every extracted link and the ones Ollama kept,
+// which is nil if the filter could not run.
+func (s *Scraper) filterLinksWithOllama(ctx context.Context, n *html.Node, baseURL *url.URL, pageTitle string, pageContent string) (allLinks, kept []string) {
 	// First extract all links using the basic method
-	allLinks := extractLinks(n, baseURL)
+	allLinks = s.filterLinks(baseURL, s.normalizeLinks(extractLinks(n, baseURL, s.config.KeepInPageLinks, s.config.StripLinkFragments)))
 
 	// Ensure we always return a non-nil slice
 	if allLinks == nil {
@@ -316,66 +1083,37 @@ func (s *Scraper) extractLinksWithOllama(ctx context.Context, n *html.Node, base
 	}
 
 	if len(allLinks) == 0 {
-		return allLinks
+		return allLinks, allLinks
 	}
 
 	// Try to sanitize using Ollama directly
 	linksJSON, err := json.Marshal(allLinks)
 	if err != nil {
-		// If marshaling fails, fall back to returning all links
-		return allLinks
+		return allLinks, nil
 	}
 
-	prompt := fmt.Sprintf(`You are a link filtering assistant. Given a list of URLs extracted from a webpage, identify and return ONLY the links that point to substantive content (articles, blog posts, reports, etc.).
-
-INCLUDE:
-- Article links (news stories, blog posts, features)
-- Opinion pieces and editorials
-- Reports, guides, and documentation
-- Individual story/content pages
-- Links to specific multimedia content (videos, podcasts with their own pages)
-
-EXCLUDE:
-- Advertising/sponsored content links
-- Site navigation (home, sections, categories, topics)
-- Social media share/follow buttons
-- Login/signup/account links
-- Footer links (privacy, terms, about, contact, jobs, press)
-- Newsletter/subscription prompts
-- Cookie/consent notices
-- Generic section/category/tag pages (unless they're the main content)
-- Search functionality links
-- Pagination controls (next, previous, page numbers)
-- Internal site tools (print, save, bookmark)
-- Related external sites/sister publications
-- Comment section links
-
-IMPORTANT: If this is a homepage or news aggregator page, it will contain MANY article links - these should ALL be included as they are the primary content. Only filter out the navigation chrome around them.
-
-Page Title: %s
-
-Page Content: %s
-
-Links to filter:
-%s
-
-Return ONLY a JSON array of the filtered URLs. Do not include any explanation or commentary.
-Format: ["url1", "url2", "url3"]`,
-		pageTitle,
-		pageContent,
-		string(linksJSON))
+	var prompt bytes.Buffer
+	err = s.linkFilterPrompt.Execute(&prompt, LinkFilterPromptData{
+		Title:   pageTitle,
+		Content: truncateContent(pageContent, s.maxContentChars()),
+		Links:   string(linksJSON),
+		Include: s.linkFilterInclude,
+		Exclude: s.linkFilterExclude,
+	})
+	if err != nil {
+		log.Printf("Failed to render link filter prompt: %v", err)
+		return allLinks, nil
+	}
 
-	response, err := s.ollamaClient.Generate(ctx, prompt)
+	response, err := s.ollamaClient.GenerateStructured(ctx, prompt.String(), ollama.URLListSchema)
 	if err != nil {
-		// If Ollama fails, fall back to returning all links
-		return allLinks
+		return allLinks, nil
 	}
 
 	// Parse JSON response
 	var sanitizedLinks []string
 	if err := json.Unmarshal([]byte(response), &sanitizedLinks); err != nil {
-		// If parsing fails, fall back to returning all links
-		return allLinks
+		return allLinks, nil
 	}
 
 	// Ensure we never return nil
@@ -383,11 +1121,32 @@ Format: ["url1", "url2", "url3"]`,
 		sanitizedLinks = []string{}
 	}
 
-	return sanitizedLinks
+	return allLinks, sanitizedLinks
 }
 
-// extractLinks extracts links from the HTML
-func extractLinks(n *html.Node, baseURL *url.URL) []string {
+// keepListed returns the links that are also in allowed, in the order of
+// links; a nil links stays nil
+func keepListed(links, allowed []string) []string {
+	if links == nil {
+		return nil
+	}
+	allowedSet := make(map[string]bool, len(allowed))
+	for _, link := range allowed {
+		allowedSet[link] = true
+	}
+	kept := []string{}
+	for _, link := range links {
+		if allowedSet[link] {
+			kept = append(kept, link)
+		}
+	}
+	return kept
+}
+
+// extractLinks extracts links from the HTML. In-page links such as
+// "#section" are dropped unless keepInPage is set, and stripFragments
+// removes the fragment from every link so anchors into one page list it once.
+func extractLinks(n *html.Node, baseURL *url.URL, keepInPage, stripFragments bool) []string {
 	var links []string
 	seen := make(map[string]bool)
 	var f func(*html.Node)
@@ -395,8 +1154,14 @@ func extractLinks(n *html.Node, baseURL *url.URL) []string {
 		if n.Type == html.ElementNode && n.Data == "a" {
 			for _, attr := range n.Attr {
 				if attr.Key == "href" && attr.Val != "" {
+					if !keepInPage && strings.HasPrefix(strings.TrimSpace(attr.Val), "#") {
+						break
+					}
 					// Resolve relative URLs
 					if linkURL, err := resolveURL(baseURL, attr.Val); err == nil {
+						if stripFragments {
+							linkURL, _, _ = strings.Cut(linkURL, "#")
+						}
 						if !seen[linkURL] {
 							seen[linkURL] = true
 							links = append(links, linkURL)
@@ -414,11 +1179,21 @@ func extractLinks(n *html.Node, baseURL *url.URL) []string {
 	return links
 }
 
-// extractMetadata extracts page metadata from meta tags
+// extractMetadata extracts page metadata from meta tags, Open Graph and
+// Twitter card properties, and JSON-LD blocks, falling back to the visible
+// byline for the author and publication date
 func extractMetadata(n *html.Node) models.PageMetadata {
 	metadata := models.PageMetadata{}
 	var f func(*html.Node)
 	f = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "script" &&
+			strings.EqualFold(strings.TrimSpace(getAttr(n, "type")), "application/ld+json") {
+			if n.FirstChild != nil {
+				addStructuredData(&metadata, n.FirstChild.Data)
+			}
+			return
+		}
+
 		if n.Type == html.ElementNode && n.Data == "meta" {
 			var name, property, content string
 			for _, attr := range n.Attr {
@@ -436,8 +1211,13 @@ func extractMetadata(n *html.Node) models.PageMetadata {
 				return
 			}
 
+			// Twitter cards are commonly published with name= rather than property=
+			if property == "" && strings.HasPrefix(name, "twitter:") {
+				property = name
+			}
+
 			switch {
-			case name == "description" || property == "og:description":
+			case name == "description" || property == "og:description" || property == "twitter:description":
 				if metadata.Description == "" {
 					metadata.Description = content
 				}
@@ -456,6 +1236,36 @@ func extractMetadata(n *html.Node) models.PageMetadata {
 				if metadata.PublishedDate == "" {
 					metadata.PublishedDate = content
 				}
+			case property == "og:title":
+				if metadata.OGTitle == "" {
+					metadata.OGTitle = content
+				}
+			case property == "og:type":
+				if metadata.Type == "" {
+					metadata.Type = content
+				}
+			case property == "twitter:card":
+				if metadata.TwitterCard == "" {
+					metadata.TwitterCard = content
+				}
+			case property == "og:image" || property == "og:image:url" || property == "og:image:secure_url":
+				if metadata.OGImage == "" {
+					metadata.OGImage = content
+				}
+				if metadata.ImageURL == "" {
+					metadata.ImageURL = content
+				}
+			case property == "twitter:image" || property == "twitter:image:src":
+				if metadata.TwitterImage == "" {
+					metadata.TwitterImage = content
+				}
+				if metadata.ImageURL == "" {
+					metadata.ImageURL = content
+				}
+			case property == "og:site_name":
+				if metadata.SiteName == "" {
+					metadata.SiteName = content
+				}
 			}
 		}
 		for c := n.FirstChild; c != nil; c = c.NextSibling {
@@ -463,9 +1273,183 @@ func extractMetadata(n *html.Node) models.PageMetadata {
 		}
 	}
 	f(n)
+	fillFromStructuredData(&metadata)
+	fillFromByline(n, &metadata)
 	return metadata
 }
 
+// addStructuredData parses a JSON-LD block and stores each object it contains
+// under its @type. Malformed blocks are skipped.
+func addStructuredData(metadata *models.PageMetadata, raw string) {
+	var parsed interface{}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(raw)), &parsed); err != nil {
+		return
+	}
+
+	var objects []map[string]interface{}
+	var collect func(interface{})
+	collect = func(v interface{}) {
+		switch v := v.(type) {
+		case []interface{}:
+			for _, item := range v {
+				collect(item)
+			}
+		case map[string]interface{}:
+			if graph, ok := v["@graph"]; ok {
+				collect(graph)
+				return
+			}
+			objects = append(objects, v)
+		}
+	}
+	collect(parsed)
+
+	for _, obj := range objects {
+		schemaType := jsonLDType(obj)
+		if schemaType == "" {
+			continue
+		}
+		if metadata.StructuredData == nil {
+			metadata.StructuredData = make(map[string]interface{})
+		}
+		// Keep the first object of each type
+		if _, exists := metadata.StructuredData[schemaType]; !exists {
+			metadata.StructuredData[schemaType] = obj
+		}
+	}
+}
+
+// jsonLDType returns the first @type of a JSON-LD object
+func jsonLDType(obj map[string]interface{}) string {
+	switch t := obj["@type"].(type) {
+	case string:
+		return t
+	case []interface{}:
+		for _, item := range t {
+			if s, ok := item.(string); ok && s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// fillFromStructuredData fills metadata fields that meta tags did not provide
+// from schema.org properties in the JSON-LD objects
+func fillFromStructuredData(metadata *models.PageMetadata) {
+	// Visit types in a stable order so the result does not depend on map iteration
+	types := make([]string, 0, len(metadata.StructuredData))
+	for schemaType := range metadata.StructuredData {
+		types = append(types, schemaType)
+	}
+	sort.Strings(types)
+
+	for _, schemaType := range types {
+		obj, ok := metadata.StructuredData[schemaType].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if metadata.PublishedDate == "" {
+			metadata.PublishedDate = jsonLDString(obj["datePublished"])
+		}
+		if metadata.Author == "" {
+			metadata.Author = jsonLDString(obj["author"])
+		}
+		if metadata.Description == "" {
+			metadata.Description = jsonLDString(obj["description"])
+		}
+		if metadata.ImageURL == "" {
+			metadata.ImageURL = jsonLDString(obj["image"])
+		}
+	}
+}
+
+// jsonLDString extracts a string from a JSON-LD value, which may be a plain
+// string, an object with a name or url, or a list of either
+func jsonLDString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case map[string]interface{}:
+		if name, ok := v["name"].(string); ok {
+			return name
+		}
+		if url, ok := v["url"].(string); ok {
+			return url
+		}
+	case []interface{}:
+		for _, item := range v {
+			if s := jsonLDString(item); s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// readingWordsPerMinute is the reading speed ScrapedData.ReadingTimeSeconds assumes
+const readingWordsPerMinute = 200
+
+// countWords counts the words in text, skipping tokens without letters or
+// digits such as Markdown's "#" and "-" markers
+func countWords(text string) int {
+	count := 0
+	for _, field := range strings.Fields(text) {
+		if strings.IndexFunc(field, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// readingTimeSeconds estimates how long reading wordCount words takes,
+// rounded to the nearest second
+func readingTimeSeconds(wordCount int) int {
+	return int(math.Round(float64(wordCount) * 60 / readingWordsPerMinute))
+}
+
+// defaultMaxBodyBytes is the page size limit used when Config.MaxBodyBytes is unset
+const defaultMaxBodyBytes = 20 * 1024 * 1024
+
+// maxBodyBytes returns Config.MaxBodyBytes, or the default if it is unset
+func (s *Scraper) maxBodyBytes() int64 {
+	if s.config.MaxBodyBytes <= 0 {
+		return defaultMaxBodyBytes
+	}
+	return s.config.MaxBodyBytes
+}
+
+// readBody reads a page response body, failing rather than truncating if it
+// exceeds the configured maximum size
+func (s *Scraper) readBody(resp *http.Response) ([]byte, error) {
+	maxBytes := s.maxBodyBytes()
+
+	// Check content length if available
+	if resp.ContentLength > maxBytes {
+		return nil, fmt.Errorf("%w: %d bytes (max: %d)", ErrPageTooLarge, resp.ContentLength, maxBytes)
+	}
+
+	// Read with size limit
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read page: %w", err)
+	}
+
+	// Check if we exceeded the limit
+	if int64(len(body)) > maxBytes {
+		return nil, fmt.Errorf("%w: exceeds %d bytes", ErrPageTooLarge, maxBytes)
+	}
+
+	return body, nil
+}
+
+// defaultMaxImageSizeBytes is the image size limit used when Config.MaxImageSizeBytes is unset
+const defaultMaxImageSizeBytes = 10 * 1024 * 1024
+
+// ErrImageTooLarge is returned (wrapped) when an image exceeds Config.MaxImageSizeBytes,
+// whether or not the server declared its length
+var ErrImageTooLarge = errors.New("image too large")
+
 // downloadImage downloads an image from a URL with size and timeout limits
 func (s *Scraper) downloadImage(ctx context.Context, imageURL string) ([]byte, error) {
 	// Create request with timeout context
@@ -476,9 +1460,9 @@ func (s *Scraper) downloadImage(ctx context.Context, imageURL string) ([]byte, e
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; Scraper/1.0)")
+	req.Header.Set("User-Agent", s.config.UserAgent)
 
-	resp, err := s.httpClient.Do(req)
+	resp, err := s.client(ctx).Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch image: %w", err)
 	}
@@ -488,34 +1472,114 @@ func (s *Scraper) downloadImage(ctx context.Context, imageURL string) ([]byte, e
 		return nil, fmt.Errorf("HTTP error: %d %s", resp.StatusCode, resp.Status)
 	}
 
-	// Check content length if available
-	if resp.ContentLength > s.config.MaxImageSizeBytes {
-		return nil, fmt.Errorf("image too large: %d bytes (max: %d)", resp.ContentLength, s.config.MaxImageSizeBytes)
+	// Reject disallowed types before downloading the body
+	contentType := resp.Header.Get("Content-Type")
+	if contentType != "" && !s.imageTypeAllowed(contentType) {
+		return nil, fmt.Errorf("image type not allowed: %s", contentType)
+	}
+
+	maxBytes := s.config.MaxImageSizeBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxImageSizeBytes
+	}
+
+	// A declared length lets us reject early, but it may be missing (chunked
+	// transfer) or describe the compressed size, so the limited read below is
+	// what actually enforces the cap
+	if resp.ContentLength > maxBytes {
+		return nil, fmt.Errorf("%w: %d bytes (max: %d)", ErrImageTooLarge, resp.ContentLength, maxBytes)
 	}
 
 	// Read with size limit
-	limitedReader := io.LimitReader(resp.Body, s.config.MaxImageSizeBytes+1)
+	limitedReader := io.LimitReader(resp.Body, maxBytes+1)
 	imageData, err := io.ReadAll(limitedReader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read image data: %w", err)
 	}
 
 	// Check if we exceeded the limit
-	if int64(len(imageData)) > s.config.MaxImageSizeBytes {
-		return nil, fmt.Errorf("image too large: exceeds %d bytes", s.config.MaxImageSizeBytes)
+	if int64(len(imageData)) > maxBytes {
+		return nil, fmt.Errorf("%w: exceeds %d bytes", ErrImageTooLarge, maxBytes)
+	}
+
+	// Without a declared type, check the sniffed one
+	if contentType == "" {
+		if sniffed := http.DetectContentType(imageData); !s.imageTypeAllowed(sniffed) {
+			return nil, fmt.Errorf("image type not allowed: %s", sniffed)
+		}
 	}
 
 	return imageData, nil
 }
 
-// processImages downloads and analyzes images if image analysis is enabled
-func (s *Scraper) processImages(ctx context.Context, images []models.ImageInfo) []models.ImageInfo {
+// imageTypeAllowed reports whether a Content-Type is in Config.AllowedImageTypes.
+// All types are allowed when the list is empty.
+func (s *Scraper) imageTypeAllowed(contentType string) bool {
+	if len(s.config.AllowedImageTypes) == 0 {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range s.config.AllowedImageTypes {
+		if strings.EqualFold(mediaType, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// maxDecodedPixels is the most pixels an image may have to be decoded for
+// hashing and thumbnails. A small compressed file can declare dimensions
+// that take gigabytes to decode; 25 megapixels is 100MB as RGBA.
+const maxDecodedPixels = 25_000_000
+
+// decodeImage reads an image's dimensions from its header and decodes it
+// only if it has at most maxDecodedPixels, so that a downloaded image is
+// decoded once for every step that needs its pixels. config is nil for
+// formats the standard library cannot decode (SVG, WebP, etc.), and img is
+// nil for those and for images over the cap.
+func decodeImage(data []byte) (config *image.Config, img image.Image) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || int64(cfg.Width)*int64(cfg.Height) > maxDecodedPixels {
+		return &cfg, nil
+	}
+	img, _, err = image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return &cfg, nil
+	}
+	return &cfg, img
+}
+
+// imageBelowMinSize reports whether an image with the given dimensions is
+// smaller than Config.MinImageWidth or MinImageHeight. Images whose format
+// cannot be decoded (a nil config) are never considered too small.
+func (s *Scraper) imageBelowMinSize(config *image.Config) bool {
+	if config == nil || (s.config.MinImageWidth <= 0 && s.config.MinImageHeight <= 0) {
+		return false
+	}
+	return config.Width < s.config.MinImageWidth || config.Height < s.config.MinImageHeight
+}
+
+// processImages downloads and analyzes images if image analysis is enabled,
+// reporting each completed image to progress when it is non-nil
+func (s *Scraper) processImages(ctx context.Context, images []models.ImageInfo, progress ProgressFunc) []models.ImageInfo {
 	if !s.config.EnableImageAnalysis {
 		log.Printf("Image analysis disabled, returning %d images without analysis", len(images))
 		return images
 	}
 
 	processedImages := make([]models.ImageInfo, 0, len(images))
+	done := func(index int, img models.ImageInfo) {
+		processedImages = append(processedImages, img)
+		if progress != nil {
+			progress(PhaseImageAnalyzed, ImageProgress{Index: index, Total: len(images), Image: img})
+		}
+	}
 
 	for i, img := range images {
 		log.Printf("Processing image %d/%d: %s", i+1, len(images), img.URL)
@@ -523,12 +1587,16 @@ func (s *Scraper) processImages(ctx context.Context, images []models.ImageInfo)
 		// Generate UUID for the image
 		img.ID = uuid.New().String()
 
+		if progress != nil {
+			progress(PhaseImageStarted, ImageProgress{Index: i, Total: len(images), Image: img})
+		}
+
 		// Download the image
 		imageData, err := s.downloadImage(ctx, img.URL)
 		if err != nil {
 			log.Printf("Failed to download image %s: %v", img.URL, err)
 			// Keep the image info but without analysis
-			processedImages = append(processedImages, img)
+			done(i, img)
 			continue
 		}
 
@@ -536,30 +1604,185 @@ func (s *Scraper) processImages(ctx context.Context, images []models.ImageInfo)
 
 		// Store base64 encoded image data
 		img.Base64Data = base64.StdEncoding.EncodeToString(imageData)
+		config, decoded := decodeImage(imageData)
+		img.Hash = hashImage(imageData, decoded)
+		img.ThumbnailData = generateThumbnail(decoded)
+
+		// Skip analysis of tracking pixels, spacers, and tiny icons
+		if s.imageBelowMinSize(config) {
+			log.Printf("Skipping analysis of image %s: below minimum dimensions", img.URL)
+			done(i, img)
+			continue
+		}
+
+		// Reuse the analysis of an identical image if one was seen before
+		if existing := s.lookupImage(img.Hash); existing != nil {
+			log.Printf("Reusing analysis for image %s (matches image %s)", img.URL, existing.ID)
+			img.Summary = existing.Summary
+			img.Tags = existing.Tags
+			img.OCRText = existing.OCRText
+			done(i, img)
+			continue
+		}
+
+		// Stay within the image analysis budget shared by a batch, if any
+		if budget := imageBudgetFromContext(ctx); budget != nil && !budget.take() {
+			log.Printf("Skipping analysis of image %s: image analysis budget used up", img.URL)
+			done(i, img)
+			continue
+		}
 
 		// Analyze the image with Ollama
-		summary, tags, err := s.ollamaClient.AnalyzeImage(ctx, imageData, img.AltText)
+		analysis, err := s.ollamaClient.AnalyzeImageDetailed(ctx, imageData, img.AltText)
+		s.metrics.observeImageAnalysis(err)
 		if err != nil {
 			log.Printf("Failed to analyze image %s: %v", img.URL, err)
 			// Keep the image info with base64 data but without analysis
-			processedImages = append(processedImages, img)
+			done(i, img)
 			continue
 		}
 
 		// Update image info with analysis results
-		img.Summary = summary
-		img.Tags = tags
-		processedImages = append(processedImages, img)
+		img.Summary = analysis.Summary
+		img.Tags = analysis.Tags
+		img.OCRText = analysis.Text
+		done(i, img)
 
-		log.Printf("Successfully analyzed image %s (summary: %d chars, tags: %d)",
-			img.URL, len(summary), len(tags))
+		log.Printf("Successfully analyzed image %s (summary: %d chars, tags: %d, text: %d chars)",
+			img.URL, len(analysis.Summary), len(analysis.Tags), len(analysis.Text))
 	}
 
 	return processedImages
 }
 
-// resolveURL resolves a potentially relative URL against a base URL
+// lookupImage returns a previously analyzed image with the given hash, if any
+func (s *Scraper) lookupImage(hash string) *models.ImageInfo {
+	if s.config.ImageLookup == nil {
+		return nil
+	}
+	existing, err := s.config.ImageLookup(hash)
+	if err != nil {
+		log.Printf("Failed to look up image hash %s: %v", hash, err)
+		return nil
+	}
+	if existing == nil || existing.Summary == "" {
+		return nil
+	}
+	return existing
+}
+
+// hashImage returns a hex SHA-256 of an image's decoded pixels (see
+// decodeImage) so that identical images re-encoded with different metadata
+// still match. Without decoded pixels, such as for SVG or WebP images or ones
+// too large to decode, the raw bytes are hashed instead.
+func hashImage(data []byte, img image.Image) string {
+	h := sha256.New()
+	if img == nil {
+		h.Write(data)
+		return hex.EncodeToString(h.Sum(nil))
+	}
+
+	bounds := img.Bounds()
+	fmt.Fprintf(h, "%dx%d:", bounds.Dx(), bounds.Dy())
+	// Most decoded images read pixels without allocating through RGBA64At,
+	// which gives the same values as At().RGBA()
+	rgba64, _ := img.(image.RGBA64Image)
+	row := make([]byte, 8*bounds.Dx())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			var r, g, b, a uint32
+			if rgba64 != nil {
+				c := rgba64.RGBA64At(x, y)
+				r, g, b, a = uint32(c.R), uint32(c.G), uint32(c.B), uint32(c.A)
+			} else {
+				r, g, b, a = img.At(x, y).RGBA()
+			}
+			pixel := row[8*(x-bounds.Min.X):]
+			binary.BigEndian.PutUint16(pixel[0:], uint16(r))
+			binary.BigEndian.PutUint16(pixel[2:], uint16(g))
+			binary.BigEndian.PutUint16(pixel[4:], uint16(b))
+			binary.BigEndian.PutUint16(pixel[6:], uint16(a))
+		}
+		h.Write(row)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// extractCanonicalURL returns the absolute URL from <link rel="canonical">, or ""
+func extractCanonicalURL(n *html.Node, baseURL *url.URL) string {
+	var canonical string
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		if canonical != "" {
+			return
+		}
+		if n.Type == html.ElementNode && n.Data == "link" {
+			for _, rel := range strings.Fields(strings.ToLower(getAttr(n, "rel"))) {
+				if rel != "canonical" {
+					continue
+				}
+				href := strings.TrimSpace(getAttr(n, "href"))
+				if href == "" {
+					break
+				}
+				if resolved, err := url.Parse(href); err == nil {
+					resolved = baseURL.ResolveReference(resolved)
+					if resolved.Scheme == "http" || resolved.Scheme == "https" {
+						canonical = resolved.String()
+					}
+				}
+				break
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			f(c)
+		}
+	}
+	f(n)
+	return canonical
+}
+
+// sameSite reports whether a URL is on the same site as the page: the same
+// host ignoring a leading "www.", or a host under the same registrable
+// domain, so an AMP page on amp.example.com can point at www.example.com.
+// Pages cannot claim canonical URLs on other sites, which would let them
+// overwrite stored results for those sites. Hosts under a public suffix such
+// as github.io are separate sites, and IP addresses must match exactly.
+func sameSite(rawURL string, page *url.URL) bool {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := func(u *url.URL) string {
+		return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	}
+	if host(parsed) == host(page) {
+		return true
+	}
+	if net.ParseIP(parsed.Hostname()) != nil || net.ParseIP(page.Hostname()) != nil {
+		return false
+	}
+	site, err := publicsuffix.EffectiveTLDPlusOne(strings.ToLower(parsed.Hostname()))
+	if err != nil {
+		return false
+	}
+	pageSite, err := publicsuffix.EffectiveTLDPlusOne(strings.ToLower(page.Hostname()))
+	return err == nil && site == pageSite
+}
+
+// hrefWhitespace removes the tabs and newlines browsers ignore inside URLs
+var hrefWhitespace = strings.NewReplacer("\t", "", "\n", "", "\r", "")
+
+// resolveURL resolves a potentially relative URL against a base URL. Like a
+// browser, it ignores surrounding whitespace and embedded tabs and newlines,
+// and treats a leading "\\" as "//", so protocol-relative references such as
+// "//cdn.example.com/a.jpg" take the base URL's scheme.
 func resolveURL(base *url.URL, href string) (string, error) {
+	href = hrefWhitespace.Replace(strings.TrimSpace(href))
+	if strings.HasPrefix(href, `\\`) || strings.HasPrefix(href, `\/`) || strings.HasPrefix(href, `/\`) {
+		href = "//" + href[2:]
+	}
+
 	// Parse the href
 	parsed, err := url.Parse(href)
 	if err != nil {
@@ -573,75 +1796,227 @@ func resolveURL(base *url.URL, href string) (string, error) {
 
 // ScoreLinkContent fetches and scores a URL to determine if it should be ingested
 func (s *Scraper) ScoreLinkContent(ctx context.Context, targetURL string) (*models.LinkScore, error) {
+	_, linkScore, err := s.TriageLink(ctx, targetURL)
+	return linkScore, err
+}
+
+// TriageLink scores a URL like ScoreLinkContent, also returning the page
+// title (the URL itself when the page has none)
+func (s *Scraper) TriageLink(ctx context.Context, targetURL string) (string, *models.LinkScore, error) {
+	title, linkScore, err := s.scoreLinkContent(ctx, targetURL, 0)
+	s.metrics.observeScore(err)
+	return title, linkScore, err
+}
+
+// scoreLinkContent implements TriageLink. With a positive maxAge, a page
+// published longer ago than that is not scored, returning errStalePage.
+func (s *Scraper) scoreLinkContent(ctx context.Context, targetURL string, maxAge time.Duration) (string, *models.LinkScore, error) {
 	// Validate URL
 	parsedURL, err := url.Parse(targetURL)
 	if err != nil {
-		return nil, fmt.Errorf("invalid URL: %w", err)
+		return "", nil, fmt.Errorf("invalid URL: %w", err)
 	}
 	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
-		return nil, fmt.Errorf("URL must be http or https")
+		return "", nil, fmt.Errorf("URL must be http or https")
 	}
 
-	// Fetch the page
-	req, err := http.NewRequestWithContext(ctx, "GET", targetURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	// Log in first if the host requires an authenticated session
+	if err := s.ensureLogin(ctx, parsedURL); err != nil {
+		return "", nil, err
 	}
-	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; Scraper/1.0)")
 
-	resp, err := s.httpClient.Do(req)
+	// Fetch the page
+	resp, err := s.fetchPage(ctx, targetURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch URL: %w", err)
+		return "", nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP error: %d %s", resp.StatusCode, resp.Status)
-	}
-
 	// Parse HTML
-	doc, err := html.Parse(resp.Body)
+	body, _, err := s.readHTML(resp)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+		return "", nil, err
+	}
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	// Skip stale pages before spending a scoring call on them
+	if maxAge > 0 {
+		date := extractMetadata(doc).PublishedDate
+		if published, ok := ParsePublishedDate(date); ok && time.Since(published) > maxAge {
+			return "", nil, fmt.Errorf("%w: published %s", errStalePage, date)
+		}
 	}
 
 	// Extract title
-	title := extractTitle(doc)
+	title := s.extractTitle(resp.Request.URL, doc)
 	if title == "" {
 		title = targetURL
 	}
 
-	// Extract text content
-	textContent := extractText(doc)
+	// Extract text content, limited to the main content when a selector is configured
+	contentRoot, _ := s.selectContent(resp.Request.URL, doc)
+	textContent := s.extractText(contentRoot)
+
+	// Score the content (with fallback to rule-based scoring)
+	linkScore := s.scoreContent(ctx, "score", targetURL, title, textContent)
+	_, trackers := extractThirdPartyHosts(doc, resp.Request.URL)
+	s.ApplyTrackerPenalty(linkScore, trackers)
+	return title, linkScore, nil
+}
 
-	// Use Ollama to score the content (with fallback to rule-based scoring)
-	score, reason, categories, maliciousIndicators, err := s.ollamaClient.ScoreContent(ctx, targetURL, title, textContent)
-	aiUsed := true
+// ScoreContent scores already-fetched content with Ollama, without fetching
+// the URL. Unlike ScoreLinkContent it does not fall back to rule-based
+// scoring: it returns an error when Ollama is unavailable, and callers can
+// use ScoreContentRuleBased instead. It waits for a free scoring slot (see
+// Config.MaxConcurrentScores) but ignores Config.ScoringMode.
+func (s *Scraper) ScoreContent(ctx context.Context, targetURL, title, content string) (*models.LinkScore, error) {
+	select {
+	case s.scoringSlots <- struct{}{}:
+		defer func() { <-s.scoringSlots }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return s.scoreWithOllama(ctx, targetURL, title, content)
+}
+
+// scoreWithOllama implements ScoreContent once a scoring slot is held
+func (s *Scraper) scoreWithOllama(ctx context.Context, targetURL, title, content string) (*models.LinkScore, error) {
+	score, reason, categories, maliciousIndicators, err := s.ollamaClient.ScoreContent(ctx, targetURL, title, truncateContent(content, s.maxContentChars()))
 	if err != nil {
-		// Fallback to rule-based scoring when Ollama is unavailable
-		log.Printf("Ollama scoring failed, using rule-based fallback: %v", err)
-		score, reason, categories, maliciousIndicators = scoreContentFallback(targetURL, title, textContent)
-		aiUsed = false
+		return nil, err
 	}
 
-	// Determine if the link is recommended based on configurable threshold
-	isRecommended := score >= s.config.LinkScoreThreshold
+	return &models.LinkScore{
+		URL:                 targetURL,
+		Score:               score,
+		Reason:              reason,
+		Categories:          categories,
+		IsRecommended:       score >= s.config.LinkScoreThreshold, // configurable threshold
+		MaliciousIndicators: maliciousIndicators,
+		AIUsed:              true, // AI-powered scoring
+		ScoringPath:         ScoringPathAI,
+	}, nil
+}
+
+// ScoreContentRuleBased scores already-fetched content with the rule-based
+// heuristics from DefaultConfig, without any HTTP or Ollama requests. The
+// result is deterministic for a given URL, title, and content.
+func ScoreContentRuleBased(targetURL, title, content string) models.LinkScore {
+	s := &Scraper{config: DefaultConfig()}
+	return s.ScoreContentRuleBased(targetURL, title, content)
+}
 
-	linkScore := &models.LinkScore{
+// ScoreContentRuleBased scores already-fetched content with the rule-based
+// heuristics, honoring the scraper's domain, keyword, and threshold settings.
+// It is the fallback used when Ollama is unavailable.
+func (s *Scraper) ScoreContentRuleBased(targetURL, title, content string) models.LinkScore {
+	score, reason, categories, maliciousIndicators := s.scoreContentFallback(targetURL, title, content)
+	return models.LinkScore{
 		URL:                 targetURL,
 		Score:               score,
 		Reason:              reason,
 		Categories:          categories,
-		IsRecommended:       isRecommended,
+		IsRecommended:       score >= s.config.LinkScoreThreshold,
 		MaliciousIndicators: maliciousIndicators,
-		AIUsed:              aiUsed,
+		AIUsed:              false, // Rule-based fallback
+	}
+}
+
+// defaultBlockedDomains maps URL substrings to the content category they block
+var defaultBlockedDomains = map[string]string{
+	"facebook.com":   "social_media",
+	"twitter.com":    "social_media",
+	"x.com":          "social_media",
+	"instagram.com":  "social_media",
+	"tiktok.com":     "social_media",
+	"reddit.com":     "forum",
+	"linkedin.com":   "social_media",
+	"pinterest.com":  "social_media",
+	"snapchat.com":   "social_media",
+	"bet":            "gambling",
+	"casino":         "gambling",
+	"poker":          "gambling",
+	"betting":        "gambling",
+	"xxx":            "adult_content",
+	"porn":           "adult_content",
+	"adult":          "adult_content",
+	"cannabis":       "drugs",
+	"weed":           "drugs",
+	"ebay.com":       "marketplace",
+	"amazon.com":     "marketplace",
+	"craigslist.org": "marketplace",
+}
+
+// defaultQualityDomains lists URL substrings that indicate a trusted source
+var defaultQualityDomains = []string{".edu", ".gov", ".org", "wikipedia", "arxiv", "github", "stackoverflow"}
+
+// DefaultBlockedDomains returns a copy of the built-in blocked domain set,
+// suitable as a starting point for Config.BlockedDomains
+func DefaultBlockedDomains() map[string]string {
+	domains := make(map[string]string, len(defaultBlockedDomains))
+	for domain, category := range defaultBlockedDomains {
+		domains[domain] = category
 	}
+	return domains
+}
 
-	return linkScore, nil
+// DefaultQualityDomains returns a copy of the built-in quality domain list,
+// suitable as a starting point for Config.QualityDomains
+func DefaultQualityDomains() []string {
+	return append([]string(nil), defaultQualityDomains...)
+}
+
+// trustedDomainBoost returns the Config.TrustedDomains entry matching the URL's
+// host and its boost. An entry matches the host itself or any subdomain, so
+// ".corp" matches every host under that TLD; the longest matching entry wins.
+func (s *Scraper) trustedDomainBoost(targetURL string) (domain string, boost float64, ok bool) {
+	if len(s.config.TrustedDomains) == 0 {
+		return "", 0, false
+	}
+
+	parsed, err := url.Parse(targetURL)
+	if err != nil {
+		return "", 0, false
+	}
+	host := strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+	if host == "" {
+		return "", 0, false
+	}
+
+	for entry, entryBoost := range s.config.TrustedDomains {
+		suffix := strings.TrimPrefix(strings.ToLower(entry), ".")
+		if suffix == "" {
+			continue
+		}
+		if host != suffix && !strings.HasSuffix(host, "."+suffix) {
+			continue
+		}
+		if !ok || len(suffix) > len(domain) {
+			domain, boost, ok = suffix, entryBoost, true
+		}
+	}
+	return domain, boost, ok
+}
+
+// qualityDomainCovers reports whether a user-set quality domain matching
+// urlLower contains the built-in blocklist entry, so adding "reddit.com" to
+// QualityDomains stops it being blocked as a forum while a broad entry like
+// ".org" leaves gambling or marketplace entries blocked
+func (s *Scraper) qualityDomainCovers(urlLower, blocked string) bool {
+	for _, domain := range s.config.QualityDomains {
+		domain = strings.ToLower(domain)
+		if strings.Contains(urlLower, domain) && strings.Contains(domain, blocked) {
+			return true
+		}
+	}
+	return false
 }
 
 // scoreContentFallback provides rule-based content scoring when Ollama is unavailable
-func scoreContentFallback(targetURL, title, content string) (score float64, reason string, categories []string, maliciousIndicators []string) {
+func (s *Scraper) scoreContentFallback(targetURL, title, content string) (score float64, reason string, categories []string, maliciousIndicators []string) {
 	score = 0.5 // Start with neutral score
 	categories = []string{}
 	maliciousIndicators = []string{}
@@ -652,32 +2027,17 @@ func scoreContentFallback(targetURL, title, content string) (score float64, reas
 	contentLower := strings.ToLower(content)
 
 	// Check for blocked content types (social media, gambling, adult, drugs, etc.)
-	blockedDomains := map[string]string{
-		"facebook.com":    "social_media",
-		"twitter.com":     "social_media",
-		"x.com":           "social_media",
-		"instagram.com":   "social_media",
-		"tiktok.com":      "social_media",
-		"reddit.com":      "forum",
-		"linkedin.com":    "social_media",
-		"pinterest.com":   "social_media",
-		"snapchat.com":    "social_media",
-		"bet":             "gambling",
-		"casino":          "gambling",
-		"poker":           "gambling",
-		"betting":         "gambling",
-		"xxx":             "adult_content",
-		"porn":            "adult_content",
-		"adult":           "adult_content",
-		"cannabis":        "drugs",
-		"weed":            "drugs",
-		"ebay.com":        "marketplace",
-		"amazon.com":      "marketplace",
-		"craigslist.org":  "marketplace",
+	blockedDomains := s.config.BlockedDomains
+	builtinBlocklist := blockedDomains == nil
+	if builtinBlocklist {
+		blockedDomains = defaultBlockedDomains
 	}
 
 	for domain, category := range blockedDomains {
 		if strings.Contains(urlLower, domain) {
+			if builtinBlocklist && s.qualityDomainCovers(urlLower, domain) {
+				continue
+			}
 			score = 0.1
 			categories = append(categories, category, "low_quality")
 			reasons = append(reasons, "Blocked content type detected: "+category)
@@ -709,10 +2069,28 @@ func scoreContentFallback(targetURL, title, content string) (score float64, reas
 		categories = append(categories, "minimal_content")
 	}
 
+	// Keyword heuristics are language-specific; content in a language without
+	// keyword lists is neither rewarded nor penalized by them. Undetected
+	// languages (usually very short text) fall back to English.
+	language := detectLanguage(content)
+	if language == "" {
+		language = "en"
+	}
+
+	spamKeywords := s.config.SpamKeywords
+	if spamKeywords == nil {
+		spamKeywords = defaultSpamKeywords
+	}
+
 	// Check for spam indicators
-	if strings.Count(contentLower, "click here") > 2 ||
-		strings.Count(contentLower, "buy now") > 2 ||
-		strings.Count(contentLower, "limited offer") > 1 {
+	spamDetected := false
+	for phrase, allowed := range spamKeywords[language] {
+		if strings.Count(contentLower, phrase) > allowed {
+			spamDetected = true
+			break
+		}
+	}
+	if spamDetected {
 		score -= 0.3
 		reasons = append(reasons, "Spam indicators detected")
 		categories = append(categories, "spam")
@@ -726,20 +2104,34 @@ func scoreContentFallback(targetURL, title, content string) (score float64, reas
 		reasons = append(reasons, "Excessive punctuation")
 	}
 
-	// Check for quality indicators in URL
-	qualityDomains := []string{".edu", ".gov", ".org", "wikipedia", "arxiv", "github", "stackoverflow"}
-	for _, domain := range qualityDomains {
-		if strings.Contains(urlLower, domain) {
-			score += 0.3
-			reasons = append(reasons, "Quality domain detected")
-			categories = append(categories, "reference", "trusted_source")
-			break
+	// Check for quality indicators in URL, preferring explicitly trusted domains
+	if domain, boost, ok := s.trustedDomainBoost(targetURL); ok {
+		score += boost
+		reasons = append(reasons, "Trusted domain detected: "+domain)
+		if boost > 0 {
+			categories = append(categories, "trusted_source")
+		}
+	} else {
+		qualityDomains := s.config.QualityDomains
+		if qualityDomains == nil {
+			qualityDomains = defaultQualityDomains
+		}
+		for _, domain := range qualityDomains {
+			if strings.Contains(urlLower, domain) {
+				score += 0.3
+				reasons = append(reasons, "Quality domain detected")
+				categories = append(categories, "reference", "trusted_source")
+				break
+			}
 		}
 	}
 
 	// Check for technical/educational content indicators
-	technicalKeywords := []string{"documentation", "tutorial", "guide", "research", "study", "analysis", "technical"}
-	for _, keyword := range technicalKeywords {
+	qualityKeywords := s.config.QualityKeywords
+	if qualityKeywords == nil {
+		qualityKeywords = defaultQualityKeywords
+	}
+	for _, keyword := range qualityKeywords[language] {
 		if strings.Contains(titleLower, keyword) || strings.Contains(contentLower, keyword) {
 			score += 0.1
 			categories = append(categories, "technical", "educational")
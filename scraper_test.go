@@ -1,15 +1,31 @@
 package scraper
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/zombar/scraper/models"
+	"golang.org/x/net/html"
 )
 
 func TestNew(t *testing.T) {
@@ -886,7 +902,7 @@ func containsHelper(s, substr string) bool {
 
 // TestScoreContentFallbackSocialMedia tests fallback scoring for social media
 func TestScoreContentFallbackSocialMedia(t *testing.T) {
-	score, reason, categories, indicators := scoreContentFallback(
+	score, reason, categories, indicators := New(DefaultConfig()).scoreContentFallback(
 		"https://www.facebook.com/profile",
 		"Facebook Profile",
 		"This is my Facebook profile with posts and photos.",
@@ -915,7 +931,7 @@ func TestScoreContentFallbackSocialMedia(t *testing.T) {
 
 // TestScoreContentFallbackQualityDomain tests fallback scoring for quality domains
 func TestScoreContentFallbackQualityDomain(t *testing.T) {
-	score, reason, categories, _ := scoreContentFallback(
+	score, reason, categories, _ := New(DefaultConfig()).scoreContentFallback(
 		"https://en.wikipedia.org/wiki/Artificial_Intelligence",
 		"Artificial Intelligence - Wikipedia",
 		strings.Repeat("This is a comprehensive article about artificial intelligence. ", 50),
@@ -936,7 +952,7 @@ func TestScoreContentFallbackQualityDomain(t *testing.T) {
 
 // TestScoreContentFallbackShortContent tests fallback scoring for short content
 func TestScoreContentFallbackShortContent(t *testing.T) {
-	score, reason, categories, _ := scoreContentFallback(
+	score, reason, categories, _ := New(DefaultConfig()).scoreContentFallback(
 		"https://example.com/short",
 		"Short Page",
 		"Very short content here.",
@@ -958,7 +974,7 @@ func TestScoreContentFallbackShortContent(t *testing.T) {
 // TestScoreContentFallbackSpam tests fallback scoring for spam content
 func TestScoreContentFallbackSpam(t *testing.T) {
 	spamContent := "Click here! Click here! Click here! Buy now! Buy now! Limited offer!"
-	score, reason, categories, indicators := scoreContentFallback(
+	score, reason, categories, indicators := New(DefaultConfig()).scoreContentFallback(
 		"https://example.com/spam",
 		"Amazing Offer",
 		spamContent,
@@ -984,7 +1000,7 @@ func TestScoreContentFallbackSpam(t *testing.T) {
 // TestScoreContentFallbackTechnical tests fallback scoring for technical content
 func TestScoreContentFallbackTechnical(t *testing.T) {
 	technicalContent := strings.Repeat("This is a technical guide about software development and programming best practices. ", 20)
-	score, reason, categories, _ := scoreContentFallback(
+	score, reason, categories, _ := New(DefaultConfig()).scoreContentFallback(
 		"https://example.com/tutorial",
 		"Software Development Tutorial",
 		technicalContent,
@@ -1005,7 +1021,7 @@ func TestScoreContentFallbackTechnical(t *testing.T) {
 
 // TestScoreContentFallbackGambling tests fallback scoring for gambling sites
 func TestScoreContentFallbackGambling(t *testing.T) {
-	score, _, categories, indicators := scoreContentFallback(
+	score, _, categories, indicators := New(DefaultConfig()).scoreContentFallback(
 		"https://www.betcasino.com",
 		"Online Casino",
 		"Place your bets and win big!",
@@ -1082,3 +1098,1684 @@ func containsString(slice []string, item string) bool {
 	}
 	return false
 }
+
+// TestScrapeRecommendedImagesOnly tests that images are only processed on recommended pages
+func TestScrapeRecommendedImagesOnly(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		score       string
+		wantScanned bool
+	}{
+		{"recommended", "0.9", true},
+		{"not recommended", "0.1", false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ollamaServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req models.OllamaRequest
+				json.NewDecoder(r.Body).Decode(&req)
+				response := `{"summary": "A test image", "tags": ["test"]}`
+				if strings.Contains(req.Prompt, "content quality assessment") {
+					response = `{"score": ` + tt.score + `, "reason": "test", "categories": [], "malicious_indicators": []}`
+				}
+				json.NewEncoder(w).Encode(models.OllamaResponse{Response: response, Done: true})
+			}))
+			defer ollamaServer.Close()
+
+			var imageHits int32
+			imageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&imageHits, 1)
+				w.Header().Set("Content-Type", "image/png")
+				w.Write([]byte("fake image data"))
+			}))
+			defer imageServer.Close()
+
+			webServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html")
+				w.Write([]byte(`<html><head><title>Images</title></head><body>
+	<img src="` + imageServer.URL + `/one.png" alt="One">
+</body></html>`))
+			}))
+			defer webServer.Close()
+
+			config := DefaultConfig()
+			config.OllamaBaseURL = ollamaServer.URL
+			config.RecommendedImagesOnly = true
+			s := New(config)
+
+			var phases []string
+			data, err := s.ScrapeWithProgress(context.Background(), webServer.URL, func(phase string, detail interface{}) {
+				phases = append(phases, phase)
+			})
+			if err != nil {
+				t.Fatalf("ScrapeWithProgress failed: %v", err)
+			}
+
+			if len(data.Images) != 1 || data.Images[0].URL != imageServer.URL+"/one.png" || data.Images[0].AltText != "One" {
+				t.Fatalf("Images = %+v, want the image URL and alt text kept", data.Images)
+			}
+			if scanned := imageHits > 0 && data.Images[0].ID != ""; scanned != tt.wantScanned {
+				t.Errorf("Image downloaded and stored = %v (hits %d, ID %q), want %v", scanned, imageHits, data.Images[0].ID, tt.wantScanned)
+			}
+			if tt.wantScanned {
+				scored := slices.Index(phases, PhaseScored)
+				analyzed := slices.Index(phases, PhaseImageAnalyzed)
+				if scored < 0 || analyzed < scored {
+					t.Errorf("Phases = %v, want scoring before image analysis", phases)
+				}
+			}
+		})
+	}
+
+	// The provisional score of deferred scoring can't decide on images
+	config := DefaultConfig()
+	config.RecommendedImagesOnly = true
+	config.ScoringMode = ScoringDeferred
+	if err := config.Validate(); err == nil {
+		t.Error("Expected Validate to reject RecommendedImagesOnly with deferred scoring")
+	}
+}
+
+// TestScrapeWithProgressReportsImages tests that each processed image is reported to the progress callback
+func TestScrapeWithProgressReportsImages(t *testing.T) {
+	ollamaServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := models.OllamaResponse{
+			Response: `{"summary": "A test image", "tags": ["test"]}`,
+			Done:     true,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer ollamaServer.Close()
+
+	imageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing.png" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("fake image data"))
+	}))
+	defer imageServer.Close()
+
+	webServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		html := `<html><head><title>Images</title></head><body>
+	<img src="` + imageServer.URL + `/one.png" alt="One">
+	<img src="` + imageServer.URL + `/missing.png" alt="Missing">
+	<img src="` + imageServer.URL + `/two.png" alt="Two">
+</body></html>`
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(html))
+	}))
+	defer webServer.Close()
+
+	config := Config{
+		HTTPTimeout:         10 * time.Second,
+		OllamaBaseURL:       ollamaServer.URL,
+		OllamaModel:         "test-model",
+		EnableImageAnalysis: true,
+		MaxImageSizeBytes:   10 * 1024 * 1024,
+		ImageTimeout:        5 * time.Second,
+	}
+	s := New(config)
+
+	var events []ImageProgress
+	progress := func(phase string, detail interface{}) {
+		if phase != PhaseImageAnalyzed {
+			return
+		}
+		events = append(events, detail.(ImageProgress))
+	}
+
+	data, err := s.ScrapeWithProgress(context.Background(), webServer.URL, progress)
+	if err != nil {
+		t.Fatalf("ScrapeWithProgress failed: %v", err)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 image events, got %d", len(events))
+	}
+
+	for i, event := range events {
+		if event.Total != 3 {
+			t.Errorf("Event %d total = %d, want 3", i, event.Total)
+		}
+		if event.Image.URL != data.Images[event.Index].URL {
+			t.Errorf("Event %d image URL = %s, want %s", i, event.Image.URL, data.Images[event.Index].URL)
+		}
+	}
+
+	// Failed downloads are still reported, just without analysis
+	if events[1].Image.Summary != "" {
+		t.Errorf("Expected no summary for failed image, got %q", events[1].Image.Summary)
+	}
+	if events[0].Image.Summary == "" {
+		t.Error("Expected summary for analyzed image")
+	}
+}
+
+// TestScoreContentFallbackCustomBlockedDomain tests that user-added blocked domains are honored
+func TestScoreContentFallbackCustomBlockedDomain(t *testing.T) {
+	config := DefaultConfig()
+	config.BlockedDomains = DefaultBlockedDomains()
+	config.BlockedDomains["example-tabloid.com"] = "tabloid"
+	s := New(config)
+
+	score, _, categories, indicators := s.scoreContentFallback(
+		"https://www.example-tabloid.com/story",
+		"Celebrity Gossip",
+		strings.Repeat("Some lengthy gossip about celebrities and their lives. ", 30),
+	)
+
+	if score != 0.1 {
+		t.Errorf("Expected score 0.1 for user-blocked domain, got %.2f", score)
+	}
+
+	if !containsString(categories, "tabloid") {
+		t.Errorf("Expected 'tabloid' category, got: %v", categories)
+	}
+
+	if !containsString(indicators, "tabloid") {
+		t.Errorf("Expected 'tabloid' malicious indicator, got: %v", indicators)
+	}
+}
+
+// TestScoreContentFallbackRemovedBlockedDomain tests that domains removed from the blocklist are no longer blocked
+func TestScoreContentFallbackRemovedBlockedDomain(t *testing.T) {
+	config := DefaultConfig()
+	config.BlockedDomains = DefaultBlockedDomains()
+	delete(config.BlockedDomains, "reddit.com")
+	s := New(config)
+
+	score, reason, categories, _ := s.scoreContentFallback(
+		"https://www.reddit.com/r/golang/comments/abc/generics_discussion",
+		"Generics discussion",
+		strings.Repeat("A long technical discussion about generics in Go and their tradeoffs. ", 30),
+	)
+
+	if score == 0.1 {
+		t.Errorf("Expected reddit.com not to be blocked, got score %.2f (%s)", score, reason)
+	}
+
+	if containsString(categories, "forum") {
+		t.Errorf("Expected no 'forum' category, got: %v", categories)
+	}
+
+	// The default configuration still blocks it
+	defaultScore, _, _, _ := New(DefaultConfig()).scoreContentFallback(
+		"https://www.reddit.com/r/golang/comments/abc/generics_discussion",
+		"Generics discussion",
+		"Discussion",
+	)
+	if defaultScore != 0.1 {
+		t.Errorf("Expected default config to block reddit.com, got score %.2f", defaultScore)
+	}
+}
+
+// TestScoreContentFallbackCustomQualityDomains tests that QualityDomains replaces the built-in list
+func TestScoreContentFallbackCustomQualityDomains(t *testing.T) {
+	config := DefaultConfig()
+	config.QualityDomains = []string{"docs.internal.example"}
+	s := New(config)
+
+	_, reason, _, _ := s.scoreContentFallback(
+		"https://docs.internal.example/handbook",
+		"Handbook",
+		strings.Repeat("Internal handbook content for employees. ", 30),
+	)
+	if !strings.Contains(reason, "Quality domain") {
+		t.Errorf("Expected custom quality domain to be detected, got: %s", reason)
+	}
+
+	_, reason, _, _ = s.scoreContentFallback(
+		"https://en.wikipedia.org/wiki/Go",
+		"Go - Wikipedia",
+		strings.Repeat("Go is a programming language. ", 30),
+	)
+	if strings.Contains(reason, "Quality domain") {
+		t.Errorf("Expected wikipedia not to be a quality domain with custom list, got: %s", reason)
+	}
+}
+
+// TestScoreContentFallbackQualityOverridesDefaultBlocklist tests that user quality domains are exempt from the built-in blocklist
+func TestScoreContentFallbackQualityOverridesDefaultBlocklist(t *testing.T) {
+	config := DefaultConfig()
+	config.QualityDomains = append(DefaultQualityDomains(), "reddit.com")
+	s := New(config)
+
+	score, reason, categories, _ := s.scoreContentFallback(
+		"https://www.reddit.com/r/golang/comments/abc/generics_discussion",
+		"Generics discussion",
+		strings.Repeat("A long technical discussion about generics in Go and their tradeoffs. ", 30),
+	)
+
+	if score <= 0.5 {
+		t.Errorf("Expected reddit.com to score as a quality domain, got %.2f (%s)", score, reason)
+	}
+	if containsString(categories, "forum") {
+		t.Errorf("Expected no 'forum' category, got: %v", categories)
+	}
+	if !containsString(categories, "trusted_source") {
+		t.Errorf("Expected 'trusted_source' category, got: %v", categories)
+	}
+
+	// Other built-in blocked domains stay blocked
+	score, _, _, _ = s.scoreContentFallback("https://www.facebook.com/somepage", "Page", "Content")
+	if score != 0.1 {
+		t.Errorf("Expected facebook.com to remain blocked, got score %.2f", score)
+	}
+
+	// Broad quality entries like ".org" don't exempt other blocked entries
+	for _, blockedURL := range []string{"https://www.casino.org/slots", "https://craigslist.org/x"} {
+		score, _, categories, _ = s.scoreContentFallback(blockedURL, "Page", "Content")
+		if score != 0.1 {
+			t.Errorf("Expected %s to remain blocked, got score %.2f", blockedURL, score)
+		}
+		if containsString(categories, "trusted_source") {
+			t.Errorf("Expected %s not to be a trusted source, got: %v", blockedURL, categories)
+		}
+	}
+
+	// An explicit blocklist is used as given
+	config.BlockedDomains = map[string]string{"reddit.com": "forum"}
+	score, _, _, _ = New(config).scoreContentFallback("https://www.reddit.com/r/golang", "Golang", "Content")
+	if score != 0.1 {
+		t.Errorf("Expected explicitly blocked reddit.com to be blocked, got score %.2f", score)
+	}
+}
+
+// TestScoreContentRuleBased tests the public rule-based scorer
+func TestScoreContentRuleBased(t *testing.T) {
+	content := strings.Repeat("This tutorial explains programming concepts with examples and documentation. ", 20)
+
+	score := ScoreContentRuleBased("https://en.wikipedia.org/wiki/Go", "Go tutorial", content)
+
+	if score.URL != "https://en.wikipedia.org/wiki/Go" {
+		t.Errorf("URL = %q, want the scored URL", score.URL)
+	}
+	if score.AIUsed {
+		t.Error("Expected AIUsed to be false for rule-based scoring")
+	}
+	if score.Reason == "" || len(score.Categories) == 0 {
+		t.Errorf("Expected reason and categories to be populated, got %+v", score)
+	}
+	if !score.IsRecommended {
+		t.Errorf("Expected quality content to be recommended, got score %.2f", score.Score)
+	}
+
+	// Scoring is deterministic
+	again := ScoreContentRuleBased("https://en.wikipedia.org/wiki/Go", "Go tutorial", content)
+	if again.Score != score.Score || again.Reason != score.Reason {
+		t.Errorf("Expected identical results, got %+v and %+v", score, again)
+	}
+
+	blocked := ScoreContentRuleBased("https://www.facebook.com/page", "Page", content)
+	if blocked.Score != 0.1 || blocked.IsRecommended {
+		t.Errorf("Expected blocked domain to score 0.1 and not be recommended, got %+v", blocked)
+	}
+}
+
+// TestScraperScoreContentRuleBasedUsesConfig tests that the method form honors the scraper's configuration
+func TestScraperScoreContentRuleBasedUsesConfig(t *testing.T) {
+	config := DefaultConfig()
+	config.BlockedDomains = map[string]string{"example-tabloid.com": "tabloid"}
+	config.LinkScoreThreshold = 0.05
+	s := New(config)
+
+	score := s.ScoreContentRuleBased("https://example-tabloid.com/story", "Story", "Gossip")
+	if score.Score != 0.1 {
+		t.Errorf("Expected configured blocked domain to score 0.1, got %.2f", score.Score)
+	}
+	if !score.IsRecommended {
+		t.Error("Expected the configured threshold to be used for IsRecommended")
+	}
+}
+
+// TestScrapeWithProgressPhases tests that all pipeline phases are reported in order
+func TestScrapeWithProgressPhases(t *testing.T) {
+	imageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("fake image data"))
+	}))
+	defer imageServer.Close()
+
+	webServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		html := `<html><head><title>Phases</title></head><body>
+	<p>Content</p>
+	<img src="` + imageServer.URL + `/one.png" alt="One">
+	<a href="/article">Article</a>
+</body></html>`
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(html))
+	}))
+	defer webServer.Close()
+
+	s := New(DefaultConfig())
+
+	var phases []string
+	progress := func(phase string, detail interface{}) {
+		phases = append(phases, phase)
+		if phase == PhaseLinksExtracted {
+			if lp := detail.(LinksProgress); lp.Count != 1 {
+				t.Errorf("Links count = %d, want 1", lp.Count)
+			}
+		}
+	}
+
+	if _, err := s.ScrapeWithProgress(context.Background(), webServer.URL, progress); err != nil {
+		t.Fatalf("ScrapeWithProgress failed: %v", err)
+	}
+
+	want := []string{
+		PhaseFetched,
+		PhaseContentExtracted,
+		PhaseImageStarted,
+		PhaseImageAnalyzed,
+		PhaseLinksExtracted,
+		PhaseScored,
+	}
+	if strings.Join(phases, ",") != strings.Join(want, ",") {
+		t.Errorf("Phases = %v, want %v", phases, want)
+	}
+}
+
+// TestScrapeTimings tests that per-phase timings are populated and fit within the total
+func TestScrapeTimings(t *testing.T) {
+	webServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Timed</title></head><body><p>Content</p></body></html>`))
+	}))
+	defer webServer.Close()
+
+	config := DefaultConfig()
+	config.EnableImageAnalysis = false
+	s := New(config)
+
+	data, err := s.Scrape(context.Background(), webServer.URL)
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+
+	if data.Timings == nil {
+		t.Fatal("Expected Timings to be populated")
+	}
+
+	if data.Timings.FetchTime < 0.02 {
+		t.Errorf("Expected fetch time to include server delay, got %.4fs", data.Timings.FetchTime)
+	}
+
+	total := data.Timings.FetchTime + data.Timings.ExtractTime + data.Timings.ImageTime + data.Timings.ScoreTime
+	if total > data.ProcessingTime {
+		t.Errorf("Phase timings sum %.4fs exceeds processing time %.4fs", total, data.ProcessingTime)
+	}
+}
+
+// TestResponseHeaderTimeout tests that a slow-header server trips the header timeout
+// while the overall HTTP timeout remains generous
+func TestResponseHeaderTimeout(t *testing.T) {
+	webServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Slow</title></head><body>Slow headers</body></html>`))
+	}))
+	defer webServer.Close()
+
+	config := DefaultConfig()
+	config.EnableImageAnalysis = false
+	config.HTTPTimeout = 10 * time.Second
+	config.ResponseHeaderTimeout = 50 * time.Millisecond
+	s := New(config)
+
+	start := time.Now()
+	_, err := s.Scrape(context.Background(), webServer.URL)
+	if err == nil {
+		t.Fatal("Expected response header timeout error")
+	}
+	if !strings.Contains(err.Error(), "timeout awaiting response headers") {
+		t.Errorf("Expected header timeout error, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected header timeout to fail fast, took %v", elapsed)
+	}
+
+	// Without a header timeout the same server succeeds
+	config.ResponseHeaderTimeout = 0
+	s = New(config)
+	if _, err := s.Scrape(context.Background(), webServer.URL); err != nil {
+		t.Errorf("Expected scrape to succeed without header timeout, got: %v", err)
+	}
+}
+
+// TestHostOverrides tests that overridden hosts connect to the given address
+// while requests keep the original hostname
+func TestHostOverrides(t *testing.T) {
+	var gotHost string
+	webServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHost = r.Host
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Pinned</title></head><body><a href="/next">Next</a></body></html>`))
+	}))
+	defer webServer.Close()
+	_, port, _ := net.SplitHostPort(webServer.Listener.Addr().String())
+
+	config := DefaultConfig()
+	config.EnableImageAnalysis = false
+	config.HostOverrides = map[string]string{
+		"Site.Test":  webServer.Listener.Addr().String(),
+		"other.test": "127.0.0.1",
+	}
+	s := New(config)
+
+	data, err := s.Scrape(context.Background(), "http://site.test/page")
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+	if data.Title != "Pinned" || gotHost != "site.test" {
+		t.Errorf("Title = %q, Host = %q; want the pinned server with the original Host", data.Title, gotHost)
+	}
+	if !containsString(data.Links, "http://site.test/next") {
+		t.Errorf("Expected links resolved against the original host, got %v", data.Links)
+	}
+
+	// An override without a port keeps the URL's port
+	if _, err := s.Scrape(context.Background(), "http://other.test:"+port+"/"); err != nil {
+		t.Errorf("Scrape with port from URL failed: %v", err)
+	}
+}
+
+// TestCustomHTTPClient tests that pages are fetched with Config.HTTPClient,
+// here one trusting a test server's certificate
+func TestCustomHTTPClient(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Pinned TLS</title></head><body></body></html>`))
+	}))
+	defer ts.Close()
+
+	config := DefaultConfig()
+	config.EnableImageAnalysis = false
+	if _, err := New(config).Scrape(context.Background(), ts.URL); err == nil {
+		t.Fatal("Expected the default client to reject the test certificate")
+	}
+
+	client := ts.Client()
+	config.HTTPClient = client
+	config.EnableCookieJar = true
+	data, err := New(config).Scrape(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+	if data.Title != "Pinned TLS" {
+		t.Errorf("Title = %q, want %q", data.Title, "Pinned TLS")
+	}
+	if client.Jar != nil {
+		t.Error("Expected the caller's client to be left unchanged")
+	}
+}
+
+// TestCustomResolver tests that hostnames are resolved with Config.Resolver
+func TestCustomResolver(t *testing.T) {
+	var dials int32
+	config := DefaultConfig()
+	config.EnableImageAnalysis = false
+	config.Resolver = &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			atomic.AddInt32(&dials, 1)
+			return nil, errors.New("resolver unavailable")
+		},
+	}
+
+	if _, err := New(config).Scrape(context.Background(), "http://resolver.test/"); err == nil {
+		t.Fatal("Expected scrape to fail when the resolver fails")
+	}
+	if atomic.LoadInt32(&dials) == 0 {
+		t.Error("Expected the custom resolver to be used")
+	}
+}
+
+// TestProcessImagesReusesAnalysisByHash tests that duplicate images skip the vision call
+func TestProcessImagesReusesAnalysisByHash(t *testing.T) {
+	var visionCalls int
+	ollamaServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Images []string `json:"images"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if len(req.Images) > 0 {
+			visionCalls++
+		}
+		resp := models.OllamaResponse{
+			Response: `{"summary": "Fresh analysis", "tags": ["fresh"]}`,
+			Done:     true,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer ollamaServer.Close()
+
+	imageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("image bytes for " + r.URL.Path))
+	}))
+	defer imageServer.Close()
+
+	knownHash := hashImage([]byte("image bytes for /logo.png"), nil)
+
+	config := Config{
+		HTTPTimeout:         10 * time.Second,
+		OllamaBaseURL:       ollamaServer.URL,
+		OllamaModel:         "test-model",
+		EnableImageAnalysis: true,
+		MaxImageSizeBytes:   10 * 1024 * 1024,
+		ImageTimeout:        5 * time.Second,
+		ImageLookup: func(hash string) (*models.ImageInfo, error) {
+			if hash == knownHash {
+				return &models.ImageInfo{ID: "existing", Summary: "Stored analysis", Tags: []string{"stored"}}, nil
+			}
+			return nil, nil
+		},
+	}
+	s := New(config)
+
+	images := s.processImages(context.Background(), []models.ImageInfo{
+		{URL: imageServer.URL + "/logo.png"},
+		{URL: imageServer.URL + "/photo.png"},
+	}, nil)
+
+	if visionCalls != 1 {
+		t.Errorf("Expected 1 vision call, got %d", visionCalls)
+	}
+
+	if images[0].Summary != "Stored analysis" || !containsString(images[0].Tags, "stored") {
+		t.Errorf("Expected reused analysis for duplicate image, got %q %v", images[0].Summary, images[0].Tags)
+	}
+	if images[0].Hash != knownHash {
+		t.Errorf("Hash = %s, want %s", images[0].Hash, knownHash)
+	}
+	if images[0].ID == "existing" {
+		t.Error("Expected duplicate image to get its own ID")
+	}
+
+	if images[1].Summary != "Fresh analysis" {
+		t.Errorf("Expected fresh analysis for new image, got %q", images[1].Summary)
+	}
+}
+
+// TestHashImage tests that image hashes depend on pixels rather than encoding
+func TestHashImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for i := range img.Pix {
+		img.Pix[i] = byte(i * 7)
+	}
+
+	// Encode the same pixels with different compression to get different bytes
+	var fast, best bytes.Buffer
+	if err := (&png.Encoder{CompressionLevel: png.NoCompression}).Encode(&fast, img); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	if err := (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(&best, img); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	if bytes.Equal(fast.Bytes(), best.Bytes()) {
+		t.Fatal("Expected encodings to differ")
+	}
+
+	hash := func(data []byte) string {
+		_, img := decodeImage(data)
+		return hashImage(data, img)
+	}
+	if hash(fast.Bytes()) != hash(best.Bytes()) {
+		t.Error("Expected identical pixels to produce identical hashes")
+	}
+
+	if len(hash(fast.Bytes())) != 64 {
+		t.Errorf("Expected 64-char hex hash, got %q", hash(fast.Bytes()))
+	}
+
+	// Undecodable data falls back to hashing raw bytes
+	if hash([]byte("<svg></svg>")) == hash([]byte("<svg> </svg>")) {
+		t.Error("Expected different raw bytes to produce different hashes")
+	}
+}
+
+// TestDecodeImageTooLarge tests that images declaring huge dimensions are not
+// decoded, and are hashed by their bytes
+func TestDecodeImageTooLarge(t *testing.T) {
+	// A PNG header declaring 30000x30000 RGBA pixels, 3.6GB decoded
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], 30000)
+	binary.BigEndian.PutUint32(ihdr[4:], 30000)
+	ihdr[8], ihdr[9] = 8, 6 // 8-bit RGBA
+	var data bytes.Buffer
+	data.WriteString("\x89PNG\r\n\x1a\n")
+	for _, chunk := range []struct {
+		kind string
+		body []byte
+	}{{"IHDR", ihdr}, {"IEND", nil}} {
+		binary.Write(&data, binary.BigEndian, uint32(len(chunk.body)))
+		data.WriteString(chunk.kind)
+		data.Write(chunk.body)
+		binary.Write(&data, binary.BigEndian, crc32.ChecksumIEEE(append([]byte(chunk.kind), chunk.body...)))
+	}
+
+	config, img := decodeImage(data.Bytes())
+	if config == nil || config.Width != 30000 || config.Height != 30000 {
+		t.Fatalf("config = %+v, want the declared 30000x30000", config)
+	}
+	if img != nil {
+		t.Error("Expected an image over maxDecodedPixels not to be decoded")
+	}
+	raw := sha256.Sum256(data.Bytes())
+	if got := hashImage(data.Bytes(), img); got != hex.EncodeToString(raw[:]) {
+		t.Errorf("hashImage() = %s, want the hash of the raw bytes", got)
+	}
+
+	if config, img := decodeImage([]byte("<svg></svg>")); config != nil || img != nil {
+		t.Errorf("decodeImage(SVG) = %+v, %v; want nothing", config, img)
+	}
+}
+
+// TestScrapeFollowsRedirects tests that redirects are followed and relative URLs resolve against the final URL
+func TestScrapeFollowsRedirects(t *testing.T) {
+	webServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/old":
+			http.Redirect(w, r, "/new/section/page", http.StatusFound)
+		case "/new/section/page":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<html><head><title>Moved</title></head><body>
+				<a href="sibling">Sibling</a>
+				<img src="pic.png" alt="Pic">
+			</body></html>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer webServer.Close()
+
+	config := DefaultConfig()
+	config.EnableImageAnalysis = false
+	s := New(config)
+
+	data, err := s.Scrape(context.Background(), webServer.URL+"/old")
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+
+	if data.URL != webServer.URL+"/old" {
+		t.Errorf("URL = %s, want requested URL", data.URL)
+	}
+	if data.FinalURL != webServer.URL+"/new/section/page" {
+		t.Errorf("FinalURL = %s, want %s", data.FinalURL, webServer.URL+"/new/section/page")
+	}
+	if data.StatusCode != http.StatusOK {
+		t.Errorf("StatusCode = %d, want %d", data.StatusCode, http.StatusOK)
+	}
+
+	if !containsString(data.Links, webServer.URL+"/new/section/sibling") {
+		t.Errorf("Expected link resolved against final URL, got %v", data.Links)
+	}
+	if len(data.Images) != 1 || data.Images[0].URL != webServer.URL+"/new/section/pic.png" {
+		t.Errorf("Expected image resolved against final URL, got %+v", data.Images)
+	}
+}
+
+// TestScrapeMaxRedirects tests the configurable redirect limit
+func TestScrapeMaxRedirects(t *testing.T) {
+	webServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// /hop/N redirects to /hop/N-1 until reaching /hop/0
+		var n int
+		fmt.Sscanf(r.URL.Path, "/hop/%d", &n)
+		if n > 0 {
+			http.Redirect(w, r, fmt.Sprintf("/hop/%d", n-1), http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Landed</title></head><body>Done</body></html>`))
+	}))
+	defer webServer.Close()
+
+	tests := []struct {
+		name         string
+		maxRedirects int
+		hops         int
+		wantErr      string
+	}{
+		{"within limit", 3, 3, ""},
+		{"exceeds limit", 2, 3, "stopped after 2 redirects"},
+		{"default limit", 0, 5, ""},
+		{"redirects disabled", -1, 1, "HTTP error: 302"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.EnableImageAnalysis = false
+			config.MaxRedirects = tt.maxRedirects
+			s := New(config)
+
+			_, err := s.Scrape(context.Background(), fmt.Sprintf("%s/hop/%d", webServer.URL, tt.hops))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestScoreContentFallbackSpanishSpam tests that spam phrases are matched in the content's language
+func TestScoreContentFallbackSpanishSpam(t *testing.T) {
+	content := "Haga clic aquí para ganar. Haga clic aquí de nuevo. Haga clic aquí y compra ahora la oferta de la semana para los clientes del país."
+	_, _, categories, indicators := New(DefaultConfig()).scoreContentFallback(
+		"https://example.es/oferta",
+		"Oferta",
+		content,
+	)
+
+	if !containsString(categories, "spam") {
+		t.Errorf("Expected 'spam' category for Spanish spam, got: %v", categories)
+	}
+	if !containsString(indicators, "spam_keywords") {
+		t.Errorf("Expected spam_keywords indicator, got: %v", indicators)
+	}
+}
+
+// TestScoreContentFallbackNonEnglishNotPenalized tests that content in a language
+// without keyword lists scores the same as comparable English content without keywords
+func TestScoreContentFallbackNonEnglishNotPenalized(t *testing.T) {
+	config := DefaultConfig()
+	config.QualityKeywords = map[string][]string{"en": {"documentation"}}
+	config.SpamKeywords = map[string]map[string]int{"en": {"click here": 2}}
+	s := New(config)
+
+	italian := strings.Repeat("Il libro della biblioteca è molto bello e non sono stanco di leggere per la scuola. ", 15)
+	english := strings.Repeat("The book from the library is very nice and it is good to read for the school. ", 15)
+
+	italianScore, _, _, _ := s.scoreContentFallback("https://example.it/libro", "Libro", italian)
+	englishScore, _, _, _ := s.scoreContentFallback("https://example.com/book", "Book", english)
+
+	if italianScore != englishScore {
+		t.Errorf("Expected equal scores for comparable content, got it=%.2f en=%.2f", italianScore, englishScore)
+	}
+}
+
+// TestScoreContentFallbackLocalizedQualityKeywords tests that quality keywords are matched per language
+func TestScoreContentFallbackLocalizedQualityKeywords(t *testing.T) {
+	content := strings.Repeat("Esta es una guía completa para los desarrolladores que quieren aprender de la programación. ", 15)
+	_, _, categories, _ := New(DefaultConfig()).scoreContentFallback(
+		"https://example.es/guia",
+		"Guía de programación",
+		content,
+	)
+
+	if !containsString(categories, "technical") {
+		t.Errorf("Expected 'technical' category for Spanish guide, got: %v", categories)
+	}
+}
+
+// TestMaxBodyBytes tests that oversized pages fail with ErrPageTooLarge instead of being truncated
+func TestMaxBodyBytes(t *testing.T) {
+	page := `<html><head><title>Big</title></head><body>` + strings.Repeat("<p>padding</p>", 200) + `</body></html>`
+
+	tests := []struct {
+		name    string
+		chunked bool // omit Content-Length so only the streaming limit applies
+	}{
+		{"content length", false},
+		{"chunked", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			webServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html")
+				if tt.chunked {
+					w.(http.Flusher).Flush()
+				}
+				w.Write([]byte(page))
+			}))
+			defer webServer.Close()
+
+			config := DefaultConfig()
+			config.EnableImageAnalysis = false
+			config.MaxBodyBytes = 1024
+			s := New(config)
+
+			ctx := context.Background()
+			if _, err := s.Scrape(ctx, webServer.URL); !errors.Is(err, ErrPageTooLarge) {
+				t.Errorf("Scrape: expected page too large error, got %v", err)
+			}
+			if _, err := s.ExtractLinks(ctx, webServer.URL); !errors.Is(err, ErrPageTooLarge) {
+				t.Errorf("ExtractLinks: expected page too large error, got %v", err)
+			}
+			if _, err := s.ScoreLinkContent(ctx, webServer.URL); !errors.Is(err, ErrPageTooLarge) {
+				t.Errorf("ScoreLinkContent: expected page too large error, got %v", err)
+			}
+
+			config.MaxBodyBytes = int64(len(page))
+			if _, err := New(config).Scrape(ctx, webServer.URL); err != nil {
+				t.Errorf("Expected page at the limit to succeed, got %v", err)
+			}
+		})
+	}
+}
+
+// TestGenerateThumbnail tests thumbnail scaling and unsupported format handling
+func TestGenerateThumbnail(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 1024, 512))
+	for i := range src.Pix {
+		src.Pix[i] = 0xff
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+
+	_, pixels := decodeImage(buf.Bytes())
+	thumbnail := generateThumbnail(pixels)
+	if thumbnail == nil {
+		t.Fatal("Expected thumbnail for PNG image")
+	}
+
+	decoded, format, err := image.Decode(bytes.NewReader(thumbnail))
+	if err != nil {
+		t.Fatalf("Failed to decode thumbnail: %v", err)
+	}
+	if format != "jpeg" {
+		t.Errorf("Thumbnail format = %q, want jpeg", format)
+	}
+	if got := decoded.Bounds().Size(); got.X != 256 || got.Y != 128 {
+		t.Errorf("Thumbnail size = %v, want 256x128", got)
+	}
+
+	// Small images keep their dimensions
+	small := image.NewRGBA(image.Rect(0, 0, 10, 20))
+	decoded, _, err = image.Decode(bytes.NewReader(generateThumbnail(small)))
+	if err != nil {
+		t.Fatalf("Failed to decode small thumbnail: %v", err)
+	}
+	if got := decoded.Bounds().Size(); got.X != 10 || got.Y != 20 {
+		t.Errorf("Thumbnail size = %v, want 10x20", got)
+	}
+
+	// No thumbnail without decoded pixels, as for SVG
+	if generateThumbnail(nil) != nil {
+		t.Error("Expected nil thumbnail without an image")
+	}
+}
+
+// TestExtractMetadataStructured tests Open Graph, Twitter card, and JSON-LD extraction
+func TestExtractMetadataStructured(t *testing.T) {
+	htmlContent := `<html><head>
+		<meta property="og:type" content="article">
+		<meta property="og:site_name" content="Example News">
+		<meta name="twitter:image" content="https://example.com/card.jpg">
+		<meta name="twitter:description" content="Card description">
+		<script type="application/ld+json">{not valid json</script>
+		<script type="application/ld+json">
+			{"@context": "https://schema.org", "@type": "NewsArticle",
+			 "headline": "Big News", "datePublished": "2024-01-02T03:04:05Z",
+			 "author": {"@type": "Person", "name": "Jane Writer"}}
+		</script>
+		<script type="application/ld+json">
+			{"@context": "https://schema.org", "@graph": [
+				{"@type": "Organization", "name": "Example News"},
+				{"@type": ["WebPage", "ItemPage"], "name": "Page"}
+			]}
+		</script>
+	</head><body></body></html>`
+
+	doc, err := html.Parse(strings.NewReader(htmlContent))
+	if err != nil {
+		t.Fatalf("Failed to parse HTML: %v", err)
+	}
+
+	metadata := extractMetadata(doc)
+
+	if metadata.Type != "article" {
+		t.Errorf("Type = %q, want article", metadata.Type)
+	}
+	if metadata.SiteName != "Example News" {
+		t.Errorf("SiteName = %q, want Example News", metadata.SiteName)
+	}
+	if metadata.ImageURL != "https://example.com/card.jpg" {
+		t.Errorf("ImageURL = %q, want twitter image", metadata.ImageURL)
+	}
+	if metadata.Description != "Card description" {
+		t.Errorf("Description = %q, want Card description", metadata.Description)
+	}
+	if metadata.PublishedDate != "2024-01-02T03:04:05Z" {
+		t.Errorf("PublishedDate = %q, want date from JSON-LD", metadata.PublishedDate)
+	}
+	if metadata.Author != "Jane Writer" {
+		t.Errorf("Author = %q, want Jane Writer", metadata.Author)
+	}
+
+	for _, schemaType := range []string{"NewsArticle", "Organization", "WebPage"} {
+		if _, ok := metadata.StructuredData[schemaType]; !ok {
+			t.Errorf("Expected structured data for %s, got %v", schemaType, metadata.StructuredData)
+		}
+	}
+	article := metadata.StructuredData["NewsArticle"].(map[string]interface{})
+	if article["headline"] != "Big News" {
+		t.Errorf("headline = %v, want Big News", article["headline"])
+	}
+}
+
+// TestExtractMetadataLinkPreview tests the Open Graph and Twitter card fields used for link previews
+func TestExtractMetadataLinkPreview(t *testing.T) {
+	htmlContent := `<html><head>
+		<title>Big News | Example News</title>
+		<meta property="og:title" content="Big News">
+		<meta property="og:type" content="article">
+		<meta property="og:site_name" content="Example News">
+		<meta property="og:image" content="https://example.com/og.jpg">
+		<meta property="og:image" content="https://example.com/og-second.jpg">
+		<meta name="twitter:card" content="summary_large_image">
+		<meta name="twitter:image" content="https://example.com/card.jpg">
+	</head><body></body></html>`
+
+	doc, err := html.Parse(strings.NewReader(htmlContent))
+	if err != nil {
+		t.Fatalf("Failed to parse HTML: %v", err)
+	}
+
+	metadata := extractMetadata(doc)
+
+	tests := []struct {
+		field string
+		got   string
+		want  string
+	}{
+		{"OGTitle", metadata.OGTitle, "Big News"},
+		{"Type", metadata.Type, "article"},
+		{"SiteName", metadata.SiteName, "Example News"},
+		{"OGImage", metadata.OGImage, "https://example.com/og.jpg"},
+		{"ImageURL", metadata.ImageURL, "https://example.com/og.jpg"},
+		{"TwitterCard", metadata.TwitterCard, "summary_large_image"},
+		{"TwitterImage", metadata.TwitterImage, "https://example.com/card.jpg"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.field, tt.got, tt.want)
+		}
+	}
+}
+
+// TestScoreContentFallbackTrustedDomains tests configurable trusted domain boosts
+func TestScoreContentFallbackTrustedDomains(t *testing.T) {
+	config := DefaultConfig()
+	config.TrustedDomains = map[string]float64{
+		".corp":            0.2,
+		"wiki.intra.corp":  0.4,
+		"spammy.github.io": -0.3,
+	}
+	s := New(config)
+	content := strings.Repeat("Plain neutral words about a topic. ", 30)
+
+	tests := []struct {
+		name      string
+		url       string
+		wantScore float64
+		trusted   bool
+	}{
+		{"TLD match", "https://portal.intra.corp/page", 0.9, true},
+		{"most specific match wins", "https://wiki.intra.corp:8443/page", 1.0, true},
+		{"negative boost overrides quality domain", "https://spammy.github.io/x", 0.4, false},
+		{"suffix without dot boundary does not match", "https://notcorp.com/page", 0.7, false},
+		{"unmatched host falls back to quality domains", "https://github.com/x", 1.0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, reason, categories, _ := s.scoreContentFallback(tt.url, "Title", content)
+			if diff := score - tt.wantScore; diff > 0.001 || diff < -0.001 {
+				t.Errorf("score = %v, want %v (reason: %s)", score, tt.wantScore, reason)
+			}
+			if containsString(categories, "trusted_source") != tt.trusted {
+				t.Errorf("trusted_source in %v, want %v", categories, tt.trusted)
+			}
+		})
+	}
+}
+
+// TestExtractImagesPictureAndBackgrounds tests image extraction beyond <img> tags
+func TestExtractImagesPictureAndBackgrounds(t *testing.T) {
+	htmlContent := `<html><body>
+		<picture>
+			<source srcset="/hero-small.webp 480w, /hero-large.webp 1200w" type="image/webp">
+			<source srcset="/hero.jpg 1x, /hero@2x.jpg 2x">
+			<img src="/hero@2x.jpg" alt="Hero">
+		</picture>
+		<source srcset="/not-in-picture.jpg">
+		<div style="color: red; background-image: url('/bg.png')"></div>
+		<section style="background: #fff url(&quot;/section.jpg&quot;) no-repeat"></section>
+		<div style="background-image: url(data:image/gif;base64,R0lGOD)"></div>
+		<img src="/hero@2x.jpg" alt="Duplicate">
+	</body></html>`
+
+	doc, err := html.Parse(strings.NewReader(htmlContent))
+	if err != nil {
+		t.Fatalf("Failed to parse HTML: %v", err)
+	}
+	base, _ := url.Parse("https://example.com/article")
+
+	images := extractImages(doc, base)
+
+	want := []struct{ url, alt string }{
+		{"https://example.com/hero-large.webp", ""},
+		{"https://example.com/hero@2x.jpg", "Hero"},
+		{"https://example.com/bg.png", ""},
+		{"https://example.com/section.jpg", ""},
+	}
+	if len(images) != len(want) {
+		t.Fatalf("Expected %d images, got %d: %+v", len(want), len(images), images)
+	}
+	for i, w := range want {
+		if images[i].URL != w.url || images[i].AltText != w.alt {
+			t.Errorf("Image %d = %q (alt %q), want %q (alt %q)", i, images[i].URL, images[i].AltText, w.url, w.alt)
+		}
+	}
+}
+
+// TestProtocolRelativeURLs tests that protocol-relative links and images take
+// the page's scheme
+func TestProtocolRelativeURLs(t *testing.T) {
+	htmlContent := `<html><body>
+		<a href="//cdn.example.com/docs/guide">Guide</a>
+		<a href="  //cdn.example.com/spaced
+			">Spaced</a>
+		<a href="\\cdn.example.com/backslashes">Backslashes</a>
+		<a href="//cdn.example.com:8443/port">Port</a>
+		<img src="//img.example.com/photo.jpg" alt="Photo">
+		<picture><source srcset="//img.example.com/hero.webp 2x"></picture>
+		<div style="background-image: url(//img.example.com/bg.png)"></div>
+	</body></html>`
+
+	doc, err := html.Parse(strings.NewReader(htmlContent))
+	if err != nil {
+		t.Fatalf("Failed to parse HTML: %v", err)
+	}
+
+	for _, scheme := range []string{"http", "https"} {
+		base, _ := url.Parse(scheme + "://www.example.com/articles/page.html")
+
+		wantLinks := []string{
+			scheme + "://cdn.example.com/docs/guide",
+			scheme + "://cdn.example.com/spaced",
+			scheme + "://cdn.example.com/backslashes",
+			scheme + "://cdn.example.com:8443/port",
+		}
+		links := extractLinks(doc, base, false, false)
+		if strings.Join(links, " ") != strings.Join(wantLinks, " ") {
+			t.Errorf("%s links = %v, want %v", scheme, links, wantLinks)
+		}
+
+		wantImages := []string{
+			scheme + "://img.example.com/photo.jpg",
+			scheme + "://img.example.com/hero.webp",
+			scheme + "://img.example.com/bg.png",
+		}
+		images := extractImages(doc, base)
+		if len(images) != len(wantImages) {
+			t.Fatalf("%s: expected %d images, got %+v", scheme, len(wantImages), images)
+		}
+		for i, want := range wantImages {
+			if images[i].URL != want {
+				t.Errorf("%s image %d = %q, want %q", scheme, i, images[i].URL, want)
+			}
+		}
+	}
+}
+
+// TestExtractLinksFragments tests dropping in-page links and stripping fragments
+func TestExtractLinksFragments(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><body>
+		<a href="#intro">Intro</a>
+		<a href=" #usage">Usage</a>
+		<a href="#">Top</a>
+		<a href="/docs/guide#install">Install</a>
+		<a href="/docs/guide#configure">Configure</a>
+		<a href="/app#/settings">Settings</a>
+	</body></html>`))
+	if err != nil {
+		t.Fatalf("Failed to parse HTML: %v", err)
+	}
+	base, _ := url.Parse("https://example.com/docs/page")
+
+	tests := []struct {
+		name           string
+		keepInPage     bool
+		stripFragments bool
+		want           []string
+	}{
+		{"default drops in-page links", false, false, []string{
+			"https://example.com/docs/guide#install",
+			"https://example.com/docs/guide#configure",
+			"https://example.com/app#/settings",
+		}},
+		{"keep in-page links", true, false, []string{
+			"https://example.com/docs/page#intro",
+			"https://example.com/docs/page#usage",
+			"https://example.com/docs/page",
+			"https://example.com/docs/guide#install",
+			"https://example.com/docs/guide#configure",
+			"https://example.com/app#/settings",
+		}},
+		{"strip fragments", false, true, []string{
+			"https://example.com/docs/guide",
+			"https://example.com/app",
+		}},
+		{"keep in-page links and strip fragments", true, true, []string{
+			"https://example.com/docs/page",
+			"https://example.com/docs/guide",
+			"https://example.com/app",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractLinks(doc, base, tt.keepInPage, tt.stripFragments); !slices.Equal(got, tt.want) {
+				t.Errorf("extractLinks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestExtractCanonicalURL tests canonical link extraction and resolution
+func TestExtractCanonicalURL(t *testing.T) {
+	base, _ := url.Parse("https://example.com/news/story?utm_source=feed")
+
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{"absolute", `<link rel="canonical" href="https://example.com/news/story">`, "https://example.com/news/story"},
+		{"relative", `<link rel="Canonical" href="/news/story">`, "https://example.com/news/story"},
+		{"multiple rel tokens", `<link rel="alternate canonical" href="/story">`, "https://example.com/story"},
+		{"non-http scheme", `<link rel="canonical" href="javascript:alert(1)">`, ""},
+		{"missing", `<link rel="stylesheet" href="/style.css">`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader("<html><head>" + tt.html + "</head><body></body></html>"))
+			if err != nil {
+				t.Fatalf("Failed to parse HTML: %v", err)
+			}
+			if got := extractCanonicalURL(doc, base); got != tt.want {
+				t.Errorf("extractCanonicalURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSameSite tests that canonical URLs on other sites are not trusted for dedup
+func TestSameSite(t *testing.T) {
+	page, _ := url.Parse("https://www.example.com/a?utm_source=x")
+
+	if !sameSite("https://example.com/a", page) {
+		t.Error("Expected www and bare host to be the same site")
+	}
+	if sameSite("https://other.com/a", page) {
+		t.Error("Expected other host to be a different site")
+	}
+
+	tests := []struct {
+		canonical string
+		page      string
+		want      bool
+	}{
+		{"https://www.example.com/story", "https://amp.example.com/story", true},
+		{"https://example.co.uk/story", "https://m.example.co.uk/story", true},
+		{"https://example.com/story", "https://example.com.evil.net/story", false},
+		{"https://alice.github.io/post", "https://mallory.github.io/post", false},
+		{"https://github.io/post", "https://alice.github.io/post", false},
+		{"http://127.0.0.1:8080/story", "http://127.0.0.1:9090/story", true},
+		{"http://10.0.0.1/story", "http://127.0.0.1/story", false},
+	}
+	for _, tt := range tests {
+		page, _ := url.Parse(tt.page)
+		if got := sameSite(tt.canonical, page); got != tt.want {
+			t.Errorf("sameSite(%q, %q) = %v, want %v", tt.canonical, tt.page, got, tt.want)
+		}
+	}
+}
+
+// encodeTestPNG encodes a blank PNG of the given size
+func encodeTestPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// TestProcessImagesFilters tests that images filtered by type or size are kept without analysis
+func TestProcessImagesFilters(t *testing.T) {
+	var analyzed []string
+	ollamaServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req models.OllamaVisionRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		analyzed = append(analyzed, req.Prompt)
+		json.NewEncoder(w).Encode(models.OllamaResponse{Response: `{"summary": "A photo", "tags": ["photo"]}`, Done: true})
+	}))
+	defer ollamaServer.Close()
+
+	pixel := encodeTestPNG(t, 1, 1)
+	photo := encodeTestPNG(t, 64, 48)
+	imageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/pixel.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(pixel)
+		case "/photo.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(photo)
+		case "/icon.svg":
+			w.Header().Set("Content-Type", "image/svg+xml")
+			w.Write([]byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`))
+		}
+	}))
+	defer imageServer.Close()
+
+	config := DefaultConfig()
+	config.OllamaBaseURL = ollamaServer.URL
+	config.AllowedImageTypes = []string{"image/jpeg", "image/png", "image/webp"}
+	config.MinImageWidth = 32
+	config.MinImageHeight = 32
+	s := New(config)
+
+	images := s.processImages(context.Background(), []models.ImageInfo{
+		{URL: imageServer.URL + "/pixel.png"},
+		{URL: imageServer.URL + "/icon.svg"},
+		{URL: imageServer.URL + "/photo.png"},
+	}, nil)
+
+	if len(images) != 3 {
+		t.Fatalf("Expected all 3 images to be kept, got %d", len(images))
+	}
+	if len(analyzed) != 1 {
+		t.Errorf("Expected only the photo to be analyzed, got %d vision calls", len(analyzed))
+	}
+	for _, img := range images[:2] {
+		if img.Summary != "" {
+			t.Errorf("Expected no analysis for filtered image %s, got %q", img.URL, img.Summary)
+		}
+	}
+	if images[1].Base64Data != "" {
+		t.Error("Expected disallowed image type not to be downloaded")
+	}
+	if images[2].Summary != "A photo" {
+		t.Errorf("Expected photo to be analyzed, got %q", images[2].Summary)
+	}
+}
+
+// TestImageTypeAllowed tests Content-Type matching against the allowlist
+func TestImageTypeAllowed(t *testing.T) {
+	s := New(Config{AllowedImageTypes: []string{"image/jpeg"}})
+
+	if !s.imageTypeAllowed("IMAGE/JPEG; charset=binary") {
+		t.Error("Expected parameters and case to be ignored")
+	}
+	if s.imageTypeAllowed("image/gif") {
+		t.Error("Expected image/gif to be rejected")
+	}
+	if !New(Config{}).imageTypeAllowed("image/gif") {
+		t.Error("Expected all types to be allowed with an empty list")
+	}
+}
+
+// TestDownloadImageTooLarge tests that the size cap holds whatever length the server declares
+func TestDownloadImageTooLarge(t *testing.T) {
+	data := bytes.Repeat([]byte{0xAB}, 4096)
+
+	var gzipped bytes.Buffer
+	zw := gzip.NewWriter(&gzipped)
+	zw.Write(data)
+	zw.Close()
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"content length", func(w http.ResponseWriter, r *http.Request) {
+			w.Write(data)
+		}},
+		{"chunked", func(w http.ResponseWriter, r *http.Request) {
+			w.(http.Flusher).Flush() // forces chunked encoding without Content-Length
+			w.Write(data)
+		}},
+		{"compressed", func(w http.ResponseWriter, r *http.Request) {
+			// Content-Length describes the small compressed body
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Header().Set("Content-Length", fmt.Sprint(gzipped.Len()))
+			w.Write(gzipped.Bytes())
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			imageServer := httptest.NewServer(tt.handler)
+			defer imageServer.Close()
+
+			config := DefaultConfig()
+			config.MaxImageSizeBytes = 1024
+			_, err := New(config).downloadImage(context.Background(), imageServer.URL)
+			if !errors.Is(err, ErrImageTooLarge) {
+				t.Errorf("Expected ErrImageTooLarge, got %v", err)
+			}
+
+			config.MaxImageSizeBytes = int64(len(data))
+			got, err := New(config).downloadImage(context.Background(), imageServer.URL)
+			if err != nil || !bytes.Equal(got, data) {
+				t.Errorf("Expected image at the limit to download, got %d bytes (err: %v)", len(got), err)
+			}
+		})
+	}
+}
+
+// TestExtractHeadings tests heading outline extraction
+func TestExtractHeadings(t *testing.T) {
+	htmlContent := `<html><body>
+		<h1>  Guide  </h1>
+		<section>
+			<h2>Getting <em>started</em></h2>
+			<h3>Install</h3>
+			<h3>   </h3>
+			<h3>Configure</h3>
+		</section>
+		<h2>Reference</h2>
+		<h6>Footnote</h6>
+	</body></html>`
+
+	doc, err := html.Parse(strings.NewReader(htmlContent))
+	if err != nil {
+		t.Fatalf("Failed to parse HTML: %v", err)
+	}
+
+	got := extractHeadings(doc)
+	want := []models.Heading{
+		{Level: 1, Text: "Guide"},
+		{Level: 2, Text: "Getting started"},
+		{Level: 3, Text: "Install"},
+		{Level: 3, Text: "Configure"},
+		{Level: 2, Text: "Reference"},
+		{Level: 6, Text: "Footnote"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d headings, got %d: %+v", len(want), len(got), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Heading %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+// TestScrapeUserAgent tests that the configured User-Agent is sent for pages and images
+func TestScrapeUserAgent(t *testing.T) {
+	var mu sync.Mutex
+	agents := map[string]string{}
+	webServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		agents[r.URL.Path] = r.Header.Get("User-Agent")
+		mu.Unlock()
+		if r.URL.Path == "/pic.png" {
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte("not really a png"))
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>UA</title></head><body><img src="/pic.png" alt="Pic"></body></html>`))
+	}))
+	defer webServer.Close()
+
+	config := DefaultConfig()
+	config.UserAgent = "TestBot/2.0 (+https://example.com/bot)"
+	config.OllamaBaseURL = "http://127.0.0.1:1"
+	if _, err := New(config).Scrape(context.Background(), webServer.URL+"/page"); err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, path := range []string{"/page", "/pic.png"} {
+		if agents[path] != config.UserAgent {
+			t.Errorf("User-Agent for %s = %q, want %q", path, agents[path], config.UserAgent)
+		}
+	}
+
+	// An empty User-Agent falls back to the default
+	config.UserAgent = "  "
+	if ua := New(config).config.UserAgent; ua != DefaultUserAgent {
+		t.Errorf("UserAgent = %q, want the default", ua)
+	}
+}
+
+// TestScrapeSkipsHiddenText tests that hidden elements are left out of the
+// extracted content unless SkipHiddenText is off
+func TestScrapeSkipsHiddenText(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body>
+			<nav aria-hidden="true">Off-screen menu</nav>
+			<p>Visible intro.</p>
+			<div style="display: none">Hidden SEO keywords</div>
+			<div style="color:red; VISIBILITY:hidden !important">Invisible text</div>
+			<section hidden><p>Hidden section</p></section>
+			<div hidden="until-found">Collapsed answer</div>
+			<p aria-hidden="false" style="display:block">Visible outro.</p>
+		</body></html>`))
+	}))
+	defer ts.Close()
+
+	config := DefaultConfig()
+	config.EnableImageAnalysis = false
+	config.TextSkipTags = []string{} // keep the nav so only hiding removes it
+	data, err := New(config).Scrape(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+	if data.Content != "Visible intro.\nCollapsed answer\nVisible outro." {
+		t.Errorf("Content = %q, want hidden text left out", data.Content)
+	}
+
+	config.SkipHiddenText = false
+	data, err = New(config).Scrape(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+	for _, text := range []string{"Off-screen menu", "Hidden SEO keywords", "Invisible text", "Hidden section"} {
+		if !strings.Contains(data.Content, text) {
+			t.Errorf("Content = %q, want %q kept with SkipHiddenText off", data.Content, text)
+		}
+	}
+}
+
+// TestScrapeSkipsLandmarks tests that navigation, page headers and footers,
+// and sidebars are left out of the extracted content
+func TestScrapeSkipsLandmarks(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body>
+			<header><a href="/">Site logo</a> Sign in</header>
+			<nav><a href="/news">News</a> <a href="/sport">Sport</a></nav>
+			<div role="navigation">Breadcrumb trail</div>
+			<main>
+				<article>
+					<header><h1>Article title</h1></header>
+					<p>The article body.</p>
+					<footer>Filed under testing</footer>
+				</article>
+				<aside>Related stories</aside>
+			</main>
+			<footer>Copyright notice</footer>
+		</body></html>`))
+	}))
+	defer ts.Close()
+
+	config := DefaultConfig()
+	config.EnableImageAnalysis = false
+	data, err := New(config).Scrape(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+	if data.Content != "Article title\nThe article body.\nFiled under testing" {
+		t.Errorf("Content = %q, want landmarks left out", data.Content)
+	}
+
+	// Only the configured tags are skipped
+	config.TextSkipTags = []string{"FOOTER"}
+	data, err = New(config).Scrape(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+	for _, text := range []string{"Site logo", "Sport", "Breadcrumb trail", "Related stories"} {
+		if !strings.Contains(data.Content, text) {
+			t.Errorf("Content = %q, want %q kept", data.Content, text)
+		}
+	}
+	if strings.Contains(data.Content, "Copyright notice") {
+		t.Errorf("Content = %q, want the page footer left out", data.Content)
+	}
+}
+
+// TestExtractTextBlockStructure tests that block elements are separated by
+// newlines while inline elements and source whitespace collapse to spaces
+func TestExtractTextBlockStructure(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><body>
+		<h1>Guide</h1>
+		<p>First   paragraph with
+			<b>bold</b> text.</p>
+		<p>Second<br>line two</p>
+		<ul><li>One</li><li>Two</li></ul>
+		<div><div>Nested</div></div>
+		<pre>a  b
+c</pre>
+	</body></html>`))
+	if err != nil {
+		t.Fatalf("Failed to parse HTML: %v", err)
+	}
+
+	want := "Guide\nFirst paragraph with bold text.\nSecond\nline two\n- One\n- Two\nNested\na  b\nc"
+	if got := extractText(doc); got != want {
+		t.Errorf("extractText() = %q, want %q", got, want)
+	}
+}
+
+// TestExtractTextListsAndQuotes tests that list items get markers, nested
+// lists are indented under their item, and quoted lines are set off
+func TestExtractTextListsAndQuotes(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><body>
+		<ul>
+			<li>Fruit
+				<ol start="3">
+					<li>Apple</li>
+					<li><p>Banana</p><p>ripe</p></li>
+				</ol>
+			</li>
+			<li></li>
+			<li>Veg</li>
+		</ul>
+		<blockquote>
+			<p>Quoted one.</p>
+			<p>Quoted <em>two</em>.</p>
+			<blockquote>Nested quote</blockquote>
+			<ul><li>Quoted item</li></ul>
+		</blockquote>
+		<p>After.</p>
+	</body></html>`))
+	if err != nil {
+		t.Fatalf("Failed to parse HTML: %v", err)
+	}
+
+	want := strings.Join([]string{
+		"- Fruit",
+		"  3. Apple",
+		"  4. Banana",
+		"     ripe",
+		"- Veg",
+		"> Quoted one.",
+		"> Quoted two.",
+		"> > Nested quote",
+		"> - Quoted item",
+		"After.",
+	}, "\n")
+	if got := extractText(doc); got != want {
+		t.Errorf("extractText() =\n%s\nwant\n%s", got, want)
+	}
+
+	// Without structure all text is joined with spaces
+	flat := "Fruit Apple Banana ripe Veg Quoted one. Quoted two. Nested quote Quoted item After."
+	if got := collectText(doc, false, false, nil); got != flat {
+		t.Errorf("collectText() unstructured = %q, want %q", got, flat)
+	}
+}
+
+// TestReadingTime tests the word count and reading time estimate
+func TestReadingTime(t *testing.T) {
+	for words, want := range map[int]int{0: 0, 1: 0, 2: 1, 200: 60, 350: 105, 1000: 300} {
+		if got := readingTimeSeconds(words); got != want {
+			t.Errorf("readingTimeSeconds(%d) = %d, want %d", words, got, want)
+		}
+	}
+
+	webServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Article</title></head><body><nav>Home About</nav><article><p>` +
+			strings.Repeat("word ", 400) + `</p></article></body></html>`))
+	}))
+	defer webServer.Close()
+
+	config := DefaultConfig()
+	config.OllamaBaseURL = "http://127.0.0.1:1" // unreachable, so content is the article's text
+	config.EnableImageAnalysis = false
+	data, err := New(config).Scrape(context.Background(), webServer.URL)
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+	if data.WordCount != 400 || data.ReadingTimeSeconds != 120 {
+		t.Errorf("WordCount, ReadingTimeSeconds = %d, %d; want 400, 120", data.WordCount, data.ReadingTimeSeconds)
+	}
+}
+
+func TestScrapeRawContent(t *testing.T) {
+	ollamaServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(models.OllamaResponse{Response: "Cleaned content", Done: true})
+	}))
+	defer ollamaServer.Close()
+
+	webServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Raw</title></head><body><p>Raw page text</p></body></html>`))
+	}))
+	defer webServer.Close()
+
+	config := DefaultConfig()
+	config.OllamaBaseURL = ollamaServer.URL
+	config.EnableImageAnalysis = false
+	s := New(config)
+
+	data, err := s.Scrape(context.Background(), webServer.URL)
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+	if data.RawContent != "" {
+		t.Errorf("RawContent = %q, want none unless asked for", data.RawContent)
+	}
+
+	data, err = s.Scrape(WithRawContent(context.Background()), webServer.URL)
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+	if data.Content != "Cleaned content" || data.RawContent != "Raw\nRaw page text" {
+		t.Errorf("Content, RawContent = %q, %q; want the cleaned and extracted text", data.Content, data.RawContent)
+	}
+}
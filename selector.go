@@ -0,0 +1,339 @@
+package scraper
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// cssSelector is a parsed CSS selector list; a node matches if any of the
+// comma-separated selectors matches. Only the subset of CSS needed to pick
+// out a page's main content is supported: type, universal, class, ID, and
+// attribute selectors ([a], [a=v], [a~=v], [a^=v], [a$=v], [a*=v]),
+// combined with the descendant and child (>) combinators.
+type cssSelector []complexSelector
+
+// complexSelector is a chain of compound selectors joined by combinators
+type complexSelector struct {
+	parts       []compoundSelector
+	combinators []byte // combinators[i] joins parts[i] and parts[i+1]: ' ' or '>'
+}
+
+// compoundSelector matches a single element, e.g. article.post-body#main
+type compoundSelector struct {
+	tag     string // empty matches any element
+	id      string
+	classes []string
+	attrs   []attrSelector
+}
+
+// attrSelector matches an attribute, with op "" testing only for presence
+type attrSelector struct {
+	key, op, value string
+}
+
+// parseSelector parses a CSS selector list
+func parseSelector(selector string) (cssSelector, error) {
+	var list cssSelector
+	for _, part := range splitSelectorList(selector) {
+		c, err := parseComplexSelector(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector %q: %w", selector, err)
+		}
+		list = append(list, c)
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("invalid selector %q: empty", selector)
+	}
+	return list, nil
+}
+
+// splitSelectorList splits a selector list on commas outside attribute brackets
+func splitSelectorList(selector string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(selector); i++ {
+		switch selector[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, selector[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, selector[start:])
+}
+
+// parseComplexSelector parses a single selector with its combinators
+func parseComplexSelector(s string) (complexSelector, error) {
+	var c complexSelector
+	pos := 0
+	for {
+		// Read the combinator before the next compound selector
+		sawSpace := false
+		for pos < len(s) && isSelectorSpace(s[pos]) {
+			pos++
+			sawSpace = true
+		}
+		if pos >= len(s) {
+			break
+		}
+		combinator := byte(0)
+		if s[pos] == '>' {
+			combinator = '>'
+			pos++
+			for pos < len(s) && isSelectorSpace(s[pos]) {
+				pos++
+			}
+		} else if sawSpace {
+			combinator = ' '
+		}
+		if len(c.parts) == 0 && combinator == '>' {
+			return c, fmt.Errorf("selector starts with a combinator")
+		}
+
+		compound, next, err := parseCompoundSelector(s, pos)
+		if err != nil {
+			return c, err
+		}
+		pos = next
+
+		if len(c.parts) > 0 {
+			if combinator == 0 {
+				combinator = ' '
+			}
+			c.combinators = append(c.combinators, combinator)
+		}
+		c.parts = append(c.parts, compound)
+	}
+
+	if len(c.parts) == 0 {
+		return c, fmt.Errorf("empty selector")
+	}
+	return c, nil
+}
+
+// parseCompoundSelector parses a compound selector starting at pos and
+// returns it with the position just after it
+func parseCompoundSelector(s string, pos int) (compoundSelector, int, error) {
+	var compound compoundSelector
+	start := pos
+	for pos < len(s) && !isSelectorSpace(s[pos]) && s[pos] != '>' {
+		switch s[pos] {
+		case '*':
+			pos++
+		case '.', '#':
+			prefix := s[pos]
+			name, next := readSelectorIdent(s, pos+1)
+			if name == "" {
+				return compound, pos, fmt.Errorf("expected name after %q", prefix)
+			}
+			if prefix == '.' {
+				compound.classes = append(compound.classes, name)
+			} else {
+				compound.id = name
+			}
+			pos = next
+		case '[':
+			attr, next, err := parseAttrSelector(s, pos+1)
+			if err != nil {
+				return compound, pos, err
+			}
+			compound.attrs = append(compound.attrs, attr)
+			pos = next
+		default:
+			if pos != start {
+				return compound, pos, fmt.Errorf("unexpected %q", s[pos])
+			}
+			name, next := readSelectorIdent(s, pos)
+			if name == "" {
+				return compound, pos, fmt.Errorf("unexpected %q", s[pos])
+			}
+			compound.tag = strings.ToLower(name)
+			pos = next
+		}
+	}
+	if pos == start {
+		return compound, pos, fmt.Errorf("expected selector")
+	}
+	return compound, pos, nil
+}
+
+// parseAttrSelector parses the inside of [key op value] starting after the [
+func parseAttrSelector(s string, pos int) (attrSelector, int, error) {
+	var attr attrSelector
+	pos = skipSelectorSpace(s, pos)
+	attr.key, pos = readSelectorIdent(s, pos)
+	if attr.key == "" {
+		return attr, pos, fmt.Errorf("expected attribute name")
+	}
+	attr.key = strings.ToLower(attr.key)
+	pos = skipSelectorSpace(s, pos)
+
+	if pos < len(s) && s[pos] != ']' {
+		switch {
+		case s[pos] == '=':
+			attr.op = "="
+			pos++
+		case pos+1 < len(s) && s[pos+1] == '=' && strings.IndexByte("~^$*", s[pos]) >= 0:
+			attr.op = s[pos : pos+2]
+			pos += 2
+		default:
+			return attr, pos, fmt.Errorf("unexpected %q in attribute selector", s[pos])
+		}
+		pos = skipSelectorSpace(s, pos)
+
+		if pos < len(s) && (s[pos] == '"' || s[pos] == '\'') {
+			end := strings.IndexByte(s[pos+1:], s[pos])
+			if end < 0 {
+				return attr, pos, fmt.Errorf("unterminated string")
+			}
+			attr.value = s[pos+1 : pos+1+end]
+			pos += end + 2
+		} else {
+			attr.value, pos = readSelectorIdent(s, pos)
+		}
+		pos = skipSelectorSpace(s, pos)
+	}
+
+	if pos >= len(s) || s[pos] != ']' {
+		return attr, pos, fmt.Errorf("unterminated attribute selector")
+	}
+	return attr, pos + 1, nil
+}
+
+// readSelectorIdent reads a CSS identifier starting at pos
+func readSelectorIdent(s string, pos int) (string, int) {
+	start := pos
+	for pos < len(s) {
+		c := s[pos]
+		if c == '-' || c == '_' || c >= 0x80 ||
+			(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
+			pos++
+			continue
+		}
+		break
+	}
+	return s[start:pos], pos
+}
+
+func isSelectorSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+func skipSelectorSpace(s string, pos int) int {
+	for pos < len(s) && isSelectorSpace(s[pos]) {
+		pos++
+	}
+	return pos
+}
+
+// match reports whether the node matches any selector in the list
+func (sel cssSelector) match(n *html.Node) bool {
+	for _, c := range sel {
+		if c.matchAt(n, len(c.parts)-1) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAt reports whether the node matches parts[i] with its ancestors
+// matching the parts before it
+func (c complexSelector) matchAt(n *html.Node, i int) bool {
+	if !c.parts[i].match(n) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+	if c.combinators[i-1] == '>' {
+		return n.Parent != nil && c.matchAt(n.Parent, i-1)
+	}
+	for p := n.Parent; p != nil; p = p.Parent {
+		if c.matchAt(p, i-1) {
+			return true
+		}
+	}
+	return false
+}
+
+// match reports whether the element matches the compound selector
+func (cs compoundSelector) match(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	if cs.tag != "" && cs.tag != n.Data {
+		return false
+	}
+	if cs.id != "" && getAttr(n, "id") != cs.id {
+		return false
+	}
+	if len(cs.classes) > 0 {
+		classes := strings.Fields(getAttr(n, "class"))
+		for _, want := range cs.classes {
+			found := false
+			for _, class := range classes {
+				if class == want {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+	}
+	for _, attr := range cs.attrs {
+		if !attr.match(n) {
+			return false
+		}
+	}
+	return true
+}
+
+// match reports whether the element's attribute satisfies the selector
+func (a attrSelector) match(n *html.Node) bool {
+	if !hasAttr(n, a.key) {
+		return false
+	}
+	val := getAttr(n, a.key)
+	switch a.op {
+	case "":
+		return true
+	case "=":
+		return val == a.value
+	case "~=":
+		for _, word := range strings.Fields(val) {
+			if word == a.value {
+				return true
+			}
+		}
+		return false
+	case "^=":
+		return a.value != "" && strings.HasPrefix(val, a.value)
+	case "$=":
+		return a.value != "" && strings.HasSuffix(val, a.value)
+	case "*=":
+		return a.value != "" && strings.Contains(val, a.value)
+	}
+	return false
+}
+
+// selectFirst returns the first element in document order matching the selector
+func (sel cssSelector) selectFirst(n *html.Node) *html.Node {
+	if sel.match(n) {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := sel.selectFirst(c); found != nil {
+			return found
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestParseSelectorMatching(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><body>
+		<div id="page" class="layout wide">
+			<nav class="menu"><a href="/">Home</a></nav>
+			<article class="post post-body" data-kind="story">
+				<section><p class="lead">Lead paragraph</p></section>
+			</article>
+		</div>
+	</body></html>`))
+	if err != nil {
+		t.Fatalf("Failed to parse HTML: %v", err)
+	}
+
+	tests := []struct {
+		selector string
+		want     string // text of the first match, "" for none
+	}{
+		{"article.post-body", "Lead paragraph"},
+		{"ARTICLE.post.post-body", "Lead paragraph"},
+		{"#page nav", "Home"},
+		{"div > article p.lead", "Lead paragraph"},
+		{"div > p.lead", ""},
+		{"article > section > .lead", "Lead paragraph"},
+		{`[data-kind="story"] .lead`, "Lead paragraph"},
+		{"[data-kind^=sto]", "Lead paragraph"},
+		{"[class~=wide] .menu", "Home"},
+		{"main, nav.menu", "Home"},
+		{"*.missing", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			sel, err := parseSelector(tt.selector)
+			if err != nil {
+				t.Fatalf("parseSelector(%q) failed: %v", tt.selector, err)
+			}
+			got := ""
+			if n := sel.selectFirst(doc); n != nil {
+				got = extractText(n)
+			}
+			if got != tt.want {
+				t.Errorf("selectFirst(%q) text = %q, want %q", tt.selector, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSelectorInvalid(t *testing.T) {
+	for _, selector := range []string{"", "   ", "> p", "div >", "div..x", "[unterminated", `[a="b]`, "a,", "p:first-child", "a + b"} {
+		if _, err := parseSelector(selector); err == nil {
+			t.Errorf("parseSelector(%q) succeeded, want error", selector)
+		}
+	}
+}
+
+func TestScrapeContentSelector(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Post</title></head><body>
+			<nav>Home About Contact</nav>
+			<article class="post-body"><h1>Post</h1><p>The actual article text.</p></article>
+			<footer>Copyright notice</footer>
+		</body></html>`))
+	}))
+	defer ts.Close()
+
+	config := DefaultConfig()
+	config.EnableImageAnalysis = false
+	config.ContentSelectors = map[string]string{
+		"127.0.0.1": "article.post-body",
+		"other.com": "main",
+	}
+	data, err := New(config).Scrape(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+
+	if data.Content != "Post\nThe actual article text." {
+		t.Errorf("Content = %q, want only the selected article text", data.Content)
+	}
+
+	// A selector that matches nothing falls back to the whole page
+	config.ContentSelectors = map[string]string{"127.0.0.1": "main.missing"}
+	config.TextSkipTags = []string{}
+	data, err = New(config).Scrape(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+	if !strings.Contains(data.Content, "Copyright notice") {
+		t.Errorf("Content = %q, want the whole page text", data.Content)
+	}
+}
+
+func TestSelectContentHostMatching(t *testing.T) {
+	config := DefaultConfig()
+	config.ContentSelectors = map[string]string{
+		"example.com":      "article",
+		"blog.example.com": "main",
+		"broken.com":       "div[",
+	}
+	s := New(config)
+
+	if _, rule := s.siteRuleFor(&url.URL{Host: "broken.com"}); rule != nil {
+		t.Error("Expected invalid selector to be ignored")
+	}
+
+	doc, err := html.Parse(strings.NewReader(`<main>Main</main><article>Article</article>`))
+	if err != nil {
+		t.Fatalf("Failed to parse HTML: %v", err)
+	}
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://example.com/post", "Article"},
+		{"https://www.example.com/post", "Article"},
+		{"https://blog.example.com/post", "Main"},
+		{"https://notexample.com/post", "Main\nArticle"},
+	}
+	for _, tt := range tests {
+		pageURL, _ := url.Parse(tt.url)
+		root, _ := s.selectContent(pageURL, doc)
+		if got := extractText(root); got != tt.want {
+			t.Errorf("selectContent(%s) text = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
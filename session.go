@@ -0,0 +1,371 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html"
+)
+
+// LoginConfig describes a form login performed before scraping pages on a host.
+// The scraper fetches LoginURL, fills in the login form (preserving hidden fields
+// such as CSRF tokens), submits it, and reuses the resulting session cookies for
+// later requests to the session's hosts. Credentials are only sent to the login
+// form and are never stored in scraped data.
+type LoginConfig struct {
+	Name     string            // Session name, used in logs and errors
+	LoginURL string            // Page containing the login form
+	Fields   map[string]string // Form fields to set (e.g. username and password)
+	Hosts    []string          // Hosts that use this session (defaults to LoginURL's host)
+}
+
+// session holds the cookie jar and login state for a LoginConfig
+type session struct {
+	config   LoginConfig
+	jar      *cookiejar.Jar
+	mu       sync.Mutex
+	loggedIn bool
+}
+
+// sessionJar is an http.CookieJar that routes cookies to per-session jars by host.
+// Cookies for hosts without a session go to a separate jar per exact host when
+// per-host jars are enabled, and are discarded otherwise, matching a client with
+// no jar. Per-host jars never share cookies, even between subdomains.
+type sessionJar struct {
+	sessions map[string]*session // keyed by host
+
+	perHost  bool
+	mu       sync.Mutex
+	hostJars map[string]*cookiejar.Jar // keyed by host, created on first use
+}
+
+// newSessionJar creates session state for the given logins, with per-host jars
+// for all other hosts if perHost is set
+func newSessionJar(logins []LoginConfig, perHost bool) (*sessionJar, error) {
+	jar := &sessionJar{
+		sessions: make(map[string]*session),
+		perHost:  perHost,
+		hostJars: make(map[string]*cookiejar.Jar),
+	}
+	for _, login := range logins {
+		loginURL, err := url.Parse(login.LoginURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid login URL for session %q: %w", login.Name, err)
+		}
+
+		cookies, err := cookiejar.New(nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cookie jar: %w", err)
+		}
+		sess := &session{config: login, jar: cookies}
+
+		hosts := login.Hosts
+		if len(hosts) == 0 {
+			hosts = []string{loginURL.Hostname()}
+		}
+		for _, host := range hosts {
+			jar.sessions[strings.ToLower(host)] = sess
+		}
+	}
+	return jar, nil
+}
+
+// sessionFor returns the session covering a URL's host, if any
+func (j *sessionJar) sessionFor(u *url.URL) *session {
+	return j.sessions[strings.ToLower(u.Hostname())]
+}
+
+// jarFor returns the cookie jar for a URL's host, or nil if its cookies are discarded
+func (j *sessionJar) jarFor(u *url.URL, create bool) *cookiejar.Jar {
+	if sess := j.sessionFor(u); sess != nil {
+		return sess.jar
+	}
+	if !j.perHost {
+		return nil
+	}
+
+	host := strings.ToLower(u.Hostname())
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	jar, ok := j.hostJars[host]
+	if !ok && create {
+		// cookiejar.New only fails for invalid options
+		jar, _ = cookiejar.New(nil)
+		j.hostJars[host] = jar
+	}
+	return jar
+}
+
+// SetCookies implements http.CookieJar
+func (j *sessionJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	if jar := j.jarFor(u, true); jar != nil {
+		jar.SetCookies(u, cookies)
+	}
+}
+
+// Cookies implements http.CookieJar
+func (j *sessionJar) Cookies(u *url.URL) []*http.Cookie {
+	if jar := j.jarFor(u, false); jar != nil {
+		return jar.Cookies(u)
+	}
+	return nil
+}
+
+// ensureLogin performs the login for the target URL's session if it has not happened yet
+func (s *Scraper) ensureLogin(ctx context.Context, target *url.URL) error {
+	if s.sessions == nil {
+		return nil
+	}
+	sess := s.sessions.sessionFor(target)
+	if sess == nil {
+		return nil
+	}
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if sess.loggedIn {
+		return nil
+	}
+
+	if err := s.login(ctx, sess.config); err != nil {
+		return fmt.Errorf("login for session %q failed: %w", sess.config.Name, err)
+	}
+	sess.loggedIn = true
+	log.Printf("Logged in session %q via %s", sess.config.Name, sess.config.LoginURL)
+	return nil
+}
+
+// login fetches the login page, fills in its form, and submits it
+func (s *Scraper) login(ctx context.Context, login LoginConfig) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", login.LoginURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", s.config.UserAgent)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch login page: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("login page HTTP error: %d %s", resp.StatusCode, resp.Status)
+	}
+
+	body, _, err := s.readHTML(resp)
+	if err != nil {
+		return err
+	}
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to parse login page: %w", err)
+	}
+
+	form := findLoginForm(doc)
+	if form == nil {
+		return fmt.Errorf("no form found on login page")
+	}
+
+	method, action, values := extractFormFields(form)
+	for name, value := range login.Fields {
+		values.Set(name, value)
+	}
+
+	actionURL, err := resolveURL(resp.Request.URL, action)
+	if err != nil {
+		return fmt.Errorf("invalid form action: %w", err)
+	}
+
+	var submit *http.Request
+	if method == http.MethodGet {
+		u, err := url.Parse(actionURL)
+		if err != nil {
+			return fmt.Errorf("invalid form action: %w", err)
+		}
+		u.RawQuery = values.Encode()
+		submit, err = http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return fmt.Errorf("failed to create login request: %w", err)
+		}
+	} else {
+		submit, err = http.NewRequestWithContext(ctx, http.MethodPost, actionURL, strings.NewReader(values.Encode()))
+		if err != nil {
+			return fmt.Errorf("failed to create login request: %w", err)
+		}
+		submit.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	submit.Header.Set("User-Agent", s.config.UserAgent)
+
+	submitResp, err := s.httpClient.Do(submit)
+	if err != nil {
+		return fmt.Errorf("failed to submit login form: %w", err)
+	}
+	defer submitResp.Body.Close()
+	io.Copy(io.Discard, submitResp.Body)
+
+	if submitResp.StatusCode >= 400 {
+		return fmt.Errorf("login submission HTTP error: %d %s", submitResp.StatusCode, submitResp.Status)
+	}
+
+	return nil
+}
+
+// findLoginForm returns the first form containing a password input,
+// or the first form on the page if none has one
+func findLoginForm(n *html.Node) *html.Node {
+	var first, withPassword *html.Node
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		if withPassword != nil {
+			return
+		}
+		if n.Type == html.ElementNode && n.Data == "form" {
+			if first == nil {
+				first = n
+			}
+			if hasPasswordInput(n) {
+				withPassword = n
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			f(c)
+		}
+	}
+	f(n)
+	if withPassword != nil {
+		return withPassword
+	}
+	return first
+}
+
+// hasPasswordInput reports whether a node contains an <input type="password">
+func hasPasswordInput(n *html.Node) bool {
+	if n.Type == html.ElementNode && n.Data == "input" && strings.EqualFold(getAttr(n, "type"), "password") {
+		return true
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if hasPasswordInput(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// extractFormFields returns a form's method, action, and default field values,
+// including hidden inputs such as CSRF tokens
+func extractFormFields(form *html.Node) (method, action string, values url.Values) {
+	method = strings.ToUpper(getAttr(form, "method"))
+	if method != http.MethodPost {
+		method = http.MethodGet
+	}
+	action = getAttr(form, "action")
+	values = url.Values{}
+
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			name := getAttr(n, "name")
+			switch n.Data {
+			case "input":
+				inputType := strings.ToLower(getAttr(n, "type"))
+				switch inputType {
+				case "submit", "button", "image", "reset", "file":
+					// Not submitted by default
+				case "checkbox", "radio":
+					if name != "" && hasAttr(n, "checked") {
+						value := getAttr(n, "value")
+						if value == "" {
+							value = "on"
+						}
+						values.Add(name, value)
+					}
+				default:
+					if name != "" {
+						values.Add(name, getAttr(n, "value"))
+					}
+				}
+			case "textarea":
+				if name != "" {
+					var text string
+					if n.FirstChild != nil {
+						text = n.FirstChild.Data
+					}
+					values.Add(name, text)
+				}
+			case "select":
+				if name != "" {
+					values.Add(name, selectedOption(n))
+				}
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			f(c)
+		}
+	}
+	f(form)
+	return method, action, values
+}
+
+// selectedOption returns the value of a <select>'s selected option, or its first option
+func selectedOption(sel *html.Node) string {
+	var first, selected *html.Node
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "option" {
+			if first == nil {
+				first = n
+			}
+			if selected == nil && hasAttr(n, "selected") {
+				selected = n
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			f(c)
+		}
+	}
+	f(sel)
+	if selected == nil {
+		selected = first
+	}
+	if selected == nil {
+		return ""
+	}
+	if hasAttr(selected, "value") {
+		return getAttr(selected, "value")
+	}
+	if selected.FirstChild != nil {
+		return strings.TrimSpace(selected.FirstChild.Data)
+	}
+	return ""
+}
+
+// getAttr returns the value of an attribute on a node, or an empty string
+func getAttr(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// hasAttr reports whether a node has the given attribute
+func hasAttr(n *html.Node, key string) bool {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return true
+		}
+	}
+	return false
+}
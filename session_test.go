@@ -0,0 +1,189 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestScrapeWithLogin(t *testing.T) {
+	var logins int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<html><body>
+				<form action="/search"><input name="q"></form>
+				<form method="post" action="/session">
+					<input type="hidden" name="csrf_token" value="tok-123">
+					<input type="text" name="username">
+					<input type="password" name="password">
+					<input type="checkbox" name="remember" checked>
+					<input type="submit" name="go" value="Log in">
+				</form>
+			</body></html>`))
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/session", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("csrf_token") != "tok-123" || r.Form.Get("username") != "alice" ||
+			r.Form.Get("password") != "s3cret" || r.Form.Get("remember") != "on" || r.Form.Has("go") {
+			http.Error(w, "bad login: "+r.Form.Encode(), http.StatusForbidden)
+			return
+		}
+		atomic.AddInt32(&logins, 1)
+		http.SetCookie(w, &http.Cookie{Name: "sid", Value: "authenticated", Path: "/"})
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+	})
+	mux.HandleFunc("/private", func(w http.ResponseWriter, r *http.Request) {
+		if c, err := r.Cookie("sid"); err != nil || c.Value != "authenticated" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Members Only</title></head><body>Secret content</body></html>`))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><head><title>Home</title></head></html>`))
+	})
+	webServer := httptest.NewServer(mux)
+	defer webServer.Close()
+
+	config := DefaultConfig()
+	config.EnableImageAnalysis = false
+	config.Logins = []LoginConfig{{
+		Name:     "members",
+		LoginURL: webServer.URL + "/login",
+		Fields:   map[string]string{"username": "alice", "password": "s3cret"},
+	}}
+	s := New(config)
+
+	for i := 0; i < 2; i++ {
+		data, err := s.Scrape(context.Background(), webServer.URL+"/private")
+		if err != nil {
+			t.Fatalf("Scrape %d failed: %v", i, err)
+		}
+		if data.Title != "Members Only" {
+			t.Errorf("Title = %q, want %q", data.Title, "Members Only")
+		}
+		if strings.Contains(data.Content, "s3cret") {
+			t.Error("Expected credentials not to appear in scraped data")
+		}
+	}
+
+	if n := atomic.LoadInt32(&logins); n != 1 {
+		t.Errorf("Expected a single login, got %d", n)
+	}
+
+	// Without a login the page is not accessible
+	plain := New(DefaultConfig())
+	if _, err := plain.Scrape(context.Background(), webServer.URL+"/private"); err == nil {
+		t.Error("Expected scrape without login to fail")
+	}
+}
+
+func TestScrapeLoginFailure(t *testing.T) {
+	webServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" && r.Method == http.MethodGet {
+			w.Write([]byte(`<html><body><form method="post"><input type="password" name="pw"></form></body></html>`))
+			return
+		}
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}))
+	defer webServer.Close()
+
+	config := DefaultConfig()
+	config.Logins = []LoginConfig{{
+		Name:     "broken",
+		LoginURL: webServer.URL + "/login",
+		Fields:   map[string]string{"pw": "wrong"},
+	}}
+	s := New(config)
+
+	_, err := s.Scrape(context.Background(), webServer.URL+"/page")
+	if err == nil || !strings.Contains(err.Error(), `login for session "broken" failed`) {
+		t.Errorf("Expected login failure error, got %v", err)
+	}
+}
+
+func TestSessionJarRoutesByHost(t *testing.T) {
+	jar, err := newSessionJar([]LoginConfig{
+		{Name: "a", LoginURL: "https://login.example.com/login", Hosts: []string{"app.example.com"}},
+	}, false)
+	if err != nil {
+		t.Fatalf("newSessionJar failed: %v", err)
+	}
+
+	app, _ := http.NewRequest("GET", "https://app.example.com/", nil)
+	other, _ := http.NewRequest("GET", "https://other.example.com/", nil)
+
+	jar.SetCookies(app.URL, []*http.Cookie{{Name: "sid", Value: "1"}})
+	jar.SetCookies(other.URL, []*http.Cookie{{Name: "sid", Value: "2"}})
+
+	if cookies := jar.Cookies(app.URL); len(cookies) != 1 || cookies[0].Value != "1" {
+		t.Errorf("Expected session cookie for app host, got %v", cookies)
+	}
+	if cookies := jar.Cookies(other.URL); len(cookies) != 0 {
+		t.Errorf("Expected no cookies for host without a session, got %v", cookies)
+	}
+}
+
+func TestSessionJarPerHost(t *testing.T) {
+	jar, err := newSessionJar(nil, true)
+	if err != nil {
+		t.Fatalf("newSessionJar failed: %v", err)
+	}
+
+	a, _ := url.Parse("https://a.example.com/")
+	b, _ := url.Parse("https://b.example.com/")
+
+	// Domain cookies must not reach sibling hosts
+	jar.SetCookies(a, []*http.Cookie{{Name: "sid", Value: "1", Domain: "example.com"}})
+
+	if cookies := jar.Cookies(a); len(cookies) != 1 || cookies[0].Value != "1" {
+		t.Errorf("Expected cookie for host that set it, got %v", cookies)
+	}
+	if cookies := jar.Cookies(b); len(cookies) != 0 {
+		t.Errorf("Expected no cookies for other host, got %v", cookies)
+	}
+}
+
+func TestScrapeWithCookieJar(t *testing.T) {
+	var sawCookie bool
+	webServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/first" {
+			http.SetCookie(w, &http.Cookie{Name: "visited", Value: "yes", Path: "/"})
+		} else if c, err := r.Cookie("visited"); err == nil && c.Value == "yes" {
+			sawCookie = true
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Page</title></head><body></body></html>`))
+	}))
+	defer webServer.Close()
+
+	for _, enabled := range []bool{false, true} {
+		sawCookie = false
+		config := DefaultConfig()
+		config.EnableImageAnalysis = false
+		config.EnableCookieJar = enabled
+		s := New(config)
+
+		if _, err := s.Scrape(context.Background(), webServer.URL+"/first"); err != nil {
+			t.Fatalf("Scrape failed: %v", err)
+		}
+		if _, err := s.Scrape(context.Background(), webServer.URL+"/second"); err != nil {
+			t.Fatalf("Scrape failed: %v", err)
+		}
+
+		if sawCookie != enabled {
+			t.Errorf("EnableCookieJar=%v: cookie sent = %v", enabled, sawCookie)
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package scraper
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"strings"
+)
+
+// defaultMaxSitemapDepth is the sitemap index nesting followed when
+// Config.MaxSitemapDepth is unset
+const defaultMaxSitemapDepth = 3
+
+// defaultMaxSitemapURLs is the URL cap used when Config.MaxSitemapURLs is
+// unset, the most a single sitemap file may list
+const defaultMaxSitemapURLs = 50000
+
+// maxSitemapFetches caps the sitemaps fetched by one ParseSitemap call, so an
+// index listing thousands of sitemaps cannot turn into thousands of requests
+const maxSitemapFetches = 100
+
+// SitemapResult holds the page URLs found by ParseSitemap
+type SitemapResult struct {
+	URLs      []string `json:"urls"`
+	Sitemaps  int      `json:"sitemaps"`  // Number of sitemaps fetched
+	Truncated bool     `json:"truncated"` // URLs or nested sitemaps were skipped because of the URL, depth, or fetch limit
+}
+
+// sitemapDocument is a <urlset> or a <sitemapindex>
+type sitemapDocument struct {
+	URLs     []sitemapEntry `xml:"url"`
+	Sitemaps []sitemapEntry `xml:"sitemap"`
+}
+
+type sitemapEntry struct {
+	Loc string `xml:"loc"`
+}
+
+// ParseSitemap fetches a sitemap and returns the page URLs it lists,
+// following sitemap indexes up to Config.MaxSitemapDepth levels deep. Each
+// sitemap is fetched at most once, so indexes that reference each other do
+// not loop, and at most maxSitemapFetches are fetched in total. At most
+// Config.MaxSitemapURLs page URLs are returned. URLs or sitemaps left out
+// because of a limit set Truncated. Only a failure to fetch the top-level
+// sitemap is an error; nested sitemaps that fail are skipped.
+func (s *Scraper) ParseSitemap(ctx context.Context, sitemapURL string) (*SitemapResult, error) {
+	parsedURL, err := url.Parse(sitemapURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+		return nil, fmt.Errorf("URL must be http or https")
+	}
+
+	type pendingSitemap struct {
+		url   string
+		depth int
+	}
+
+	maxDepth := s.maxSitemapDepth()
+	maxURLs := s.maxSitemapURLs()
+	result := &SitemapResult{URLs: []string{}}
+	seenURLs := make(map[string]bool)
+	visited := map[string]bool{sitemapURL: true}
+	queue := []pendingSitemap{{url: sitemapURL}}
+
+	for len(queue) > 0 {
+		if result.Sitemaps >= maxSitemapFetches || len(result.URLs) >= maxURLs {
+			result.Truncated = true
+			break
+		}
+		current := queue[0]
+		queue = queue[1:]
+
+		result.Sitemaps++
+		doc, err := s.fetchSitemap(ctx, current.url)
+		if err != nil {
+			if current.depth == 0 || ctx.Err() != nil {
+				return nil, err
+			}
+			log.Printf("Skipping nested sitemap %s: %v", current.url, err)
+			continue
+		}
+
+		for _, entry := range doc.URLs {
+			loc := sitemapLoc(entry.Loc)
+			if loc == "" || seenURLs[loc] {
+				continue
+			}
+			if len(result.URLs) >= maxURLs {
+				result.Truncated = true
+				break
+			}
+			seenURLs[loc] = true
+			result.URLs = append(result.URLs, loc)
+		}
+
+		for _, entry := range doc.Sitemaps {
+			loc := sitemapLoc(entry.Loc)
+			if loc == "" || visited[loc] {
+				continue
+			}
+			if current.depth >= maxDepth {
+				result.Truncated = true
+				continue
+			}
+			visited[loc] = true
+			queue = append(queue, pendingSitemap{url: loc, depth: current.depth + 1})
+		}
+	}
+
+	return result, nil
+}
+
+// maxSitemapDepth returns the configured sitemap index nesting limit
+func (s *Scraper) maxSitemapDepth() int {
+	switch {
+	case s.config.MaxSitemapDepth < 0:
+		return 0
+	case s.config.MaxSitemapDepth == 0:
+		return defaultMaxSitemapDepth
+	}
+	return s.config.MaxSitemapDepth
+}
+
+// maxSitemapURLs returns the configured cap on URLs returned from sitemaps
+func (s *Scraper) maxSitemapURLs() int {
+	if s.config.MaxSitemapURLs > 0 {
+		return s.config.MaxSitemapURLs
+	}
+	return defaultMaxSitemapURLs
+}
+
+// fetchSitemap fetches and parses one sitemap, decompressing gzipped
+// (.xml.gz) sitemaps
+func (s *Scraper) fetchSitemap(ctx context.Context, sitemapURL string) (*sitemapDocument, error) {
+	resp, err := s.fetchPage(ctx, sitemapURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := s.readBody(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var reader io.Reader = bytes.NewReader(body)
+	if bytes.HasPrefix(body, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress sitemap: %w", err)
+		}
+		defer gz.Close()
+		// Bound the decompressed size too, so a small gzip bomb cannot exhaust memory
+		reader = io.LimitReader(gz, s.maxSitemapBytes())
+	}
+
+	var doc sitemapDocument
+	if err := xml.NewDecoder(reader).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse sitemap: %w", err)
+	}
+	return &doc, nil
+}
+
+// maxSitemapBytes returns the largest decompressed sitemap that is parsed,
+// the same as the page size limit
+func (s *Scraper) maxSitemapBytes() int64 {
+	if s.config.MaxBodyBytes > 0 {
+		return s.config.MaxBodyBytes
+	}
+	return defaultMaxBodyBytes
+}
+
+// sitemapLoc returns a <loc> value if it is an absolute http(s) URL, or ""
+func sitemapLoc(loc string) string {
+	loc = strings.TrimSpace(loc)
+	u, err := url.Parse(loc)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
+	}
+	return loc
+}
+
+// FetchSitemap finds a site's sitemap and returns the page URLs it lists. A
+// siteURL ending in .xml or .xml.gz is read as the sitemap itself. Otherwise
+// /sitemap.xml at the site root is tried first, then the Sitemap entries of
+// /robots.txt. Sitemap indexes are followed and the URLs capped as in
+// ParseSitemap.
+func (s *Scraper) FetchSitemap(ctx context.Context, siteURL string) ([]string, error) {
+	parsedURL, err := url.Parse(siteURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+		return nil, fmt.Errorf("URL must be http or https")
+	}
+
+	path := strings.ToLower(parsedURL.Path)
+	if strings.HasSuffix(path, ".xml") || strings.HasSuffix(path, ".xml.gz") {
+		result, err := s.ParseSitemap(ctx, siteURL)
+		if err != nil {
+			return nil, err
+		}
+		return result.URLs, nil
+	}
+
+	root := parsedURL.Scheme + "://" + parsedURL.Host
+	result, err := s.ParseSitemap(ctx, root+"/sitemap.xml")
+	if err == nil {
+		return result.URLs, nil
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	sitemaps, robotsErr := s.robotsSitemaps(ctx, root+"/robots.txt")
+	if robotsErr != nil || len(sitemaps) == 0 {
+		return nil, fmt.Errorf("no sitemap found for %s: %w", root, err)
+	}
+
+	urls := []string{}
+	seen := make(map[string]bool)
+	var lastErr error
+	parsed := false
+	for _, sitemapURL := range sitemaps {
+		result, err := s.ParseSitemap(ctx, sitemapURL)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			log.Printf("Skipping sitemap %s listed in robots.txt: %v", sitemapURL, err)
+			lastErr = err
+			continue
+		}
+		parsed = true
+		for _, u := range result.URLs {
+			if seen[u] {
+				continue
+			}
+			if len(urls) >= s.maxSitemapURLs() {
+				return urls, nil
+			}
+			seen[u] = true
+			urls = append(urls, u)
+		}
+	}
+	if !parsed {
+		return nil, fmt.Errorf("no sitemap found for %s: %w", root, lastErr)
+	}
+	return urls, nil
+}
+
+// robotsSitemaps returns the sitemap URLs listed in a robots.txt file
+func (s *Scraper) robotsSitemaps(ctx context.Context, robotsURL string) ([]string, error) {
+	resp, err := s.fetchPage(ctx, robotsURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := s.readBody(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var sitemaps []string
+	for _, line := range strings.Split(string(body), "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || !strings.EqualFold(strings.TrimSpace(key), "sitemap") {
+			continue
+		}
+		if loc := sitemapLoc(value); loc != "" {
+			sitemaps = append(sitemaps, loc)
+		}
+	}
+	return sitemaps, nil
+}
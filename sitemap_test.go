@@ -0,0 +1,263 @@
+package scraper
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// sitemapServer serves sitemap indexes and url sets from a map of path to
+// entries; paths ending in "index.xml" are indexes of other paths
+func sitemapServer(t *testing.T, sitemaps map[string][]string) (*httptest.Server, *int32) {
+	t.Helper()
+	var fetches int32
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entries, ok := sitemaps[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		atomic.AddInt32(&fetches, 1)
+
+		var b strings.Builder
+		b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>`)
+		if strings.HasSuffix(r.URL.Path, "index.xml") {
+			b.WriteString(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+			for _, path := range entries {
+				fmt.Fprintf(&b, "<sitemap><loc>%s%s</loc></sitemap>", ts.URL, path)
+			}
+			b.WriteString(`</sitemapindex>`)
+		} else {
+			b.WriteString(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+			for _, page := range entries {
+				fmt.Fprintf(&b, "<url><loc>\n  %s\n</loc></url>", page)
+			}
+			b.WriteString(`</urlset>`)
+		}
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write([]byte(b.String()))
+	}))
+	t.Cleanup(ts.Close)
+	return ts, &fetches
+}
+
+func TestParseSitemap(t *testing.T) {
+	ts, _ := sitemapServer(t, map[string][]string{
+		"/sitemap-index.xml": {"/posts.xml", "/pages.xml", "/missing.xml"},
+		"/posts.xml":         {"https://example.com/posts/1", "https://example.com/posts/2"},
+		"/pages.xml":         {"https://example.com/about", "https://example.com/posts/1", "not a url"},
+	})
+
+	result, err := New(DefaultConfig()).ParseSitemap(context.Background(), ts.URL+"/sitemap-index.xml")
+	if err != nil {
+		t.Fatalf("ParseSitemap() error = %v", err)
+	}
+
+	want := []string{"https://example.com/posts/1", "https://example.com/posts/2", "https://example.com/about"}
+	if strings.Join(result.URLs, " ") != strings.Join(want, " ") {
+		t.Errorf("URLs = %v, want %v", result.URLs, want)
+	}
+	if result.Truncated {
+		t.Error("Expected result not to be truncated")
+	}
+	if result.Sitemaps != 4 {
+		t.Errorf("Sitemaps = %d, want 4", result.Sitemaps)
+	}
+}
+
+func TestParseSitemapMaxDepth(t *testing.T) {
+	ts, fetches := sitemapServer(t, map[string][]string{
+		"/a-index.xml": {"/b-index.xml", "/top.xml"},
+		"/b-index.xml": {"/c-index.xml"},
+		"/c-index.xml": {"/deep.xml"},
+		"/top.xml":     {"https://example.com/top"},
+		"/deep.xml":    {"https://example.com/deep"},
+	})
+
+	config := DefaultConfig()
+	config.MaxSitemapDepth = 2
+	result, err := New(config).ParseSitemap(context.Background(), ts.URL+"/a-index.xml")
+	if err != nil {
+		t.Fatalf("ParseSitemap() error = %v", err)
+	}
+
+	if strings.Join(result.URLs, " ") != "https://example.com/top" {
+		t.Errorf("URLs = %v, want only the page within the depth limit", result.URLs)
+	}
+	if !result.Truncated {
+		t.Error("Expected result to be truncated at the depth limit")
+	}
+	if *fetches != 4 {
+		t.Errorf("Fetched %d sitemaps, want 4", *fetches)
+	}
+
+	// A negative depth reads only the top-level sitemap
+	config.MaxSitemapDepth = -1
+	result, err = New(config).ParseSitemap(context.Background(), ts.URL+"/a-index.xml")
+	if err != nil {
+		t.Fatalf("ParseSitemap() error = %v", err)
+	}
+	if len(result.URLs) != 0 || !result.Truncated || result.Sitemaps != 1 {
+		t.Errorf("Result = %+v, want no URLs from one truncated sitemap", result)
+	}
+}
+
+func TestParseSitemapCycle(t *testing.T) {
+	ts, fetches := sitemapServer(t, map[string][]string{
+		"/a-index.xml": {"/b-index.xml", "/a-index.xml"},
+		"/b-index.xml": {"/a-index.xml", "/pages.xml"},
+		"/pages.xml":   {"https://example.com/page"},
+	})
+
+	config := DefaultConfig()
+	config.MaxSitemapDepth = 50
+	result, err := New(config).ParseSitemap(context.Background(), ts.URL+"/a-index.xml")
+	if err != nil {
+		t.Fatalf("ParseSitemap() error = %v", err)
+	}
+	if strings.Join(result.URLs, " ") != "https://example.com/page" {
+		t.Errorf("URLs = %v", result.URLs)
+	}
+	if result.Truncated {
+		t.Error("Expected revisited sitemaps not to count as truncation")
+	}
+	if *fetches != 3 {
+		t.Errorf("Fetched %d sitemaps, want each of the 3 once", *fetches)
+	}
+}
+
+func TestParseSitemapFetchLimit(t *testing.T) {
+	children := make([]string, maxSitemapFetches+50)
+	sitemaps := map[string][]string{"/index.xml": nil}
+	for i := range children {
+		children[i] = fmt.Sprintf("/part-%d.xml", i)
+		sitemaps[children[i]] = []string{fmt.Sprintf("https://example.com/%d", i)}
+	}
+	sitemaps["/index.xml"] = children
+	ts, fetches := sitemapServer(t, sitemaps)
+
+	result, err := New(DefaultConfig()).ParseSitemap(context.Background(), ts.URL+"/index.xml")
+	if err != nil {
+		t.Fatalf("ParseSitemap() error = %v", err)
+	}
+	if *fetches != maxSitemapFetches {
+		t.Errorf("Fetched %d sitemaps, want %d", *fetches, maxSitemapFetches)
+	}
+	if !result.Truncated {
+		t.Error("Expected result to be truncated at the fetch limit")
+	}
+	if len(result.URLs) != maxSitemapFetches-1 {
+		t.Errorf("Got %d URLs, want %d", len(result.URLs), maxSitemapFetches-1)
+	}
+}
+
+func TestParseSitemapGzip(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte(`<urlset><url><loc>https://example.com/zipped</loc></url></urlset>`))
+	gz.Close()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/gzip")
+		w.Write(buf.Bytes())
+	}))
+	defer ts.Close()
+
+	result, err := New(DefaultConfig()).ParseSitemap(context.Background(), ts.URL+"/sitemap.xml.gz")
+	if err != nil {
+		t.Fatalf("ParseSitemap() error = %v", err)
+	}
+	if strings.Join(result.URLs, " ") != "https://example.com/zipped" {
+		t.Errorf("URLs = %v", result.URLs)
+	}
+}
+
+func TestParseSitemapErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken.xml" {
+			w.Write([]byte("<urlset><url>"))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer ts.Close()
+
+	s := New(DefaultConfig())
+	for _, u := range []string{"ftp://example.com/sitemap.xml", ts.URL + "/missing.xml", ts.URL + "/broken.xml"} {
+		if _, err := s.ParseSitemap(context.Background(), u); err == nil {
+			t.Errorf("ParseSitemap(%q) expected error", u)
+		}
+	}
+}
+
+func TestFetchSitemap(t *testing.T) {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap.xml", "/custom-sitemap.xml":
+			fmt.Fprintf(w, `<urlset><url><loc>%s/from%s</loc></url></urlset>`, ts.URL, r.URL.Path)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	s := New(DefaultConfig())
+	urls, err := s.FetchSitemap(context.Background(), ts.URL+"/blog/post?page=2")
+	if err != nil {
+		t.Fatalf("FetchSitemap() error = %v", err)
+	}
+	if strings.Join(urls, " ") != ts.URL+"/from/sitemap.xml" {
+		t.Errorf("URLs = %v, want the root sitemap's", urls)
+	}
+
+	// A sitemap URL is read directly
+	urls, err = s.FetchSitemap(context.Background(), ts.URL+"/custom-sitemap.xml")
+	if err != nil {
+		t.Fatalf("FetchSitemap() error = %v", err)
+	}
+	if strings.Join(urls, " ") != ts.URL+"/from/custom-sitemap.xml" {
+		t.Errorf("URLs = %v, want the given sitemap's", urls)
+	}
+}
+
+func TestFetchSitemapRobotsFallback(t *testing.T) {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			fmt.Fprintf(w, "User-agent: *\r\nDisallow: /admin\r\nSitemap: %s/missing.xml\r\nsitemap:%s/pages.xml\r\n", ts.URL, ts.URL)
+		case "/pages.xml":
+			fmt.Fprintf(w, `<urlset><url><loc>%s/a</loc></url><url><loc>%s/b</loc></url><url><loc>%s/c</loc></url></urlset>`, ts.URL, ts.URL, ts.URL)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	config := DefaultConfig()
+	config.MaxSitemapURLs = 2
+	urls, err := New(config).FetchSitemap(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatalf("FetchSitemap() error = %v", err)
+	}
+	if strings.Join(urls, " ") != ts.URL+"/a "+ts.URL+"/b" {
+		t.Errorf("URLs = %v, want the first 2 from the robots.txt sitemap", urls)
+	}
+}
+
+func TestFetchSitemapNotFound(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+
+	if _, err := New(DefaultConfig()).FetchSitemap(context.Background(), ts.URL); err == nil {
+		t.Error("Expected an error for a site without a sitemap")
+	}
+}
@@ -0,0 +1,262 @@
+package scraper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// SiteRule customizes extraction for a site whose pages the generic pipeline
+// handles poorly. Selectors use the CSS subset supported by ContentSelectors;
+// link patterns are regular expressions matched against absolute link URLs.
+type SiteRule struct {
+	ContentSelector string   `json:"content_selector,omitempty"` // Element holding the main content
+	TitleSelector   string   `json:"title_selector,omitempty"`   // Element holding the title, instead of <title>
+	AuthorSelector  string   `json:"author_selector,omitempty"`  // Element holding the author, instead of meta tags
+	LinkInclude     []string `json:"link_include,omitempty"`     // Only links matching one of these are kept (empty keeps all)
+	LinkExclude     []string `json:"link_exclude,omitempty"`     // Links matching any of these are dropped
+}
+
+// SiteRules maps hosts to their extraction rules. A host entry also applies
+// to its subdomains, and the longest matching entry wins.
+type SiteRules map[string]SiteRule
+
+// LoadSiteRules reads and validates a JSON site rules file, e.g.
+//
+//	{"example.com": {"content_selector": "article.post-body", "link_exclude": ["/tag/"]}}
+func LoadSiteRules(path string) (SiteRules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read site rules: %w", err)
+	}
+
+	var rules SiteRules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse site rules: %w", err)
+	}
+	if err := rules.Validate(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// Validate checks that every rule's selectors and link patterns compile
+func (rules SiteRules) Validate() error {
+	_, err := rules.compile()
+	return err
+}
+
+// siteRule is a compiled SiteRule; nil selectors are unset
+type siteRule struct {
+	content     cssSelector
+	title       cssSelector
+	author      cssSelector
+	linkInclude []*regexp.Regexp
+	linkExclude []*regexp.Regexp
+}
+
+// compile compiles every rule, keyed by normalized host
+func (rules SiteRules) compile() (map[string]*siteRule, error) {
+	compiled := make(map[string]*siteRule, len(rules))
+	for host, rule := range rules {
+		key := ruleHost(host)
+		if key == "" {
+			return nil, errors.New("invalid site rules: empty host")
+		}
+		c, err := rule.compile()
+		if err != nil {
+			return nil, fmt.Errorf("invalid site rule for %s: %w", host, err)
+		}
+		compiled[key] = c
+	}
+	return compiled, nil
+}
+
+// compile compiles the rule's selectors and link patterns
+func (rule SiteRule) compile() (*siteRule, error) {
+	var c siteRule
+	selectors := []struct {
+		value string
+		dst   *cssSelector
+	}{
+		{rule.ContentSelector, &c.content},
+		{rule.TitleSelector, &c.title},
+		{rule.AuthorSelector, &c.author},
+	}
+	for _, sel := range selectors {
+		if sel.value == "" {
+			continue
+		}
+		compiled, err := parseSelector(sel.value)
+		if err != nil {
+			return nil, err
+		}
+		*sel.dst = compiled
+	}
+
+	var err error
+	if c.linkInclude, err = compilePatterns(rule.LinkInclude); err != nil {
+		return nil, err
+	}
+	if c.linkExclude, err = compilePatterns(rule.LinkExclude); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// compilePatterns compiles a list of regular expressions
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid link pattern %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// ruleHost normalizes a host key from SiteRules or ContentSelectors
+func ruleHost(host string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(strings.ToLower(strings.TrimSpace(host)), "."), ".")
+}
+
+// setSiteRules compiles the rules, merges in Config.ContentSelectors for hosts
+// without a content selector of their own, and makes them active. Invalid
+// rules are rejected as a whole and leave the active rules unchanged.
+func (s *Scraper) setSiteRules(rules SiteRules) error {
+	compiled, err := rules.compile()
+	if err != nil {
+		return err
+	}
+
+	for host, selector := range s.config.ContentSelectors {
+		sel, err := parseSelector(selector)
+		if err != nil {
+			log.Printf("Ignoring content selector for %s: %v", host, err)
+			continue
+		}
+		key := ruleHost(host)
+		if rule, ok := compiled[key]; ok {
+			if rule.content == nil {
+				rule.content = sel
+			}
+			continue
+		}
+		compiled[key] = &siteRule{content: sel}
+	}
+
+	s.siteRules.Store(&compiled)
+	return nil
+}
+
+// ReloadSiteRules re-reads Config.SiteRulesFile and atomically replaces the
+// active rules. If the file is invalid, the current rules are kept.
+func (s *Scraper) ReloadSiteRules() error {
+	if s.config.SiteRulesFile == "" {
+		return errors.New("no site rules file configured")
+	}
+	rules, err := LoadSiteRules(s.config.SiteRulesFile)
+	if err != nil {
+		return err
+	}
+	return s.setSiteRules(rules)
+}
+
+// siteRuleFor returns the rule for the page's host, preferring the longest
+// matching host entry, or nil if none applies
+func (s *Scraper) siteRuleFor(pageURL *url.URL) (string, *siteRule) {
+	rules := s.siteRules.Load()
+	if rules == nil || len(*rules) == 0 {
+		return "", nil
+	}
+
+	host := ruleHost(pageURL.Hostname())
+	matched := ""
+	var rule *siteRule
+	for entry, candidate := range *rules {
+		if host != entry && !strings.HasSuffix(host, "."+entry) {
+			continue
+		}
+		if rule == nil || len(entry) > len(matched) {
+			matched, rule = entry, candidate
+		}
+	}
+	return matched, rule
+}
+
+// selectContent returns the subtree holding the page's main content: the
+// first match of the content selector for the page's host. It returns the
+// whole document and false when no selector applies or it matches nothing.
+func (s *Scraper) selectContent(pageURL *url.URL, doc *html.Node) (*html.Node, bool) {
+	host, rule := s.siteRuleFor(pageURL)
+	if rule == nil || rule.content == nil {
+		return doc, false
+	}
+
+	if root := rule.content.selectFirst(doc); root != nil {
+		return root, true
+	}
+	log.Printf("Content selector for %s matched nothing on %s, using the whole page", host, pageURL)
+	return doc, false
+}
+
+// extractTitle returns the page title from the host's title selector, falling
+// back to the <title> element
+func (s *Scraper) extractTitle(pageURL *url.URL, doc *html.Node) string {
+	if _, rule := s.siteRuleFor(pageURL); rule != nil {
+		if title := selectText(rule.title, doc); title != "" {
+			return title
+		}
+	}
+	return extractTitle(doc)
+}
+
+// selectText returns the text of the first match of the selector, or ""
+func selectText(sel cssSelector, doc *html.Node) string {
+	if sel == nil {
+		return ""
+	}
+	if n := sel.selectFirst(doc); n != nil {
+		return nodeText(n)
+	}
+	return ""
+}
+
+// filterLinks applies the page host's link include and exclude patterns
+func (s *Scraper) filterLinks(pageURL *url.URL, links []string) []string {
+	_, rule := s.siteRuleFor(pageURL)
+	if rule == nil || (len(rule.linkInclude) == 0 && len(rule.linkExclude) == 0) {
+		return links
+	}
+
+	filtered := make([]string, 0, len(links))
+	for _, link := range links {
+		if len(rule.linkInclude) > 0 && !matchesAny(rule.linkInclude, link) {
+			continue
+		}
+		if matchesAny(rule.linkExclude, link) {
+			continue
+		}
+		filtered = append(filtered, link)
+	}
+	return filtered
+}
+
+// matchesAny reports whether any pattern matches the text
+func matchesAny(patterns []*regexp.Regexp, text string) bool {
+	for _, re := range patterns {
+		if re.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
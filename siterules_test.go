@@ -0,0 +1,175 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestLoadSiteRules(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		return path
+	}
+
+	rules, err := LoadSiteRules(write("valid.json", `{
+		"example.com": {
+			"content_selector": "article.post-body",
+			"title_selector": "h1.headline",
+			"author_selector": ".byline a",
+			"link_include": ["^https://example\\.com/posts/"],
+			"link_exclude": ["/tag/"]
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("LoadSiteRules failed: %v", err)
+	}
+	rule := rules["example.com"]
+	if rule.ContentSelector != "article.post-body" || rule.AuthorSelector != ".byline a" || len(rule.LinkExclude) != 1 {
+		t.Errorf("Unexpected rule: %+v", rule)
+	}
+
+	invalid := map[string]string{
+		"missing.json":  "",
+		"syntax.json":   `{"example.com": `,
+		"selector.json": `{"example.com": {"title_selector": "h1["}}`,
+		"pattern.json":  `{"example.com": {"link_exclude": ["("]}}`,
+		"host.json":     `{" ": {"content_selector": "main"}}`,
+	}
+	for name, content := range invalid {
+		path := filepath.Join(dir, name)
+		if content != "" {
+			path = write(name, content)
+		}
+		if _, err := LoadSiteRules(path); err == nil {
+			t.Errorf("LoadSiteRules(%s) succeeded, want error", name)
+		}
+	}
+}
+
+func TestScrapeSiteRules(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Site Name | Post</title><meta name="author" content="Site Staff"></head><body>
+			<nav><a href="/tag/news">News</a></nav>
+			<h1 class="headline">The Real Headline</h1>
+			<p class="byline">By <a href="/authors/jane">Jane Doe</a></p>
+			<article class="post-body"><p>The actual article text.</p><a href="/posts/next">Next</a></article>
+			<footer><a href="/about">About</a></footer>
+		</body></html>`))
+	}))
+	defer ts.Close()
+
+	config := DefaultConfig()
+	config.EnableImageAnalysis = false
+	config.SiteRules = SiteRules{
+		"127.0.0.1": {
+			ContentSelector: "article.post-body",
+			TitleSelector:   "h1.headline",
+			AuthorSelector:  ".byline a",
+			LinkInclude:     []string{"/posts/", "/tag/"},
+			LinkExclude:     []string{"/tag/"},
+		},
+	}
+	data, err := New(config).Scrape(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+
+	if data.Title != "The Real Headline" {
+		t.Errorf("Title = %q, want the rule's title", data.Title)
+	}
+	if data.Metadata.Author != "Jane Doe" {
+		t.Errorf("Author = %q, want the rule's author", data.Metadata.Author)
+	}
+	if data.Content != "The actual article text.\nNext" {
+		t.Errorf("Content = %q, want only the selected article text", data.Content)
+	}
+	if len(data.Links) != 1 || data.Links[0] != ts.URL+"/posts/next" {
+		t.Errorf("Links = %v, want only the included post link", data.Links)
+	}
+}
+
+func TestSiteRulesPrecedence(t *testing.T) {
+	config := DefaultConfig()
+	config.ContentSelectors = map[string]string{
+		"example.com":      "main",
+		"news.example.com": "main",
+	}
+	config.SiteRules = SiteRules{
+		"example.com":      {ContentSelector: "article"},
+		"news.example.com": {TitleSelector: "h1"},
+	}
+	s := New(config)
+
+	doc, err := html.Parse(strings.NewReader(`<main>Main</main><article>Article</article>`))
+	if err != nil {
+		t.Fatalf("Failed to parse HTML: %v", err)
+	}
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://example.com/", "Article"},     // The site rule wins over ContentSelectors
+		{"https://news.example.com/", "Main"},   // ContentSelectors fills a rule without a content selector
+		{"https://www.example.com/", "Article"}, // Subdomains use the parent's rule
+	}
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.url)
+		root, selected := s.selectContent(u, doc)
+		if !selected || nodeText(root) != tt.want {
+			t.Errorf("selectContent(%s) = %q, %v; want %q", tt.url, nodeText(root), selected, tt.want)
+		}
+	}
+}
+
+func TestReloadSiteRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	if err := os.WriteFile(path, []byte(`{"example.com": {"content_selector": "article"}}`), 0o644); err != nil {
+		t.Fatalf("Failed to write rules: %v", err)
+	}
+
+	config := DefaultConfig()
+	config.SiteRulesFile = path
+	config.SiteRules, _ = LoadSiteRules(path)
+	s := New(config)
+	u, _ := url.Parse("https://example.com/")
+
+	if err := os.WriteFile(path, []byte(`{"example.com": {"content_selector": "main"}}`), 0o644); err != nil {
+		t.Fatalf("Failed to write rules: %v", err)
+	}
+	if err := s.ReloadSiteRules(); err != nil {
+		t.Fatalf("ReloadSiteRules failed: %v", err)
+	}
+	doc, _ := html.Parse(strings.NewReader(`<main>Main</main><article>Article</article>`))
+	if root, _ := s.selectContent(u, doc); nodeText(root) != "Main" {
+		t.Errorf("After reload selected %q, want Main", nodeText(root))
+	}
+
+	// An invalid file keeps the current rules
+	if err := os.WriteFile(path, []byte(`{"example.com": {"content_selector": "main["}}`), 0o644); err != nil {
+		t.Fatalf("Failed to write rules: %v", err)
+	}
+	if err := s.ReloadSiteRules(); err == nil {
+		t.Error("Expected reload of an invalid file to fail")
+	}
+	if root, _ := s.selectContent(u, doc); nodeText(root) != "Main" {
+		t.Errorf("After failed reload selected %q, want Main", nodeText(root))
+	}
+
+	if err := New(DefaultConfig()).ReloadSiteRules(); err == nil {
+		t.Error("Expected reload without a rules file to fail")
+	}
+}
@@ -0,0 +1,89 @@
+package scraper
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+)
+
+// ThumbnailMaxDimension is the maximum width or height of generated thumbnails
+const ThumbnailMaxDimension = 256
+
+// thumbnailQuality is the JPEG quality used for thumbnails
+const thumbnailQuality = 80
+
+// generateThumbnail returns a JPEG thumbnail of a decoded image (see
+// decodeImage) no larger than ThumbnailMaxDimension on its long edge. Returns
+// nil without one, such as for SVG or WebP images or ones too large to decode.
+func generateThumbnail(src image.Image) []byte {
+	if src == nil {
+		return nil
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return nil
+	}
+
+	// Scale the long edge down to the maximum, preserving aspect ratio
+	thumbWidth, thumbHeight := width, height
+	if width > ThumbnailMaxDimension || height > ThumbnailMaxDimension {
+		if width >= height {
+			thumbWidth = ThumbnailMaxDimension
+			thumbHeight = max(1, height*ThumbnailMaxDimension/width)
+		} else {
+			thumbHeight = ThumbnailMaxDimension
+			thumbWidth = max(1, width*ThumbnailMaxDimension/height)
+		}
+	}
+
+	thumb := scaleImage(src, thumbWidth, thumbHeight)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return nil
+	}
+	return buf.Bytes()
+}
+
+// scaleImage resizes src to the given dimensions by averaging the source pixels
+// covered by each destination pixel. Transparent areas are composited onto white
+// since JPEG has no alpha channel.
+func scaleImage(src image.Image, width, height int) *image.RGBA {
+	bounds := src.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*srcHeight/height
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*srcHeight/height)
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*srcWidth/width
+			x1 := max(x0+1, bounds.Min.X+(x+1)*srcWidth/width)
+
+			var r, g, b, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					// Premultiplied colors over a white background
+					white := 0xffff - uint64(pa)
+					r += uint64(pr) + white
+					g += uint64(pg) + white
+					b += uint64(pb) + white
+					n++
+				}
+			}
+
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(b / n),
+				A: 0xffff,
+			})
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,120 @@
+package scraper
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/zombar/scraper/models"
+	"golang.org/x/net/html"
+)
+
+// trackerDomains are analytics and advertising domains; a third-party host
+// on one of them or a subdomain counts towards PageMetadata.TrackerCount
+var trackerDomains = []string{
+	"google-analytics.com",
+	"googletagmanager.com",
+	"googlesyndication.com",
+	"googleadservices.com",
+	"doubleclick.net",
+	"facebook.net",
+	"connect.facebook.com",
+	"analytics.twitter.com",
+	"ads-twitter.com",
+	"bat.bing.com",
+	"clarity.ms",
+	"hotjar.com",
+	"segment.com",
+	"segment.io",
+	"mixpanel.com",
+	"amplitude.com",
+	"newrelic.com",
+	"nr-data.net",
+	"scorecardresearch.com",
+	"quantserve.com",
+	"chartbeat.com",
+	"criteo.com",
+	"taboola.com",
+	"outbrain.com",
+	"adnxs.com",
+	"matomo.cloud",
+	"plausible.io",
+}
+
+// trackerPenalty is subtracted from the score of a page loading more than
+// Config.MaxTrackers known trackers
+const trackerPenalty = 0.2
+
+// extractThirdPartyHosts returns the hosts of scripts, images, and iframes
+// the page loads from other sites, in document order without duplicates, and
+// how many of those hosts are on trackerDomains. Hosts on the page's
+// registrable domain, such as a cdn. subdomain, are first-party.
+func extractThirdPartyHosts(doc *html.Node, page *url.URL) (hosts []string, trackers int) {
+	seen := make(map[string]bool)
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && (n.Data == "script" || n.Data == "img" || n.Data == "iframe") {
+			if src := getAttr(n, "src"); src != "" {
+				if host := thirdPartyHost(page, src); host != "" && !seen[host] {
+					seen[host] = true
+					hosts = append(hosts, host)
+					if isTrackerHost(host) {
+						trackers++
+					}
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return hosts, trackers
+}
+
+// thirdPartyHost returns the lowercased host src resolves to, or "" if it is
+// not an http(s) URL on another site
+func thirdPartyHost(page *url.URL, src string) string {
+	resolved, err := resolveURL(page, src)
+	if err != nil {
+		return ""
+	}
+	u, err := url.Parse(resolved)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ""
+	}
+	if sameSite(resolved, page) {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// isTrackerHost reports whether host is one of trackerDomains or a subdomain of one
+func isTrackerHost(host string) bool {
+	for _, domain := range trackerDomains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyTrackerPenalty lowers score by trackerPenalty when the page loads more
+// than Config.MaxTrackers known trackers, noting it in the reason and
+// categories. A zero MaxTrackers disables the penalty. Scrape applies it
+// itself; callers re-scoring stored content with ScoreContent pass the stored
+// Metadata.TrackerCount.
+func (s *Scraper) ApplyTrackerPenalty(score *models.LinkScore, trackers int) {
+	if score == nil || s.config.MaxTrackers <= 0 || trackers <= s.config.MaxTrackers {
+		return
+	}
+	score.Score -= trackerPenalty
+	if score.Score < 0 {
+		score.Score = 0
+	}
+	score.Categories = append(score.Categories, "tracker_heavy")
+	if score.Reason != "" {
+		score.Reason += "; "
+	}
+	score.Reason += "page loads many third-party trackers"
+	score.IsRecommended = score.IsRecommended && score.Score >= s.config.LinkScoreThreshold
+}
@@ -0,0 +1,100 @@
+package scraper
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zombar/scraper/models"
+	"golang.org/x/net/html"
+)
+
+func TestExtractThirdPartyHosts(t *testing.T) {
+	page, _ := url.Parse("https://www.example.com/articles/1")
+	doc, err := html.Parse(strings.NewReader(`<html><head>
+		<script src="https://www.googletagmanager.com/gtag/js?id=G-1"></script>
+		<script src="/static/app.js"></script>
+		<script src="https://cdn.example.com/lib.js"></script>
+		<script>inline()</script>
+	</head><body>
+		<img src="https://images.Other-Site.org/a.jpg">
+		<img src="https://images.other-site.org/b.jpg">
+		<img src="data:image/gif;base64,R0lGOD">
+		<iframe src="//www.youtube.com/embed/x"></iframe>
+		<img src="https://stats.g.doubleclick.net/pixel.gif">
+		<a href="https://elsewhere.com/">not loaded</a>
+	</body></html>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hosts, trackers := extractThirdPartyHosts(doc, page)
+	want := []string{"www.googletagmanager.com", "images.other-site.org", "www.youtube.com", "stats.g.doubleclick.net"}
+	if !reflect.DeepEqual(hosts, want) {
+		t.Errorf("hosts = %v, want %v", hosts, want)
+	}
+	if trackers != 2 {
+		t.Errorf("trackers = %d, want 2", trackers)
+	}
+}
+
+func TestIsTrackerHost(t *testing.T) {
+	tests := map[string]bool{
+		"www.google-analytics.com": true,
+		"google-analytics.com":     true,
+		"connect.facebook.net":     true,
+		"notgoogle-analytics.com":  false,
+		"www.facebook.com":         false,
+		"example.com":              false,
+	}
+	for host, want := range tests {
+		if got := isTrackerHost(host); got != want {
+			t.Errorf("isTrackerHost(%q) = %v, want %v", host, got, want)
+		}
+	}
+}
+
+func TestApplyTrackerPenalty(t *testing.T) {
+	score := func() *models.LinkScore {
+		return &models.LinkScore{Score: 0.6, Reason: "Good content", IsRecommended: true}
+	}
+
+	disabled := New(DefaultConfig())
+	got := score()
+	disabled.ApplyTrackerPenalty(got, 10)
+	if got.Score != 0.6 || !got.IsRecommended {
+		t.Errorf("with MaxTrackers unset, score = %+v, want it unchanged", got)
+	}
+
+	config := DefaultConfig()
+	config.MaxTrackers = 3
+	s := New(config)
+
+	got = score()
+	s.ApplyTrackerPenalty(got, 3)
+	if got.Score != 0.6 {
+		t.Errorf("at the limit, score = %v, want 0.6", got.Score)
+	}
+
+	got = score()
+	s.ApplyTrackerPenalty(got, 4)
+	if got.Score < 0.39 || got.Score > 0.41 {
+		t.Errorf("over the limit, score = %v, want 0.4", got.Score)
+	}
+	if got.IsRecommended {
+		t.Error("over the limit, score below the threshold is still recommended")
+	}
+	if !reflect.DeepEqual(got.Categories, []string{"tracker_heavy"}) {
+		t.Errorf("Categories = %v, want [tracker_heavy]", got.Categories)
+	}
+	if !strings.Contains(got.Reason, "trackers") {
+		t.Errorf("Reason = %q, want it to mention trackers", got.Reason)
+	}
+
+	got = &models.LinkScore{Score: 0.1}
+	s.ApplyTrackerPenalty(got, 4)
+	if got.Score != 0 {
+		t.Errorf("score = %v, want it clamped at 0", got.Score)
+	}
+}
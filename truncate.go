@@ -0,0 +1,77 @@
+package scraper
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// defaultMaxContentChars is the prompt content limit used when Config.MaxContentChars is unset
+const defaultMaxContentChars = 12000
+
+// truncationMarker is appended to content cut short by truncateContent
+const truncationMarker = " ..."
+
+// truncateContent shortens text to at most maxChars characters (including the
+// truncation marker), cutting at the last paragraph break, sentence end, or
+// word boundary that keeps at least half of the allowed text. Text that fits,
+// or a maxChars of 0 or less, is returned unchanged.
+func truncateContent(text string, maxChars int) string {
+	if maxChars <= 0 || utf8.RuneCountInString(text) <= maxChars {
+		return text
+	}
+
+	// Limits too small to hold the marker are a plain cut
+	limit := maxChars - utf8.RuneCountInString(truncationMarker)
+	if limit <= 0 {
+		return text[:runeOffset(text, maxChars)]
+	}
+
+	head := text[:runeOffset(text, limit)]
+	minCut := len(head) / 2
+
+	if i := strings.LastIndex(head, "\n\n"); i >= minCut {
+		return strings.TrimRightFunc(head[:i], unicode.IsSpace) + truncationMarker
+	}
+	if i := lastSentenceEnd(head); i >= minCut {
+		return head[:i] + truncationMarker
+	}
+	if i := strings.LastIndexFunc(head, unicode.IsSpace); i >= minCut {
+		return strings.TrimRightFunc(head[:i], unicode.IsSpace) + truncationMarker
+	}
+	return head + truncationMarker
+}
+
+// runeOffset returns the byte offset of the n-th character of text
+func runeOffset(text string, n int) int {
+	for i := range text {
+		if n == 0 {
+			return i
+		}
+		n--
+	}
+	return len(text)
+}
+
+// lastSentenceEnd returns the offset just past the last sentence-ending
+// punctuation followed by whitespace, or -1 if there is none
+func lastSentenceEnd(text string) int {
+	for i := len(text) - 1; i > 0; i-- {
+		if !unicode.IsSpace(rune(text[i])) {
+			continue
+		}
+		switch text[i-1] {
+		case '.', '!', '?':
+			return i
+		}
+	}
+	return -1
+}
+
+// maxContentChars returns the configured prompt content limit
+func (s *Scraper) maxContentChars() int {
+	if s.config.MaxContentChars == 0 {
+		return defaultMaxContentChars
+	}
+	return s.config.MaxContentChars
+}
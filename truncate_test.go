@@ -0,0 +1,73 @@
+package scraper
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateContent(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		maxChars int
+		want     string
+	}{
+		{"fits", "Short text.", 50, "Short text."},
+		{"exactly at limit", "12345", 5, "12345"},
+		{"disabled", strings.Repeat("a ", 50), 0, strings.Repeat("a ", 50)},
+		{"negative disables", strings.Repeat("a ", 50), -1, strings.Repeat("a ", 50)},
+		{
+			"prefers paragraph break",
+			"First paragraph here. More words.\n\nSecond paragraph that is long",
+			50,
+			"First paragraph here. More words. ...",
+		},
+		{
+			"prefers sentence end",
+			"One sentence here. Another sentence that keeps going on",
+			40,
+			"One sentence here. ...",
+		},
+		{
+			"falls back to word boundary",
+			"alpha beta gamma delta epsilon zeta eta theta",
+			24,
+			"alpha beta gamma ...",
+		},
+		{
+			"ignores early boundaries",
+			"Hi. Supercalifragilisticexpialidocious",
+			20,
+			"Hi. Supercalifra ...",
+		},
+		{
+			"hard cut without whitespace",
+			strings.Repeat("x", 30),
+			10,
+			"xxxxxx ...",
+		},
+		{
+			"does not split multibyte characters",
+			strings.Repeat("é", 30),
+			10,
+			strings.Repeat("é", 6) + " ...",
+		},
+		{"limit smaller than marker", "some long text", 2, "so"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateContent(tt.text, tt.maxChars)
+			if got != tt.want {
+				t.Errorf("truncateContent(%q, %d) = %q, want %q", tt.text, tt.maxChars, got, tt.want)
+			}
+			if tt.maxChars > 0 && utf8.RuneCountInString(got) > tt.maxChars {
+				t.Errorf("Result has %d characters, want at most %d", utf8.RuneCountInString(got), tt.maxChars)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("Result is not valid UTF-8: %q", got)
+			}
+		})
+	}
+}
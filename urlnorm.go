@@ -0,0 +1,116 @@
+package scraper
+
+import (
+	"net/url"
+	"strings"
+)
+
+// trackingParams are query parameters that identify where a visitor came
+// from rather than what page they asked for; names ending in "*" are prefixes
+var trackingParams = []string{"utm_*", "fbclid", "gclid"}
+
+// NormalizeURL returns the URL results for rawURL are stored and looked up
+// under. With Config.URLNormalization off, rawURL is returned unchanged.
+func (s *Scraper) NormalizeURL(rawURL string) string {
+	if !s.config.URLNormalization {
+		return rawURL
+	}
+	return normalizeURL(rawURL, s.config.StripTrailingSlash)
+}
+
+// normalizeLinks returns links in normalized form with trailing slashes
+// stripped, keeping the first of each set that normalize alike, when
+// Config.NormalizeLinks is on. Otherwise links are returned unchanged.
+func (s *Scraper) normalizeLinks(links []string) []string {
+	if !s.config.NormalizeLinks {
+		return links
+	}
+	var normalized []string
+	seen := make(map[string]bool, len(links))
+	for _, link := range links {
+		link = normalizeURL(link, true)
+		if !seen[link] {
+			seen[link] = true
+			normalized = append(normalized, link)
+		}
+	}
+	return normalized
+}
+
+// normalizeURL lowercases the scheme and host, drops the default port,
+// tracking query parameters, and the fragment, and optionally strips a
+// trailing slash from the path. URLs that are not absolute http(s) URLs are
+// returned unchanged.
+func normalizeURL(rawURL string, stripTrailingSlash bool) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return rawURL
+	}
+
+	host, port := strings.ToLower(u.Hostname()), u.Port()
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" && !(u.Scheme == "http" && port == "80") && !(u.Scheme == "https" && port == "443") {
+		host += ":" + port
+	}
+	u.Host = host
+
+	if u.Path == "" {
+		u.Path = "/"
+		u.RawPath = ""
+	} else if stripTrailingSlash && len(u.Path) > 1 {
+		u.Path = strings.TrimRight(u.Path, "/")
+		u.RawPath = strings.TrimRight(u.RawPath, "/")
+		if u.Path == "" {
+			u.Path = "/"
+		}
+	}
+
+	u.RawQuery = stripTrackingParams(u.RawQuery)
+	u.ForceQuery = false
+	u.Fragment = ""
+	u.RawFragment = ""
+	return u.String()
+}
+
+// stripTrackingParams removes trackingParams from a raw query string, keeping
+// the remaining parameters in their original order and encoding
+func stripTrackingParams(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	var kept []string
+	for _, param := range strings.Split(rawQuery, "&") {
+		if param == "" {
+			continue
+		}
+		name, _, _ := strings.Cut(param, "=")
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if !isTrackingParam(strings.ToLower(name)) {
+			kept = append(kept, param)
+		}
+	}
+	return strings.Join(kept, "&")
+}
+
+// isTrackingParam reports whether a lowercased query parameter name is one
+// of trackingParams
+func isTrackingParam(name string) bool {
+	for _, param := range trackingParams {
+		if prefix, ok := strings.CutSuffix(param, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == param {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package scraper
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeURLRules(t *testing.T) {
+	tests := []struct {
+		name               string
+		in                 string
+		stripTrailingSlash bool
+		want               string
+	}{
+		{"lowercases host", "https://Example.COM/Page", false, "https://example.com/Page"},
+		{"lowercases scheme", "HTTPS://example.com/page", false, "https://example.com/page"},
+		{"strips https default port", "https://example.com:443/page", false, "https://example.com/page"},
+		{"strips http default port", "http://example.com:80/page", false, "http://example.com/page"},
+		{"keeps other ports", "https://example.com:8443/page", false, "https://example.com:8443/page"},
+		{"keeps mismatched default port", "http://example.com:443/page", false, "http://example.com:443/page"},
+		{"strips utm params", "https://example.com/page?utm_source=x&utm_Medium=y", false, "https://example.com/page"},
+		{"strips fbclid and gclid", "https://example.com/page?fbclid=a&id=7&gclid=b", false, "https://example.com/page?id=7"},
+		{"keeps other params in order", "https://example.com/page?b=2&utm_campaign=z&a=1", false, "https://example.com/page?b=2&a=1"},
+		{"keeps lookalike params", "https://example.com/page?utmost=1&xfbclid=2", false, "https://example.com/page?utmost=1&xfbclid=2"},
+		{"drops empty query", "https://example.com/page?", false, "https://example.com/page"},
+		{"drops fragment", "https://example.com/page#section", false, "https://example.com/page"},
+		{"adds root path", "https://example.com", false, "https://example.com/"},
+		{"keeps trailing slash by default", "https://example.com/page/", false, "https://example.com/page/"},
+		{"strips trailing slash", "https://example.com/page/", true, "https://example.com/page"},
+		{"strips repeated trailing slashes", "https://example.com/page//", true, "https://example.com/page"},
+		{"keeps root slash", "https://example.com/", true, "https://example.com/"},
+		{"strips trailing slash before query", "https://example.com/page/?id=7", true, "https://example.com/page?id=7"},
+		{"lowercases IPv6 host", "http://[2001:DB8::1]:80/page", false, "http://[2001:db8::1]/page"},
+		{"leaves non-http URLs", "mailto:someone@Example.com", false, "mailto:someone@Example.com"},
+		{"leaves relative URLs", "/page?utm_source=x", false, "/page?utm_source=x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeURL(tt.in, tt.stripTrailingSlash); got != tt.want {
+				t.Errorf("normalizeURL(%q, %v) = %q, want %q", tt.in, tt.stripTrailingSlash, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeURLConfig(t *testing.T) {
+	raw := "https://Example.com/page/?utm_source=x"
+
+	if got := New(DefaultConfig()).NormalizeURL(raw); got != raw {
+		t.Errorf("NormalizeURL() with normalization off = %q, want the URL unchanged", got)
+	}
+
+	config := DefaultConfig()
+	config.URLNormalization = true
+	if got := New(config).NormalizeURL(raw); got != "https://example.com/page/" {
+		t.Errorf("NormalizeURL() = %q", got)
+	}
+
+	config.StripTrailingSlash = true
+	if got := New(config).NormalizeURL(raw); got != "https://example.com/page" {
+		t.Errorf("NormalizeURL() with StripTrailingSlash = %q", got)
+	}
+}
+
+func TestNormalizeLinks(t *testing.T) {
+	links := []string{
+		"https://example.com/a",
+		"https://example.com/a/",
+		"https://example.com/a?utm_source=x",
+		"https://Example.com:443/a#top",
+		"https://example.com/b?id=1&fbclid=z",
+		"https://example.com/b?id=1",
+		"https://example.com/b?id=2",
+		"https://example.com/",
+		"https://example.com",
+		"mailto:team@example.com",
+	}
+
+	config := DefaultConfig()
+	if got := New(config).normalizeLinks(links); !reflect.DeepEqual(got, links) {
+		t.Errorf("normalizeLinks() with NormalizeLinks off = %v, want the links unchanged", got)
+	}
+
+	config.NormalizeLinks = true
+	want := []string{
+		"https://example.com/a",
+		"https://example.com/b?id=1",
+		"https://example.com/b?id=2",
+		"https://example.com/",
+		"mailto:team@example.com",
+	}
+	if got := New(config).normalizeLinks(links); !reflect.DeepEqual(got, want) {
+		t.Errorf("normalizeLinks() = %v, want %v", got, want)
+	}
+}